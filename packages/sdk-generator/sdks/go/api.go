@@ -0,0 +1,128 @@
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// Do sends a JSON request to an endpoint the client has no method for,
+// such as one added by a deployment, and decodes the response into a T,
+// always closing the body. The request gets everything the typed methods
+// get: credentials, retries, hooks, middlewares and caching options. body,
+// when not nil, is sent as JSON. A non-2xx response yields an *APIError
+// carrying the decoded error envelope. When T is a pointer type, a 204 No
+// Content or an empty body yields nil; for other types only a 204 yields
+// the zero T, and an empty body is a decode error.
+func Do[T any](ctx context.Context, c *ControlPlaneClient, method, path string, body interface{}, opts ...RequestOption) (T, error) {
+	var out T
+	if reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Ptr {
+		opts = append([]RequestOption{withEmptyBody()}, opts...)
+	}
+	if err := c.call(ctx, method, path, body, &out, opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// DoJSON sends a JSON request and decodes the JSON response into a T. It
+// is Do under the name that states its encoding, and behaves identically.
+func DoJSON[T any](ctx context.Context, c *ControlPlaneClient, method, path string, body interface{}, opts ...RequestOption) (T, error) {
+	return Do[T](ctx, c, method, path, body, opts...)
+}
+
+// withEmptyBody lets call accept a successful response with an empty body,
+// leaving out unchanged.
+func withEmptyBody() RequestOption {
+	return func(o *requestOptions) { o.emptyBody = true }
+}
+
+// call sends a JSON request and decodes a successful response into out,
+// which may be nil when the response body is not needed.
+func (c *ControlPlaneClient) call(ctx context.Context, method, path string, in, out interface{}, opts ...RequestOption) error {
+	if c.life.isClosed() {
+		return ErrClientClosed
+	}
+	if method == http.MethodGet && out != nil {
+		if o := c.applyOptions(opts); o.cacheTTL > 0 || (o.cacheable && (o.cache != nil || c.config.Cache != nil)) {
+			return c.cachedGet(ctx, path, out, o, opts)
+		}
+	}
+	resp, err := c.Request(ctx, method, path, in, opts...)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode == http.StatusConflict && c.applyOptions(opts).idempotencyKey != "" {
+		if ok, err := c.decodeReplayed(resp, out); ok {
+			return err
+		}
+	}
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := c.decode(resp.Body, out); err != nil {
+		if err == io.EOF && c.applyOptions(opts).emptyBody {
+			return nil
+		}
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// cachedGet serves a GET through the WithCache memory cache, when requested,
+// and the ETag cache.
+func (c *ControlPlaneClient) cachedGet(ctx context.Context, path string, out interface{}, o requestOptions, opts []RequestOption) error {
+	var data []byte
+	var err error
+	if o.cacheTTL > 0 {
+		data, err = c.memoizedGet(ctx, path, o, opts)
+	} else {
+		data, err = c.getBody(ctx, path, opts)
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode GET %s response: %w", path, err)
+	}
+	return nil
+}
+
+// checkStatus returns an *APIError for a non-2xx response.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	return nil
+}
+
+// decodeReplayed handles a 409 Conflict to an idempotent request. When the
+// body holds the original resource rather than an error envelope, it is
+// decoded into out and ok is true.
+func (c *ControlPlaneClient) decodeReplayed(resp *http.Response, out interface{}) (ok bool, err error) {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return false, nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	if parseErrorEnvelope(data) != nil {
+		return false, nil
+	}
+	if out == nil {
+		return true, nil
+	}
+	if err := c.unmarshal(data, out); err != nil {
+		return true, fmt.Errorf("decode replayed response: %w", err)
+	}
+	return true, nil
+}
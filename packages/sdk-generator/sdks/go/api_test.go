@@ -0,0 +1,96 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type quota struct {
+	Tenant string `json:"tenant"`
+	Limit  int    `json:"limit"`
+}
+
+func TestDoJSON(t *testing.T) {
+	client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Contract-Version") == "" {
+			http.Error(w, "missing contract version", http.StatusBadRequest)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "PUT /v1/custom/quotas/acme":
+			var q quota
+			json.NewDecoder(r.Body).Decode(&q)
+			q.Tenant = "acme"
+			json.NewEncoder(w).Encode(q)
+		case "DELETE /v1/custom/quotas/acme":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(NewErrorEnvelope(ErrorCategoryRESOURCE_NOT_FOUND, "NO_QUOTA", "no such quota", "quotas"))
+		}
+	}))
+	ctx := context.Background()
+
+	got, err := DoJSON[quota](ctx, client, http.MethodPut, "/v1/custom/quotas/acme", quota{Limit: 5})
+	if err != nil || got != (quota{Tenant: "acme", Limit: 5}) {
+		t.Errorf("PUT = %+v, %v", got, err)
+	}
+
+	deleted, err := DoJSON[*quota](ctx, client, http.MethodDelete, "/v1/custom/quotas/acme", nil)
+	if err != nil || deleted != nil {
+		t.Errorf("DELETE = %+v, %v", deleted, err)
+	}
+
+	missing, err := DoJSON[quota](ctx, client, http.MethodGet, "/v1/custom/quotas/other", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Envelope.Code != "NO_QUOTA" {
+		t.Errorf("GET missing error = %v", err)
+	}
+	if !IsNotFound(err) || missing != (quota{}) {
+		t.Errorf("GET missing = %+v, IsNotFound = %v", missing, IsNotFound(err))
+	}
+}
+
+func TestDo(t *testing.T) {
+	client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/custom/quotas/acme":
+			w.Write([]byte(`{"tenant":"acme","limit":5}`))
+		case "/v1/custom/empty":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/custom/garbled":
+			w.Write([]byte(`{"tenant":`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(NewErrorEnvelope(ErrorCategoryAUTHORIZATION_ERROR, "NO_ACCESS", "tenant has no access", "quotas"))
+		}
+	}))
+	ctx := context.Background()
+
+	got, err := Do[*quota](ctx, client, http.MethodGet, "/v1/custom/quotas/acme", nil)
+	if err != nil || got == nil || *got != (quota{Tenant: "acme", Limit: 5}) {
+		t.Errorf("GET = %+v, %v", got, err)
+	}
+
+	_, err = Do[quota](ctx, client, http.MethodGet, "/v1/custom/forbidden", nil)
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusForbidden || apiErr.Envelope.Code != "NO_ACCESS" {
+		t.Errorf("error envelope = %v", err)
+	}
+
+	empty, err := Do[*quota](ctx, client, http.MethodGet, "/v1/custom/empty", nil)
+	if err != nil || empty != nil {
+		t.Errorf("empty body as pointer = %+v, %v", empty, err)
+	}
+	if _, err := Do[quota](ctx, client, http.MethodGet, "/v1/custom/empty", nil); err == nil {
+		t.Error("empty body decoded into a non-pointer type")
+	}
+
+	_, err = Do[quota](ctx, client, http.MethodGet, "/v1/custom/garbled", nil)
+	if err == nil || !strings.Contains(err.Error(), "decode GET /v1/custom/garbled response") {
+		t.Errorf("decode error = %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenProvider supplies the bearer token sent in the Authorization header.
+// It is consulted before every attempt, so it may return a different token
+// each time as credentials rotate.
+//
+// If the server rejects a token with 401, the client calls Invalidate on
+// providers that implement it, fetches a new token and retries the request
+// once.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSource is the former name of TokenProvider.
+//
+// Deprecated: Use TokenProvider.
+type TokenSource = TokenProvider
+
+// StaticToken returns a TokenProvider that always returns token. NewClient
+// uses it to wrap ClientConfig.APIKey.
+func StaticToken(token string) TokenProvider {
+	return staticToken(token)
+}
+
+type staticToken string
+
+func (t staticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// TokenError reports that the TokenProvider failed to supply a token, as
+// opposed to the server rejecting one.
+type TokenError struct {
+	Err error
+}
+
+func (e *TokenError) Error() string {
+	return "token provider: " + e.Err.Error()
+}
+
+func (e *TokenError) Unwrap() error {
+	return e.Err
+}
+
+// TokenFetcher obtains a new token and the time it expires. A zero expiry
+// means the token does not expire.
+type TokenFetcher func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// CachingTokenSource is a TokenProvider that reuses a fetched token until
+// shortly before it expires. It is safe for concurrent use.
+type CachingTokenSource struct {
+	fetch  TokenFetcher
+	leeway time.Duration
+	// clock judges expiry; nil means the system time.
+	clock Clock
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewCachingTokenSource returns a TokenProvider that calls fetch only when it
+// has no token or the cached one expires within leeway. A zero leeway
+// defaults to 30 seconds.
+func NewCachingTokenSource(fetch TokenFetcher, leeway time.Duration) *CachingTokenSource {
+	if leeway <= 0 {
+		leeway = 30 * time.Second
+	}
+	return &CachingTokenSource{fetch: fetch, leeway: leeway}
+}
+
+// WithClock sets the clock expiry is judged by, such as the client's
+// ClientConfig.Clock or a controlplanetest.FakeClock in tests, and returns
+// s. The system time is used when none is set.
+func (s *CachingTokenSource) WithClock(clock Clock) *CachingTokenSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+	return s
+}
+
+// Token returns the cached token, fetching a new one when needed.
+func (s *CachingTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clock := s.clock
+	if clock == nil {
+		clock = RealClock()
+	}
+	if s.token != "" && (s.expiry.IsZero() || s.expiry.Sub(clock.Now()) > s.leeway) {
+		return s.token, nil
+	}
+	token, expiry, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiry = token, expiry
+	return token, nil
+}
+
+// Invalidate drops the cached token so the next call to Token fetches one.
+func (s *CachingTokenSource) Invalidate() {
+	s.mu.Lock()
+	s.token = ""
+	s.mu.Unlock()
+}
+
+// shouldRefreshToken reports whether resp rejected the token the client
+// sent, so that a fresh token may succeed. A static token cannot change, so
+// it is never refreshed.
+func (c *ControlPlaneClient) shouldRefreshToken(resp *http.Response) bool {
+	if c.config.TokenProvider == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	if _, static := c.config.TokenProvider.(staticToken); static {
+		return false
+	}
+	env := peekErrorEnvelope(resp)
+	return env == nil || env.Category == ErrorCategoryAUTHENTICATION_ERROR
+}
+
+// invalidateToken asks the token provider to drop its cached token.
+func (c *ControlPlaneClient) invalidateToken() {
+	if inv, ok := c.config.TokenProvider.(interface{ Invalidate() }); ok {
+		inv.Invalidate()
+	}
+}
@@ -0,0 +1,172 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachingTokenSource(t *testing.T) {
+	var fetches int32
+	expiry := time.Now().Add(time.Hour)
+	source := NewCachingTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		n := atomic.AddInt32(&fetches, 1)
+		return fmt.Sprintf("tok-%d", n), expiry, nil
+	}, time.Minute)
+
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "static", TokenSource: source})
+
+	for i := 0; i < 3; i++ {
+		if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 || seen[2] != "Bearer tok-1" {
+		t.Errorf("fetches = %d, seen = %v", fetches, seen)
+	}
+
+	// A token inside the leeway window is replaced before use.
+	expiry = time.Now().Add(30 * time.Second)
+	source.Invalidate()
+	client.call(context.Background(), http.MethodGet, "/health", nil, nil)
+	client.call(context.Background(), http.MethodGet, "/health", nil, nil)
+	if fetches != 3 {
+		t.Errorf("fetches = %d, want 3", fetches)
+	}
+}
+
+func TestCachingTokenSourceClock(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	fetches := 0
+	source := NewCachingTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		fetches++
+		return "tok", clock.now.Add(time.Hour), nil
+	}, time.Minute).WithClock(clock)
+
+	ctx := context.Background()
+	source.Token(ctx)
+	clock.now = clock.now.Add(58 * time.Minute)
+	source.Token(ctx)
+	if fetches != 1 {
+		t.Errorf("fetches = %d before the leeway, want 1", fetches)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	source.Token(ctx)
+	if fetches != 2 {
+		t.Errorf("fetches = %d within the leeway, want 2", fetches)
+	}
+}
+
+func TestTokenRefreshedOnceOn401(t *testing.T) {
+	const authError = `{"id":"e1","category":"AUTHENTICATION_ERROR","severity":"error","code":"TOKEN_EXPIRED","message":"m","service":"api"}`
+	var fetches int32
+	source := NewCachingTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		n := atomic.AddInt32(&fetches, 1)
+		return fmt.Sprintf("tok-%d", n), time.Time{}, nil
+	}, 0)
+
+	valid := "Bearer tok-2"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(authError))
+		}
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, TokenSource: source})
+
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+		t.Fatalf("refresh did not recover: %v", err)
+	}
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want 2", fetches)
+	}
+
+	// A token that is rejected again is not refreshed a second time.
+	valid = "never"
+	fetches = 0
+	source.Invalidate()
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err == nil {
+		t.Fatal("expected 401 error")
+	}
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want 2", fetches)
+	}
+}
+
+func TestTokenSourceError(t *testing.T) {
+	boom := errors.New("idp down")
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: "http://127.0.0.1:0",
+		TokenSource: NewCachingTokenSource(func(ctx context.Context) (string, time.Time, error) {
+			return "", time.Time{}, boom
+		}, 0),
+	})
+	err := client.call(context.Background(), http.MethodGet, "/health", nil, nil)
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) || !errors.Is(err, boom) {
+		t.Errorf("err = %v, want *TokenError wrapping the provider error", err)
+	}
+}
+
+type countingProvider struct{ calls int32 }
+
+func (p *countingProvider) Token(ctx context.Context) (string, error) {
+	n := atomic.AddInt32(&p.calls, 1)
+	return fmt.Sprintf("tok-%d", n), nil
+}
+
+func TestTokenProviderConsultedEveryRequest(t *testing.T) {
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer tok-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	provider := &countingProvider{}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "ignored", TokenProvider: provider})
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(seen) != "[Bearer tok-1 Bearer tok-2 Bearer tok-3]" {
+		t.Errorf("seen = %v", seen)
+	}
+}
+
+func TestStaticAPIKeyNotRetriedOn401(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "key"})
+	err := client.call(context.Background(), http.MethodGet, "/health", nil, nil)
+	var tokenErr *TokenError
+	if err == nil || errors.As(err, &tokenErr) {
+		t.Errorf("err = %v, want a server rejection", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
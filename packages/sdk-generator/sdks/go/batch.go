@@ -0,0 +1,105 @@
+package controlplane
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is the number of workers RunBatch uses when
+// BatchOpts.Concurrency is zero.
+const DefaultBatchConcurrency = 8
+
+// RateLimiter paces requests. Wait blocks until a request may be sent or
+// ctx is done. *rate.Limiter from golang.org/x/time/rate satisfies it.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// BatchOpts configures RunBatch.
+type BatchOpts struct {
+	// Concurrency bounds how many calls run at once. Zero selects
+	// DefaultBatchConcurrency.
+	Concurrency int
+	// FailFast stops starting new calls after the first failure.
+	FailFast bool
+	// ItemTimeout bounds each call. Zero leaves calls bounded only by the
+	// batch context.
+	ItemTimeout time.Duration
+}
+
+// Result is the outcome of one RunBatch call.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// RunBatch calls fn for every input on a bounded pool of workers and returns
+// the results in input order. Calls made through a client with a
+// ClientConfig.RateLimiter are paced by it, so large batches do not flood
+// the API.
+//
+// Failures are reported per item and the returned error is nil unless ctx
+// ends early or, with FailFast, a call fails; it is then that error, and the
+// inputs that were never started carry the cancellation error.
+func RunBatch[I, T any](ctx context.Context, inputs []I, fn func(ctx context.Context, in I) (T, error), opts BatchOpts) ([]Result[T], error) {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result[T], len(inputs))
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := batchCtx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Value, results[i].Err = runItem(batchCtx, inputs[i], fn, opts.ItemTimeout)
+				if results[i].Err != nil && opts.FailFast {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = results[i].Err
+					}
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+	return results, ctx.Err()
+}
+
+func runItem[I, T any](ctx context.Context, in I, fn func(context.Context, I) (T, error), timeout time.Duration) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fn(ctx, in)
+}
@@ -0,0 +1,114 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBatchPreservesOrderAndBoundsConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	inputs := []int{5, 1, 4, 2, 3, 0}
+	results, err := RunBatch(context.Background(), inputs, func(ctx context.Context, n int) (int, error) {
+		cur := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		if n == 4 {
+			return 0, errors.New("four")
+		}
+		return n * 10, nil
+	}, BatchOpts{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if inputs[i] == 4 {
+			if r.Err == nil {
+				t.Errorf("result %d: missing error", i)
+			}
+		} else if r.Err != nil || r.Value != inputs[i]*10 {
+			t.Errorf("result %d = %+v", i, r)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("peak concurrency = %d", peak)
+	}
+}
+
+func TestRunBatchFailFast(t *testing.T) {
+	boom := errors.New("boom")
+	var calls int32
+	inputs := make([]int, 50)
+	results, err := RunBatch(context.Background(), inputs, func(ctx context.Context, _ int) (struct{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return struct{}{}, boom
+	}, BatchOpts{Concurrency: 1, FailFast: true})
+	if err != boom {
+		t.Fatalf("err = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if !errors.Is(results[len(results)-1].Err, context.Canceled) {
+		t.Errorf("skipped item err = %v", results[len(results)-1].Err)
+	}
+}
+
+func TestRunBatchItemTimeout(t *testing.T) {
+	results, err := RunBatch(context.Background(), []time.Duration{0, time.Second}, func(ctx context.Context, d time.Duration) (bool, error) {
+		select {
+		case <-time.After(d):
+			return true, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}, BatchOpts{ItemTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Value || !errors.Is(results[1].Err, context.DeadlineExceeded) {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+type countingLimiter struct{ waits int32 }
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	atomic.AddInt32(&l.waits, 1)
+	return ctx.Err()
+}
+
+func TestRunBatchUsesClientRateLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"j","status":"queued"}`))
+	}))
+	defer srv.Close()
+	limiter := &countingLimiter{}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, RateLimiter: limiter})
+
+	ids := []string{"a", "b", "c"}
+	results, err := RunBatch(context.Background(), ids, func(ctx context.Context, id string) (*JobResponse, error) {
+		return client.GetJob(ctx, id)
+	}, BatchOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("result %d: %v", i, r.Err)
+		}
+	}
+	if limiter.waits != 3 {
+		t.Errorf("limiter waits = %d, want 3", limiter.waits)
+	}
+}
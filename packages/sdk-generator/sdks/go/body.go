@@ -0,0 +1,102 @@
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxResponseBytes is the response size limit used when
+// ClientConfig.MaxResponseBytes is zero.
+const DefaultMaxResponseBytes = 32 << 20
+
+// ErrResponseTooLarge is matched by errors.Is when a response body exceeds
+// ClientConfig.MaxResponseBytes. See ResponseTooLargeError.
+var ErrResponseTooLarge = errors.New("controlplane: response body exceeds MaxResponseBytes")
+
+// ResponseTooLargeError is returned when reading a response body, or a
+// single frame of a streaming response, past ClientConfig.MaxResponseBytes.
+type ResponseTooLargeError struct {
+	// Limit is the configured maximum.
+	Limit int64
+	// BytesRead is how many bytes were read before giving up.
+	BytesRead int64
+	// ContentType is the response's Content-Type header.
+	ContentType string
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds MaxResponseBytes (%d): read %d bytes of %q",
+		e.Limit, e.BytesRead, e.ContentType)
+}
+
+// Is reports whether target is ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Is(target error) bool { return target == ErrResponseTooLarge }
+
+type bodyLimitKey struct{}
+
+// withBodyLimit sets the body limit limitResponses applies to requests made
+// with ctx. A negative limit disables it.
+func withBodyLimit(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, bodyLimitKey{}, limit)
+}
+
+// limitResponses wraps every response body returned by send in a
+// limitedBody, so middlewares, hooks and envelope decoding are all bounded.
+func limitResponses(send RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		resp, err := send(req)
+		if err != nil {
+			return resp, err
+		}
+		if limit, _ := req.Context().Value(bodyLimitKey{}).(int64); limit > 0 {
+			resp.Body = newLimitedBody(resp.Body, limit, resp.Header.Get("Content-Type"))
+		}
+		return resp, nil
+	}
+}
+
+// limitedBody fails with a *ResponseTooLargeError once more than its limit
+// has been read, rather than silently truncating like io.LimitReader. The
+// error is sticky, so drainAndClose stops immediately and the connection is
+// closed instead of being drained for reuse.
+type limitedBody struct {
+	io.ReadCloser
+	r           io.Reader
+	limit       int64
+	read        int64
+	contentType string
+	err         error
+}
+
+func newLimitedBody(body io.ReadCloser, max int64, contentType string) *limitedBody {
+	// Allow one byte past the limit so an oversized body can be told apart
+	// from one that is exactly max bytes long.
+	return &limitedBody{ReadCloser: body, r: io.LimitReader(body, max+1), limit: max, contentType: contentType}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		n -= int(b.read - b.limit)
+		b.err = &ResponseTooLargeError{Limit: b.limit, BytesRead: b.read, ContentType: b.contentType}
+		return n, b.err
+	}
+	return n, err
+}
+
+// errReader returns err once its data is exhausted.
+func errReader(data []byte, err error) io.ReadCloser {
+	return io.NopCloser(io.MultiReader(bytes.NewReader(data), &failingReader{err}))
+}
+
+type failingReader struct{ err error }
+
+func (r *failingReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,74 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseBytesDefault(t *testing.T) {
+	client := mustNewClient(t, ClientConfig{BaseURL: "http://example.com"})
+	if client.config.MaxResponseBytes != DefaultMaxResponseBytes {
+		t.Errorf("MaxResponseBytes = %d", client.config.MaxResponseBytes)
+	}
+}
+
+func TestOversizedErrorBody(t *testing.T) {
+	page := strings.Repeat("<p>bad gateway</p>", 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	var seen *ResponseInfo
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:          srv.URL,
+		MaxResponseBytes: 1024,
+		OnResponse:       func(ctx context.Context, info *ResponseInfo) { seen = info },
+	})
+	_, err := client.GetJob(context.Background(), "j1")
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.Is(err, ErrResponseTooLarge) || !errors.As(err, &tooLarge) {
+		t.Fatalf("err = %v", err)
+	}
+	if tooLarge.Limit != 1024 || tooLarge.BytesRead <= 1024 || tooLarge.ContentType != "text/html" {
+		t.Errorf("error = %+v", tooLarge)
+	}
+	if seen == nil || seen.Envelope != nil {
+		t.Errorf("response info = %+v", seen)
+	}
+}
+
+func TestSubscribeTruthFrameLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 20; i++ {
+			sseAssertion(w, fmt.Sprintf("a%d", i))
+		}
+		fmt.Fprintf(w, "data: %s\n\n", strings.Repeat("x", 4096))
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, MaxResponseBytes: 1024, Reconnect: fastReconnect})
+	assertions, errc, err := client.SubscribeTruth(context.Background(), TruthSubscription{Id: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for range assertions {
+		n++
+	}
+	if n != 20 {
+		t.Errorf("received %d assertions, want all 20 despite the stream exceeding the limit", n)
+	}
+	if err := <-errc; !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("err = %v", err)
+	}
+}
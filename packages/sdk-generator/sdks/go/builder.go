@@ -0,0 +1,199 @@
+package controlplane
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JobRequestBuilder assembles a JobRequest from typed parts. Methods record
+// the first encoding error, which Build returns.
+//
+//	job, err := controlplane.NewJobRequestBuilder("csv.import").
+//		WithPayload(map[string]interface{}{"url": src}).
+//		WithMetadata(controlplane.JobMetadata{Source: "billing-worker"}).
+//		WithTimeout(time.Minute).
+//		Build()
+type JobRequestBuilder struct {
+	job      JobRequest
+	payload  *JobPayload
+	metadata *JobMetadata
+	// maxPayloadBytes is resolved by payloadLimit.
+	maxPayloadBytes int
+	// clock stamps CreatedAt; nil means the system time.
+	clock Clock
+	err   error
+}
+
+// NewJobRequestBuilder starts a request for a job of the given type.
+func NewJobRequestBuilder(jobType string) *JobRequestBuilder {
+	return &JobRequestBuilder{job: JobRequest{Type: jobType}}
+}
+
+// WithID sets the job id instead of a generated one, e.g. to resubmit a job
+// under the same id.
+func (b *JobRequestBuilder) WithID(id string) *JobRequestBuilder {
+	b.job.Id = id
+	return b
+}
+
+// WithClock sets the clock Build reads to fill in a zero CreatedAt and to
+// check ScheduledAt and ExpiresAt, such as a client's ClientConfig.Clock or
+// a controlplanetest.FakeClock in tests. The system time is used when none
+// is set.
+func (b *JobRequestBuilder) WithClock(clock Clock) *JobRequestBuilder {
+	b.clock = clock
+	return b
+}
+
+// WithPayload sets the job payload. A JobPayload is used as is; any other
+// value is encoded as the payload data, typed with the job type.
+func (b *JobRequestBuilder) WithPayload(v interface{}) *JobRequestBuilder {
+	switch p := v.(type) {
+	case JobPayload:
+		b.payload = &p
+	case *JobPayload:
+		b.payload = p
+	default:
+		var data map[string]interface{}
+		if err := decodeMap(v, &data); err != nil {
+			b.fail(fmt.Errorf("encode payload data: %w", err))
+			return b
+		}
+		b.payload = &JobPayload{Data: data}
+	}
+	return b
+}
+
+// WithMetadata sets the job metadata, with its tags normalized by
+// NormalizeTags. A zero CreatedAt is filled in by Build from the builder's
+// clock.
+func (b *JobRequestBuilder) WithMetadata(m JobMetadata) *JobRequestBuilder {
+	m = m.NormalizeTags()
+	b.metadata = &m
+	return b
+}
+
+// WithPriority sets the job priority, from 0 to 100.
+func (b *JobRequestBuilder) WithPriority(p int) *JobRequestBuilder {
+	b.job.Priority = Int(p)
+	return b
+}
+
+// WithRetryPolicy sets how the control plane retries the job.
+func (b *JobRequestBuilder) WithRetryPolicy(p RetryPolicy) *JobRequestBuilder {
+	var m map[string]interface{}
+	if err := decodeMap(p, &m); err != nil {
+		b.fail(fmt.Errorf("encode retry policy: %w", err))
+		return b
+	}
+	b.job.RetryPolicy = m
+	return b
+}
+
+// WithTimeout sets TimeoutMs, truncated to whole milliseconds.
+func (b *JobRequestBuilder) WithTimeout(d time.Duration) *JobRequestBuilder {
+	b.job.TimeoutMs = Float(float64(d.Milliseconds()))
+	return b
+}
+
+// WithMaxPayloadBytes sets the largest JSON-encoded payload Build accepts.
+// Zero selects DefaultMaxPayloadBytes; negative disables the check.
+func (b *JobRequestBuilder) WithMaxPayloadBytes(n int) *JobRequestBuilder {
+	b.maxPayloadBytes = n
+	return b
+}
+
+func (b *JobRequestBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build assembles the request, generating a UUID id when none was set, and
+// validates it along with its payload and metadata, checking the schedule
+// and expiry against the builder's clock. Validation failures are
+// returned as ValidationErrors with "payload." and "metadata." prefixes for
+// the nested fields; an oversized payload is reported as "payload" (see
+// WithMaxPayloadBytes).
+func (b *JobRequestBuilder) Build() (JobRequest, error) {
+	if b.err != nil {
+		return JobRequest{}, b.err
+	}
+	job := b.job
+	if job.Id == "" {
+		job.Id = newUUID()
+	}
+
+	var payload JobPayload
+	if b.payload != nil {
+		payload = *b.payload
+	}
+	if payload.Type == "" {
+		payload.Type = job.Type
+	}
+	if payload.Data == nil {
+		payload.Data = map[string]interface{}{}
+	}
+	var meta JobMetadata
+	if b.metadata != nil {
+		meta = *b.metadata
+	}
+	clock := b.clock
+	if clock == nil {
+		clock = RealClock()
+	}
+	now := clock.Now()
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = now.UTC()
+	}
+	if err := decodeMap(payload, &job.Payload); err != nil {
+		return JobRequest{}, fmt.Errorf("encode payload: %w", err)
+	}
+	if err := decodeMap(meta, &job.Metadata); err != nil {
+		return JobRequest{}, fmt.Errorf("encode metadata: %w", err)
+	}
+
+	var errs ValidationErrors
+	// Validate checks the payload against DefaultMaxPayloadBytes, so the
+	// size is checked separately against the builder's limit.
+	unsized := job
+	unsized.Payload = nil
+	addNested(&errs, "", unsized.ValidateAt(now))
+	checkPayloadSize(job.Payload, payloadLimit(b.maxPayloadBytes), &errs)
+	addNested(&errs, "payload.", payload.Validate())
+	addNested(&errs, "metadata.", meta.ValidateAt(now))
+	if !errs.IsValid() {
+		return job, &errs
+	}
+	return job, nil
+}
+
+// addNested adds the fields of a validation error to errs under prefix,
+// skipping any that errs already holds: JobRequest's own rules check some
+// metadata fields too.
+func addNested(errs *ValidationErrors, prefix string, err error) {
+	if err == nil {
+		return
+	}
+	verrs, ok := err.(*ValidationErrors)
+	if !ok {
+		errs.Add(strings.TrimSuffix(prefix, "."), err.Error())
+		return
+	}
+	for _, e := range verrs.Errors {
+		e.Field = prefix + e.Field
+		if !containsValidationError(errs.Errors, e) {
+			errs.Add(e.Field, e.Message)
+		}
+	}
+}
+
+func containsValidationError(errs []ValidationError, e ValidationError) bool {
+	for _, have := range errs {
+		if have == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package controlplane
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestJobRequestBuilderBuildsValidRequest(t *testing.T) {
+	job, err := NewJobRequestBuilder("csv.import").
+		WithPayload(map[string]interface{}{"url": "s3://bucket/file.csv"}).
+		WithMetadata(JobMetadata{Source: "billing-worker", Tags: []string{"nightly"}}).
+		WithPriority(80).
+		WithRetryPolicy(RetryPolicy{MaxRetries: Int(3), BackoffMs: Float(500)}).
+		WithTimeout(90 * time.Second).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(job.Id) {
+		t.Errorf("id = %q", job.Id)
+	}
+	if job.Type != "csv.import" || IntValue(job.Priority) != 80 || FloatValue(job.TimeoutMs) != 90000 {
+		t.Errorf("job = %+v", job)
+	}
+	if job.Payload["type"] != "csv.import" || job.Payload["data"].(map[string]interface{})["url"] != "s3://bucket/file.csv" {
+		t.Errorf("payload = %v", job.Payload)
+	}
+	if job.Metadata["source"] != "billing-worker" || job.Metadata["createdAt"] == "0001-01-01T00:00:00Z" {
+		t.Errorf("metadata = %v", job.Metadata)
+	}
+	if job.RetryPolicy["maxRetries"] != float64(3) {
+		t.Errorf("retry policy = %v", job.RetryPolicy)
+	}
+	if err := job.Validate(); err != nil {
+		t.Errorf("built job invalid: %v", err)
+	}
+}
+
+func TestJobRequestBuilderKeepsTypedPayloadAndID(t *testing.T) {
+	job, err := NewJobRequestBuilder("csv.import").
+		WithID("job-1").
+		WithPayload(JobPayload{Type: "csv.v2", Data: map[string]interface{}{"rows": 3}}).
+		WithMetadata(JobMetadata{Source: "cli"}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Id != "job-1" || job.Payload["type"] != "csv.v2" {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func TestJobRequestBuilderWithClock(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	job, err := NewJobRequestBuilder("csv.import").
+		WithClock(clock).
+		WithMetadata(JobMetadata{Source: "cli"}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := job.Metadata["createdAt"]; got != "2024-01-02T03:04:05Z" {
+		t.Errorf("createdAt = %v", got)
+	}
+}
+
+func TestJobRequestBuilderReportsValidationErrors(t *testing.T) {
+	_, err := NewJobRequestBuilder("").
+		WithPayload(map[string]interface{}{"url": "x"}).
+		WithMetadata(JobMetadata{ExpiresAt: time.Now().Add(-time.Hour)}).
+		Build()
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("err = %v", err)
+	}
+	got := map[string]int{}
+	for _, e := range verrs.Errors {
+		got[e.Field]++
+	}
+	for _, field := range []string{"type", "payload.type", "metadata.source", "metadata.expiresAt"} {
+		if got[field] == 0 {
+			t.Errorf("no error for %s in %v", field, verrs.Errors)
+		}
+	}
+	if got["metadata.expiresAt"] != 2 {
+		// createdAt and expiry checks, each reported once.
+		t.Errorf("metadata.expiresAt errors = %d: %v", got["metadata.expiresAt"], verrs.Errors)
+	}
+}
+
+func TestJobRequestBuilderReportsEncodingError(t *testing.T) {
+	_, err := NewJobRequestBuilder("csv.import").WithPayload([]int{1}).Build()
+	if err == nil {
+		t.Fatal("non-object payload accepted")
+	}
+}
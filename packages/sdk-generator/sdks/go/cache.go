@@ -0,0 +1,208 @@
+package controlplane
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a cached response is revalidated with its ETag
+// when ClientConfig.CacheTTL is zero.
+const DefaultCacheTTL = 10 * time.Minute
+
+// Cache stores GET responses for conditional requests. Implementations must
+// be safe for concurrent use. See NewLRUCache.
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+}
+
+// CacheEntry is a cached response body and the ETag it was served with.
+type CacheEntry struct {
+	ETag     string
+	Body     []byte
+	StoredAt time.Time
+	// FreshUntil is when the Cache-Control max-age of the response runs
+	// out. Until then the body is served without contacting the server.
+	FreshUntil time.Time
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used entry
+// once it holds maxEntries.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewLRUCache returns an LRUCache holding at most maxEntries responses.
+func NewLRUCache(maxEntries int) *LRUCache {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &LRUCache{maxEntries: maxEntries, order: list.New(), items: map[string]*list.Element{}}
+}
+
+// Get returns the entry stored under key and marks it recently used.
+func (c *LRUCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruItem).entry, true
+}
+
+// Set stores entry under key, evicting the least recently used entry when
+// the cache is full.
+func (c *LRUCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// withETagCache marks a GET whose response may be stored in
+// ClientConfig.Cache and revalidated.
+func withETagCache() RequestOption {
+	return func(o *requestOptions) { o.cacheable = true }
+}
+
+// WithResponseCache serves a GET through cache like the registry and
+// marketplace lookups use ClientConfig.Cache, for any GET and with a cache
+// of the caller's choosing, such as one backed by Redis. It has no effect
+// on other methods.
+func WithResponseCache(cache Cache) RequestOption {
+	return func(o *requestOptions) {
+		o.cacheable = true
+		o.cache = cache
+	}
+}
+
+// getBody performs a GET and returns the body of a successful response.
+//
+// A call marked withETagCache or WithResponseCache goes through a Cache. An
+// entry within its Cache-Control max-age is served without a request; after
+// that, an entry younger than CacheTTL is revalidated with If-None-Match and
+// served on 304 Not Modified. Older entries are ignored so a server that
+// stops sending ETags cannot pin stale data. Responses are stored when they
+// carry an ETag or a max-age, unless marked no-store. Entries are kept per
+// tenant.
+func (c *ControlPlaneClient) getBody(ctx context.Context, path string, opts []RequestOption) ([]byte, error) {
+	o := c.applyOptions(opts)
+	cache := o.cache
+	if cache == nil {
+		cache = c.config.Cache
+	}
+	var key string
+	var entry CacheEntry
+	fresh := false
+	if o.cacheable && cache != nil {
+		var err error
+		if key, err = c.resolveURL(path, o.query); err != nil {
+			return nil, err
+		}
+		key = tenantCacheKey(key, c.tenant(ctx))
+		var ok bool
+		entry, ok = cache.Get(key)
+		now := c.config.Clock.Now()
+		if ok && now.Before(entry.FreshUntil) {
+			return entry.Body, nil
+		}
+		fresh = ok && entry.ETag != "" && now.Sub(entry.StoredAt) < c.config.CacheTTL
+		if fresh {
+			opts = append(opts, WithHeader("If-None-Match", entry.ETag))
+		}
+	}
+
+	resp, err := c.Request(ctx, http.MethodGet, path, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer drainAndClose(resp.Body)
+	if fresh && resp.StatusCode == http.StatusNotModified {
+		now := c.config.Clock.Now()
+		entry.StoredAt = now
+		entry.FreshUntil = freshUntil(resp.Header, now)
+		cache.Set(key, entry)
+		return entry.Body, nil
+	}
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read GET %s response: %w", path, err)
+	}
+	if key != "" && !hasCacheDirective(resp.Header, "no-store") {
+		now := c.config.Clock.Now()
+		entry := CacheEntry{ETag: resp.Header.Get("ETag"), Body: data, StoredAt: now, FreshUntil: freshUntil(resp.Header, now)}
+		if entry.ETag != "" || !entry.FreshUntil.IsZero() {
+			cache.Set(key, entry)
+		}
+	}
+	return data, nil
+}
+
+// freshUntil returns when a response stored at now goes stale according to
+// its Cache-Control max-age, or the zero time when it must be revalidated
+// on every use.
+func freshUntil(h http.Header, now time.Time) time.Time {
+	if hasCacheDirective(h, "no-cache") || hasCacheDirective(h, "no-store") {
+		return time.Time{}
+	}
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		secs, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil || secs <= 0 {
+			return time.Time{}
+		}
+		return now.Add(time.Duration(secs) * time.Second)
+	}
+	return time.Time{}
+}
+
+// hasCacheDirective reports whether the Cache-Control header holds the
+// directive.
+func hasCacheDirective(h http.Header, directive string) bool {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, directive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type manualClock struct{ now time.Time }
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func (c *manualClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", CacheEntry{ETag: "1"})
+	c.Set("b", CacheEntry{ETag: "2"})
+	c.Get("a")
+	c.Set("c", CacheEntry{ETag: "3"})
+	if _, ok := c.Get("b"); ok {
+		t.Error("b not evicted")
+	}
+	if e, ok := c.Get("a"); !ok || e.ETag != "1" {
+		t.Errorf("a = %+v, %v", e, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("len = %d", c.Len())
+	}
+}
+
+func TestCachedGetRevalidatesWithETag(t *testing.T) {
+	var conditional []string
+	etag := `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write([]byte(`{"version":"1.0.0","runners":[{"id":"r1"}]}`))
+	}))
+	defer srv.Close()
+	clock := &manualClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Cache: NewLRUCache(8), CacheTTL: time.Minute, Clock: clock})
+
+	for i := 0; i < 2; i++ {
+		reg, err := client.GetCapabilityRegistry(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reg.Version != "1.0.0" || len(reg.Runners) != 1 {
+			t.Errorf("call %d: registry = %+v", i, reg)
+		}
+	}
+	if conditional[0] != "" || conditional[1] != `"v1"` {
+		t.Errorf("If-None-Match = %q", conditional)
+	}
+
+	// Once the entry is older than the TTL it is fetched in full again.
+	clock.now = clock.now.Add(2 * time.Minute)
+	etag = ""
+	if _, err := client.GetCapabilityRegistry(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if conditional[2] != "" {
+		t.Errorf("expired entry revalidated with %q", conditional[2])
+	}
+}
+
+func TestCachedGetHonorsMaxAge(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.Header.Get("If-None-Match"))
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Header().Set("ETag", `"i1"`)
+		if r.Header.Get("If-None-Match") == `"i1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"version":"2.0.0"}`))
+	}))
+	defer srv.Close()
+	clock := &manualClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Cache: NewLRUCache(8), Clock: clock})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		index, err := client.GetMarketplaceIndex(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index.Version != "2.0.0" {
+			t.Errorf("call %d: version = %q", i, index.Version)
+		}
+	}
+	if len(requests) != 1 {
+		t.Fatalf("requests within max-age = %q", requests)
+	}
+
+	// Past max-age the entry is revalidated, and the 304 restarts max-age.
+	clock.now = clock.now.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		if index, err := client.GetMarketplaceIndex(ctx); err != nil || index.Version != "2.0.0" {
+			t.Fatalf("revalidated index = %+v, %v", index, err)
+		}
+	}
+	if len(requests) != 2 || requests[1] != `GET "i1"` {
+		t.Errorf("requests = %q", requests)
+	}
+}
+
+func TestWithResponseCacheSkipsNoStoreAndMutations(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/health" {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		w.Header().Set("ETag", `"h1"`)
+		w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	defer srv.Close()
+	cache := NewLRUCache(8)
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	ctx := context.Background()
+
+	if _, err := client.GetHealth(ctx, WithResponseCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("no-store response cached")
+	}
+	var out map[string]interface{}
+	if err := client.call(ctx, http.MethodPost, "/v1/jobs", map[string]string{}, &out, WithResponseCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 0 || hits != 2 {
+		t.Errorf("POST went through cache: len = %d, hits = %d", cache.Len(), hits)
+	}
+}
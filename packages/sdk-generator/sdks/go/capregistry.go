@@ -0,0 +1,219 @@
+package controlplane
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RegistrySummary is the typed form of CapabilityRegistry.Summary.
+type RegistrySummary struct {
+	TotalRunners      int `json:"totalRunners"`
+	TotalCapabilities int `json:"totalCapabilities"`
+	TotalConnectors   int `json:"totalConnectors"`
+	HealthyRunners    int `json:"healthyRunners"`
+	HealthyConnectors int `json:"healthyConnectors"`
+	// Categories counts runners by category, such as "ops" or "finops".
+	Categories map[string]int `json:"categories"`
+	// RunnersByHealth counts runners by health status and
+	// ConnectorsByStatus connectors by status. The server does not send
+	// them; SummaryTyped tallies them from the registry's entries.
+	RunnersByHealth    map[string]int `json:"-"`
+	ConnectorsByStatus map[string]int `json:"-"`
+}
+
+// RegisteredRunners decodes Runners. Every entry is decoded; entries that
+// fail are omitted from the result and reported together in the returned
+// ValidationErrors, keyed by their index.
+func (m CapabilityRegistry) RegisteredRunners() ([]RegisteredRunner, error) {
+	var errs ValidationErrors
+	runners := make([]RegisteredRunner, 0, len(m.Runners))
+	for i, raw := range m.Runners {
+		var r RegisteredRunner
+		if err := decodeMap(raw, &r); err != nil {
+			errs.Add(fmt.Sprintf("runners[%d]", i), err.Error())
+			continue
+		}
+		runners = append(runners, r)
+	}
+	if !errs.IsValid() {
+		return runners, &errs
+	}
+	return runners, nil
+}
+
+// ConnectorInstances decodes Connectors like RegisteredRunners.
+func (m CapabilityRegistry) ConnectorInstances() ([]ConnectorInstance, error) {
+	var errs ValidationErrors
+	connectors := make([]ConnectorInstance, 0, len(m.Connectors))
+	for i, raw := range m.Connectors {
+		var c ConnectorInstance
+		if err := decodeMap(raw, &c); err != nil {
+			errs.Add(fmt.Sprintf("connectors[%d]", i), err.Error())
+			continue
+		}
+		connectors = append(connectors, c)
+	}
+	if !errs.IsValid() {
+		return connectors, &errs
+	}
+	return connectors, nil
+}
+
+// ConnectorConfigs decodes the config of every entry in Connectors, dropping
+// their connection status; see ConnectorInstances. Entries that fail are
+// reported like in RegisteredRunners.
+func (m CapabilityRegistry) ConnectorConfigs() ([]ConnectorConfig, error) {
+	var errs ValidationErrors
+	configs := make([]ConnectorConfig, 0, len(m.Connectors))
+	for i, raw := range m.Connectors {
+		var c ConnectorConfig
+		cfg, ok := raw["config"].(map[string]interface{})
+		if !ok {
+			errs.Add(fmt.Sprintf("connectors[%d].config", i), "is required")
+			continue
+		}
+		if err := decodeMap(cfg, &c); err != nil {
+			errs.Add(fmt.Sprintf("connectors[%d].config", i), err.Error())
+			continue
+		}
+		configs = append(configs, c)
+	}
+	if !errs.IsValid() {
+		return configs, &errs
+	}
+	return configs, nil
+}
+
+// SummaryTyped decodes Summary and adds the health breakdown of the
+// registry's runners and connectors. Entries without a status are counted
+// as "unknown".
+func (m CapabilityRegistry) SummaryTyped() (RegistrySummary, error) {
+	var s RegistrySummary
+	if m.Summary == nil {
+		return s, errors.New("summary is not set")
+	}
+	if err := decodeMap(m.Summary, &s); err != nil {
+		return s, fmt.Errorf("decode summary: %w", err)
+	}
+	s.RunnersByHealth = make(map[string]int)
+	for _, r := range m.Runners {
+		health, _ := r["health"].(map[string]interface{})
+		s.RunnersByHealth[statusOrUnknown(health["status"])]++
+	}
+	s.ConnectorsByStatus = make(map[string]int)
+	for _, c := range m.Connectors {
+		s.ConnectorsByStatus[statusOrUnknown(c["status"])]++
+	}
+	return s, nil
+}
+
+func statusOrUnknown(v interface{}) string {
+	if s, ok := v.(string); ok && s != "" {
+		return s
+	}
+	return "unknown"
+}
+
+// DefaultRegistryQuery returns the query the server applies when its fields
+// are omitted: runners of any category and health, with their capabilities
+// and connectors included.
+func DefaultRegistryQuery() RegistryQuery {
+	return RegistryQuery{HealthStatus: "any", IncludeCapabilities: Bool(true), IncludeConnectors: Bool(true)}
+}
+
+// Filter applies q to the registry locally, e.g. to a cached copy, and
+// returns the filtered copy; the registry itself is not modified.
+//
+// Runners are kept when they match Category and HealthStatus, where an
+// empty or "any" HealthStatus matches every runner. ConnectorType keeps the
+// connectors of that type and the runners that use one of them. The include
+// flags default to true, as on the server: IncludeCapabilities false empties
+// each runner's capabilities and IncludeConnectors false empties the
+// connector lists. The summary is copied unchanged and still describes the
+// whole registry.
+func (m CapabilityRegistry) Filter(q RegistryQuery) CapabilityRegistry {
+	connectors := m.Connectors
+	if q.ConnectorType != "" {
+		connectors = nil
+		for _, c := range m.Connectors {
+			if cfg, _ := c["config"].(map[string]interface{}); cfg["type"] == q.ConnectorType {
+				connectors = append(connectors, c)
+			}
+		}
+	}
+	includeCapabilities := q.IncludeCapabilities == nil || *q.IncludeCapabilities
+	includeConnectors := q.IncludeConnectors == nil || *q.IncludeConnectors
+	kept := make(map[string]bool, len(connectors))
+	for _, c := range connectors {
+		if cfg, _ := c["config"].(map[string]interface{}); cfg != nil {
+			if id, ok := cfg["id"].(string); ok {
+				kept[id] = true
+			}
+		}
+	}
+
+	runners := make([]map[string]interface{}, 0, len(m.Runners))
+	for _, r := range m.Runners {
+		if q.Category != "" && r["category"] != q.Category {
+			continue
+		}
+		if q.HealthStatus != "" && q.HealthStatus != "any" {
+			if health, _ := r["health"].(map[string]interface{}); health["status"] != q.HealthStatus {
+				continue
+			}
+		}
+		if q.ConnectorType != "" && !usesConnector(r, kept) {
+			continue
+		}
+		if !includeCapabilities || !includeConnectors {
+			r = copyMap(r)
+			if !includeCapabilities {
+				r["capabilities"] = []interface{}{}
+				if meta, ok := r["metadata"].(map[string]interface{}); ok {
+					meta = copyMap(meta)
+					meta["capabilities"] = []interface{}{}
+					r["metadata"] = meta
+				}
+			}
+			if !includeConnectors {
+				r["connectors"] = []interface{}{}
+			}
+		}
+		runners = append(runners, r)
+	}
+
+	m.Runners = runners
+	if includeConnectors {
+		m.Connectors = append([]map[string]interface{}{}, connectors...)
+	} else {
+		m.Connectors = []map[string]interface{}{}
+	}
+	return m
+}
+
+// usesConnector reports whether a raw RegisteredRunner lists one of ids.
+func usesConnector(runner map[string]interface{}, ids map[string]bool) bool {
+	switch list := runner["connectors"].(type) {
+	case []string:
+		for _, id := range list {
+			if ids[id] {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, id := range list {
+			if s, ok := id.(string); ok && ids[s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
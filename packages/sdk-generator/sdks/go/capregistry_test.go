@@ -0,0 +1,164 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func loadRegistry(t *testing.T) CapabilityRegistry {
+	t.Helper()
+	data, err := os.ReadFile("testdata/registry.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reg CapabilityRegistry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestCapabilityRegistryAccessors(t *testing.T) {
+	reg := loadRegistry(t)
+
+	runners, err := reg.RegisteredRunners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runners) != 2 || runners[0].Category != "finops" || runners[1].Health["status"] != "degraded" {
+		t.Errorf("runners = %+v", runners)
+	}
+
+	configs, err := reg.ConnectorConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 || configs[0].Id != "postgres-main" || !BoolValue(configs[0].Required) || configs[1].Type != "queue" {
+		t.Errorf("connector configs = %+v", configs)
+	}
+	instances, err := reg.ConnectorInstances()
+	if err != nil || len(instances) != 2 || instances[1].ErrorMessage != "connection refused" {
+		t.Errorf("connector instances = %+v, %v", instances, err)
+	}
+
+	summary, err := reg.SummaryTyped()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RegistrySummary{
+		TotalRunners:       2,
+		TotalCapabilities:  1,
+		TotalConnectors:    2,
+		HealthyRunners:     1,
+		HealthyConnectors:  1,
+		Categories:         map[string]int{"finops": 1, "ops": 1},
+		RunnersByHealth:    map[string]int{"healthy": 1, "degraded": 1},
+		ConnectorsByStatus: map[string]int{"connected": 1, "error": 1},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+}
+
+func TestCapabilityRegistryAccessorErrorsNameElement(t *testing.T) {
+	reg := loadRegistry(t)
+	reg.Runners[1]["category"] = 7
+	reg.Connectors[0] = map[string]interface{}{"status": "connected"}
+
+	runners, err := reg.RegisteredRunners()
+	verrs, ok := err.(*ValidationErrors)
+	if !ok || len(verrs.Errors) != 1 || verrs.Errors[0].Field != "runners[1]" {
+		t.Errorf("RegisteredRunners() error = %v", err)
+	}
+	if len(runners) != 1 || runners[0].Category != "finops" {
+		t.Errorf("runners = %+v", runners)
+	}
+
+	configs, err := reg.ConnectorConfigs()
+	verrs, ok = err.(*ValidationErrors)
+	if !ok || len(verrs.Errors) != 1 || verrs.Errors[0].Field != "connectors[0].config" {
+		t.Errorf("ConnectorConfigs() error = %v", err)
+	}
+	if len(configs) != 1 || configs[0].Id != "events-queue" {
+		t.Errorf("configs = %+v", configs)
+	}
+
+	if _, err := (CapabilityRegistry{}).SummaryTyped(); err == nil {
+		t.Error("SummaryTyped() of an empty registry succeeded")
+	}
+}
+
+func runnerIDs(t *testing.T, reg CapabilityRegistry) []string {
+	t.Helper()
+	runners, err := reg.RegisteredRunners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, r := range runners {
+		ids = append(ids, r.Metadata["id"].(string))
+	}
+	return ids
+}
+
+func TestCapabilityRegistryFilter(t *testing.T) {
+	reg := loadRegistry(t)
+	query := func(edit func(*RegistryQuery)) RegistryQuery {
+		q := DefaultRegistryQuery()
+		edit(&q)
+		return q
+	}
+	cases := []struct {
+		name       string
+		q          RegistryQuery
+		runners    []string
+		connectors int
+	}{
+		{"default", DefaultRegistryQuery(), []string{"finops-runner", "ops-runner"}, 2},
+		{"category", query(func(q *RegistryQuery) { q.Category = "ops" }), []string{"ops-runner"}, 2},
+		{"health", query(func(q *RegistryQuery) { q.HealthStatus = "healthy" }), []string{"finops-runner"}, 2},
+		{"health any", query(func(q *RegistryQuery) { q.HealthStatus = "any" }), []string{"finops-runner", "ops-runner"}, 2},
+		{"connector type", query(func(q *RegistryQuery) { q.ConnectorType = "database" }), []string{"finops-runner"}, 1},
+		{"unused connector type", query(func(q *RegistryQuery) { q.ConnectorType = "queue" }), []string{}, 1},
+		{"no match", query(func(q *RegistryQuery) { q.Category = "security" }), []string{}, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := reg.Filter(tc.q)
+			if ids := runnerIDs(t, got); !reflect.DeepEqual(ids, tc.runners) {
+				t.Errorf("runners = %v, want %v", ids, tc.runners)
+			}
+			if len(got.Connectors) != tc.connectors {
+				t.Errorf("connectors = %d, want %d", len(got.Connectors), tc.connectors)
+			}
+		})
+	}
+}
+
+func TestCapabilityRegistryFilterIncludeFlags(t *testing.T) {
+	reg := loadRegistry(t)
+
+	q := DefaultRegistryQuery()
+	q.IncludeCapabilities = Bool(false)
+	got := reg.Filter(q)
+	runners, _ := got.RegisteredRunners()
+	if len(runners) != 2 || len(runners[0].Capabilities) != 0 || len(runners[0].Connectors) != 1 {
+		t.Errorf("without capabilities: %+v", runners[0])
+	}
+	if caps := reg.Runners[0]["capabilities"].([]interface{}); len(caps) != 1 {
+		t.Errorf("original runner stripped: %v", caps)
+	}
+
+	q = DefaultRegistryQuery()
+	q.IncludeConnectors = Bool(false)
+	got = reg.Filter(q)
+	runners, _ = got.RegisteredRunners()
+	if len(got.Connectors) != 0 || len(runners[0].Connectors) != 0 || len(runners[0].Capabilities) != 1 {
+		t.Errorf("without connectors: %d connectors, runner %+v", len(got.Connectors), runners[0])
+	}
+	if len(reg.Connectors) != 2 || len(reg.Runners[0]["connectors"].([]interface{})) != 1 {
+		t.Error("original connectors stripped")
+	}
+}
@@ -0,0 +1,285 @@
+// Package cassette records the ControlPlane client's HTTP traffic to a JSON
+// file and replays it later, so integration-style tests written once
+// against a real control plane can run offline in CI.
+//
+//	rec, err := cassette.New("testdata/jobs.json", cassette.Options{
+//		Mode:         cassette.ModeReplay,
+//		IgnoreFields: []string{"id", "metadata.createdAt"},
+//	})
+//	client, err := controlplane.NewClient(controlplane.ClientConfig{
+//		BaseURL:   baseURL,
+//		Transport: rec,
+//	})
+//
+// Run once with ModeRecord and call Save to write the cassette.
+// Credentials are never written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette and fails on requests it
+	// does not contain.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests through Options.Transport and records them.
+	ModeRecord
+)
+
+// ErrNoMatch is returned in replay mode for a request the cassette has no
+// unused interaction for.
+var ErrNoMatch = errors.New("cassette: no recorded interaction matches request")
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file format: interactions in the order they were made.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Matcher reports whether a live request matches a recorded one.
+type Matcher func(recorded, live Request) bool
+
+// Options configures a Recorder.
+type Options struct {
+	Mode Mode
+	// Transport sends requests in record mode. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// IgnoreFields lists JSON body fields, as dotted paths such as
+	// "metadata.createdAt", left out when matching bodies. Use it for
+	// timestamps, generated ids and other values that change between runs.
+	IgnoreFields []string
+	// Match replaces the default matching of method, path, query and body.
+	Match Matcher
+}
+
+// credentialHeaders are dropped from recorded requests and responses.
+var credentialHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Signature",
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	path string
+	opts Options
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New returns a Recorder for the cassette at path. In replay mode the file
+// is loaded immediately; in record mode it is written by Save.
+func New(path string, opts Options) (*Recorder, error) {
+	r := &Recorder{path: path, opts: opts}
+	if r.opts.Transport == nil {
+		r.opts.Transport = http.DefaultTransport
+	}
+	if r.opts.Match == nil {
+		r.opts.Match = r.defaultMatch
+	}
+	if opts.Mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: decode %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	live, err := captureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.Mode == ModeReplay {
+		return r.replay(req, live)
+	}
+	return r.record(req, live)
+}
+
+func (r *Recorder) replay(req *http.Request, live Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !r.opts.Match(in.Request, live) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, live.Method, live.Path)
+}
+
+func (r *Recorder) record(req *http.Request, live Request) (*http.Response, error) {
+	resp, err := r.opts.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  live,
+		Response: Response{Status: resp.StatusCode, Header: stripCredentials(resp.Header), Body: string(body)},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It does
+// nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.opts.Mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Unused returns the recorded interactions that were never replayed, which
+// usually means the code under test made fewer calls than when recorded.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Interaction
+	for i, used := range r.used {
+		if !used {
+			out = append(out, r.cassette.Interactions[i])
+		}
+	}
+	return out
+}
+
+func captureRequest(req *http.Request) (Request, error) {
+	c := Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Header: stripCredentials(req.Header),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return c, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		c.Body = string(body)
+	}
+	return c, nil
+}
+
+func stripCredentials(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range credentialHeaders {
+		out.Del(name)
+	}
+	return out
+}
+
+// defaultMatch compares method, path and query exactly and bodies as JSON
+// with the ignored fields removed. Headers are not compared.
+func (r *Recorder) defaultMatch(recorded, live Request) bool {
+	if recorded.Method != live.Method || recorded.Path != live.Path || recorded.Query != live.Query {
+		return false
+	}
+	return r.bodiesMatch(recorded.Body, live.Body)
+}
+
+func (r *Recorder) bodiesMatch(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var av, bv interface{}
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		return false
+	}
+	for _, field := range r.opts.IgnoreFields {
+		path := strings.Split(field, ".")
+		deletePath(av, path)
+		deletePath(bv, path)
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// deletePath removes the field at path from a decoded JSON object. Arrays
+// along the path have the field removed from every element.
+func deletePath(v interface{}, path []string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(v, path[0])
+			return
+		}
+		deletePath(v[path[0]], path[1:])
+	case []interface{}:
+		for _, item := range v {
+			deletePath(item, path)
+		}
+	}
+}
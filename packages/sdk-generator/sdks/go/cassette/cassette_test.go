@@ -0,0 +1,96 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	controlplane "github.com/controlplane/sdk-go"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`{"id":"j1","status":"queued"}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	rec, err := New(path, Options{Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: srv.URL, APIKey: "live-key", Transport: rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := controlplane.JobRequest{Id: "j1", Type: "csv", Metadata: map[string]interface{}{"createdAt": "2024-01-01T00:00:00Z"}}
+	if _, err := client.SubmitJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, secret := range []string{"live-key", "session=secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	srv.Close()
+	replay, err := New(path, Options{Mode: ModeReplay, IgnoreFields: []string{"id", "metadata.createdAt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err = controlplane.NewClient(controlplane.ClientConfig{BaseURL: srv.URL, Transport: replay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Id = "j2"
+	job.Metadata["createdAt"] = "2025-06-01T00:00:00Z"
+	resp, err := client.SubmitJob(context.Background(), job, controlplane.WithIdempotencyKey("j1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "queued" {
+		t.Errorf("replayed response = %+v", resp)
+	}
+	if len(replay.Unused()) != 0 {
+		t.Errorf("unused = %v", replay.Unused())
+	}
+
+	_, err = client.SubmitJob(context.Background(), job)
+	if !errors.Is(err, ErrNoMatch) {
+		t.Errorf("second replay err = %v, want ErrNoMatch", err)
+	}
+	job.Type = "render"
+	replay, _ = New(path, Options{Mode: ModeReplay, IgnoreFields: []string{"id", "metadata.createdAt"}})
+	client, _ = controlplane.NewClient(controlplane.ClientConfig{BaseURL: srv.URL, Transport: replay})
+	if _, err := client.SubmitJob(context.Background(), job); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("changed body err = %v, want ErrNoMatch", err)
+	}
+}
+
+func TestCustomMatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.json")
+	os.WriteFile(path, []byte(`{"interactions":[{"request":{"method":"GET","path":"/health","query":"probe=1"},"response":{"status":200,"body":"{\"status\":\"healthy\"}"}}]}`), 0o644)
+
+	rec, err := New(path, Options{Mode: ModeReplay, Match: func(recorded, live Request) bool {
+		return recorded.Method == live.Method && recorded.Path == live.Path
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ := controlplane.NewClient(controlplane.ClientConfig{BaseURL: "http://offline.test", Transport: rec})
+	health, err := client.GetHealth(context.Background())
+	if err != nil || health.Status != "healthy" {
+		t.Errorf("health = %+v, %v", health, err)
+	}
+}
@@ -0,0 +1,130 @@
+package controlplane
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Checksum returns a hex SHA-256 of the registry's canonical JSON form. It
+// ignores GeneratedAt, so a regenerated but otherwise identical registry
+// has the same checksum.
+func (m CapabilityRegistry) Checksum() (string, error) {
+	return checksum(m, "generatedAt")
+}
+
+// Checksum returns a hex SHA-256 of the index's canonical JSON form. It
+// ignores GeneratedAt, so a regenerated but otherwise identical index has
+// the same checksum.
+func (m MarketplaceIndex) Checksum() (string, error) {
+	return checksum(m, "generatedAt")
+}
+
+// Checksum returns a hex SHA-256 of the runner's canonical JSON form. It
+// ignores LastHeartbeatAt, which changes without the runner changing.
+func (m RunnerMetadata) Checksum() (string, error) {
+	return checksum(m, "lastHeartbeatAt")
+}
+
+// checksum hashes the canonical JSON form of v, leaving out the given
+// top-level fields.
+func checksum(v interface{}, ignore ...string) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("checksum %s: %w", typeName(v), err)
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return "", fmt.Errorf("checksum %s: %w", typeName(v), err)
+	}
+	if obj, ok := doc.(map[string]interface{}); ok {
+		for _, key := range ignore {
+			delete(obj, key)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(&buf, doc); err != nil {
+		return "", fmt.Errorf("checksum %s: %w", typeName(v), err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeCanonicalJSON writes v, a value produced by decoding JSON with
+// UseNumber, with object keys sorted bytewise and no insignificant
+// whitespace. Numbers keep their original text so formatting cannot drift
+// between Go versions.
+func writeCanonicalJSON(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case json.Number:
+		buf.WriteString(v.String())
+	case string:
+		writeCanonicalString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonicalJSON(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected JSON value of type %T", v)
+	}
+	return nil
+}
+
+// writeCanonicalString quotes s, escaping only what JSON requires.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hexDigits[r>>4])
+			buf.WriteByte(hexDigits[r&0xf])
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
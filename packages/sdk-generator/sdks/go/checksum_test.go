@@ -0,0 +1,75 @@
+package controlplane
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// shuffledMap builds a map with keys inserted in a random order.
+func shuffledMap(r *rand.Rand, kv map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	r.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	out := make(map[string]interface{}, len(kv))
+	for _, k := range keys {
+		out[k] = kv[k]
+	}
+	return out
+}
+
+func TestChecksumIgnoresMapOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	build := func() CapabilityRegistry {
+		runner := map[string]interface{}{"id": "r1", "name": "csv", "version": "1.0.0", "tags": []interface{}{"a", "b"}, "score": 0.5}
+		return CapabilityRegistry{
+			Version:     "1",
+			GeneratedAt: time.Now(),
+			System:      shuffledMap(r, map[string]interface{}{"name": "cp", "region": "eu", "nodes": 3, "note": "<&>"}),
+			Runners:     []map[string]interface{}{shuffledMap(r, runner)},
+			Summary:     shuffledMap(r, map[string]interface{}{"runners": 1, "connectors": 0}),
+		}
+	}
+
+	want, err := build().Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		got, err := build().Checksum()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("checksum changed: %s != %s", got, want)
+		}
+	}
+
+	changed := build()
+	changed.Summary["runners"] = 2
+	if got, _ := changed.Checksum(); got == want {
+		t.Error("checksum did not change with content")
+	}
+}
+
+func TestChecksumCanonicalForm(t *testing.T) {
+	// Pin the canonical encoding so it cannot drift between releases. The
+	// expected value is the SHA-256 of
+	// {"capabilities":null,"contractVersion":{"major":1,"minor":2,"patch":0},"healthCheckEndpoint":"","id":"r1","name":"","registeredAt":"0001-01-01T00:00:00Z","supportedContracts":["jobs"],"version":""}
+	m := RunnerMetadata{
+		Id:                 "r1",
+		ContractVersion:    map[string]interface{}{"patch": 0, "major": 1, "minor": 2},
+		SupportedContracts: []string{"jobs"},
+		LastHeartbeatAt:    time.Now(),
+	}
+	got, err := m.Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "4815424801d49f40158f7a7a72bdc3b31b756fe1ce383880f1754a1784bb712a"
+	if got != want {
+		t.Errorf("checksum = %s, want %s", got, want)
+	}
+}
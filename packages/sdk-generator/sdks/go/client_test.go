@@ -0,0 +1,226 @@
+package controlplane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func mustNewClient(t *testing.T, config ClientConfig) *ControlPlaneClient {
+	t.Helper()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+// captureURL is a middleware that records the outgoing URL without sending
+// the request.
+func captureURL(got *string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			*got = req.URL.String()
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+	}
+}
+
+func TestRequestURLJoining(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+	}{
+		{"https://cp.example.com", "/health", "https://cp.example.com/health"},
+		{"https://cp.example.com/", "/health", "https://cp.example.com/health"},
+		{"https://cp.example.com/api/v1", "/jobs", "https://cp.example.com/api/v1/jobs"},
+		{"https://cp.example.com/api/v1/", "/jobs", "https://cp.example.com/api/v1/jobs"},
+		{"https://cp.example.com/api/v1/", "jobs?limit=10", "https://cp.example.com/api/v1/jobs?limit=10"},
+		{"http://localhost:8080", "/v1/jobs/a%2Fb", "http://localhost:8080/v1/jobs/a%2Fb"},
+	}
+	for _, tt := range tests {
+		var got string
+		client := mustNewClient(t, ClientConfig{BaseURL: tt.base, Middlewares: []Middleware{captureURL(&got)}})
+		resp, err := client.Request(context.Background(), http.MethodGet, tt.path, nil)
+		if err != nil {
+			t.Fatalf("%s + %s: %v", tt.base, tt.path, err)
+		}
+		resp.Body.Close()
+		if got != tt.want {
+			t.Errorf("%s + %s = %s, want %s", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientRejectsMalformedBaseURL(t *testing.T) {
+	for _, base := range []string{"", "cp.example.com", "ftp://cp.example.com", "http://", "http://cp.example.com/?x=1", "://bad"} {
+		if _, err := NewClient(ClientConfig{BaseURL: base}); err == nil {
+			t.Errorf("NewClient(%q) succeeded", base)
+		}
+	}
+}
+
+func TestRequestRejectsAbsolutePath(t *testing.T) {
+	client := mustNewClient(t, ClientConfig{BaseURL: "https://cp.example.com"})
+	if _, err := client.Request(context.Background(), http.MethodGet, "https://evil.example.com/x", nil); err == nil {
+		t.Error("absolute path accepted")
+	}
+}
+
+func TestUserAgentOnEveryAttempt(t *testing.T) {
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		if len(agents) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:         srv.URL,
+		Retry:           &RetryPolicy{MaxRetries: Int(1)},
+		UserAgentSuffix: "billing-worker/2.3",
+	})
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "controlplane-go-sdk/" + SDKVersion + " contract/1.0.0 go/" + strings.TrimPrefix(runtime.Version(), "go") + " billing-worker/2.3"
+	if len(agents) != 2 || agents[0] != want || agents[1] != want {
+		t.Errorf("agents = %q, want %q", agents, want)
+	}
+}
+
+func TestUserAgentOverrideAndClientName(t *testing.T) {
+	var agent string
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:         "https://cp.example.com",
+		UserAgent:       "dashboard-sdk/1.0",
+		UserAgentSuffix: "dashboard/4.2",
+		Middlewares: []Middleware{func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				agent = req.Header.Get("User-Agent")
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			}
+		}},
+	})
+
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if agent != "dashboard-sdk/1.0 dashboard/4.2" {
+		t.Errorf("agent = %q", agent)
+	}
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil, WithClientName("runner-panel", "0.9")); err != nil {
+		t.Fatal(err)
+	}
+	if agent != "dashboard-sdk/1.0 dashboard/4.2 runner-panel/0.9" {
+		t.Errorf("agent with client name = %q", agent)
+	}
+}
+
+// uploadServer records each uploaded body and answers 503 to the first
+// attempt.
+func uploadServer(t *testing.T, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+			t.Errorf("transfer encoding = %v", r.TransferEncoding)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/octet-stream" {
+			t.Errorf("content type = %q", ct)
+		}
+		data, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(data))
+		if len(*bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+}
+
+func TestRequestStreamDoesNotRetryWithoutGetBody(t *testing.T) {
+	var bodies []string
+	srv := uploadServer(t, &bodies)
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Retry: &RetryPolicy{MaxRetries: Int(2)}})
+
+	// A reader without Len or Seek, as a file or pipe would be.
+	body := io.MultiReader(strings.NewReader("artifact-"), strings.NewReader("bytes"))
+	resp, err := client.RequestStream(context.Background(), http.MethodPut, "/v1/artifacts/a1", body, "application/octet-stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+	if len(bodies) != 1 || bodies[0] != "artifact-bytes" {
+		t.Errorf("bodies = %q", bodies)
+	}
+}
+
+func TestRequestStreamRetriesWithGetBody(t *testing.T) {
+	var bodies []string
+	srv := uploadServer(t, &bodies)
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Retry: &RetryPolicy{MaxRetries: Int(2)}})
+
+	opened := 0
+	getBody := func() (io.ReadCloser, error) {
+		opened++
+		return io.NopCloser(io.MultiReader(strings.NewReader("artifact-"), strings.NewReader("bytes"))), nil
+	}
+	resp, err := client.RequestStream(context.Background(), http.MethodPut, "/v1/artifacts/a1", nil, "application/octet-stream",
+		WithGetBody(getBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || opened != 2 {
+		t.Errorf("status = %d, opened = %d", resp.StatusCode, opened)
+	}
+	if len(bodies) != 2 || bodies[0] != "artifact-bytes" || bodies[1] != "artifact-bytes" {
+		t.Errorf("bodies = %q", bodies)
+	}
+}
+
+// TestClientConcurrentUse is meant to be run with -race.
+func TestClientConcurrentUse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"name":"cp","version":"1","contractVersion":"1.0.0","startTime":"2026-01-01T00:00:00Z"}`)
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Request(ctx, http.MethodGet, "/health", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			if v := client.GetContractVersion(); v.Major != 1 {
+				t.Errorf("contract version = %v", v)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.NegotiateContractVersion(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
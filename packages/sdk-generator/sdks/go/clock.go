@@ -0,0 +1,46 @@
+package controlplane
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time for the client: retry and reconnect delays,
+// Retry-After dates, request durations, deadlines, deprecation sunsets,
+// polling intervals and the time checks of ControlPlaneClient.Validate.
+// Tests can substitute controlplanetest.FakeClock to control time by hand.
+//
+// Functions not tied to a client read the system time, and each has a way
+// to take another: JobRequestBuilder.WithClock,
+// CachingTokenSource.WithClock, WithTimestamp for NewErrorEnvelope and
+// ToEnvelope, and the ValidateAt, AllowsAt and ApiRequestFromHTTPAt
+// variants that take the current time. Certificates are checked against the
+// system time, as the TLS handshake is.
+type Clock interface {
+	Now() time.Time
+	// Sleep blocks for d or until ctx is done, returning ctx.Err() in the
+	// latter case.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// RealClock returns the Clock backed by the system time, which is the
+// default.
+func RealClock() Clock { return realClock{} }
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
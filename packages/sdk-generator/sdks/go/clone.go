@@ -0,0 +1,96 @@
+package controlplane
+
+import "reflect"
+
+// Clone returns a deep copy of v: maps, slices, pointers and interface
+// values are copied recursively, so the result shares no mutable state with
+// v. The generated model types hold their nested documents in maps and
+// slices, so a plain struct copy still aliases them; use Clone or the
+// DeepCopy methods before mutating a model that is shared.
+func Clone[T any](v T) T {
+	out := deepCopy(reflect.ValueOf(&v).Elem())
+	return out.Interface().(T)
+}
+
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return out
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(deepCopy(v.Elem()))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(deepCopy(v.Elem()))
+		return out
+	case reflect.Struct:
+		// Copy the whole struct first so unexported fields, such as those
+		// of time.Time, are carried over by value.
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				out.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m JobRequest) DeepCopy() JobRequest { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m JobResponse) DeepCopy() JobResponse { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m RunnerCapability) DeepCopy() RunnerCapability { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m RunnerMetadata) DeepCopy() RunnerMetadata { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m RunnerRegistrationRequest) DeepCopy() RunnerRegistrationRequest { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m ModuleManifest) DeepCopy() ModuleManifest { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m TruthAssertion) DeepCopy() TruthAssertion { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m MarketplaceRunner) DeepCopy() MarketplaceRunner { return Clone(m) }
+
+// DeepCopy returns a copy of m that shares no maps or slices with it.
+func (m MarketplaceConnector) DeepCopy() MarketplaceConnector { return Clone(m) }
@@ -0,0 +1,54 @@
+package controlplane
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeepCopyDoesNotAlias(t *testing.T) {
+	orig := JobRequest{
+		Id:   "job-1",
+		Type: "csv",
+		Payload: map[string]interface{}{
+			"rows": []interface{}{map[string]interface{}{"a": 1.0}},
+		},
+		Metadata: map[string]interface{}{"createdAt": "2026-01-01T00:00:00Z"},
+	}
+	want := Clone(orig)
+
+	c := orig.DeepCopy()
+	c.Payload["rows"].([]interface{})[0].(map[string]interface{})["a"] = 2.0
+	c.Payload["extra"] = true
+	c.Metadata["createdAt"] = "changed"
+	if !reflect.DeepEqual(orig, want) {
+		t.Errorf("original mutated: %+v", orig)
+	}
+
+	runner := RunnerMetadata{
+		Id:              "r1",
+		Tags:            []string{"a", "b"},
+		Capabilities:    []map[string]interface{}{{"id": "cap"}},
+		LastHeartbeatAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	rc := runner.DeepCopy()
+	rc.Tags[0] = "z"
+	rc.Capabilities[0]["id"] = "other"
+	if runner.Tags[0] != "a" || runner.Capabilities[0]["id"] != "cap" {
+		t.Errorf("runner mutated: %+v", runner)
+	}
+	if !rc.LastHeartbeatAt.Equal(runner.LastHeartbeatAt) {
+		t.Errorf("time not copied: %v", rc.LastHeartbeatAt)
+	}
+}
+
+func TestCloneKeepsNil(t *testing.T) {
+	var m JobRequest
+	if c := Clone(m); c.Payload != nil || c.Metadata != nil {
+		t.Errorf("nil maps became %+v", c)
+	}
+	var p *JobRequest
+	if Clone(p) != nil {
+		t.Error("nil pointer cloned to non-nil")
+	}
+}
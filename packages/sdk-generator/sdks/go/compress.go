@@ -0,0 +1,58 @@
+package controlplane
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipPayload compresses a request payload.
+func gzipPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressResponses replaces a gzip-encoded response body with its
+// decompressed form. Responses without Content-Encoding: gzip, such as
+// plain JSON from a server that ignored Accept-Encoding, pass through.
+func decompressResponses(send RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		resp, err := send(req)
+		if err != nil || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			return resp, err
+		}
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("decompress %s response: %w", req.Method, err)
+		}
+		resp.Body = &gzipReadCloser{Reader: zr, body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+		return resp, nil
+	}
+}
+
+// gzipReadCloser reads a decompressed stream and closes the underlying
+// body.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipReadCloser) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
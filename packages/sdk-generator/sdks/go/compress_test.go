@@ -0,0 +1,95 @@
+package controlplane
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipResponses(t *testing.T) {
+	const body = `{"id":"job-1","status":"completed"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/jobs/plain" {
+			io.WriteString(w, body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, body)
+		zw.Close()
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Gzip: true})
+
+	for _, id := range []string{"zipped", "plain"} {
+		job, err := client.GetJob(context.Background(), id)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if job.Id != "job-1" || job.Status != JobStatusCOMPLETED {
+			t.Errorf("%s: job = %+v", id, job)
+		}
+	}
+}
+
+func TestGzipResponsesRespectBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, `{"id":"`+strings.Repeat("x", 4096)+`"}`)
+		zw.Close()
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Gzip: true, MaxResponseBytes: 1024})
+
+	if _, err := client.GetJob(context.Background(), "j"); err == nil || !strings.Contains(err.Error(), "MaxResponseBytes") {
+		t.Errorf("err = %v, want the decompressed size to be limited", err)
+	}
+}
+
+func TestGzipRequestBodies(t *testing.T) {
+	var encodings []string
+	var ids []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = zr
+		}
+		data, _ := io.ReadAll(body)
+		if !bytes.Contains(data, []byte(`"type":"noop"`)) {
+			t.Errorf("body = %q", data)
+		}
+		ids = append(ids, r.Header.Get("Idempotency-Key"))
+		io.WriteString(w, `{"id":"job","status":"queued"}`)
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, GzipRequestMinBytes: 200})
+
+	small := JobRequest{Id: "small", Type: "noop"}
+	large := JobRequest{Id: "large", Type: "noop", Payload: map[string]interface{}{"data": strings.Repeat("x", 500)}}
+	for _, job := range []JobRequest{small, large} {
+		if _, err := client.SubmitJob(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if encodings[0] != "" || encodings[1] != "gzip" {
+		t.Errorf("encodings = %q", encodings)
+	}
+	if ids[1] != "large" {
+		t.Errorf("idempotency key lost: %q", ids)
+	}
+}
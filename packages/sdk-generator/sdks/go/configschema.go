@@ -0,0 +1,105 @@
+package controlplane
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerRule("ModuleManifest", func(m ModuleManifest, errs *ValidationErrors) {
+		if m.ConfigSchema == nil || m.DefaultConfig == nil {
+			return
+		}
+		validateSchema(m.ConfigSchema, m.DefaultConfig, "defaultConfig", errs)
+	})
+}
+
+// ValidateConfig checks config against ConfigSchema with the JSON Schema
+// validator RunnerCapability.ValidateInput uses. Failures are reported with
+// dotted fields, such as "servers[1].port". A manifest without a
+// ConfigSchema accepts any config.
+func (m ModuleManifest) ValidateConfig(config map[string]interface{}) error {
+	if m.ConfigSchema == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	validateSchema(m.ConfigSchema, config, "", &errs)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// validateSchema checks value against schema, reporting failures under path,
+// or "config" when path is empty. A schema that does not compile is
+// reported as a failure of the value.
+func validateSchema(schema map[string]interface{}, value interface{}, path string, errs *ValidationErrors) {
+	err := defaultSchemas.validate(schema, value)
+	if err == nil {
+		return
+	}
+	verrs, ok := err.(*ValidationErrors)
+	if !ok {
+		errs.Add(configField(path), "cannot be checked: "+err.Error())
+		return
+	}
+	var doc interface{}
+	decodeMap(value, &doc)
+	for _, e := range verrs.Errors {
+		errs.Add(pointerField(path, doc, e.Field), e.Message)
+	}
+}
+
+// pointerField converts the JSON pointer ptr into doc to a dotted field
+// under path.
+func pointerField(path string, doc interface{}, ptr string) string {
+	field := path
+	if ptr != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+			token = unescapePointer(token)
+			if items, ok := doc.([]interface{}); ok {
+				field = configField(field) + "[" + token + "]"
+				i, _ := strconv.Atoi(token)
+				doc = nil
+				if i >= 0 && i < len(items) {
+					doc = items[i]
+				}
+				continue
+			}
+			object, _ := doc.(map[string]interface{})
+			field = joinField(field, token)
+			doc = object[token]
+		}
+	}
+	return configField(field)
+}
+
+func configField(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return path
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// toFloat accepts the numeric types a config built in Go may hold as well as
+// the float64 produced by encoding/json.
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+)
+
+func testManifest(defaults map[string]interface{}) ModuleManifest {
+	return ModuleManifest{
+		Id: "m1", Name: "redis", Version: "1.0.0", Description: "d", EntryPoint: "index.js",
+		ConfigSchema: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"host", "port"},
+			"properties": map[string]interface{}{
+				"host": map[string]interface{}{"type": "string"},
+				"port": map[string]interface{}{"type": "integer"},
+				"mode": map[string]interface{}{"type": "string", "enum": []interface{}{"standalone", "cluster"}},
+			},
+		},
+		DefaultConfig: defaults,
+	}
+}
+
+func TestModuleManifestValidDefault(t *testing.T) {
+	m := testManifest(map[string]interface{}{"host": "localhost", "port": float64(6379), "mode": "cluster"})
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ValidateConfig(map[string]interface{}{"host": "cache", "port": 6380}); err != nil {
+		t.Errorf("ValidateConfig: %v", err)
+	}
+}
+
+func TestModuleManifestDefaultMissingRequired(t *testing.T) {
+	m := testManifest(map[string]interface{}{"host": "localhost"})
+	err := m.Validate()
+	if err == nil || !strings.Contains(err.Error(), "defaultConfig.port") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestModuleManifestDefaultTypeMismatch(t *testing.T) {
+	m := testManifest(map[string]interface{}{"host": "localhost", "port": "6379"})
+	err := m.Validate()
+	if err == nil || !strings.Contains(err.Error(), "defaultConfig.port") {
+		t.Errorf("err = %v", err)
+	}
+
+	err = m.ValidateConfig(map[string]interface{}{"host": "h", "port": 1.5, "mode": "sharded"})
+	verrs, ok := err.(*ValidationErrors)
+	if !ok || len(verrs.Errors) != 2 || verrs.Errors[0].Field != "mode" || verrs.Errors[1].Field != "port" {
+		t.Errorf("err = %v", err)
+	}
+}
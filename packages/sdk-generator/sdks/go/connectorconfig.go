@@ -0,0 +1,57 @@
+package controlplane
+
+// connectorConfigChecks holds the requirements of connector types that their
+// schemas do not express, keyed by connector type.
+var connectorConfigChecks = map[string]func(config map[string]interface{}, field string, errs *ValidationErrors){
+	ConnectorTypeDATABASE: checkDatabaseConfig,
+}
+
+// ValidateInstanceConfig checks the config of an instance of the connector
+// against ConfigSchema, interpreted like ModuleManifest.ValidateConfig, and
+// against the requirements of the connector type: a database connector needs
+// a connectionString, or a host and port.
+func (m ConnectorConfig) ValidateInstanceConfig(config map[string]interface{}) error {
+	var errs ValidationErrors
+	m.checkInstanceConfig(config, "", &errs)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// ValidateFor validates the instance and checks its Config against parent,
+// the connector it is an instance of. Config failures are reported under
+// "config.".
+func (m ConnectorInstance) ValidateFor(parent ConnectorConfig) error {
+	var errs ValidationErrors
+	addNested(&errs, "", m.Validate())
+	parent.checkInstanceConfig(m.Config, "config", &errs)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// checkInstanceConfig reports failures of config under path.
+func (m ConnectorConfig) checkInstanceConfig(config map[string]interface{}, path string, errs *ValidationErrors) {
+	if m.ConfigSchema != nil {
+		validateSchema(m.ConfigSchema, config, path, errs)
+	}
+	if check := connectorConfigChecks[m.Type]; check != nil {
+		field := path
+		if field == "" {
+			field = "config"
+		}
+		check(config, field, errs)
+	}
+}
+
+func checkDatabaseConfig(config map[string]interface{}, field string, errs *ValidationErrors) {
+	if s, _ := config["connectionString"].(string); s != "" {
+		return
+	}
+	host, _ := config["host"].(string)
+	if _, ok := config["port"]; host == "" || !ok {
+		errs.Add(field, "database connector requires connectionString or host and port")
+	}
+}
@@ -0,0 +1,76 @@
+package controlplane
+
+import (
+	"testing"
+)
+
+func testConnector(connectorType string) ConnectorConfig {
+	return ConnectorConfig{
+		Id: "c1", Name: "orders", Type: connectorType, Version: "1.0.0", Description: "d",
+		ConfigSchema: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"database"},
+			"properties": map[string]interface{}{
+				"database": map[string]interface{}{"type": "string"},
+				"host":     map[string]interface{}{"type": "string"},
+				"port":     map[string]interface{}{"type": "integer"},
+				"sslMode":  map[string]interface{}{"type": "string", "enum": []interface{}{"disable", "require"}},
+			},
+		},
+	}
+}
+
+func fields(err error) []string {
+	verrs, _ := err.(*ValidationErrors)
+	var out []string
+	for _, e := range verrs.Errors {
+		out = append(out, e.Field)
+	}
+	return out
+}
+
+func TestValidateInstanceConfig(t *testing.T) {
+	c := testConnector(ConnectorTypeDATABASE)
+	for _, config := range []map[string]interface{}{
+		{"database": "orders", "connectionString": "postgres://db/orders"},
+		{"database": "orders", "host": "db", "port": 5432, "sslMode": "require"},
+	} {
+		if err := c.ValidateInstanceConfig(config); err != nil {
+			t.Errorf("%v: %v", config, err)
+		}
+	}
+}
+
+func TestValidateInstanceConfigMissingRequired(t *testing.T) {
+	c := testConnector(ConnectorTypeDATABASE)
+	err := c.ValidateInstanceConfig(map[string]interface{}{"host": "db"})
+	if got := fields(err); len(got) != 2 || got[0] != "database" || got[1] != "config" {
+		t.Errorf("fields = %v (%v)", got, err)
+	}
+
+	// The host/port requirement is specific to database connectors.
+	if err := testConnector(ConnectorTypeQUEUE).ValidateInstanceConfig(map[string]interface{}{"database": "q"}); err != nil {
+		t.Errorf("queue connector: %v", err)
+	}
+}
+
+func TestValidateInstanceConfigTypeMismatch(t *testing.T) {
+	c := testConnector(ConnectorTypeDATABASE)
+	err := c.ValidateInstanceConfig(map[string]interface{}{"database": 7, "host": "db", "port": "5432", "sslMode": "prefer"})
+	if got := fields(err); len(got) != 3 || got[0] != "database" || got[1] != "port" || got[2] != "sslMode" {
+		t.Errorf("fields = %v (%v)", got, err)
+	}
+}
+
+func TestConnectorInstanceValidateFor(t *testing.T) {
+	c := testConnector(ConnectorTypeDATABASE)
+	inst := ConnectorInstance{Status: "connected", Config: map[string]interface{}{"database": "orders", "port": 5432}}
+	if got := fields(inst.ValidateFor(c)); len(got) != 1 || got[0] != "config" {
+		t.Errorf("fields = %v", got)
+	}
+	inst.Config["host"] = "db"
+	inst.Status = ""
+	if got := fields(inst.ValidateFor(c)); len(got) != 1 || got[0] != "status" {
+		t.Errorf("fields = %v", got)
+	}
+}
@@ -0,0 +1,68 @@
+package controlplane
+
+import (
+	"context"
+	"time"
+)
+
+// ConsistencyHeader carries the consistency level of a truth operation.
+const ConsistencyHeader = "X-Consistency-Level"
+
+// DefaultConsistencyLevel is the level truth operations use without
+// WithConsistency.
+const DefaultConsistencyLevel = ConsistencyLevelEVENTUAL
+
+// ErrCodeNotYetConsistent is the error envelope code a server answers a
+// strict read with when the data has not converged yet.
+const ErrCodeNotYetConsistent = "NOT_YET_CONSISTENT"
+
+// strictReadAttempts and strictReadBackoff bound how long a strict read
+// waits for the data to converge: the pause grows by strictReadBackoff
+// after each attempt.
+const (
+	strictReadAttempts = 4
+	strictReadBackoff  = 100 * time.Millisecond
+)
+
+// WithConsistency sets the consistency level of a truth operation:
+// ConsistencyLevelSTRICT, ConsistencyLevelEVENTUAL or
+// ConsistencyLevelBEST_EFFORT. It is sent in ConsistencyHeader. A strict
+// read the server reports as not yet consistent is retried briefly.
+func WithConsistency(level string) RequestOption {
+	return func(o *requestOptions) {
+		o.consistency = level
+	}
+}
+
+// consistencyOptions adds DefaultConsistencyLevel to opts unless they set a
+// level, and returns the level, which must be a known one.
+func (c *ControlPlaneClient) consistencyOptions(opts []RequestOption) ([]RequestOption, string, error) {
+	opts = append([]RequestOption{WithConsistency(DefaultConsistencyLevel)}, opts...)
+	level := c.applyOptions(opts).consistency
+	var errs ValidationErrors
+	checkEnum("consistency", level, consistencyLevelValues, &errs)
+	if !errs.IsValid() {
+		return nil, "", &errs
+	}
+	return opts, level, nil
+}
+
+// readConsistently runs read once, or for a strict read, again after a
+// short pause while the server reports the data as not yet consistent.
+func (c *ControlPlaneClient) readConsistently(ctx context.Context, level string, read func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := read()
+		if level != ConsistencyLevelSTRICT || attempt == strictReadAttempts || !isNotYetConsistent(err) {
+			return err
+		}
+		c.config.Logger.Debug("controlplane: strict read not yet consistent, retrying", "attempt", attempt)
+		if err := c.config.Clock.Sleep(ctx, time.Duration(attempt)*strictReadBackoff); err != nil {
+			return err
+		}
+	}
+}
+
+func isNotYetConsistent(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.Envelope.Code == ErrCodeNotYetConsistent
+}
@@ -0,0 +1,95 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConsistencyLevelTransmitted(t *testing.T) {
+	var got []string
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, r.Header.Get(ConsistencyHeader))
+			w.Write([]byte(`{"queryId":"q-1","assertions":[],"totalCount":0,"queryTimeMs":1}`))
+		})),
+	})
+	ctx := context.Background()
+
+	for _, level := range []string{"", ConsistencyLevelSTRICT, ConsistencyLevelEVENTUAL, ConsistencyLevelBEST_EFFORT} {
+		var opts []RequestOption
+		if level != "" {
+			opts = append(opts, WithConsistency(level))
+		}
+		got = nil
+		if _, err := client.QueryTruth(ctx, TruthQuery{Id: "q-1"}, opts...); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.AssertTruth(ctx, TruthAssertion{Subject: "s"}, opts...); err != nil {
+			t.Fatal(err)
+		}
+		want := level
+		if want == "" {
+			want = DefaultConsistencyLevel
+		}
+		if len(got) != 2 || got[0] != want || got[1] != want {
+			t.Errorf("level %q: sent %q, want %q", level, got, want)
+		}
+	}
+
+	got = nil
+	_, err := client.QueryTruth(ctx, TruthQuery{Id: "q-1"}, WithConsistency("linearizable"))
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || verrs.Errors[0].Field != "consistency" {
+		t.Errorf("err = %v, want a consistency ValidationError", err)
+	}
+	if _, err := client.AssertTruth(ctx, TruthAssertion{}, WithConsistency("STRICT")); err == nil {
+		t.Error("AssertTruth accepted an unknown level")
+	}
+	if len(got) != 0 {
+		t.Errorf("sent %d requests with an invalid level", len(got))
+	}
+}
+
+func TestStrictReadRetriesUntilConsistent(t *testing.T) {
+	const notYet = `{"id":"e1","category":"RESOURCE_CONFLICT","severity":"warning","code":"NOT_YET_CONSISTENT","message":"replicas catching up","service":"truthcore"}`
+	calls, failures := 0, 0
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Clock:   clock,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= failures {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(notYet))
+				return
+			}
+			w.Write([]byte(`{"queryId":"q-1","assertions":[],"totalCount":3,"queryTimeMs":1}`))
+		})),
+	})
+	ctx := context.Background()
+
+	failures = 2
+	result, err := client.QueryTruth(ctx, TruthQuery{Id: "q-1"}, WithConsistency(ConsistencyLevelSTRICT))
+	if err != nil || result.TotalCount != 3 {
+		t.Fatalf("result = %+v, err = %v", result, err)
+	}
+	if calls != 3 || clock.now.Sub(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) != 300*time.Millisecond {
+		t.Errorf("calls = %d, waited %v", calls, clock.now.Sub(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	}
+
+	calls, failures = 0, 1
+	if _, err := client.QueryTruth(ctx, TruthQuery{Id: "q-1"}); !isNotYetConsistent(err) || calls != 1 {
+		t.Errorf("eventual read: calls = %d, err = %v", calls, err)
+	}
+
+	calls, failures = 0, 100
+	if _, err := client.QueryTruth(ctx, TruthQuery{Id: "q-1"}, WithConsistency(ConsistencyLevelSTRICT)); !isNotYetConsistent(err) || calls != strictReadAttempts {
+		t.Errorf("strict read giving up: calls = %d, err = %v", calls, err)
+	}
+}
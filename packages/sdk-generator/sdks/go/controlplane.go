@@ -0,0 +1,665 @@
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ClientConfig holds configuration for the ControlPlane client
+type ClientConfig struct {
+	BaseURL    string
+	APIKey     string
+	Timeout    time.Duration
+	HTTPClient *http.Client
+
+	// BaseURLs lists several control plane endpoints, e.g. an active and a
+	// standby, to use instead of BaseURL. An attempt that fails with a
+	// connection error or a 5xx status is sent to the next endpoint at once,
+	// without waiting for a retry, and the failed endpoint is avoided for
+	// EndpointCooldown (default DefaultEndpointCooldown). Setting both
+	// BaseURL and BaseURLs is an error.
+	BaseURLs []string
+	// EndpointStrategy chooses among BaseURLs: EndpointStrategyFailover,
+	// the default, or EndpointStrategyRoundRobin.
+	EndpointStrategy string
+	EndpointCooldown time.Duration
+	// HealthProbeInterval is how often GetHealth probes every endpoint in
+	// the background when BaseURLs has more than one, so the client fails
+	// back to an earlier endpoint as soon as it recovers. Zero selects
+	// DefaultHealthProbeInterval; negative disables probing. Close stops
+	// the probes.
+	HealthProbeInterval time.Duration
+
+	// TokenProvider supplies bearer tokens that may change over time. When
+	// set it overrides APIKey. See NewCachingTokenSource.
+	TokenProvider TokenProvider
+
+	// TokenSource is used when TokenProvider is nil.
+	//
+	// Deprecated: Use TokenProvider.
+	TokenSource TokenSource
+
+	// MaxResponseBytes caps the size of a response body, including error
+	// bodies, or of each event on a streaming subscription. Reading past it
+	// fails with a *ResponseTooLargeError and the connection is closed.
+	// Zero selects DefaultMaxResponseBytes (32 MiB); negative disables it.
+	MaxResponseBytes int64
+
+	// StrictDecoding makes the typed methods reject responses with fields
+	// the SDK's types do not define, reporting an *UnknownFieldError, as
+	// UnmarshalStrict does. Leave it off in production: servers may add
+	// fields before the SDK is regenerated. Streams are always decoded
+	// leniently.
+	StrictDecoding bool
+
+	// SchemaCompiler compiles the capability schemas checked by
+	// WithCapabilitySchemas, WithOutputValidation and the client's
+	// ValidateCapability methods. Nil selects the built-in compiler, which
+	// covers the common keywords; the jsonschema module provides a complete
+	// one.
+	SchemaCompiler SchemaCompiler
+	// SchemaCacheSize is the number of compiled schemas the client keeps,
+	// least recently used first out. Zero selects DefaultSchemaCacheSize;
+	// negative disables caching.
+	SchemaCacheSize int
+
+	// Gzip sends Accept-Encoding: gzip and transparently decompresses
+	// gzip-encoded responses. Servers may still answer uncompressed.
+	// MaxResponseBytes applies to the decompressed body.
+	Gzip bool
+	// GzipRequestMinBytes, when positive, compresses request bodies of at
+	// least this many bytes and sends them with Content-Encoding: gzip.
+	GzipRequestMinBytes int
+
+	// UserAgent replaces the SDK's own product tokens at the start of the
+	// User-Agent header, which default to
+	// "controlplane-go-sdk/<version> contract/<contract version> go/<go version>".
+	UserAgent string
+
+	// UserAgentSuffix identifies the application in the User-Agent header,
+	// e.g. "billing-worker/2.3". It is appended to the SDK's own product
+	// tokens. See also WithClientName.
+	UserAgentSuffix string
+
+	// Transport sends requests when HTTPClient is nil, e.g. a
+	// HandlerTransport in tests or an instrumented RoundTripper, in place
+	// of the transport NewClient would build. The SDK's own behavior stays
+	// layered on top of it; see Middleware for the order. It cannot be
+	// combined with HTTPClient, TransportOptions or the TLS settings.
+	Transport http.RoundTripper
+
+	// RedirectPolicy controls redirects: RedirectFollow (the default),
+	// RedirectFollowSameHost or RedirectNever. Followed redirects to
+	// RedirectAllowedHosts, given as hostnames or host:port, carry the
+	// client's default headers and credentials even across hosts, which
+	// net/http would otherwise strip. A chain longer than MaxRedirects
+	// (default DefaultMaxRedirects) fails with a *RedirectError. These
+	// settings cannot be combined with HTTPClient.
+	RedirectPolicy       string
+	RedirectAllowedHosts []string
+	MaxRedirects         int
+
+	// TransportOptions tunes the connection pool of the transport NewClient
+	// builds when HTTPClient and Transport are nil. The zero value selects
+	// pooling suited to many concurrent requests, with HTTP/2 enabled.
+	TransportOptions TransportOptions
+
+	// TLSConfig configures the transport NewClient builds when HTTPClient is
+	// nil, e.g. Certificates for mutual TLS or RootCAs to pin the server's
+	// certificate authority. Setting both TLSConfig and HTTPClient is an
+	// error.
+	TLSConfig *tls.Config
+
+	// TLSCertFile and TLSKeyFile name a PEM client certificate and key for
+	// mutual TLS. The files are checked at every handshake and reloaded
+	// when they change, so rotated certificates are picked up. TLSCAFile
+	// names a PEM bundle of root CAs to trust instead of the system pool.
+	// Like TLSConfig, they cannot be combined with HTTPClient.
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+
+	// SupportedContracts is the range of server contract versions Handshake
+	// accepts. Nil selects DefaultContractRange.
+	SupportedContracts *ContractRange
+	// ContractCheck selects what happens when a response's
+	// X-Contract-Version has a different major version than the client's:
+	// ContractCheckLenient (the default) only warns, ContractCheckStrict
+	// fails the request with a *ContractVersionMismatchError.
+	ContractCheck string
+
+	// TimeoutMargin is subtracted from the context's remaining time when
+	// SubmitJob or ExecuteJob derives an unset TimeoutMs from the deadline,
+	// so the server gives up before the client does. Zero selects
+	// DefaultTimeoutMargin; negative disables the margin.
+	TimeoutMargin time.Duration
+
+	// MaxPayloadBytes bounds the JSON-encoded payload SubmitJob sends; a
+	// larger job fails with ValidationErrors before any request is made.
+	// Zero selects DefaultMaxPayloadBytes; negative disables the check.
+	MaxPayloadBytes int
+
+	// DefaultTenant is sent as the X-Tenant-Id header of every request
+	// whose context carries no tenant from WithTenant. Empty sends no
+	// header.
+	DefaultTenant string
+
+	// Cache, when set, stores registry and marketplace responses with their
+	// ETags and revalidates them with If-None-Match, serving the cached
+	// body on 304 Not Modified. Within a Cache-Control max-age the body is
+	// served without a request. Entries older than CacheTTL (default
+	// DefaultCacheTTL) are refetched in full.
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// Retry enables automatic retries of failed requests. Nil disables
+	// retries; see DefaultRetryPolicy for the contract defaults.
+	Retry *RetryPolicy
+	// RetryJitter picks every retry delay at random between zero and the
+	// policy's backoff ("full jitter"), so clients that failed together do
+	// not retry in lockstep. Retry-After delays are used as sent.
+	RetryJitter bool
+	// RetryBudget, when set, caps retries across all requests. A failure
+	// that would exceed it is returned at once as a *RetryBudgetError.
+	RetryBudget *RetryBudget
+
+	// Hedge, when set, sends a second copy of GET and HEAD attempts that
+	// are slower than its Delay and uses whichever answers first. Nil
+	// disables hedging.
+	Hedge *HedgePolicy
+
+	// Reconnect controls how SubscribeTruth and StreamTruthAssertions
+	// re-establish a dropped stream. MaxRetries bounds consecutive failed
+	// reconnects. Nil uses ten attempts backing off from half a second up
+	// to thirty.
+	Reconnect *RetryPolicy
+
+	// RateLimiter, when set, is waited on before every request attempt,
+	// including retries, so concurrent callers such as RunBatch share one
+	// request rate.
+	RateLimiter RateLimiter
+
+	// Middlewares wrap every request attempt, including retries. They are
+	// applied in order, so Middlewares[0] sees the request first.
+	Middlewares []Middleware
+
+	// OnRequest and OnResponse are called around every request attempt.
+	// OnResponse is also called when the attempt fails before a response is
+	// received. See RequestInfo and ResponseInfo.
+	OnRequest  func(ctx context.Context, info *RequestInfo)
+	OnResponse func(ctx context.Context, info *ResponseInfo)
+
+	// Logger receives request, retry and validation logs. Credentials are
+	// redacted from logged headers. Defaults to NopLogger.
+	Logger Logger
+
+	// DebugWriter, when set, receives a wire-level dump of every request
+	// and response attempt, labeled with its attempt number. Credentials,
+	// the headers in DebugRedactHeaders and any header whose name mentions
+	// a key, token or secret are replaced with "[REDACTED]", so dumps are
+	// safe to ship to log aggregation.
+	DebugWriter io.Writer
+	// DebugRedactHeaders names additional headers to redact in dumps.
+	DebugRedactHeaders []string
+	// DebugMaxBodyBytes truncates dumped bodies. Zero selects 4 KiB;
+	// negative omits bodies.
+	DebugMaxBodyBytes int
+
+	// Clock supplies the current time and performs retry and reconnect
+	// sleeps. Defaults to RealClock.
+	Clock Clock
+
+	// Metrics receives request counts, latencies, retries and in-flight
+	// changes, labeled by route template. Nil disables metrics.
+	Metrics MetricsCollector
+}
+
+// ControlPlaneClient is the main SDK client
+//
+// A client is safe for concurrent use by multiple goroutines and is meant to
+// be shared. Its configuration is fixed by NewClient; the state that changes
+// afterwards, the negotiated contract version, cached responses and tokens
+// held by a CachingTokenSource, is synchronized internally.
+type ControlPlaneClient struct {
+	config ClientConfig
+	// baseURL is the first endpoint, against which paths are validated and
+	// cache keys are built.
+	baseURL   *url.URL
+	endpoints *endpointPool
+	// retryBudget is nil when ClientConfig.RetryBudget is.
+	retryBudget *retryBudget
+	// hedger is nil when hedging is disabled.
+	hedger *hedger
+	// life tracks in-flight requests and background work for Close.
+	life *lifecycle
+	// contractVersion is replaced by NegotiateContractVersion and read on
+	// every request, so it is swapped atomically rather than locked.
+	contractVersion atomic.Pointer[ContractVersion]
+	client          *http.Client
+	send            RoundTripFunc
+	responses       *responseCache
+	schemas         *schemaCache
+}
+
+// NewClient creates a new ControlPlane SDK client. It returns an error when
+// the configuration is invalid, such as a malformed BaseURL.
+func NewClient(config ClientConfig) (*ControlPlaneClient, error) {
+	var err error
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = NopLogger()
+	}
+	if config.TimeoutMargin == 0 {
+		config.TimeoutMargin = DefaultTimeoutMargin
+	} else if config.TimeoutMargin < 0 {
+		config.TimeoutMargin = 0
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	if config.MaxResponseBytes == 0 {
+		config.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if config.TokenProvider == nil {
+		config.TokenProvider = config.TokenSource
+	}
+	if config.TokenProvider == nil && config.APIKey != "" {
+		config.TokenProvider = StaticToken(config.APIKey)
+	}
+	if config.HTTPClient != nil && usesTLSSettings(config) {
+		return nil, errHTTPClientAndTLS
+	}
+	if config.HTTPClient != nil && config.Transport != nil {
+		return nil, errHTTPClientAndTransport
+	}
+	if config.HTTPClient != nil && config.TransportOptions != (TransportOptions{}) {
+		return nil, errHTTPClientAndTransportOptions
+	}
+	if config.HTTPClient != nil && usesRedirectSettings(config) {
+		return nil, errHTTPClientAndRedirects
+	}
+	if err := validateRedirectPolicy(config.RedirectPolicy); err != nil {
+		return nil, err
+	}
+	if err := validateContractCheck(config.ContractCheck); err != nil {
+		return nil, err
+	}
+	ownClient := config.HTTPClient == nil
+	if config.HTTPClient == nil {
+		config.HTTPClient, err = newHTTPClient(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.Metrics == nil {
+		config.Metrics = nopMetrics{}
+	}
+	if config.Clock == nil {
+		config.Clock = RealClock()
+	}
+	endpoints, err := newEndpointPool(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ControlPlaneClient{
+		config:      config,
+		baseURL:     endpoints.bases[0],
+		endpoints:   endpoints,
+		client:      config.HTTPClient,
+		retryBudget: newRetryBudget(config.RetryBudget, config.Clock),
+		hedger:      newHedger(config.Hedge),
+		responses:   newResponseCache(),
+		schemas:     newSchemaCache(config.SchemaCompiler, config.SchemaCacheSize, config.Clock),
+		life:        newLifecycle(),
+	}
+	if ownClient {
+		c.client.CheckRedirect = c.checkRedirect
+	}
+	version := clientContractVersion
+	c.contractVersion.Store(&version)
+	send := RoundTripFunc(c.client.Do)
+	if config.DebugWriter != nil {
+		send = newDebugDumper(config).wrap(send)
+	}
+	if config.Gzip {
+		send = decompressResponses(send)
+	}
+	c.send = chainMiddlewares(limitResponses(send), config.Middlewares)
+	if interval := config.HealthProbeInterval; endpoints.multi() && interval >= 0 {
+		if interval == 0 {
+			interval = DefaultHealthProbeInterval
+		}
+		ctx, release, _ := c.startBackground(context.Background())
+		go func() {
+			defer release()
+			c.probeEndpoints(ctx, interval)
+		}()
+	}
+	return c, nil
+}
+
+func parseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BaseURL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid BaseURL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid BaseURL %q: missing host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid BaseURL %q: query and fragment are not allowed", raw)
+	}
+	return u, nil
+}
+
+// resolveURL joins a request path, which may carry a query string, onto the
+// base URL and appends the extra query parameters. Any path prefix on the
+// base URL is preserved and exactly one slash separates the two.
+func (c *ControlPlaneClient) resolveURL(path string, extra url.Values) (string, error) {
+	return resolveURLOn(c.baseURL, path, extra)
+}
+
+// resolveURLOn is resolveURL against the given endpoint.
+func resolveURLOn(base *url.URL, path string, extra url.Values) (string, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	if ref.Scheme != "" || ref.Host != "" {
+		return "", fmt.Errorf("invalid request path %q: must be relative to BaseURL", path)
+	}
+
+	joined := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(ref.EscapedPath(), "/")
+	u := *base
+	u.Path, err = url.PathUnescape(joined)
+	if err != nil {
+		return "", fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	u.RawPath = joined
+	u.RawQuery = ref.RawQuery
+	if len(extra) > 0 {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += extra.Encode()
+	}
+	return u.String(), nil
+}
+
+// GetContractVersion returns the contract version used by this client
+func (c *ControlPlaneClient) GetContractVersion() ContractVersion {
+	return *c.contractVersion.Load()
+}
+
+func (c *ControlPlaneClient) serializeVersion(v ContractVersion) string {
+	return v.String()
+}
+
+func (c *ControlPlaneClient) defaultHeaders() map[string]string {
+	headers := map[string]string{
+		"Content-Type":       "application/json",
+		"User-Agent":         c.userAgent(),
+		"X-Contract-Version": c.serializeVersion(c.GetContractVersion()),
+	}
+	if c.config.Gzip {
+		headers["Accept-Encoding"] = "gzip"
+	}
+	return headers
+}
+
+// userAgent identifies the SDK, contract and Go versions, or the configured
+// UserAgent, followed by the configured application suffix.
+func (c *ControlPlaneClient) userAgent() string {
+	ua := c.config.UserAgent
+	if ua == "" {
+		v := c.GetContractVersion()
+		ua = fmt.Sprintf("controlplane-go-sdk/%s contract/%d.%d.%d go/%s",
+			SDKVersion, v.Major, v.Minor, v.Patch,
+			strings.TrimPrefix(runtime.Version(), "go"))
+	}
+	if c.config.UserAgentSuffix != "" {
+		ua += " " + c.config.UserAgentSuffix
+	}
+	return ua
+}
+
+// Request makes an HTTP request to the ControlPlane API. The call is bounded
+// by ClientConfig.Timeout unless overridden with WithTimeout; the deadline is
+// released when the response body is closed.
+func (c *ControlPlaneClient) Request(ctx context.Context, method, path string, body interface{}, opts ...RequestOption) (*http.Response, error) {
+	o := c.applyOptions(opts)
+	var payload []byte
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = jsonBody
+	}
+
+	target, err := c.resolveURL(path, o.query)
+	if err != nil {
+		return nil, err
+	}
+	header := o.header()
+	if min := c.config.GzipRequestMinBytes; min > 0 && len(payload) >= min {
+		if payload, err = gzipPayload(payload); err != nil {
+			return nil, err
+		}
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	return c.do(ctx, o, &requestSpec{
+		method:  method,
+		path:    path,
+		query:   o.query,
+		route:   routeTemplate(path),
+		url:     target,
+		header:  header,
+		payload: payload,
+	})
+}
+
+// RequestStream is like Request but streams body to the server with chunked
+// encoding instead of marshaling it in memory, for uploads too large to
+// buffer. contentType, when not empty, is sent as the Content-Type header.
+//
+// A reader can only be sent once, so a failed attempt is not retried unless
+// WithGetBody supplies a way to reopen the body; the failure is returned
+// instead. When WithGetBody is given, body may be nil and every attempt
+// reads from the factory.
+func (c *ControlPlaneClient) RequestStream(ctx context.Context, method, path string, body io.Reader, contentType string, opts ...RequestOption) (*http.Response, error) {
+	o := c.applyOptions(opts)
+	if body == nil && o.getBody == nil {
+		return nil, errNoStreamBody
+	}
+	target, err := c.resolveURL(path, o.query)
+	if err != nil {
+		return nil, err
+	}
+	header := o.header()
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return c.do(ctx, o, &requestSpec{
+		method:   method,
+		path:     path,
+		query:    o.query,
+		route:    routeTemplate(path),
+		url:      target,
+		header:   header,
+		streamed: true,
+		stream:   body,
+		getBody:  o.getBody,
+	})
+}
+
+var errNoStreamBody = errors.New("RequestStream needs a body or WithGetBody")
+
+// do sends spec with retries within the options' deadline, which is released
+// when the response body is closed. Until then the request counts as in
+// flight for Close.
+func (c *ControlPlaneClient) do(ctx context.Context, o requestOptions, spec *requestSpec) (*http.Response, error) {
+	if o.clientName != "" && spec.header.Get("User-Agent") == "" {
+		spec.header.Set("User-Agent", c.userAgent()+" "+o.clientName)
+	}
+	ctx, release, err := c.startRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := o.withDeadline(ctx, c.config.Clock)
+	spec.endpoint = o.endpoint
+	spec.maxBody = c.config.MaxResponseBytes
+	if o.unlimitedBody {
+		spec.maxBody = -1
+	}
+	resp, err := c.doWithRetry(ctx, spec)
+	if isMutating(spec.method) {
+		c.responses.invalidate(spec.path)
+	}
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() {
+		cancel()
+		release()
+	}}
+	return resp, nil
+}
+
+// NewRequest builds a request for path with the client's default headers and
+// credentials, without sending it. It is meant for protocols the client does
+// not speak itself, such as the WebSocket upgrade in the wsrunner module;
+// retries, middlewares and hooks do not apply. The request goes to the
+// active endpoint.
+func (c *ControlPlaneClient) NewRequest(ctx context.Context, method, path string) (*http.Request, error) {
+	if c.life.isClosed() {
+		return nil, ErrClientClosed
+	}
+	target, err := resolveURLOn(c.endpoints.active(), path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.newRequest(ctx, &requestSpec{method: method, path: path, route: routeTemplate(path), url: target})
+}
+
+// requestSpec describes a logical request, which may be sent several times.
+type requestSpec struct {
+	method  string
+	path    string
+	query   url.Values
+	route   string
+	url     string
+	header  http.Header
+	payload []byte
+	// maxBody limits the response body; negative means unlimited.
+	maxBody int64
+	// endpoint pins the request to endpoint-1 of the client's BaseURLs,
+	// without failover, when positive.
+	endpoint int
+
+	// streamed marks a RequestStream body, sent from stream on the first
+	// attempt and from getBody on later ones, if at all.
+	streamed bool
+	stream   io.Reader
+	getBody  func() (io.ReadCloser, error)
+}
+
+// replayable reports whether the request can be sent again.
+func (s *requestSpec) replayable() bool {
+	return !s.streamed || s.getBody != nil
+}
+
+// nextBody returns the body of the next attempt.
+func (s *requestSpec) nextBody() (io.Reader, error) {
+	if !s.streamed {
+		return bytes.NewReader(s.payload), nil
+	}
+	if s.stream != nil {
+		body := s.stream
+		s.stream = nil
+		return body, nil
+	}
+	if s.getBody == nil {
+		return nil, errBodyConsumed
+	}
+	body, err := s.getBody()
+	if err != nil {
+		return nil, fmt.Errorf("reopen request body: %w", err)
+	}
+	return body, nil
+}
+
+var errBodyConsumed = errors.New("request body already sent and cannot be replayed")
+
+// newRequest builds a single request attempt with the default headers set.
+func (c *ControlPlaneClient) newRequest(ctx context.Context, spec *requestSpec) (*http.Request, error) {
+	body, err := spec.nextBody()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, spec.method, spec.url, body)
+	if err != nil {
+		if rc, ok := body.(io.Closer); ok && spec.streamed {
+			rc.Close()
+		}
+		return nil, err
+	}
+	if spec.streamed {
+		// Unknown length: the body is sent with chunked encoding. GetBody
+		// stays nil so middlewares and the debug dumper do not reopen it.
+		req.ContentLength = -1
+	}
+
+	for key, value := range c.defaultHeaders() {
+		req.Header.Set(key, value)
+	}
+	if tenant := c.tenant(ctx); tenant != "" {
+		req.Header.Set(TenantHeader, tenant)
+	}
+	if c.config.TokenProvider != nil {
+		token, err := c.config.TokenProvider.Token(ctx)
+		if err != nil {
+			return nil, &TokenError{Err: err}
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range spec.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	return req, nil
+}
+
+// Validate validates a model using the generated validators. Checks that
+// depend on the current time, such as a job's expiry, read the client's
+// Clock.
+func (c *ControlPlaneClient) Validate(model Validatable) error {
+	validate := model.Validate
+	if timed, ok := model.(interface{ ValidateAt(time.Time) error }); ok {
+		validate = func() error { return timed.ValidateAt(c.config.Clock.Now()) }
+	}
+	if err := validate(); err != nil {
+		c.config.Logger.Warn("controlplane: validation failed", "model", typeName(model), "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package controlplanetest provides helpers for testing code that uses the
+// ControlPlane client.
+package controlplanetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+)
+
+// FakeClock is a controlplane.Clock whose time only moves when Advance or
+// Set is called. Sleepers wake once the clock reaches their deadline, so
+// retries and reconnects run without real delays.
+type FakeClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	sleepers []*sleeper
+	changed  chan struct{}
+}
+
+type sleeper struct {
+	until time.Time
+	done  chan struct{}
+}
+
+var _ controlplane.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start, changed: make(chan struct{})}
+}
+
+// Now returns the fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep blocks until the clock has been advanced by d or ctx is done.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	s := &sleeper{until: c.now.Add(d), done: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.notifyLocked()
+	c.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.removeLocked(s)
+		c.notifyLocked()
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Advance moves the clock forward by d, waking every sleeper whose deadline
+// has been reached.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+// Set moves the clock to t, which must not be before the current time.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.Before(c.now) {
+		panic("controlplanetest: FakeClock cannot move backwards")
+	}
+	c.setLocked(t)
+}
+
+// Sleepers returns the number of goroutines blocked in Sleep.
+func (c *FakeClock) Sleepers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sleepers)
+}
+
+// BlockUntil waits until n goroutines are blocked in Sleep or ctx is done.
+// It lets a test advance the clock only once the code under test is
+// waiting on it.
+func (c *FakeClock) BlockUntil(ctx context.Context, n int) error {
+	for {
+		c.mu.Lock()
+		if len(c.sleepers) >= n {
+			c.mu.Unlock()
+			return nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// NextWake returns the earliest deadline among the sleepers, if any.
+func (c *FakeClock) NextWake() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.sleepers) == 0 {
+		return time.Time{}, false
+	}
+	return c.sleepers[0].until, true
+}
+
+func (c *FakeClock) setLocked(t time.Time) {
+	c.now = t
+	kept := c.sleepers[:0]
+	for _, s := range c.sleepers {
+		if s.until.After(t) {
+			kept = append(kept, s)
+		} else {
+			close(s.done)
+		}
+	}
+	c.sleepers = kept
+	c.notifyLocked()
+}
+
+func (c *FakeClock) removeLocked(s *sleeper) {
+	for i, other := range c.sleepers {
+		if other == s {
+			c.sleepers = append(c.sleepers[:i], c.sleepers[i+1:]...)
+			return
+		}
+	}
+}
+
+// notifyLocked keeps sleepers ordered by deadline and wakes BlockUntil.
+func (c *FakeClock) notifyLocked() {
+	sort.SliceStable(c.sleepers, func(i, j int) bool {
+		return c.sleepers[i].until.Before(c.sleepers[j].until)
+	})
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
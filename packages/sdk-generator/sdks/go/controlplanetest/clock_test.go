@@ -0,0 +1,148 @@
+package controlplanetest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"github.com/controlplane/sdk-go/controlplanetest"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClockWakesSleepersInOrder(t *testing.T) {
+	clock := controlplanetest.NewFakeClock(epoch)
+	ctx := context.Background()
+
+	woke := make(chan time.Duration, 2)
+	for _, d := range []time.Duration{2 * time.Second, time.Second} {
+		d := d
+		go func() {
+			if err := clock.Sleep(ctx, d); err == nil {
+				woke <- d
+			}
+		}()
+	}
+	if err := clock.BlockUntil(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := clock.NextWake(); !ok || !at.Equal(epoch.Add(time.Second)) {
+		t.Errorf("next wake = %v, %v", at, ok)
+	}
+
+	clock.Advance(time.Second)
+	if d := <-woke; d != time.Second {
+		t.Errorf("woke %v first", d)
+	}
+	if n := clock.Sleepers(); n != 1 {
+		t.Errorf("sleepers = %d", n)
+	}
+	clock.Advance(time.Second)
+	<-woke
+	if got := clock.Now(); !got.Equal(epoch.Add(2 * time.Second)) {
+		t.Errorf("now = %v", got)
+	}
+}
+
+func TestFakeClockSleepHonorsContext(t *testing.T) {
+	clock := controlplanetest.NewFakeClock(epoch)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := clock.Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("err = %v", err)
+	}
+	if n := clock.Sleepers(); n != 0 {
+		t.Errorf("sleepers = %d", n)
+	}
+}
+
+func TestFakeClockDrivesRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", epoch.Add(time.Hour).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	clock := controlplanetest.NewFakeClock(epoch)
+	client, err := controlplane.NewClient(controlplane.ClientConfig{
+		BaseURL: srv.URL,
+		Retry:   &controlplane.RetryPolicy{MaxRetries: controlplane.Int(1)},
+		Clock:   clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := client.Request(context.Background(), http.MethodGet, "/health", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := clock.BlockUntil(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if at, _ := clock.NextWake(); !at.Equal(epoch.Add(time.Hour)) {
+		t.Errorf("retry scheduled for %v, want the Retry-After date", at)
+	}
+	clock.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("calls = %d", n)
+	}
+}
+
+func TestFakeClockDrivesWatchHealth(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := "healthy"
+		if atomic.AddInt32(&polls, 1) > 1 {
+			status = "degraded"
+		}
+		w.Write([]byte(`{"service":"cp","status":"` + status + `"}`))
+	}))
+	defer srv.Close()
+
+	clock := controlplanetest.NewFakeClock(epoch)
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: srv.URL, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, stop := client.WatchHealth(context.Background(), time.Minute)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if h := <-ch; h.Status != "healthy" {
+		t.Fatalf("first report = %s", h.Status)
+	}
+	if err := clock.BlockUntil(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if at, _ := clock.NextWake(); !at.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("next poll at %v, want a minute on", at)
+	}
+	if n := atomic.LoadInt32(&polls); n != 1 {
+		t.Errorf("polled %d times before the clock moved", n)
+	}
+	clock.Advance(time.Minute)
+	if h := <-ch; h.Status != "degraded" {
+		t.Errorf("second report = %s", h.Status)
+	}
+}
@@ -0,0 +1,161 @@
+package controlplane
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+)
+
+// defaultDebugBodyBytes is how much of each body is dumped when
+// ClientConfig.DebugMaxBodyBytes is zero.
+const defaultDebugBodyBytes = 4 << 10
+
+// debugDumper writes redacted wire dumps of every attempt to a writer.
+type debugDumper struct {
+	mu      sync.Mutex
+	w       io.Writer
+	redact  []string
+	maxBody int
+}
+
+func newDebugDumper(config ClientConfig) *debugDumper {
+	d := &debugDumper{w: config.DebugWriter, redact: config.DebugRedactHeaders, maxBody: config.DebugMaxBodyBytes}
+	if d.maxBody == 0 {
+		d.maxBody = defaultDebugBodyBytes
+	}
+	return d
+}
+
+// wrap dumps each request before it is sent and each response as received.
+func (d *debugDumper) wrap(send RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		attempt := AttemptFromContext(req.Context())
+		d.dumpRequest(req, attempt)
+		resp, err := send(req)
+		if err != nil {
+			d.write(fmt.Sprintf("<<< response (attempt %d)\nerror: %v\n", attempt, err))
+			return resp, err
+		}
+		d.dumpResponse(resp, attempt)
+		return resp, nil
+	}
+}
+
+func (d *debugDumper) dumpRequest(req *http.Request, attempt int) {
+	clone := req.Clone(req.Context())
+	clone.Header = d.redactHeader(req.Header)
+	clone.Body = nil
+	head, err := httputil.DumpRequest(clone, false)
+	if err != nil {
+		d.write(fmt.Sprintf(">>> request (attempt %d)\ndump failed: %v\n", attempt, err))
+		return
+	}
+
+	var body []byte
+	truncated := false
+	if req.GetBody != nil && d.maxBody > 0 {
+		if rc, err := req.GetBody(); err == nil {
+			body, truncated = readPrefix(rc, d.maxBody)
+			rc.Close()
+		}
+	}
+	d.write(formatDump(fmt.Sprintf(">>> request (attempt %d)", attempt), head, body, truncated))
+}
+
+func (d *debugDumper) dumpResponse(resp *http.Response, attempt int) {
+	saved := resp.Header
+	resp.Header = d.redactHeader(saved)
+	head, err := httputil.DumpResponse(resp, false)
+	resp.Header = saved
+	if err != nil {
+		d.write(fmt.Sprintf("<<< response (attempt %d)\ndump failed: %v\n", attempt, err))
+		return
+	}
+
+	var body []byte
+	truncated := false
+	if d.maxBody > 0 && resp.Body != nil {
+		// Read one byte more than is dumped to tell whether the body was cut,
+		// then put the bytes back in front of the rest of the body.
+		prefix, readErr := readFull(resp.Body, d.maxBody+1)
+		rest := io.Reader(resp.Body)
+		if readErr != nil {
+			rest = &failingReader{readErr}
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(prefix), rest), resp.Body}
+		body, truncated = prefix, len(prefix) > d.maxBody
+		if truncated {
+			body = body[:d.maxBody]
+		}
+	}
+	d.write(formatDump(fmt.Sprintf("<<< response (attempt %d)", attempt), head, body, truncated))
+}
+
+// redactHeader copies h with credentials and the configured headers
+// replaced by a marker.
+func (d *debugDumper) redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if isSensitiveHeader(name) || d.isRedacted(name) {
+			out[name] = []string{redacted}
+		}
+	}
+	return out
+}
+
+func (d *debugDumper) isRedacted(name string) bool {
+	for _, r := range d.redact {
+		if strings.EqualFold(name, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *debugDumper) write(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	io.WriteString(d.w, s)
+}
+
+func formatDump(title string, head, body []byte, truncated bool) string {
+	var b strings.Builder
+	b.WriteString(title)
+	b.WriteByte('\n')
+	b.Write(bytes.TrimRight(head, "\r\n"))
+	b.WriteString("\n\n")
+	if len(body) > 0 {
+		b.Write(body)
+		if truncated {
+			b.WriteString("\n[truncated]")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// readPrefix reads up to n bytes of r, reporting whether more remained.
+func readPrefix(r io.Reader, n int) ([]byte, bool) {
+	data, _ := readFull(r, n+1)
+	if len(data) > n {
+		return data[:n], true
+	}
+	return data, false
+}
+
+// readFull reads up to n bytes, returning a nil error at end of input.
+func readFull(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	got, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:got], err
+}
@@ -0,0 +1,64 @@
+package controlplane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugDumpRedactsAndTruncates(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Session-Ref", "sess-123")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(strings.Repeat("z", 100)))
+	}))
+	defer srv.Close()
+
+	var dump strings.Builder
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:            srv.URL,
+		APIKey:             "super-secret-key",
+		Retry:              &RetryPolicy{MaxRetries: Int(1)},
+		DebugWriter:        &dump,
+		DebugRedactHeaders: []string{"X-Session-Ref"},
+		DebugMaxBodyBytes:  16,
+	})
+	resp, err := client.Request(context.Background(), http.MethodPost, "/v1/jobs", map[string]string{"note": strings.Repeat("y", 64)},
+		WithHeader("X-Signature", "sig-abc"), WithIdempotencyKey("note-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(body) != 100 {
+		t.Errorf("caller received %d bytes, want the full body", len(body))
+	}
+
+	out := dump.String()
+	for _, secret := range []string{"super-secret-key", "sig-abc", "sess-123"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("dump leaks %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{
+		">>> request (attempt 1)", "<<< response (attempt 1)",
+		">>> request (attempt 2)", "<<< response (attempt 2)",
+		"POST /v1/jobs", "503 Service Unavailable", "Authorization: [REDACTED]",
+		strings.Repeat("z", 16) + "\n[truncated]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, strings.Repeat("z", 17)) || strings.Contains(out, strings.Repeat("y", 17)) {
+		t.Errorf("body not truncated:\n%s", out)
+	}
+}
@@ -0,0 +1,199 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// DecodeError reports a document that could not be decoded into the target
+// type. It is returned by UnmarshalValidate and DecodeValidate so callers can
+// tell malformed input apart from a well-formed document that fails Validate.
+type DecodeError struct {
+	Type string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s: %v", e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalValidate decodes a JSON document into a T and validates it.
+// Required numeric fields, which Validate accepts at zero, must also be
+// present in the document.
+//
+// A decoding failure is returned as a *DecodeError; a validation failure is
+// returned unchanged from T's Validate method, with any missing numeric
+// fields added when it is a *ValidationErrors.
+func UnmarshalValidate[T Validatable](data []byte) (T, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, &DecodeError{Type: typeName(v), Err: err}
+	}
+	return validateDecoded(v, data)
+}
+
+// DecodeValidate is the streaming counterpart of UnmarshalValidate. It reads a
+// single JSON document from r.
+func DecodeValidate[T Validatable](r io.Reader) (T, error) {
+	var v T
+	var data json.RawMessage
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return v, &DecodeError{Type: typeName(v), Err: err}
+	}
+	return UnmarshalValidate[T](data)
+}
+
+// ValidateDocument decodes raw into the SchemaRegistry type named
+// schemaName and validates it as UnmarshalValidate does. An unregistered
+// name returns an error wrapping ErrUnknownSchema.
+func ValidateDocument(schemaName string, raw json.RawMessage) error {
+	t, err := schemaType(schemaName)
+	if err != nil {
+		return err
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return &DecodeError{Type: schemaName, Err: err}
+	}
+	_, err = validateDecoded(ptr.Elem().Interface().(Validatable), raw)
+	return err
+}
+
+// ValidateDocuments validates each document against the schema it is keyed
+// by and returns a single ValidationErrors whose fields are prefixed with the
+// schema name, e.g. "JobRequest.metadata.source: is required". Unknown schema
+// names and undecodable documents are reported under the name itself. It
+// returns nil when every document is valid.
+func ValidateDocuments(docs map[string]json.RawMessage) error {
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs ValidationErrors
+	for _, name := range names {
+		err := ValidateDocument(name, docs[name])
+		if verrs, ok := err.(*ValidationErrors); ok {
+			for _, e := range verrs.Errors {
+				errs.Add(name+"."+e.Field, e.Message)
+			}
+		} else if err != nil {
+			errs.Add(name, err.Error())
+		}
+	}
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+func validateDecoded[T Validatable](v T, data []byte) (T, error) {
+	// A JSON null leaves pointer targets nil, and calling Validate through a
+	// nil pointer would panic.
+	if rv := reflect.ValueOf(&v).Elem(); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return v, &DecodeError{Type: typeName(v), Err: fmt.Errorf("document is null")}
+	}
+	err := v.Validate()
+	errs, ok := err.(*ValidationErrors)
+	if err != nil && !ok {
+		return v, err
+	}
+	if errs == nil {
+		errs = &ValidationErrors{}
+	}
+	for _, field := range missingNumericFields(typeName(v), data) {
+		errs.Add(field, "is required")
+	}
+	if !errs.IsValid() {
+		return v, errs
+	}
+	return v, nil
+}
+
+// missingNumericFields returns the required numeric fields of the schema
+// that are absent from the JSON object data. Documents that are not objects,
+// such as a ContractVersion in its string form, have none missing.
+func missingNumericFields(schema string, data []byte) []string {
+	fields := requiredNumericFields[schema]
+	if len(fields) == 0 {
+		return nil
+	}
+	var doc map[string]json.RawMessage
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+	var missing []string
+	for _, f := range fields {
+		if _, ok := doc[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// decodeMap converts a loosely typed value, typically one of the generated
+// map[string]interface{} fields, into a typed value by way of JSON.
+func decodeMap(src interface{}, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func typeName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return "<nil>"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// ValidateAll validates every item and returns a single ValidationErrors
+// whose fields are prefixed with the item's index, e.g.
+// "item[3].subject: is required". It returns nil when all items are valid.
+func ValidateAll[T Validatable](items []T) error {
+	return validateAll(items, false)
+}
+
+// ValidateAllFailFast is like ValidateAll but stops at the first invalid
+// item.
+func ValidateAllFailFast[T Validatable](items []T) error {
+	return validateAll(items, true)
+}
+
+func validateAll[T Validatable](items []T, failFast bool) error {
+	var errs ValidationErrors
+	for i, item := range items {
+		prefix := fmt.Sprintf("item[%d]", i)
+		if rv := reflect.ValueOf(&item).Elem(); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			errs.Add(prefix, "is null")
+		} else if err := item.Validate(); err != nil {
+			if verrs, ok := err.(*ValidationErrors); ok {
+				for _, e := range verrs.Errors {
+					errs.Add(prefix+"."+e.Field, e.Message)
+				}
+			} else {
+				errs.Add(prefix, err.Error())
+			}
+		}
+		if failFast && !errs.IsValid() {
+			break
+		}
+	}
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
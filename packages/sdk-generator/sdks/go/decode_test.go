@@ -0,0 +1,182 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalValidate(t *testing.T) {
+	data := []byte(`{
+		"id": "550e8400-e29b-41d4-a716-446655440000",
+		"type": "process-data",
+		"payload": {"type": "csv", "data": {"rows": [1, 2, 3], "nested": {"ok": true}}},
+		"metadata": {"source": "test"}
+	}`)
+
+	job, err := UnmarshalValidate[JobRequest](data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Type != "process-data" {
+		t.Errorf("type = %q", job.Type)
+	}
+	nested, ok := job.Payload["data"].(map[string]interface{})["nested"].(map[string]interface{})
+	if !ok || nested["ok"] != true {
+		t.Errorf("payload not decoded: %#v", job.Payload)
+	}
+}
+
+func TestUnmarshalValidateErrorsAreDistinguishable(t *testing.T) {
+	_, err := UnmarshalValidate[JobRequest]([]byte(`{"id": "abc",`))
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("malformed JSON: expected *DecodeError, got %T", err)
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected wrapped *json.SyntaxError, got %v", err)
+	}
+
+	_, err = UnmarshalValidate[JobRequest]([]byte(`{"id": "abc"}`))
+	if errors.As(err, &decErr) {
+		t.Fatalf("invalid document reported as decode error: %v", err)
+	}
+	if _, ok := err.(*ValidationErrors); !ok {
+		t.Fatalf("expected ValidationErrors, got %T", err)
+	}
+}
+
+func TestUnmarshalValidatePointerNull(t *testing.T) {
+	_, err := UnmarshalValidate[*JobRequest]([]byte(`null`))
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+}
+
+func TestDecodeValidate(t *testing.T) {
+	r := strings.NewReader(`{"id": "q-1", "pattern": {"subject": "svc"}}`)
+	q, err := DecodeValidate[TruthQuery](r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Pattern["subject"] != "svc" {
+		t.Errorf("pattern = %#v", q.Pattern)
+	}
+
+	_, err = DecodeValidate[TruthQuery](strings.NewReader(`{"pattern": {}}`))
+	if _, ok := err.(*ValidationErrors); !ok {
+		t.Fatalf("expected ValidationErrors, got %T (%v)", err, err)
+	}
+}
+
+func TestValidateAllReportsIndices(t *testing.T) {
+	items := []TruthAssertion{
+		{Id: "a0", Subject: "s", Predicate: "p", Source: "x"},
+		{Id: "a1", Predicate: "p", Source: "x"},
+		{Id: "a2", Subject: "s", Predicate: "p", Source: "x"},
+		{Id: "a3", Subject: "s", Source: "x"},
+	}
+	err := ValidateAll(items)
+	verrs, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("err = %T %v", err, err)
+	}
+	var fields []string
+	for _, e := range verrs.Errors {
+		fields = append(fields, e.Field)
+	}
+	if strings.Join(fields, ",") != "item[1].subject,item[3].predicate" {
+		t.Errorf("fields = %v", fields)
+	}
+	if err.Error() != "item[1].subject: is required; item[3].predicate: is required" {
+		t.Errorf("message = %q", err)
+	}
+
+	verrs = ValidateAllFailFast(items).(*ValidationErrors)
+	if len(verrs.Errors) != 1 || verrs.Errors[0].Field != "item[1].subject" {
+		t.Errorf("fail fast = %+v", verrs.Errors)
+	}
+	if err := ValidateAll(items[:1]); err != nil {
+		t.Errorf("valid batch: %v", err)
+	}
+	if err := ValidateAll([]*TruthAssertion{nil}); err == nil || err.Error() != "item[0]: is null" {
+		t.Errorf("nil item: %v", err)
+	}
+}
+
+func TestValidateAcceptsZeroNumericFields(t *testing.T) {
+	models := []Validatable{
+		ContractVersion{Major: 1},
+		ContractVersion{Minor: 3},
+		PaginatedResponse{Items: []interface{}{}, Limit: 20},
+		PaginatedResponse{Limit: 20, Offset: 0, Total: 0},
+		RunnerExecutionResponse{JobId: "j1", RunnerId: "r1", Success: true, ExecutionTimeMs: 0},
+	}
+	for _, m := range models {
+		if err := m.Validate(); err != nil {
+			t.Errorf("%+v: %v", m, err)
+		}
+	}
+}
+
+func TestUnmarshalValidateRequiresNumericFields(t *testing.T) {
+	if _, err := UnmarshalValidate[ContractVersion]([]byte(`{"major":1,"minor":0,"patch":0}`)); err != nil {
+		t.Errorf("version 1.0.0: %v", err)
+	}
+	if _, err := UnmarshalValidate[ContractVersion]([]byte(`"0.4.0"`)); err != nil {
+		t.Errorf("string version 0.4.0: %v", err)
+	}
+	if _, err := UnmarshalValidate[PaginatedResponse]([]byte(`{"items":[],"total":0,"limit":20,"offset":0,"hasMore":false}`)); err != nil {
+		t.Errorf("empty page at offset 0: %v", err)
+	}
+	if _, err := DecodeValidate[RunnerExecutionResponse](strings.NewReader(`{"jobId":"j1","runnerId":"r1","success":true,"executionTimeMs":0}`)); err != nil {
+		t.Errorf("sub-millisecond execution: %v", err)
+	}
+
+	_, err := UnmarshalValidate[ContractVersion]([]byte(`{"major":1}`))
+	if err == nil || err.Error() != "minor: is required; patch: is required" {
+		t.Errorf("missing minor and patch: err = %v", err)
+	}
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs.Errors) != 2 || verrs.Errors[1].Field != "patch" {
+		t.Errorf("errors = %+v", verrs.Errors)
+	}
+	if _, err := DecodeValidate[*PaginatedResponse](strings.NewReader(`{"items":[],"limit":20,"offset":0}`)); err == nil || err.Error() != "total: is required" {
+		t.Errorf("missing total: err = %v", err)
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	if err := ValidateDocument("ContractVersion", json.RawMessage(`{"major":1,"minor":0,"patch":0}`)); err != nil {
+		t.Errorf("valid: %v", err)
+	}
+	if err := ValidateDocument("ContractVersion", json.RawMessage(`{"major":1}`)); err == nil || err.Error() != "minor: is required; patch: is required" {
+		t.Errorf("missing fields: err = %v", err)
+	}
+	var decodeErr *DecodeError
+	if err := ValidateDocument("JobRequest", json.RawMessage(`{"id":`)); !errors.As(err, &decodeErr) || decodeErr.Type != "JobRequest" {
+		t.Errorf("malformed: err = %v", err)
+	}
+	if err := ValidateDocument("Job", json.RawMessage(`{}`)); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("unknown schema: err = %v", err)
+	}
+}
+
+func TestValidateDocuments(t *testing.T) {
+	err := ValidateDocuments(map[string]json.RawMessage{
+		"ContractVersion": json.RawMessage(`{"major":1,"minor":2,"patch":3}`),
+		"JobRequest":      json.RawMessage(`{"id":"j1","payload":{}}`),
+		"Jobs":            json.RawMessage(`[]`),
+		"RetryPolicy":     json.RawMessage(`"often"`),
+	})
+	assertFields(t, err, "JobRequest.type", "JobRequest.payload.type", "Jobs", "RetryPolicy")
+	if !strings.Contains(err.Error(), `unknown schema "Jobs"`) {
+		t.Errorf("err = %v", err)
+	}
+	if err := ValidateDocuments(map[string]json.RawMessage{"ContractVersion": json.RawMessage(`"1.2.3"`)}); err != nil {
+		t.Errorf("valid: %v", err)
+	}
+}
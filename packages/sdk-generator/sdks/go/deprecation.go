@@ -0,0 +1,133 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DeprecationInfo is the typed form of the deprecation field of marketplace
+// runners and connectors.
+type DeprecationInfo struct {
+	IsDeprecated bool `json:"isDeprecated"`
+	// SunsetAt is when the item is removed; zero when no date is set.
+	SunsetAt       time.Time `json:"deprecationDate,omitempty"`
+	ReplacementId  string    `json:"replacementId,omitempty"`
+	MigrationGuide string    `json:"migrationGuide,omitempty"`
+	Reason         string    `json:"reason,omitempty"`
+}
+
+// IsSunset reports whether the item is deprecated and its sunset date is
+// not after now.
+func (d DeprecationInfo) IsSunset(now time.Time) bool {
+	return d.IsDeprecated && !d.SunsetAt.IsZero() && !now.Before(d.SunsetAt)
+}
+
+// ErrItemSunset is matched by errors.Is when a marketplace item is resolved
+// after its sunset date. See SunsetError.
+var ErrItemSunset = errors.New("marketplace item is past its sunset date")
+
+// SunsetError is returned when resolving a marketplace item that has been
+// removed.
+type SunsetError struct {
+	ItemID      string
+	Deprecation DeprecationInfo
+}
+
+func (e *SunsetError) Error() string {
+	msg := fmt.Sprintf("marketplace item %s was sunset on %s", e.ItemID, e.Deprecation.SunsetAt.Format(time.RFC3339))
+	if e.Deprecation.ReplacementId != "" {
+		msg += "; use " + e.Deprecation.ReplacementId
+	}
+	return msg
+}
+
+// Is reports whether target is ErrItemSunset.
+func (e *SunsetError) Is(target error) bool { return target == ErrItemSunset }
+
+// DeprecationTyped decodes the runner's deprecation field. It returns nil
+// when the field is not set.
+func (m MarketplaceRunner) DeprecationTyped() (*DeprecationInfo, error) {
+	return decodeDeprecation(m.Deprecation)
+}
+
+// IsDeprecated reports whether the runner is marked deprecated. A
+// deprecation field that cannot be decoded counts as not deprecated.
+func (m MarketplaceRunner) IsDeprecated() bool {
+	d, err := m.DeprecationTyped()
+	return err == nil && d != nil && d.IsDeprecated
+}
+
+// DeprecationTyped decodes the connector's deprecation field. It returns nil
+// when the field is not set.
+func (m MarketplaceConnector) DeprecationTyped() (*DeprecationInfo, error) {
+	return decodeDeprecation(m.Deprecation)
+}
+
+// IsDeprecated reports whether the connector is marked deprecated. A
+// deprecation field that cannot be decoded counts as not deprecated.
+func (m MarketplaceConnector) IsDeprecated() bool {
+	d, err := m.DeprecationTyped()
+	return err == nil && d != nil && d.IsDeprecated
+}
+
+func decodeDeprecation(raw map[string]interface{}) (*DeprecationInfo, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var d DeprecationInfo
+	if err := decodeMap(raw, &d); err != nil {
+		return nil, fmt.Errorf("decode deprecation: %w", err)
+	}
+	return &d, nil
+}
+
+// GetMarketplaceRunner resolves a marketplace runner by id. A deprecated
+// runner is returned with a warning logged; one past its sunset date fails
+// with a *SunsetError.
+func (c *ControlPlaneClient) GetMarketplaceRunner(ctx context.Context, id string, opts ...RequestOption) (*MarketplaceRunner, error) {
+	var runner MarketplaceRunner
+	opts = append([]RequestOption{withETagCache()}, opts...)
+	if err := c.call(ctx, http.MethodGet, "/v1/marketplace/runners/"+url.PathEscape(id), nil, &runner, opts...); err != nil {
+		return nil, err
+	}
+	if err := c.checkDeprecation("runner", id, runner.Deprecation); err != nil {
+		return nil, err
+	}
+	return &runner, nil
+}
+
+// GetMarketplaceConnector resolves a marketplace connector by id, handling
+// deprecation like GetMarketplaceRunner.
+func (c *ControlPlaneClient) GetMarketplaceConnector(ctx context.Context, id string, opts ...RequestOption) (*MarketplaceConnector, error) {
+	var connector MarketplaceConnector
+	opts = append([]RequestOption{withETagCache()}, opts...)
+	if err := c.call(ctx, http.MethodGet, "/v1/marketplace/connectors/"+url.PathEscape(id), nil, &connector, opts...); err != nil {
+		return nil, err
+	}
+	if err := c.checkDeprecation("connector", id, connector.Deprecation); err != nil {
+		return nil, err
+	}
+	return &connector, nil
+}
+
+// checkDeprecation logs a warning for a deprecated item and fails once it
+// is past its sunset date.
+func (c *ControlPlaneClient) checkDeprecation(kind, id string, raw map[string]interface{}) error {
+	d, err := decodeDeprecation(raw)
+	if err != nil {
+		return fmt.Errorf("marketplace %s %s: %w", kind, id, err)
+	}
+	if d == nil || !d.IsDeprecated {
+		return nil
+	}
+	if d.IsSunset(c.config.Clock.Now()) {
+		return &SunsetError{ItemID: id, Deprecation: *d}
+	}
+	c.config.Logger.Warn("controlplane: marketplace item is deprecated",
+		"kind", kind, "id", id, "reason", d.Reason, "replacement", d.ReplacementId, "sunset", d.SunsetAt)
+	return nil
+}
@@ -0,0 +1,76 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func marketplaceServer(t *testing.T, deprecation map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/marketplace/runners/csv-runner" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "csv-runner", "deprecation": deprecation})
+	}))
+}
+
+func TestGetMarketplaceRunnerActive(t *testing.T) {
+	srv := marketplaceServer(t, nil)
+	defer srv.Close()
+
+	logs := &recordingLogger{}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Logger: logs})
+	runner, err := client.GetMarketplaceRunner(context.Background(), "csv-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner.IsDeprecated() || logs.contains("deprecated") {
+		t.Errorf("active runner treated as deprecated: %v", logs.lines)
+	}
+}
+
+func TestGetMarketplaceRunnerFutureSunset(t *testing.T) {
+	sunset := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	srv := marketplaceServer(t, map[string]interface{}{
+		"isDeprecated":    true,
+		"deprecationDate": sunset.Format(time.RFC3339),
+		"replacementId":   "csv-runner-v2",
+		"reason":          "superseded",
+	})
+	defer srv.Close()
+
+	logs := &recordingLogger{}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Logger: logs})
+	runner, err := client.GetMarketplaceRunner(context.Background(), "csv-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !runner.IsDeprecated() || !logs.contains("WARN controlplane: marketplace item is deprecated") {
+		t.Errorf("no deprecation warning: %v", logs.lines)
+	}
+	d, err := runner.DeprecationTyped()
+	if err != nil || !d.SunsetAt.Equal(sunset) || d.ReplacementId != "csv-runner-v2" {
+		t.Errorf("deprecation = %+v, %v", d, err)
+	}
+}
+
+func TestGetMarketplaceRunnerPastSunset(t *testing.T) {
+	srv := marketplaceServer(t, map[string]interface{}{
+		"isDeprecated":    true,
+		"deprecationDate": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"replacementId":   "csv-runner-v2",
+	})
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	_, err := client.GetMarketplaceRunner(context.Background(), "csv-runner")
+	var sunset *SunsetError
+	if !errors.Is(err, ErrItemSunset) || !errors.As(err, &sunset) || sunset.Deprecation.ReplacementId != "csv-runner-v2" {
+		t.Errorf("err = %v", err)
+	}
+}
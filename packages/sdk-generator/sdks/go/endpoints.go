@@ -0,0 +1,195 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Strategies for choosing among ClientConfig.BaseURLs.
+const (
+	// EndpointStrategyFailover sends every request to the first healthy
+	// endpoint in order, so the others are standbys.
+	EndpointStrategyFailover = "failover"
+	// EndpointStrategyRoundRobin spreads requests over the healthy
+	// endpoints in turn.
+	EndpointStrategyRoundRobin = "round-robin"
+)
+
+// DefaultEndpointCooldown is how long a failed endpoint is avoided when
+// ClientConfig.EndpointCooldown is zero.
+const DefaultEndpointCooldown = 30 * time.Second
+
+// DefaultHealthProbeInterval is how often endpoints are probed when
+// ClientConfig.HealthProbeInterval is zero.
+const DefaultHealthProbeInterval = 10 * time.Second
+
+var errBaseURLAndBaseURLs = errors.New("invalid config: set either BaseURL or BaseURLs, not both")
+
+// endpointPool tracks the health of the client's base URLs and picks the
+// one each request attempt is sent to.
+type endpointPool struct {
+	bases      []*url.URL
+	roundRobin bool
+	cooldown   time.Duration
+	clock      Clock
+
+	mu sync.Mutex
+	// downUntil is when each endpoint's cooldown ends; zero when healthy.
+	downUntil []time.Time
+	// next is where round-robin resumes; last is the endpoint picked last.
+	next int
+	last int
+}
+
+func newEndpointPool(config ClientConfig) (*endpointPool, error) {
+	raw := config.BaseURLs
+	if len(raw) == 0 {
+		raw = []string{config.BaseURL}
+	} else if config.BaseURL != "" {
+		return nil, errBaseURLAndBaseURLs
+	}
+	p := &endpointPool{
+		cooldown:  config.EndpointCooldown,
+		clock:     config.Clock,
+		downUntil: make([]time.Time, len(raw)),
+	}
+	for _, r := range raw {
+		u, err := parseBaseURL(r)
+		if err != nil {
+			return nil, err
+		}
+		p.bases = append(p.bases, u)
+	}
+	switch config.EndpointStrategy {
+	case "", EndpointStrategyFailover:
+	case EndpointStrategyRoundRobin:
+		p.roundRobin = true
+	default:
+		return nil, fmt.Errorf("invalid config: unknown EndpointStrategy %q", config.EndpointStrategy)
+	}
+	if p.cooldown <= 0 {
+		p.cooldown = DefaultEndpointCooldown
+	}
+	return p, nil
+}
+
+// multi reports whether there is more than one endpoint to fail over to.
+func (p *endpointPool) multi() bool { return len(p.bases) > 1 }
+
+// pick returns the endpoint for the next attempt, skipping those in tried,
+// or -1 when every endpoint has been tried. Healthy endpoints come first;
+// when none is left, the one whose cooldown ends soonest is used.
+func (p *endpointPool) pick(tried []bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := 0
+	if p.roundRobin {
+		start = p.next
+		p.next = (p.next + 1) % len(p.bases)
+	}
+	i := p.choose(tried, start)
+	if i >= 0 {
+		p.last = i
+	}
+	return i
+}
+
+// untried reports whether any endpoint is not in tried.
+func untried(tried []bool) bool {
+	for _, t := range tried {
+		if !t {
+			return true
+		}
+	}
+	return tried == nil
+}
+
+func (p *endpointPool) choose(tried []bool, start int) int {
+	now := p.clock.Now()
+	best := -1
+	for k := range p.bases {
+		i := (start + k) % len(p.bases)
+		if tried != nil && tried[i] {
+			continue
+		}
+		if !now.Before(p.downUntil[i]) {
+			return i
+		}
+		if best < 0 || p.downUntil[i].Before(p.downUntil[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// active returns the endpoint requests currently go to: the preferred
+// healthy one with failover, the one picked last with round-robin.
+func (p *endpointPool) active() *url.URL {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.roundRobin {
+		return p.bases[p.last]
+	}
+	return p.bases[p.choose(nil, 0)]
+}
+
+// markDown takes an endpoint out of rotation for the cooldown.
+func (p *endpointPool) markDown(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.downUntil[i] = p.clock.Now().Add(p.cooldown)
+}
+
+// markUp puts an endpoint back into rotation.
+func (p *endpointPool) markUp(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.downUntil[i] = time.Time{}
+}
+
+// isEndpointFailure reports whether an attempt failed in a way that another
+// endpoint might not: a connection error or a 502, 503 or 504 status. A 500
+// comes from a live server that handled the request.
+func isEndpointFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrRedirectRefused) && !errors.Is(err, ErrContractVersionMismatch)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ActiveEndpoint returns the base URL requests are currently sent to, for
+// logging. With several BaseURLs it is the first healthy one under
+// EndpointStrategyFailover and the one used last under
+// EndpointStrategyRoundRobin.
+func (c *ControlPlaneClient) ActiveEndpoint() string {
+	return c.endpoints.active().String()
+}
+
+// probeEndpoints calls GetHealth on every endpoint each interval until ctx
+// is done, taking endpoints that fail or report unhealthy out of rotation and
+// putting the others back, so a recovered primary is used again without
+// waiting for its cooldown.
+func (c *ControlPlaneClient) probeEndpoints(ctx context.Context, interval time.Duration) {
+	for c.config.Clock.Sleep(ctx, interval) == nil {
+		for i := range c.endpoints.bases {
+			health, err := c.GetHealth(ctx, withEndpoint(i), WithTimeout(interval))
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil || health.Status == HealthStatusUNHEALTHY {
+				c.endpoints.markDown(i)
+				continue
+			}
+			c.endpoints.markUp(i)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// endpointSet serves several fake control planes by host. A host whose
+// status is 0 refuses connections.
+type endpointSet struct {
+	mu     sync.Mutex
+	status map[string]int
+	hits   map[string]int
+}
+
+func newEndpointSet(status map[string]int) *endpointSet {
+	return &endpointSet{status: status, hits: map[string]int{}}
+}
+
+func (s *endpointSet) set(host string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[host] = status
+}
+
+func (s *endpointSet) count(host string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[host]
+}
+
+func (s *endpointSet) transport() http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		s.mu.Lock()
+		status := s.status[req.URL.Host]
+		if req.URL.Path != "/health" {
+			s.hits[req.URL.Host]++
+		}
+		s.mu.Unlock()
+		if status == 0 {
+			return nil, errors.New("connection refused")
+		}
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(status)
+		if status == http.StatusOK {
+			rec.WriteString(`{"status":"healthy","timestamp":"2024-01-01T00:00:00Z","version":"1","uptime":1}`)
+		}
+		resp := rec.Result()
+		resp.Request = req
+		return resp, nil
+	})
+}
+
+func TestFailoverToStandby(t *testing.T) {
+	for _, primary := range []int{0, http.StatusServiceUnavailable} {
+		set := newEndpointSet(map[string]int{"a.test": primary, "b.test": http.StatusOK})
+		client := mustNewClient(t, ClientConfig{
+			BaseURLs:            []string{"http://a.test", "http://b.test"},
+			Transport:           set.transport(),
+			HealthProbeInterval: -1,
+		})
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			if _, err := client.GetJob(ctx, "j1"); err != nil {
+				t.Fatalf("primary %d: GetJob: %v", primary, err)
+			}
+		}
+		if a, b := set.count("a.test"), set.count("b.test"); a != 1 || b != 3 {
+			t.Errorf("primary %d: hits a=%d b=%d; want the primary tried once, then skipped", primary, a, b)
+		}
+		if got := client.ActiveEndpoint(); got != "http://b.test" {
+			t.Errorf("primary %d: ActiveEndpoint = %s", primary, got)
+		}
+	}
+}
+
+func TestFailoverReturnsLastFailureWhenAllDown(t *testing.T) {
+	set := newEndpointSet(map[string]int{"a.test": http.StatusBadGateway, "b.test": http.StatusInternalServerError})
+	client := mustNewClient(t, ClientConfig{
+		BaseURLs:            []string{"http://a.test", "http://b.test"},
+		Transport:           set.transport(),
+		HealthProbeInterval: -1,
+	})
+	_, err := client.GetJob(context.Background(), "j1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("err = %v", err)
+	}
+	if set.count("a.test") != 1 || set.count("b.test") != 1 {
+		t.Errorf("hits a=%d b=%d", set.count("a.test"), set.count("b.test"))
+	}
+}
+
+func TestFailoverOnlyRepeatsSafeRequests(t *testing.T) {
+	ctx := context.Background()
+	newClient := func(set *endpointSet, budget *RetryBudget) *ControlPlaneClient {
+		return mustNewClient(t, ClientConfig{
+			BaseURLs:            []string{"http://a.test", "http://b.test", "http://c.test"},
+			Transport:           set.transport(),
+			HealthProbeInterval: -1,
+			RetryBudget:         budget,
+		})
+	}
+
+	// A 500 comes from a live server; another endpoint would not help.
+	set := newEndpointSet(map[string]int{"a.test": http.StatusInternalServerError, "b.test": http.StatusOK})
+	if _, err := newClient(set, nil).GetJob(ctx, "j1"); err == nil || set.count("b.test") != 0 {
+		t.Errorf("500: err = %v, standby hits = %d", err, set.count("b.test"))
+	}
+
+	set = newEndpointSet(map[string]int{"a.test": http.StatusServiceUnavailable, "b.test": http.StatusOK})
+	client := newClient(set, nil)
+	assertion := TruthAssertion{Subject: "s"}
+	if err := client.call(ctx, http.MethodPost, "/v1/truth/assertions", assertion, nil); err == nil || set.count("b.test") != 0 {
+		t.Errorf("POST: err = %v, standby hits = %d", err, set.count("b.test"))
+	}
+	client = newClient(set, nil)
+	if err := client.call(ctx, http.MethodPost, "/v1/truth/assertions", assertion, nil, WithIdempotencyKey("t1")); err != nil || set.count("b.test") != 1 {
+		t.Errorf("POST with Idempotency-Key: err = %v, standby hits = %d", err, set.count("b.test"))
+	}
+
+	// Failing over spends from the retry budget.
+	set = newEndpointSet(map[string]int{"a.test": http.StatusBadGateway, "b.test": http.StatusBadGateway, "c.test": http.StatusOK})
+	_, err := newClient(set, &RetryBudget{MaxRetries: 1, Window: time.Minute}).GetJob(ctx, "j1")
+	if err == nil || set.count("b.test") != 1 || set.count("c.test") != 0 {
+		t.Errorf("budget: err = %v, hits b=%d c=%d", err, set.count("b.test"), set.count("c.test"))
+	}
+}
+
+func TestFailbackAfterHealthProbe(t *testing.T) {
+	set := newEndpointSet(map[string]int{"a.test": 0, "b.test": http.StatusOK})
+	client := mustNewClient(t, ClientConfig{
+		BaseURLs:            []string{"http://a.test", "http://b.test"},
+		Transport:           set.transport(),
+		EndpointCooldown:    time.Hour,
+		HealthProbeInterval: 5 * time.Millisecond,
+	})
+	defer client.Close(context.Background())
+
+	if _, err := client.GetJob(context.Background(), "j1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.ActiveEndpoint(); got != "http://b.test" {
+		t.Fatalf("ActiveEndpoint = %s", got)
+	}
+
+	set.set("a.test", http.StatusOK)
+	deadline := time.Now().Add(5 * time.Second)
+	for client.ActiveEndpoint() != "http://a.test" {
+		if time.Now().After(deadline) {
+			t.Fatal("no failback to the recovered primary")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := client.GetJob(context.Background(), "j1"); err != nil {
+		t.Fatal(err)
+	}
+	if set.count("a.test") != 2 {
+		t.Errorf("primary hits = %d", set.count("a.test"))
+	}
+}
+
+func TestRoundRobinEndpoints(t *testing.T) {
+	set := newEndpointSet(map[string]int{"a.test": http.StatusOK, "b.test": http.StatusOK})
+	client := mustNewClient(t, ClientConfig{
+		BaseURLs:            []string{"http://a.test", "http://b.test"},
+		EndpointStrategy:    EndpointStrategyRoundRobin,
+		Transport:           set.transport(),
+		HealthProbeInterval: -1,
+	})
+	for i := 0; i < 4; i++ {
+		if _, err := client.GetJob(context.Background(), "j1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if set.count("a.test") != 2 || set.count("b.test") != 2 {
+		t.Errorf("hits a=%d b=%d", set.count("a.test"), set.count("b.test"))
+	}
+}
+
+func TestEndpointConfigErrors(t *testing.T) {
+	for _, cfg := range []ClientConfig{
+		{BaseURL: "http://a.test", BaseURLs: []string{"http://b.test"}},
+		{BaseURLs: []string{"http://a.test", "ftp://b.test"}},
+		{BaseURLs: []string{"http://a.test"}, EndpointStrategy: "random"},
+	} {
+		if _, err := NewClient(cfg); err == nil {
+			t.Errorf("NewClient(%+v) accepted", cfg)
+		}
+	}
+}
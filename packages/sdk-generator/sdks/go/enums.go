@@ -0,0 +1,14 @@
+package controlplane
+
+// Values of the inline status enums, which have no schema of their own to
+// generate constants from.
+const (
+	ConnectorStatusCONNECTED    = "connected"
+	ConnectorStatusDISCONNECTED = "disconnected"
+	ConnectorStatusERROR        = "error"
+	ConnectorStatusUNKNOWN      = "unknown"
+
+	HeartbeatStatusHEALTHY   = "healthy"
+	HeartbeatStatusDEGRADED  = "degraded"
+	HeartbeatStatusUNHEALTHY = "unhealthy"
+)
@@ -0,0 +1,79 @@
+package controlplane
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnumValidation(t *testing.T) {
+	env := ErrorEnvelope{Id: "e1", Category: "whatever", Severity: "banana", Code: "X", Message: "m", Service: "svc"}
+	var verrs *ValidationErrors
+	if !errors.As(env.Validate(), &verrs) || len(verrs.Errors) != 2 {
+		t.Fatalf("err = %v", env.Validate())
+	}
+	if e := verrs.Errors[1]; e.Field != "severity" || e.Message != "must be one of [fatal, error, warning, info]" {
+		t.Errorf("severity error = %+v", e)
+	}
+
+	env.Category, env.Severity = ErrorCategoryTIMEOUT, ErrorSeverityERROR
+	if err := env.Validate(); err != nil {
+		t.Errorf("valid envelope: %v", err)
+	}
+
+	signals := MarketplaceTrustSignals{
+		OverallTrust:       TrustStatusVERIFIED,
+		ContractTestStatus: ContractTestStatusPASSING,
+		VerificationMethod: VerificationMethodAUTOMATED_CI,
+		SecurityScanStatus: "clean",
+	}
+	verrs = nil
+	if !errors.As(signals.Validate(), &verrs) || len(verrs.Errors) != 1 || verrs.Errors[0].Field != "securityScanStatus" {
+		t.Errorf("err = %v", signals.Validate())
+	}
+
+	conn := ConnectorInstance{}
+	verrs = nil
+	errors.As(conn.Validate(), &verrs)
+	for _, e := range verrs.Errors {
+		if e.Field == "status" && e.Message != "is required" {
+			t.Errorf("empty status reported as %q", e.Message)
+		}
+	}
+}
+
+func TestCheckEnumAcceptsEmpty(t *testing.T) {
+	var errs ValidationErrors
+	checkEnum("healthStatus", "", healthStatusValues, &errs)
+	checkEnum("status", HeartbeatStatusDEGRADED, runnerHeartbeatStatusValues, &errs)
+	if !errs.IsValid() {
+		t.Errorf("errors = %v", errs.Errors)
+	}
+	checkEnum("status", "ok", runnerHeartbeatStatusValues, &errs)
+	if len(errs.Errors) != 1 {
+		t.Errorf("errors = %v", errs.Errors)
+	}
+}
+
+func TestValidateChecksEveryEnumField(t *testing.T) {
+	for schema, fields := range enumFields {
+		typ, err := schemaType(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name := range fields {
+			v := reflect.New(typ).Elem()
+			f, ok := fieldByJSONName(v, name)
+			if !ok {
+				t.Fatalf("%s has no field %s", schema, name)
+			}
+			f.SetString("bogus")
+			var verrs *ValidationErrors
+			errors.As(SchemaRegistry[schema](v.Interface()), &verrs)
+			if errs := verrs.ByField(name); len(errs) != 1 || !strings.HasPrefix(errs[0].Message, "must be one of") {
+				t.Errorf("%s.%s: errors = %v", schema, name, errs)
+			}
+		}
+	}
+}
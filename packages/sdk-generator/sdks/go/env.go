@@ -0,0 +1,158 @@
+package controlplane
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEnvPrefix is the prefix ConfigFromEnv uses when given none.
+const DefaultEnvPrefix = "CONTROLPLANE"
+
+// envVars are the variables ConfigFromEnv reads, without the prefix.
+var envVars = map[string]func(cfg *ClientConfig, value string) string{
+	"BASE_URL": func(cfg *ClientConfig, v string) string {
+		if _, err := parseBaseURL(v); err != nil {
+			return err.Error()
+		}
+		cfg.BaseURL = v
+		return ""
+	},
+	"API_KEY": func(cfg *ClientConfig, v string) string {
+		cfg.APIKey = v
+		return ""
+	},
+	"TIMEOUT": func(cfg *ClientConfig, v string) string {
+		return parseEnvDuration(v, &cfg.Timeout)
+	},
+	"MAX_RETRIES": func(cfg *ClientConfig, v string) string {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return "must be a non-negative integer"
+		}
+		envRetry(cfg).MaxRetries = Int(n)
+		return ""
+	},
+	"RETRY_BACKOFF": func(cfg *ClientConfig, v string) string {
+		var d time.Duration
+		if msg := parseEnvDuration(v, &d); msg != "" {
+			return msg
+		}
+		envRetry(cfg).BackoffMs = Float(float64(d.Milliseconds()))
+		return ""
+	},
+	"RETRY_MAX_BACKOFF": func(cfg *ClientConfig, v string) string {
+		var d time.Duration
+		if msg := parseEnvDuration(v, &d); msg != "" {
+			return msg
+		}
+		envRetry(cfg).MaxBackoffMs = Float(float64(d.Milliseconds()))
+		return ""
+	},
+	"TLS_CERT_FILE": func(cfg *ClientConfig, v string) string {
+		cfg.TLSCertFile = v
+		return ""
+	},
+	"TLS_KEY_FILE": func(cfg *ClientConfig, v string) string {
+		cfg.TLSKeyFile = v
+		return ""
+	},
+	"TLS_CA_FILE": func(cfg *ClientConfig, v string) string {
+		cfg.TLSCAFile = v
+		return ""
+	},
+	"DEBUG": func(cfg *ClientConfig, v string) string {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return "must be a boolean"
+		}
+		if on {
+			cfg.DebugWriter = os.Stderr
+		}
+		return ""
+	},
+}
+
+// ConfigFromEnv builds a ClientConfig from environment variables named
+// <prefix>_<NAME>, with prefix defaulting to DefaultEnvPrefix:
+//
+//	CONTROLPLANE_BASE_URL           BaseURL (required)
+//	CONTROLPLANE_API_KEY            APIKey
+//	CONTROLPLANE_TIMEOUT            Timeout, as a duration such as "10s"
+//	CONTROLPLANE_MAX_RETRIES        Retry.MaxRetries
+//	CONTROLPLANE_RETRY_BACKOFF      Retry.BackoffMs, as a duration
+//	CONTROLPLANE_RETRY_MAX_BACKOFF  Retry.MaxBackoffMs, as a duration
+//	CONTROLPLANE_TLS_CERT_FILE      TLSCertFile
+//	CONTROLPLANE_TLS_KEY_FILE       TLSKeyFile
+//	CONTROLPLANE_TLS_CA_FILE        TLSCAFile
+//	CONTROLPLANE_DEBUG              DebugWriter set to os.Stderr when true
+//
+// Setting any retry variable starts from DefaultRetryPolicy. Malformed
+// values, a missing base URL and unrecognized variables with the prefix,
+// such as a misspelled CONTROLPLANE_TIMOUT, are reported together as
+// ValidationErrors whose fields are the variable names.
+func ConfigFromEnv(prefix string) (ClientConfig, error) {
+	prefix = strings.TrimSuffix(prefix, "_")
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix += "_"
+
+	var cfg ClientConfig
+	var errs ValidationErrors
+	names := make([]string, 0, len(envVars))
+	for name := range envVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, ok := os.LookupEnv(prefix + name)
+		if !ok || value == "" {
+			continue
+		}
+		if msg := envVars[name](&cfg, value); msg != "" {
+			errs.Add(prefix+name, msg)
+		}
+	}
+	if os.Getenv(prefix+"BASE_URL") == "" {
+		errs.Add(prefix+"BASE_URL", "is required")
+	}
+
+	var unknown []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			if _, known := envVars[name]; !known {
+				unknown = append(unknown, key)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs.Add(key, "is not a recognized variable")
+	}
+
+	if !errs.IsValid() {
+		return cfg, &errs
+	}
+	return cfg, nil
+}
+
+func parseEnvDuration(v string, dst *time.Duration) string {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return "must be a non-negative duration such as \"30s\""
+	}
+	*dst = d
+	return ""
+}
+
+// envRetry returns the config's retry policy, starting from the defaults.
+func envRetry(cfg *ClientConfig) *RetryPolicy {
+	if cfg.Retry == nil {
+		cfg.Retry = DefaultRetryPolicy()
+	}
+	return cfg.Retry
+}
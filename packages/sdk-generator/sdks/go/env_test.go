@@ -0,0 +1,65 @@
+package controlplane
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CP_TEST_BASE_URL", "https://cp.example.com")
+	t.Setenv("CP_TEST_API_KEY", "secret")
+	t.Setenv("CP_TEST_TIMEOUT", "10s")
+	t.Setenv("CP_TEST_MAX_RETRIES", "5")
+	t.Setenv("CP_TEST_RETRY_BACKOFF", "250ms")
+	t.Setenv("CP_TEST_TLS_CA_FILE", "/etc/cp/ca.pem")
+	t.Setenv("CP_TEST_DEBUG", "true")
+
+	cfg, err := ConfigFromEnv("CP_TEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BaseURL != "https://cp.example.com" || cfg.APIKey != "secret" || cfg.Timeout != 10*time.Second {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.Retry == nil || IntValue(cfg.Retry.MaxRetries) != 5 || FloatValue(cfg.Retry.BackoffMs) != 250 ||
+		FloatValue(cfg.Retry.MaxBackoffMs) != FloatValue(DefaultRetryPolicy().MaxBackoffMs) {
+		t.Errorf("retry = %+v", cfg.Retry)
+	}
+	if cfg.TLSCAFile != "/etc/cp/ca.pem" || cfg.DebugWriter != os.Stderr {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestConfigFromEnvReportsEachBadVariable(t *testing.T) {
+	t.Setenv("CP_TEST_BASE_URL", "://nope")
+	t.Setenv("CP_TEST_TIMEOUT", "ten seconds")
+	t.Setenv("CP_TEST_MAX_RETRIES", "-1")
+	t.Setenv("CP_TEST_DEBUG", "sometimes")
+	t.Setenv("CP_TEST_TIMOUT", "10s")
+
+	_, err := ConfigFromEnv("CP_TEST_")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("err = %v", err)
+	}
+	got := map[string]bool{}
+	for _, e := range verrs.Errors {
+		got[e.Field] = true
+	}
+	for _, name := range []string{"CP_TEST_BASE_URL", "CP_TEST_TIMEOUT", "CP_TEST_MAX_RETRIES", "CP_TEST_DEBUG", "CP_TEST_TIMOUT"} {
+		if !got[name] {
+			t.Errorf("no error for %s in %v", name, verrs.Errors)
+		}
+	}
+}
+
+func TestConfigFromEnvRequiresBaseURL(t *testing.T) {
+	t.Setenv("CP_TEST_API_KEY", "secret")
+	_, err := ConfigFromEnv("CP_TEST")
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs.Errors) != 1 || verrs.Errors[0].Field != "CP_TEST_BASE_URL" {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package controlplane
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorOption customizes an envelope built by NewErrorEnvelope.
+type ErrorOption func(*ErrorEnvelope)
+
+// WithDetails attaches structured details, such as the fields that failed
+// validation.
+func WithDetails(details ...ErrorDetail) ErrorOption {
+	return func(e *ErrorEnvelope) {
+		for _, d := range details {
+			var m map[string]interface{}
+			if decodeMap(d, &m) == nil {
+				e.Details = append(e.Details, m)
+			}
+		}
+	}
+}
+
+// WithCorrelationID sets the correlation id of the request that failed.
+func WithCorrelationID(id string) ErrorOption {
+	return func(e *ErrorEnvelope) { e.CorrelationId = id }
+}
+
+// WithRetryAfter records how long the caller should wait before retrying,
+// in whole seconds like the Retry-After header, and marks the error
+// retryable.
+func WithRetryAfter(d time.Duration) ErrorOption {
+	return func(e *ErrorEnvelope) {
+		e.RetryAfter = Float(d.Seconds())
+		e.Retryable = Bool(true)
+	}
+}
+
+// WithTimestamp sets the time the error occurred instead of the current
+// system time, e.g. WithTimestamp(clock.Now()) to stamp envelopes from a
+// Clock.
+func WithTimestamp(t time.Time) ErrorOption {
+	return func(e *ErrorEnvelope) { e.Timestamp = t.UTC() }
+}
+
+// NewErrorEnvelope builds a valid ErrorEnvelope with a random UUID id, the
+// current system time, unless WithTimestamp is given, and the client's
+// contract version. Severity follows the category: TIMEOUT, NETWORK_ERROR,
+// SERVICE_UNAVAILABLE and RATE_LIMITED are warnings, INTERNAL_ERROR is fatal
+// and the rest are errors. Retryable follows IsRetryableCategory.
+func NewErrorEnvelope(category, code, message, service string, opts ...ErrorOption) ErrorEnvelope {
+	e := ErrorEnvelope{
+		Id:              newUUID(),
+		Timestamp:       time.Now().UTC(),
+		Category:        category,
+		Severity:        defaultSeverity(category),
+		Code:            code,
+		Message:         message,
+		Service:         service,
+		Retryable:       Bool(IsRetryableCategory(category)),
+		ContractVersion: clientContractVersion.toMap(),
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+func defaultSeverity(category string) string {
+	switch {
+	case category == ErrorCategoryINTERNAL_ERROR:
+		return ErrorSeverityFATAL
+	case IsRetryableCategory(category) && category != ErrorCategoryRUNTIME_ERROR:
+		return ErrorSeverityWARNING
+	}
+	return ErrorSeverityERROR
+}
+
+// DefaultRedactFields are the detail fields ErrorEnvelope.Redact redacts
+// when given none.
+var DefaultRedactFields = []string{"password", "token", "secret", "authorization"}
+
+// Redact returns a copy of the envelope, safe to log, in which the Value of
+// every detail whose path names one of fields is replaced with
+// "[REDACTED]". A field names a path when it equals the path's trailing
+// elements joined with dots, ignoring case: "password" matches both
+// ["password"] and ["user", "credentials", "password"], while
+// "credentials.password" matches only the latter. With no fields,
+// DefaultRedactFields is used. The envelope itself is not modified.
+func (m ErrorEnvelope) Redact(fields ...string) ErrorEnvelope {
+	if len(fields) == 0 {
+		fields = DefaultRedactFields
+	}
+	if m.Details == nil {
+		return m
+	}
+	details := make([]map[string]interface{}, len(m.Details))
+	for i, d := range m.Details {
+		details[i] = d
+		if _, ok := d["value"]; !ok || !pathNamed(detailPath(d), fields) {
+			continue
+		}
+		cp := copyMap(d)
+		cp["value"] = redacted
+		details[i] = cp
+	}
+	m.Details = details
+	return m
+}
+
+// detailPath returns the path of a raw ErrorDetail, which is a []string when
+// built in code and a []interface{} when decoded from JSON.
+func detailPath(d map[string]interface{}) []string {
+	switch p := d["path"].(type) {
+	case []string:
+		return p
+	case []interface{}:
+		path := make([]string, len(p))
+		for i, e := range p {
+			path[i] = fmt.Sprint(e)
+		}
+		return path
+	}
+	return nil
+}
+
+// pathNamed reports whether one of fields names path; see
+// ErrorEnvelope.Redact.
+func pathNamed(path []string, fields []string) bool {
+	for _, f := range fields {
+		want := strings.Split(f, ".")
+		if len(want) > len(path) {
+			continue
+		}
+		tail := path[len(path)-len(want):]
+		match := true
+		for i := range want {
+			if !strings.EqualFold(tail[i], want[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// ToErrorDetails converts the errors to the API's ErrorDetail form. Each
+// field becomes a path, split at dots and indexes, so "runners[1].id"
+// becomes ["runners", "1", "id"]; a JSON pointer field such as
+// "/runners/1/id" is split into the same tokens.
+func (e *ValidationErrors) ToErrorDetails() []ErrorDetail {
+	if e == nil {
+		return []ErrorDetail{}
+	}
+	details := make([]ErrorDetail, 0, len(e.Errors))
+	for _, ve := range e.Errors {
+		details = append(details, ErrorDetail{Path: fieldPath(ve.Field), Message: ve.Message})
+	}
+	return details
+}
+
+// MarshalJSON encodes the errors as their ToErrorDetails array, which can
+// be decoded into ErrorEnvelope.Details as is.
+func (e *ValidationErrors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.ToErrorDetails())
+}
+
+// ToEnvelope reports the errors as a VALIDATION_ERROR envelope with code
+// VALIDATION_FAILED, as the server would, with one detail per error (see
+// ToErrorDetails). opts apply as in NewErrorEnvelope, e.g. WithTimestamp.
+func (e *ValidationErrors) ToEnvelope(service, operation string, opts ...ErrorOption) ErrorEnvelope {
+	message := e.Error()
+	if !e.IsValid() && len(e.Errors) > 1 {
+		message = fmt.Sprintf("%s (and %d more)", e.Errors[0].Error(), len(e.Errors)-1)
+	}
+	opts = append([]ErrorOption{WithDetails(e.ToErrorDetails()...)}, opts...)
+	env := NewErrorEnvelope(ErrorCategoryVALIDATION_ERROR, "VALIDATION_FAILED", message, service, opts...)
+	env.Operation = operation
+	return env
+}
+
+// fieldPath splits a ValidationError field into path elements.
+func fieldPath(field string) []string {
+	if field == "" {
+		return nil
+	}
+	if strings.HasPrefix(field, "/") {
+		path := strings.Split(field[1:], "/")
+		for i, token := range path {
+			path[i] = unescapePointer(token)
+		}
+		return path
+	}
+	var path []string
+	for _, part := range strings.Split(field, ".") {
+		for {
+			open := strings.IndexByte(part, '[')
+			end := strings.IndexByte(part, ']')
+			if open < 0 || end < open {
+				break
+			}
+			if open > 0 {
+				path = append(path, part[:open])
+			}
+			path = append(path, part[open+1:end])
+			part = part[end+1:]
+		}
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+	return path
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("controlplane: crypto/rand failed: " + err.Error())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,187 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewErrorEnvelopeDefaults(t *testing.T) {
+	before := time.Now()
+	e := NewErrorEnvelope(ErrorCategoryRESOURCE_NOT_FOUND, "JOB_NOT_FOUND", "no such job", "jobs")
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !uuidPattern.MatchString(e.Id) {
+		t.Errorf("id = %q", e.Id)
+	}
+	if e.Timestamp.Before(before.Add(-time.Second)) || e.Timestamp.After(time.Now()) {
+		t.Errorf("timestamp = %v", e.Timestamp)
+	}
+	if v, err := e.ContractVersionTyped(); err != nil || v != clientContractVersion {
+		t.Errorf("contract version = %v, %v", v, err)
+	}
+	if e.Severity != ErrorSeverityERROR || BoolValue(e.Retryable) {
+		t.Errorf("severity = %q, retryable = %v", e.Severity, e.Retryable)
+	}
+	if other := NewErrorEnvelope(ErrorCategoryTIMEOUT, "c", "m", "s"); other.Id == e.Id {
+		t.Error("ids repeat")
+	}
+}
+
+func TestNewErrorEnvelopeCategoryDefaults(t *testing.T) {
+	cases := []struct {
+		category  string
+		severity  string
+		retryable bool
+	}{
+		{ErrorCategoryTIMEOUT, ErrorSeverityWARNING, true},
+		{ErrorCategoryRATE_LIMITED, ErrorSeverityWARNING, true},
+		{ErrorCategorySERVICE_UNAVAILABLE, ErrorSeverityWARNING, true},
+		{ErrorCategoryRUNTIME_ERROR, ErrorSeverityERROR, true},
+		{ErrorCategoryVALIDATION_ERROR, ErrorSeverityERROR, false},
+		{ErrorCategoryAUTHORIZATION_ERROR, ErrorSeverityERROR, false},
+		{ErrorCategoryINTERNAL_ERROR, ErrorSeverityFATAL, false},
+	}
+	for _, c := range cases {
+		e := NewErrorEnvelope(c.category, "c", "m", "s")
+		if e.Severity != c.severity || BoolValue(e.Retryable) != c.retryable {
+			t.Errorf("%s: severity = %q, retryable = %v", c.category, e.Severity, e.Retryable)
+		}
+	}
+}
+
+func TestNewErrorEnvelopeOptions(t *testing.T) {
+	e := NewErrorEnvelope(ErrorCategoryVALIDATION_ERROR, "INVALID_JOB", "invalid job", "jobs",
+		WithDetails(ErrorDetail{Path: []string{"type"}, Message: "is required"}),
+		WithCorrelationID("c0ffee"),
+		WithRetryAfter(30*time.Second),
+		WithTimestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))))
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Details) != 1 || e.Details[0]["message"] != "is required" {
+		t.Errorf("details = %v", e.Details)
+	}
+	if e.CorrelationId != "c0ffee" || FloatValue(e.RetryAfter) != 30 || !BoolValue(e.Retryable) ||
+		e.Timestamp != time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC) {
+		t.Errorf("envelope = %+v", e)
+	}
+}
+
+func TestErrorEnvelopeRedact(t *testing.T) {
+	e := NewErrorEnvelope(ErrorCategoryVALIDATION_ERROR, "INVALID", "bad request", "auth", WithDetails(
+		ErrorDetail{Path: []string{"user", "credentials", "Password"}, Message: "too short", Value: "hunter2"},
+		ErrorDetail{Path: []string{"user", "name"}, Message: "taken", Value: "alice"},
+		ErrorDetail{Path: []string{"apiToken"}, Message: "expired", Value: "tok"},
+		ErrorDetail{Path: []string{"session", "token"}, Message: "expired", Value: "abc"},
+	))
+
+	got := e.Redact()
+	values := func(env ErrorEnvelope) []interface{} {
+		var out []interface{}
+		for _, d := range env.Details {
+			out = append(out, d["value"])
+		}
+		return out
+	}
+	want := []interface{}{redacted, "alice", "tok", redacted}
+	if fmt.Sprint(values(got)) != fmt.Sprint(want) {
+		t.Errorf("Redact() values = %v, want %v", values(got), want)
+	}
+	if v := values(e); v[0] != "hunter2" || v[3] != "abc" {
+		t.Errorf("original mutated: %v", v)
+	}
+
+	got = e.Redact("credentials.password", "user.name")
+	want = []interface{}{redacted, redacted, "tok", "abc"}
+	if fmt.Sprint(values(got)) != fmt.Sprint(want) {
+		t.Errorf("Redact(nested) values = %v, want %v", values(got), want)
+	}
+	if got = e.Redact("account.credentials.password"); values(got)[0] != "hunter2" {
+		t.Error("longer field matched a shorter path")
+	}
+}
+
+func TestErrorEnvelopeRedactDecoded(t *testing.T) {
+	var e ErrorEnvelope
+	if err := json.Unmarshal([]byte(`{"details":[{"path":["auth","secret"],"message":"m","value":"s3"}]}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	if v := e.Redact().Details[0]["value"]; v != redacted {
+		t.Errorf("value = %v", v)
+	}
+	if v := e.Details[0]["value"]; v != "s3" {
+		t.Errorf("original mutated: %v", v)
+	}
+}
+
+func TestValidationErrorsToErrorDetails(t *testing.T) {
+	errs := ValidationErrors{Errors: []ValidationError{
+		{Field: "type", Message: "is required"},
+		{Field: "metadata.source", Message: "is required"},
+		{Field: "runners[1].capabilities[0]", Message: "is unknown"},
+		{Field: "", Message: "is empty"},
+		{Field: "/rows/0/a~1b", Message: "is not allowed"},
+	}}
+	details := errs.ToErrorDetails()
+	want := [][]string{{"type"}, {"metadata", "source"}, {"runners", "1", "capabilities", "0"}, nil, {"rows", "0", "a/b"}}
+	if len(details) != len(want) {
+		t.Fatalf("details = %+v", details)
+	}
+	for i, d := range details {
+		if fmt.Sprint(d.Path) != fmt.Sprint(want[i]) || d.Message != errs.Errors[i].Message {
+			t.Errorf("detail %d = %+v, want path %q", i, d, want[i])
+		}
+	}
+}
+
+func TestValidationErrorsToEnvelope(t *testing.T) {
+	// Nested fields from the builder keep their path.
+	_, err := NewJobRequestBuilder("csv.import").Build()
+	verrs, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("err = %v", err)
+	}
+	e := verrs.ToEnvelope("jobs", "submit")
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Category != ErrorCategoryVALIDATION_ERROR || e.Code != "VALIDATION_FAILED" || e.Service != "jobs" || e.Operation != "submit" {
+		t.Errorf("envelope = %+v", e)
+	}
+	if e.Message != "metadata.source: is required" {
+		t.Errorf("message = %q", e.Message)
+	}
+	if len(e.Details) != 1 || fmt.Sprint(detailPath(e.Details[0])) != "[metadata source]" {
+		t.Errorf("details = %v", e.Details)
+	}
+
+	two := ValidationErrors{Errors: []ValidationError{{"id", "is required"}, {"type", "is required"}}}
+	if got := two.ToEnvelope("jobs", "").Message; got != "id: is required (and 1 more)" {
+		t.Errorf("message = %q", got)
+	}
+}
+
+func TestValidationErrorsMarshalJSON(t *testing.T) {
+	verrs := &ValidationErrors{Errors: []ValidationError{{"capabilities[2].inputSchema", "is required"}}}
+	data, err := json.Marshal(verrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"path":["capabilities","2","inputSchema"],"message":"is required"}]`; string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+
+	env := NewErrorEnvelope(ErrorCategoryVALIDATION_ERROR, "VALIDATION_FAILED", verrs.Error(), "jobs")
+	if err := json.Unmarshal(data, &env.Details); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Validate(); err != nil || fmt.Sprint(detailPath(env.Details[0])) != "[capabilities 2 inputSchema]" {
+		t.Errorf("details = %v, err = %v", env.Details, err)
+	}
+}
@@ -0,0 +1,151 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// APIError is returned by the typed endpoint methods for a non-2xx response.
+// Envelope holds the decoded error envelope; when the body is not a valid
+// envelope, one is synthesized with category INTERNAL_ERROR and Raw still
+// holds the body as received. Errors reported by a job rather than by a
+// response, see JobResult.AsError, have a zero StatusCode.
+type APIError struct {
+	StatusCode int
+	Envelope   ErrorEnvelope
+	Raw        []byte
+
+	// synthesized is set when Envelope was not sent by the server.
+	synthesized bool
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		if e.Envelope.Code == "" {
+			return e.Envelope.Message
+		}
+		return e.Envelope.Code + ": " + e.Envelope.Message
+	}
+	if e.Envelope.Code == "" {
+		return fmt.Sprintf("status %d: %s", e.StatusCode, e.Envelope.Message)
+	}
+	return fmt.Sprintf("status %d: %s: %s", e.StatusCode, e.Envelope.Code, e.Envelope.Message)
+}
+
+// AsAPIError finds the first *APIError in err's chain.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// CategoryOf returns the ErrorCategory of err, looking through wrapping.
+// An *APIError reports its envelope's category, or one derived from the
+// status code when the server sent no envelope. Client-side failures are
+// mapped too: deadlines and network timeouts are TIMEOUT, other network
+// failures NETWORK_ERROR, token provider failures AUTHENTICATION_ERROR,
+// output schema mismatches SCHEMA_MISMATCH and ValidationErrors
+// VALIDATION_ERROR. Other errors, including nil, return "".
+func CategoryOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		if apiErr.synthesized || apiErr.Envelope.Category == "" {
+			return statusCategory(apiErr.StatusCode)
+		}
+		return apiErr.Envelope.Category
+	}
+
+	var netErr net.Error
+	var tokenErr *TokenError
+	var mismatch *OutputMismatchError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCategoryTIMEOUT
+	case errors.As(err, &tokenErr):
+		return ErrorCategoryAUTHENTICATION_ERROR
+	case errors.As(err, &mismatch):
+		return mismatch.Envelope.Category
+	case errors.Is(err, ErrValidation):
+		return ErrorCategoryVALIDATION_ERROR
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ErrorCategoryTIMEOUT
+		}
+		return ErrorCategoryNETWORK_ERROR
+	}
+	return ""
+}
+
+// statusCategory maps an HTTP status to the closest ErrorCategory.
+func statusCategory(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrorCategoryVALIDATION_ERROR
+	case http.StatusUnauthorized:
+		return ErrorCategoryAUTHENTICATION_ERROR
+	case http.StatusForbidden:
+		return ErrorCategoryAUTHORIZATION_ERROR
+	case http.StatusNotFound:
+		return ErrorCategoryRESOURCE_NOT_FOUND
+	case http.StatusConflict:
+		return ErrorCategoryRESOURCE_CONFLICT
+	case http.StatusTooManyRequests:
+		return ErrorCategoryRATE_LIMITED
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrorCategoryTIMEOUT
+	case http.StatusServiceUnavailable:
+		return ErrorCategorySERVICE_UNAVAILABLE
+	}
+	return ErrorCategoryINTERNAL_ERROR
+}
+
+// IsNotFound reports whether err has category RESOURCE_NOT_FOUND.
+func IsNotFound(err error) bool { return CategoryOf(err) == ErrorCategoryRESOURCE_NOT_FOUND }
+
+// IsConflict reports whether err has category RESOURCE_CONFLICT.
+func IsConflict(err error) bool { return CategoryOf(err) == ErrorCategoryRESOURCE_CONFLICT }
+
+// IsRateLimited reports whether err has category RATE_LIMITED.
+func IsRateLimited(err error) bool { return CategoryOf(err) == ErrorCategoryRATE_LIMITED }
+
+// IsTimeout reports whether err has category TIMEOUT, which includes an
+// exceeded context deadline.
+func IsTimeout(err error) bool { return CategoryOf(err) == ErrorCategoryTIMEOUT }
+
+// IsValidation reports whether err has category VALIDATION_ERROR or
+// SCHEMA_MISMATCH.
+func IsValidation(err error) bool {
+	c := CategoryOf(err)
+	return c == ErrorCategoryVALIDATION_ERROR || c == ErrorCategorySCHEMA_MISMATCH
+}
+
+// newAPIError reads the response body into an APIError. A body that cannot
+// be read in full, e.g. one over MaxResponseBytes, yields the read error
+// instead.
+func newAPIError(resp *http.Response) error {
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read status %d response: %w", resp.StatusCode, err)
+	}
+	e := &APIError{StatusCode: resp.StatusCode, Raw: raw}
+	if env := parseErrorEnvelope(raw); env != nil {
+		e.Envelope = *env
+		return e
+	}
+	e.synthesized = true
+	e.Envelope = ErrorEnvelope{
+		Category: ErrorCategoryINTERNAL_ERROR,
+		Severity: "error",
+		Code:     "UNEXPECTED_RESPONSE",
+		Message:  fmt.Sprintf("unexpected status %d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+	}
+	return e
+}
@@ -0,0 +1,170 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedMethodsReturnAPIError(t *testing.T) {
+	const envelope = `{"id":"e1","category":"NOT_FOUND","severity":"error","code":"JOB_NOT_FOUND","message":"job j1 not found","service":"api"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(envelope))
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	_, err := client.GetJob(context.Background(), "j1")
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		t.Fatalf("err = %T %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Envelope.Code != "JOB_NOT_FOUND" || string(apiErr.Raw) != envelope {
+		t.Errorf("api error = %+v", apiErr)
+	}
+	if err.Error() != "status 404: JOB_NOT_FOUND: job j1 not found" {
+		t.Errorf("message = %q", err)
+	}
+}
+
+func TestAPIErrorSynthesizesEnvelope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>bad gateway</html>"))
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	_, err := client.GetHealth(context.Background())
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		t.Fatalf("err = %T %v", err, err)
+	}
+	if apiErr.Envelope.Category != ErrorCategoryINTERNAL_ERROR || string(apiErr.Raw) != "<html>bad gateway</html>" {
+		t.Errorf("api error = %+v", apiErr)
+	}
+}
+
+func TestAsAPIErrorThroughWrapping(t *testing.T) {
+	inner := &APIError{StatusCode: http.StatusConflict}
+	wrapped := fmt.Errorf("submit: %w", fmt.Errorf("retrying: %w", inner))
+	if got, ok := AsAPIError(wrapped); !ok || got != inner {
+		t.Errorf("AsAPIError = %v, %v", got, ok)
+	}
+	if _, ok := AsAPIError(fmt.Errorf("plain")); ok {
+		t.Error("plain error reported as APIError")
+	}
+}
+
+// categoryPredicates records which predicate, if any, each ErrorCategory
+// satisfies. TestCategoryPredicatesCoverContract fails when the contract
+// gains a category missing from this table.
+var categoryPredicates = map[string]string{
+	ErrorCategoryVALIDATION_ERROR:     "IsValidation",
+	ErrorCategorySCHEMA_MISMATCH:      "IsValidation",
+	ErrorCategoryRUNTIME_ERROR:        "",
+	ErrorCategoryTIMEOUT:              "IsTimeout",
+	ErrorCategoryNETWORK_ERROR:        "",
+	ErrorCategoryAUTHENTICATION_ERROR: "",
+	ErrorCategoryAUTHORIZATION_ERROR:  "",
+	ErrorCategoryRESOURCE_NOT_FOUND:   "IsNotFound",
+	ErrorCategoryRESOURCE_CONFLICT:    "IsConflict",
+	ErrorCategoryRATE_LIMITED:         "IsRateLimited",
+	ErrorCategorySERVICE_UNAVAILABLE:  "",
+	ErrorCategoryRUNNER_ERROR:         "",
+	ErrorCategoryTRUTHCORE_ERROR:      "",
+	ErrorCategoryINTERNAL_ERROR:       "",
+}
+
+func TestCategoryPredicates(t *testing.T) {
+	predicates := map[string]func(error) bool{
+		"IsValidation":  IsValidation,
+		"IsTimeout":     IsTimeout,
+		"IsNotFound":    IsNotFound,
+		"IsConflict":    IsConflict,
+		"IsRateLimited": IsRateLimited,
+	}
+	for category, want := range categoryPredicates {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: 500, Envelope: ErrorEnvelope{Category: category}})
+		if got := CategoryOf(err); got != category {
+			t.Errorf("CategoryOf = %q, want %q", got, category)
+		}
+		for name, pred := range predicates {
+			if pred(err) != (name == want) {
+				t.Errorf("%s(%s) = %v", name, category, !(name == want))
+			}
+		}
+	}
+}
+
+func TestCategoryPredicatesCoverContract(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "types.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "ErrorCategory") || i >= len(spec.Values) {
+				continue
+			}
+			lit, ok := spec.Values[i].(*ast.BasicLit)
+			if !ok {
+				continue
+			}
+			if _, ok := categoryPredicates[strings.Trim(lit.Value, `"`)]; !ok {
+				t.Errorf("no predicate decision for %s", name.Name)
+			}
+		}
+		return true
+	})
+}
+
+func TestCategoryOfTransportErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	client := mustNewClient(t, ClientConfig{BaseURL: "http://127.0.0.1:0"})
+	_, err := client.GetHealth(ctx)
+	if !IsTimeout(err) {
+		t.Errorf("deadline: CategoryOf(%v) = %q", err, CategoryOf(err))
+	}
+
+	_, err = client.GetHealth(context.Background())
+	if got := CategoryOf(err); got != ErrorCategoryNETWORK_ERROR {
+		t.Errorf("refused: CategoryOf(%v) = %q", err, got)
+	}
+
+	if got := CategoryOf(&net.DNSError{IsTimeout: true}); got != ErrorCategoryTIMEOUT {
+		t.Errorf("dns timeout: %q", got)
+	}
+	if got := CategoryOf(&TokenError{Err: fmt.Errorf("expired")}); got != ErrorCategoryAUTHENTICATION_ERROR {
+		t.Errorf("token: %q", got)
+	}
+	if CategoryOf(nil) != "" || CategoryOf(fmt.Errorf("other")) != "" {
+		t.Error("unrelated errors should have no category")
+	}
+}
+
+func TestCategoryOfWithoutEnvelope(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	_, err := client.GetJob(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Errorf("CategoryOf(%v) = %q", err, CategoryOf(err))
+	}
+}
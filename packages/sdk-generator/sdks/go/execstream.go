@@ -0,0 +1,188 @@
+package controlplane
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// ExecutionChunk is one piece of the output of a streamed execution. Progress
+// chunks carry partial Data; the last chunk has Final set and carries the
+// complete result in Response, with Data set to Response.Data.
+type ExecutionChunk struct {
+	Data     interface{}              `json:"data,omitempty"`
+	Final    bool                     `json:"final,omitempty"`
+	Response *RunnerExecutionResponse `json:"-"`
+}
+
+// eventStreamMediaType is the media type of server-sent events.
+const eventStreamMediaType = "text/event-stream"
+
+// ExecuteStreaming asks a runner to execute a job like ExecuteJob, but
+// delivers its output incrementally, for progress reporting on long jobs.
+// The runner answers with server-sent events, "chunk" events holding an
+// ExecutionChunk and a "result" event holding the RunnerExecutionResponse,
+// or with newline-delimited JSON in which the final record has "final":
+// true. A runner that cannot stream may answer with a plain
+// RunnerExecutionResponse, which arrives as the final chunk.
+//
+// The request is made before ExecuteStreaming returns, and its failure is
+// returned directly. Afterwards, chunks are delivered until the final one;
+// a stream that ends early, an error event or a decoding failure is sent on
+// the error channel instead. Both channels are closed when the stream ends.
+// Executions are not resumed: a dropped stream is an error.
+//
+// The stream is not bounded by ClientConfig.Timeout; an unset req.TimeoutMs is
+// derived from the deadline of ctx, as in ExecuteJob. WithCapabilitySchemas
+// checks the payload, but not the streamed output.
+func (c *ControlPlaneClient) ExecuteStreaming(ctx context.Context, runnerID string, req RunnerExecutionRequest, opts ...RequestOption) (<-chan ExecutionChunk, <-chan error, error) {
+	if FloatValue(req.TimeoutMs) <= 0 {
+		req.TimeoutMs = c.deriveTimeoutMs(ctx, opts)
+	}
+	if capability := c.applyOptions(opts).inputSchema; capability != nil {
+		if err := c.schemas.validateInput(*capability, req.Payload); err != nil {
+			return nil, nil, fmt.Errorf("execute %s: payload: %w", capability.Id, err)
+		}
+	}
+	ctx, release, err := c.startBackground(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	accept := eventStreamMediaType + ", " + ndjsonMediaType + ";q=0.9, application/json;q=0.8"
+	opts = append([]RequestOption{WithTimeout(0), WithHeader("Accept", accept), withUnlimitedBody()}, opts...)
+	resp, err := c.Request(ctx, http.MethodPost, "/v1/runners/"+url.PathEscape(runnerID)+"/execute", req, opts...)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	if err := checkStatus(resp); err != nil {
+		drainAndClose(resp.Body)
+		release()
+		return nil, nil, err
+	}
+
+	chunks := make(chan ExecutionChunk)
+	errc := make(chan error, 1)
+	go func() {
+		defer release()
+		defer close(chunks)
+		defer close(errc)
+		defer resp.Body.Close()
+		r := executionReader{ctx: ctx, out: chunks, limit: c.config.MaxResponseBytes}
+		if err := r.read(resp); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			errc <- fmt.Errorf("execute on runner %s: %w", runnerID, err)
+		}
+	}()
+	return chunks, errc, nil
+}
+
+// errExecutionIncomplete reports a stream that ended before its final chunk.
+var errExecutionIncomplete = errors.New("stream ended before the final chunk")
+
+type executionReader struct {
+	ctx   context.Context
+	out   chan<- ExecutionChunk
+	limit int64
+	done  bool
+}
+
+// read delivers the chunks of resp, in whichever format the runner chose,
+// until the final one.
+func (r *executionReader) read(resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	mt, _, _ := mime.ParseMediaType(contentType)
+	var err error
+	switch mt {
+	case eventStreamMediaType:
+		sse := sseReader{limit: r.limit, contentType: contentType}
+		err = sse.read(resp.Body, func(event, _, data string) error {
+			switch event {
+			case "error":
+				if err := sseError(data); err != nil {
+					return err
+				}
+				return fmt.Errorf("error event: %s", data)
+			case "result", "final":
+				return r.record([]byte(data), true)
+			}
+			return r.record([]byte(data), false)
+		})
+	case ndjsonMediaType:
+		err = r.readLines(resp.Body, contentType)
+	default:
+		var data []byte
+		if data, err = io.ReadAll(resp.Body); err == nil {
+			err = r.record(data, true)
+		}
+	}
+	if r.done {
+		return nil
+	}
+	if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errExecutionIncomplete
+	}
+	return err
+}
+
+func (r *executionReader) readLines(body io.Reader, contentType string) error {
+	limit := r.limit
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64<<10), int(min64(limit+1, math.MaxInt32)))
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		if err := r.record(sc.Bytes(), false); err != nil {
+			return err
+		}
+	}
+	if errors.Is(sc.Err(), bufio.ErrTooLong) {
+		return &ResponseTooLargeError{Limit: limit, BytesRead: limit + 1, ContentType: contentType}
+	}
+	return sc.Err()
+}
+
+// errExecutionDone stops reading once the final chunk is delivered.
+var errExecutionDone = errors.New("execution done")
+
+// record decodes and delivers one chunk. Records after the final one are
+// ignored.
+func (r *executionReader) record(data []byte, final bool) error {
+	if r.done {
+		return errExecutionDone
+	}
+	var chunk ExecutionChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return fmt.Errorf("decode chunk: %w", err)
+	}
+	if final || chunk.Final {
+		var resp RunnerExecutionResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("decode result: %w", err)
+		}
+		chunk = ExecutionChunk{Data: resp.Data, Final: true, Response: &resp}
+	}
+	select {
+	case r.out <- chunk:
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+	if chunk.Final {
+		r.done = true
+		return errExecutionDone
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func collectChunks(t *testing.T, chunks <-chan ExecutionChunk, errc <-chan error) ([]ExecutionChunk, error) {
+	t.Helper()
+	var got []ExecutionChunk
+	for c := range chunks {
+		got = append(got, c)
+	}
+	return got, <-errc
+}
+
+func TestExecuteStreamingSSE(t *testing.T) {
+	var sent RunnerExecutionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/runners/r1/execute" || !strings.HasPrefix(r.Header.Get("Accept"), eventStreamMediaType) {
+			t.Errorf("request = %s %v", r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Header().Set("Content-Type", eventStreamMediaType)
+		flusher := w.(http.Flusher)
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "event: chunk\ndata: {\"data\":{\"progress\":%d}}\n\n", i*30)
+			flusher.Flush()
+		}
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event: result\ndata: {\"jobId\":\"j1\",\"success\":true,\"data\":{\"rows\":42},\"executionTimeMs\":183000,\"runnerId\":\"r1\"}\n\n")
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	chunks, errc, err := client.ExecuteStreaming(context.Background(), "r1", RunnerExecutionRequest{JobId: "j1", ModuleId: "m", CapabilityId: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := collectChunks(t, chunks, errc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.JobId != "j1" {
+		t.Errorf("sent = %+v", sent)
+	}
+	if len(got) != 4 {
+		t.Fatalf("chunks = %+v", got)
+	}
+	for i, c := range got[:3] {
+		if c.Final || c.Data.(map[string]interface{})["progress"] != float64((i+1)*30) {
+			t.Errorf("chunk %d = %+v", i, c)
+		}
+	}
+	final := got[3]
+	if !final.Final || final.Response == nil || final.Response.ExecutionTimeMs != 183000 || !final.Response.Success {
+		t.Fatalf("final = %+v", final)
+	}
+	if final.Data.(map[string]interface{})["rows"] != float64(42) {
+		t.Errorf("final data = %v", final.Data)
+	}
+}
+
+func TestExecuteStreamingNDJSONAndPlainJSON(t *testing.T) {
+	bodies := map[string]string{
+		ndjsonMediaType: `{"data":"a"}` + "\n" + `{"data":"b"}` + "\n" +
+			`{"final":true,"jobId":"j1","success":true,"data":"ab","executionTimeMs":12,"runnerId":"r1"}` + "\n",
+		"application/json": `{"jobId":"j1","success":true,"data":"ab","executionTimeMs":12,"runnerId":"r1"}`,
+	}
+	for contentType, body := range bodies {
+		client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			fmt.Fprint(w, body)
+		}))
+		chunks, errc, err := client.ExecuteStreaming(context.Background(), "r1", RunnerExecutionRequest{JobId: "j1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := collectChunks(t, chunks, errc)
+		if err != nil {
+			t.Fatalf("%s: %v", contentType, err)
+		}
+		last := got[len(got)-1]
+		if !last.Final || last.Data != "ab" || last.Response.ExecutionTimeMs != 12 {
+			t.Errorf("%s: chunks = %+v", contentType, got)
+		}
+		if contentType == ndjsonMediaType && len(got) != 3 {
+			t.Errorf("%s: %d chunks", contentType, len(got))
+		}
+	}
+}
+
+func TestExecuteStreamingFailures(t *testing.T) {
+	tests := map[string]string{
+		"ended early": "event: chunk\ndata: {\"data\":1}\n\n",
+		"error event": "event: error\ndata: {\"code\":\"RUNNER_CRASHED\",\"message\":\"boom\",\"category\":\"RUNTIME_ERROR\"}\n\n",
+	}
+	for name, body := range tests {
+		client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", eventStreamMediaType)
+			fmt.Fprint(w, body)
+		}))
+		chunks, errc, err := client.ExecuteStreaming(context.Background(), "r1", RunnerExecutionRequest{JobId: "j1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = collectChunks(t, chunks, errc)
+		switch name {
+		case "ended early":
+			if !errors.Is(err, errExecutionIncomplete) {
+				t.Errorf("%s: err = %v", name, err)
+			}
+		case "error event":
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Envelope.Code != "RUNNER_CRASHED" {
+				t.Errorf("%s: err = %v", name, err)
+			}
+		}
+	}
+
+	client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such runner", http.StatusNotFound)
+	}))
+	if _, _, err := client.ExecuteStreaming(context.Background(), "r1", RunnerExecutionRequest{}); !IsNotFound(err) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ApiRequestFromHTTP captures r in the ApiRequest envelope form. Header names
+// are folded to lower case and repeated values joined with ", ". Query
+// parameters with one value are kept as a string and repeated ones as a
+// []string. A JSON body (application/json or a +json type) is decoded;
+// any other body is kept as raw bytes. Id is taken from X-Request-Id when
+// present, otherwise generated, and r.Body is replaced so it can be read
+// again. The timestamp metadata entry is the current time.
+func ApiRequestFromHTTP(r *http.Request) (ApiRequest, error) {
+	return ApiRequestFromHTTPAt(r, time.Now())
+}
+
+// ApiRequestFromHTTPAt is ApiRequestFromHTTP with the timestamp metadata
+// entry set to now, such as a Clock's Now, rather than the system time.
+func ApiRequestFromHTTPAt(r *http.Request, now time.Time) (ApiRequest, error) {
+	req := ApiRequest{
+		Id:     r.Header.Get("X-Request-Id"),
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Metadata: map[string]interface{}{
+			"timestamp": now.UTC().Format(time.RFC3339Nano),
+		},
+	}
+	if req.Id == "" {
+		req.Id = newUUID()
+	}
+	if id := r.Header.Get("X-Correlation-Id"); id != "" {
+		req.Metadata["correlationId"] = id
+	}
+	if len(r.Header) > 0 {
+		req.Headers = make(map[string]string, len(r.Header))
+		for name, values := range r.Header {
+			req.Headers[strings.ToLower(name)] = strings.Join(values, ", ")
+		}
+	}
+	if q := r.URL.Query(); len(q) > 0 {
+		req.Query = make(map[string]interface{}, len(q))
+		for name, values := range q {
+			if len(values) == 1 {
+				req.Query[name] = values[0]
+			} else {
+				req.Query[name] = values
+			}
+		}
+	}
+
+	if r.Body == nil {
+		return req, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return req, fmt.Errorf("read request body: %w", err)
+	}
+	if len(data) == 0 {
+		return req, nil
+	}
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		req.Body = data
+		return req, nil
+	}
+	if err := json.Unmarshal(data, &req.Body); err != nil {
+		return req, fmt.Errorf("decode request body: %w", err)
+	}
+	return req, nil
+}
+
+// WriteHTTP writes m to w: its headers, its status code (200 when zero) and
+// its body. A []byte or json.RawMessage body is written as is, as is a string
+// body when the Content-Type header is not JSON; any other body is encoded
+// as JSON. When Body is nil, Error is written instead, if set.
+func (m ApiResponse) WriteHTTP(w http.ResponseWriter) error {
+	h := w.Header()
+	for name, value := range m.Headers {
+		h.Set(name, value)
+	}
+	status := m.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	var body []byte
+	text, isText := m.Body.(string)
+	isText = isText && h.Get("Content-Type") != "" && !isJSONContentType(h.Get("Content-Type"))
+	switch b := m.Body.(type) {
+	case nil:
+		if m.Error != nil {
+			data, err := json.Marshal(m.Error)
+			if err != nil {
+				return fmt.Errorf("encode error envelope: %w", err)
+			}
+			body = data
+		}
+	case []byte:
+		body = b
+	case json.RawMessage:
+		body = b
+	default:
+		if isText {
+			body = []byte(text)
+			break
+		}
+		data, err := json.Marshal(m.Body)
+		if err != nil {
+			return fmt.Errorf("encode response body: %w", err)
+		}
+		body = data
+	}
+	if body != nil && h.Get("Content-Type") == "" {
+		if _, raw := m.Body.([]byte); raw {
+			h.Set("Content-Type", "application/octet-stream")
+		} else {
+			h.Set("Content-Type", "application/json")
+		}
+	}
+
+	w.WriteHeader(status)
+	if len(body) == 0 {
+		return nil
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// isJSONContentType reports whether a Content-Type is application/json or a
+// +json structured syntax type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,121 @@
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApiRequestFromHTTP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/jobs?dryRun=true&tag=a&tag=b", bytes.NewBufferString(`{"id":"job-1","payload":{"n":1}}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("X-Request-Id", "req-1")
+	r.Header.Add("X-Trace", "one")
+	r.Header.Add("x-trace", "two")
+
+	req, err := ApiRequestFromHTTP(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if req.Id != "req-1" || req.Method != http.MethodPost || req.Path != "/v1/jobs" {
+		t.Errorf("request = %+v", req)
+	}
+	if req.Headers["x-trace"] != "one, two" || req.Headers["content-type"] != "application/json; charset=utf-8" {
+		t.Errorf("headers = %v", req.Headers)
+	}
+	if req.Query["dryRun"] != "true" || !reflect.DeepEqual(req.Query["tag"], []string{"a", "b"}) {
+		t.Errorf("query = %v", req.Query)
+	}
+	want := map[string]interface{}{"id": "job-1", "payload": map[string]interface{}{"n": 1.0}}
+	if !reflect.DeepEqual(req.Body, want) {
+		t.Errorf("body = %#v", req.Body)
+	}
+	if data, _ := io.ReadAll(r.Body); len(data) == 0 {
+		t.Error("request body not restored")
+	}
+
+	// The envelope survives its own JSON encoding.
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ApiRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Body, want) || decoded.Headers["x-trace"] != "one, two" {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
+
+func TestApiRequestFromHTTPAt(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/jobs", nil)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	req, err := ApiRequestFromHTTPAt(r, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Metadata["timestamp"]; got != "2024-01-02T02:04:05Z" {
+		t.Errorf("timestamp = %v", got)
+	}
+}
+
+func TestApiRequestFromHTTPRawBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPut, "/upload", bytes.NewBufferString("a,b\n1,2\n"))
+	r.Header.Set("Content-Type", "text/csv")
+	req, err := ApiRequestFromHTTP(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := req.Body.([]byte); !ok || string(b) != "a,b\n1,2\n" {
+		t.Errorf("body = %#v", req.Body)
+	}
+	if req.Id == "" {
+		t.Error("id not generated")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/v1/jobs", bytes.NewBufferString("{"))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := ApiRequestFromHTTP(r); err == nil {
+		t.Error("malformed JSON body accepted")
+	}
+}
+
+func TestApiResponseWriteHTTP(t *testing.T) {
+	cases := []struct {
+		name        string
+		resp        ApiResponse
+		status      int
+		body        string
+		contentType string
+	}{
+		{"json", ApiResponse{StatusCode: 201, Body: map[string]interface{}{"id": "job-1"}, Headers: map[string]string{"x-request-id": "r1"}},
+			201, `{"id":"job-1"}`, "application/json"},
+		{"raw bytes", ApiResponse{Body: []byte("a,b\n")}, 200, "a,b\n", "application/octet-stream"},
+		{"text", ApiResponse{Body: "hello", Headers: map[string]string{"content-type": "text/plain"}}, 200, "hello", "text/plain"},
+		{"error", ApiResponse{StatusCode: 404, Error: map[string]interface{}{"code": "NOT_FOUND"}}, 404, `{"code":"NOT_FOUND"}`, "application/json"},
+		{"empty", ApiResponse{StatusCode: 204}, 204, "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		if err := c.resp.WriteHTTP(rec); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if rec.Code != c.status || rec.Body.String() != c.body || rec.Header().Get("Content-Type") != c.contentType {
+			t.Errorf("%s: got %d %q %q", c.name, rec.Code, rec.Body.String(), rec.Header().Get("Content-Type"))
+		}
+	}
+	rec := httptest.NewRecorder()
+	ApiResponse{Headers: map[string]string{"x-request-id": "r1"}}.WriteHTTP(rec)
+	if rec.Header().Get("X-Request-Id") != "r1" {
+		t.Errorf("headers = %v", rec.Header())
+	}
+}
@@ -0,0 +1,157 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// DefaultContractRange returns the server contract versions the client
+// supports when ClientConfig.SupportedContracts is nil: every version with
+// the client's major version.
+func DefaultContractRange() ContractRange {
+	return ContractRange{
+		Min: ContractVersion{Major: clientContractVersion.Major}.toMap(),
+		Max: ContractVersion{Major: clientContractVersion.Major, Minor: math.MaxInt32, Patch: math.MaxInt32}.toMap(),
+	}
+}
+
+// ErrIncompatibleContract is matched by errors.Is when Handshake or
+// NegotiateContractVersion finds the server's contract version unsupported.
+// See IncompatibleContractError.
+var ErrIncompatibleContract = errors.New("incompatible contract version")
+
+// IncompatibleContractError reports a server whose contract version the
+// client does not support.
+type IncompatibleContractError struct {
+	Server    ContractVersion
+	Supported ContractRange
+}
+
+func (e *IncompatibleContractError) Error() string {
+	return fmt.Sprintf("server contract version %s is not supported by this client (contract %s)",
+		e.Server, clientContractVersion)
+}
+
+// Is reports whether target is ErrIncompatibleContract.
+func (e *IncompatibleContractError) Is(target error) bool { return target == ErrIncompatibleContract }
+
+// HandshakeResult describes the control plane a client connected to.
+type HandshakeResult struct {
+	ServerName      string
+	ServerVersion   string
+	Environment     string
+	ContractVersion ContractVersion
+	Features        []string
+	// Latency is the round-trip time of the handshake call, retries
+	// included.
+	Latency time.Duration
+}
+
+// GetServiceMetadata fetches the name, version and contract version of the
+// control plane.
+func (c *ControlPlaneClient) GetServiceMetadata(ctx context.Context, opts ...RequestOption) (*ServiceMetadata, error) {
+	meta, _, err := c.getServiceMetadata(ctx, opts)
+	return meta, err
+}
+
+func (c *ControlPlaneClient) getServiceMetadata(ctx context.Context, opts []RequestOption) (*ServiceMetadata, http.Header, error) {
+	const path = "/v1/metadata"
+	resp, err := c.Request(ctx, http.MethodGet, path, nil, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer drainAndClose(resp.Body)
+	if err := checkStatus(resp); err != nil {
+		return nil, nil, err
+	}
+	var meta ServiceMetadata
+	if err := c.decode(resp.Body, &meta); err != nil {
+		return nil, nil, fmt.Errorf("decode GET %s response: %w", path, err)
+	}
+	return &meta, resp.Header, nil
+}
+
+// Handshake verifies that the control plane is reachable, accepts the
+// client's credentials and speaks a contract version within
+// ClientConfig.SupportedContracts, with a single GetServiceMetadata call.
+// It is meant to run at startup so a service refuses to boot against an
+// incompatible control plane. Rejected credentials fail with the *APIError
+// of the call; an unsupported contract fails with an
+// *IncompatibleContractError.
+func (c *ControlPlaneClient) Handshake(ctx context.Context, opts ...RequestOption) (*HandshakeResult, error) {
+	start := c.config.Clock.Now()
+	meta, header, err := c.getServiceMetadata(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("handshake: %w", err)
+	}
+	latency := c.config.Clock.Now().Sub(start)
+
+	server, err := serverContractVersion(meta, header)
+	if err != nil {
+		return nil, fmt.Errorf("handshake: %w", err)
+	}
+	supported := DefaultContractRange()
+	if c.config.SupportedContracts != nil {
+		supported = *c.config.SupportedContracts
+	}
+	ok, err := supported.Includes(server)
+	if err != nil {
+		return nil, fmt.Errorf("handshake: supported contracts: %w", err)
+	}
+	if !ok {
+		return nil, &IncompatibleContractError{Server: server, Supported: supported}
+	}
+
+	return &HandshakeResult{
+		ServerName:      meta.Name,
+		ServerVersion:   meta.Version,
+		Environment:     meta.Environment,
+		ContractVersion: server,
+		Features:        meta.Features,
+		Latency:         latency,
+	}, nil
+}
+
+// serverContractVersion returns the contract version the server reports in
+// its metadata, or else in the X-Contract-Version header.
+func serverContractVersion(meta *ServiceMetadata, header http.Header) (ContractVersion, error) {
+	raw := meta.ContractVersion
+	if raw == "" {
+		raw = header.Get("X-Contract-Version")
+	}
+	if raw == "" {
+		return ContractVersion{}, errors.New("server reported no contract version")
+	}
+	return ParseContractVersion(raw)
+}
+
+// NegotiateContractVersion asks the server for its contract version and
+// switches the client to the older of the server's and its own, so that
+// requests only use what both sides understand. The version sent in
+// X-Contract-Version and returned by GetContractVersion changes for every
+// request that starts afterwards; requests in flight are unaffected. A
+// server with a different major version fails with an
+// *IncompatibleContractError and leaves the client unchanged.
+func (c *ControlPlaneClient) NegotiateContractVersion(ctx context.Context, opts ...RequestOption) (ContractVersion, error) {
+	meta, header, err := c.getServiceMetadata(ctx, opts)
+	if err != nil {
+		return ContractVersion{}, fmt.Errorf("negotiate contract version: %w", err)
+	}
+	server, err := serverContractVersion(meta, header)
+	if err != nil {
+		return ContractVersion{}, fmt.Errorf("negotiate contract version: %w", err)
+	}
+	if server.Major != clientContractVersion.Major {
+		return ContractVersion{}, &IncompatibleContractError{Server: server, Supported: DefaultContractRange()}
+	}
+	negotiated := clientContractVersion
+	if server.Compare(negotiated) < 0 {
+		negotiated = server
+	}
+	c.contractVersion.Store(&negotiated)
+	return negotiated, nil
+}
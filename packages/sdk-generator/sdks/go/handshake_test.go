@@ -0,0 +1,104 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func metadataServer(t *testing.T, contract string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metadata" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":"UNAUTHORIZED","category":"AUTHENTICATION_ERROR","message":"bad key"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"name":"control-plane","version":"3.2.1","contractVersion":%q,"environment":"staging","startTime":"2026-01-01T00:00:00Z"}`, contract)
+	}))
+}
+
+func TestHandshake(t *testing.T) {
+	srv := metadataServer(t, "1.4.0")
+	defer srv.Close()
+	clock := &manualClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	slow := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			clock.now = clock.now.Add(40 * time.Millisecond)
+			return next(req)
+		}
+	}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "good", Clock: clock, Middlewares: []Middleware{slow}})
+
+	res, err := client.Handshake(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ServerName != "control-plane" || res.ServerVersion != "3.2.1" || res.Environment != "staging" {
+		t.Errorf("result = %+v", res)
+	}
+	if res.ContractVersion != (ContractVersion{Major: 1, Minor: 4}) || res.Latency != 40*time.Millisecond {
+		t.Errorf("contract = %v, latency = %v", res.ContractVersion, res.Latency)
+	}
+}
+
+func TestHandshakeRejectedKey(t *testing.T) {
+	srv := metadataServer(t, "1.0.0")
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "bad"})
+
+	_, err := client.Handshake(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestHandshakeIncompatibleContract(t *testing.T) {
+	srv := metadataServer(t, "2.0.0")
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "good"})
+
+	_, err := client.Handshake(context.Background())
+	var incompatible *IncompatibleContractError
+	if !errors.Is(err, ErrIncompatibleContract) || !errors.As(err, &incompatible) || incompatible.Server.Major != 2 {
+		t.Errorf("err = %v", err)
+	}
+
+	// A narrower range rejects a same-major server below its minimum.
+	narrow := ContractRange{Min: ContractVersion{Major: 2, Minor: 1}.toMap()}
+	client = mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "good", SupportedContracts: &narrow})
+	if _, err := client.Handshake(context.Background()); !errors.Is(err, ErrIncompatibleContract) {
+		t.Errorf("narrow range: err = %v", err)
+	}
+}
+
+func TestNegotiateContractVersion(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Header.Get("X-Contract-Version"))
+		fmt.Fprint(w, `{"name":"cp","version":"1","contractVersion":"1.0.0-rc.1","startTime":"2026-01-01T00:00:00Z"}`)
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	v, err := client.NegotiateContractVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "1.0.0-rc.1" || client.GetContractVersion() != v {
+		t.Errorf("negotiated = %v, client = %v", v, client.GetContractVersion())
+	}
+	if _, err := client.GetServiceMetadata(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if sent[0] != "1.0.0" || sent[1] != "1.0.0-rc.1" {
+		t.Errorf("X-Contract-Version = %q", sent)
+	}
+}
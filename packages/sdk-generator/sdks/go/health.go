@@ -0,0 +1,80 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// HealthSubCheck is a typed view of one entry in HealthCheck.Checks.
+type HealthSubCheck struct {
+	Name           string  `json:"name"`
+	Status         string  `json:"status"`
+	ResponseTimeMs float64 `json:"responseTimeMs"`
+	Message        string  `json:"message,omitempty"`
+}
+
+// SubChecks returns the typed component checks. Fields with an unexpected
+// type are left at their zero value.
+func (m HealthCheck) SubChecks() []HealthSubCheck {
+	checks := make([]HealthSubCheck, 0, len(m.Checks))
+	for _, raw := range m.Checks {
+		var sc HealthSubCheck
+		sc.Name, _ = raw["name"].(string)
+		sc.Status, _ = raw["status"].(string)
+		sc.ResponseTimeMs, _ = raw["responseTimeMs"].(float64)
+		sc.Message, _ = raw["message"].(string)
+		checks = append(checks, sc)
+	}
+	return checks
+}
+
+// GetHealth fetches the control plane health report.
+func (c *ControlPlaneClient) GetHealth(ctx context.Context, opts ...RequestOption) (*HealthCheck, error) {
+	var health HealthCheck
+	if err := c.call(ctx, http.MethodGet, "/health", nil, &health, opts...); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// WatchHealth polls GetHealth every interval, as measured by the client's
+// Clock, and emits the report whenever the overall status changes, starting
+// with the first successful poll. Failed polls are skipped, so a briefly
+// unavailable endpoint does not end the watch.
+//
+// The channel is closed when ctx is cancelled, the returned stop function
+// is called or the client is closed.
+func (c *ControlPlaneClient) WatchHealth(ctx context.Context, interval time.Duration) (<-chan HealthCheck, func()) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ch := make(chan HealthCheck, 1)
+	ctx, release, err := c.startBackground(ctx)
+	if err != nil {
+		close(ch)
+		return ch, func() {}
+	}
+	ctx, stop := context.WithCancel(ctx)
+
+	go func() {
+		defer release()
+		defer close(ch)
+
+		last := ""
+		for {
+			if health, err := c.GetHealth(ctx); err == nil && health.Status != last {
+				last = health.Status
+				select {
+				case ch <- *health:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if c.config.Clock.Sleep(ctx, interval) != nil {
+				return
+			}
+		}
+	}()
+	return ch, stop
+}
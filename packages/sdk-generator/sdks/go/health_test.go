@@ -0,0 +1,75 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetHealthSubChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"service":"controlplane","status":"healthy","version":"1.0.0","uptime":12,
+			"timestamp":"2026-01-01T00:00:00Z",
+			"checks":[{"name":"db","status":"healthy","responseTimeMs":3.5},{"name":"queue","status":"degraded","responseTimeMs":40,"message":"lagging"}]}`))
+	}))
+	defer srv.Close()
+
+	health, err := mustNewClient(t, ClientConfig{BaseURL: srv.URL}).GetHealth(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := health.SubChecks()
+	if len(checks) != 2 {
+		t.Fatalf("checks = %+v", checks)
+	}
+	want := HealthSubCheck{Name: "queue", Status: "degraded", ResponseTimeMs: 40, Message: "lagging"}
+	if checks[1] != want {
+		t.Errorf("checks[1] = %+v", checks[1])
+	}
+}
+
+func TestWatchHealthEmitsTransitions(t *testing.T) {
+	var mu sync.Mutex
+	statuses := []string{"healthy", "healthy", "", "degraded", "degraded", "healthy"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		status := "healthy"
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		if status == "" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(HealthCheck{Service: "cp", Status: status})
+	}))
+	defer srv.Close()
+
+	ch, stop := mustNewClient(t, ClientConfig{BaseURL: srv.URL}).WatchHealth(context.Background(), time.Millisecond)
+
+	var got []string
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case h := <-ch:
+			got = append(got, h.Status)
+		case <-timeout:
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	stop()
+
+	if got[0] != "healthy" || got[1] != "degraded" || got[2] != "healthy" {
+		t.Errorf("transitions = %v", got)
+	}
+	for range ch {
+	}
+}
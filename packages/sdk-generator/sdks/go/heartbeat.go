@@ -0,0 +1,166 @@
+package controlplane
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// StaleHeartbeatMultiple is how many heartbeat intervals may pass without a
+// heartbeat before a runner is considered stale. Allowing more than one
+// tolerates a late or dropped heartbeat.
+const StaleHeartbeatMultiple = 3
+
+// RunnerStatusUnhealthy is the status FilterStaleRunners gives stale runners.
+const RunnerStatusUnhealthy = "unhealthy"
+
+// IsStale reports whether more than StaleHeartbeatMultiple heartbeat
+// intervals have elapsed between the runner's last heartbeat and now. A
+// runner that has never sent a heartbeat is measured from RegisteredAt.
+func (m RunnerMetadata) IsStale(now time.Time, interval time.Duration) bool {
+	last := m.LastHeartbeatAt
+	if last.IsZero() {
+		last = m.RegisteredAt
+	}
+	return now.Sub(last) > StaleHeartbeatMultiple*interval
+}
+
+// FilterStaleRunners splits runners into those with a recent heartbeat and
+// stale ones, preserving order. The stale runners are copies with Status
+// set to unhealthy; the input is not modified.
+func FilterStaleRunners(runners []RunnerMetadata, now time.Time, interval time.Duration) (live, stale []RunnerMetadata) {
+	for _, r := range runners {
+		if r.IsStale(now, interval) {
+			r.Status = RunnerStatusUnhealthy
+			stale = append(stale, r)
+			continue
+		}
+		live = append(live, r)
+	}
+	return live, stale
+}
+
+// Keys of the standard runner metrics in RunnerHeartbeat.Metrics.
+const (
+	metricCPU           = "cpu"
+	metricMemory        = "memory"
+	metricJobsCompleted = "jobsCompleted"
+	metricAvgLatencyMs  = "avgLatencyMs"
+)
+
+// RunnerMetrics is the typed form of RunnerHeartbeat.Metrics.
+type RunnerMetrics struct {
+	// CPU is CPU utilization as a percentage of the runner's allocation.
+	CPU float64
+	// MemoryBytes is the memory in use, in bytes.
+	MemoryBytes int64
+	// JobsCompleted counts the jobs the runner has finished since it started.
+	JobsCompleted int64
+	// AvgLatencyMs is the mean job execution time in milliseconds.
+	AvgLatencyMs float64
+	// Extra holds custom metrics under any other key, kept as is.
+	Extra map[string]interface{}
+}
+
+// Validate rejects negative metrics.
+func (r RunnerMetrics) Validate() error {
+	var errs ValidationErrors
+	r.validate("", &errs)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+func (r RunnerMetrics) validate(prefix string, errs *ValidationErrors) {
+	if r.CPU < 0 {
+		errs.Add(prefix+metricCPU, "must not be negative")
+	}
+	if r.MemoryBytes < 0 {
+		errs.Add(prefix+metricMemory, "must not be negative")
+	}
+	if r.JobsCompleted < 0 {
+		errs.Add(prefix+metricJobsCompleted, "must not be negative")
+	}
+	if r.AvgLatencyMs < 0 {
+		errs.Add(prefix+metricAvgLatencyMs, "must not be negative")
+	}
+}
+
+func init() {
+	registerRule("RunnerHeartbeat", func(m RunnerHeartbeat, errs *ValidationErrors) {
+		if m.Metrics == nil {
+			return
+		}
+		r, err := m.MetricsTyped()
+		if err != nil {
+			errs.Add("metrics", err.Error())
+			return
+		}
+		r.validate("metrics.", errs)
+	})
+}
+
+// MetricsTyped decodes Metrics. Keys other than the standard metrics are
+// returned in Extra; missing metrics are zero.
+func (m RunnerHeartbeat) MetricsTyped() (RunnerMetrics, error) {
+	var r RunnerMetrics
+	for k, v := range m.Metrics {
+		switch k {
+		case metricCPU, metricAvgLatencyMs:
+			f, ok := toFloat(v)
+			if !ok {
+				return r, fmt.Errorf("%s: want a number, got %T", k, v)
+			}
+			if k == metricCPU {
+				r.CPU = f
+			} else {
+				r.AvgLatencyMs = f
+			}
+		case metricMemory, metricJobsCompleted:
+			f, ok := toFloat(v)
+			if !ok || f != math.Trunc(f) {
+				return r, fmt.Errorf("%s: want an integer, got %v", k, v)
+			}
+			if k == metricMemory {
+				r.MemoryBytes = int64(f)
+			} else {
+				r.JobsCompleted = int64(f)
+			}
+		default:
+			if r.Extra == nil {
+				r.Extra = map[string]interface{}{}
+			}
+			r.Extra[k] = v
+		}
+	}
+	return r, nil
+}
+
+// SetMetrics replaces Metrics with r. Zero standard metrics are omitted, and
+// Extra keys are stored alongside them, so SetMetrics(MetricsTyped())
+// preserves custom metrics. Extra keys that collide with a standard metric
+// are ignored.
+func (m *RunnerHeartbeat) SetMetrics(r RunnerMetrics) {
+	raw := make(map[string]interface{}, len(r.Extra)+4)
+	for k, v := range r.Extra {
+		raw[k] = v
+	}
+	delete(raw, metricCPU)
+	delete(raw, metricMemory)
+	delete(raw, metricJobsCompleted)
+	delete(raw, metricAvgLatencyMs)
+	if r.CPU != 0 {
+		raw[metricCPU] = r.CPU
+	}
+	if r.MemoryBytes != 0 {
+		raw[metricMemory] = float64(r.MemoryBytes)
+	}
+	if r.JobsCompleted != 0 {
+		raw[metricJobsCompleted] = float64(r.JobsCompleted)
+	}
+	if r.AvgLatencyMs != 0 {
+		raw[metricAvgLatencyMs] = r.AvgLatencyMs
+	}
+	m.Metrics = raw
+}
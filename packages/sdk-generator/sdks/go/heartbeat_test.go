@@ -0,0 +1,121 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunnerIsStaleBoundary(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	interval := 30 * time.Second
+	limit := StaleHeartbeatMultiple * interval
+
+	tests := []struct {
+		name  string
+		since time.Duration
+		stale bool
+	}{
+		{"fresh", 0, false},
+		{"one interval", interval, false},
+		{"at limit", limit, false},
+		{"just past limit", limit + time.Nanosecond, true},
+		{"long gone", time.Hour, true},
+	}
+	for _, tt := range tests {
+		r := RunnerMetadata{LastHeartbeatAt: now.Add(-tt.since)}
+		if got := r.IsStale(now, interval); got != tt.stale {
+			t.Errorf("%s: IsStale = %v", tt.name, got)
+		}
+	}
+
+	neverBeat := RunnerMetadata{RegisteredAt: now.Add(-time.Minute)}
+	if neverBeat.IsStale(now, interval) {
+		t.Error("newly registered runner reported stale")
+	}
+}
+
+func TestFilterStaleRunners(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	runners := []RunnerMetadata{
+		{Id: "a", Status: "healthy", LastHeartbeatAt: now.Add(-10 * time.Second)},
+		{Id: "b", Status: "healthy", LastHeartbeatAt: now.Add(-5 * time.Minute)},
+		{Id: "c", Status: "degraded", LastHeartbeatAt: now},
+	}
+	live, stale := FilterStaleRunners(runners, now, 30*time.Second)
+	if len(live) != 2 || live[0].Id != "a" || live[1].Id != "c" {
+		t.Errorf("live = %+v", live)
+	}
+	if len(stale) != 1 || stale[0].Id != "b" || stale[0].Status != RunnerStatusUnhealthy {
+		t.Errorf("stale = %+v", stale)
+	}
+	if runners[1].Status != "healthy" {
+		t.Error("input runner was modified")
+	}
+}
+
+func TestRunnerHeartbeatMetricsRoundTrip(t *testing.T) {
+	const wire = `{"runnerId":"r-1","timestamp":"2024-01-01T12:00:00Z","status":"healthy",
+		"metrics":{"cpu":42.5,"memory":536870912,"jobsCompleted":17,"avgLatencyMs":230.5,
+		"gpuTempC":61,"queue":{"depth":3}}}`
+	var hb RunnerHeartbeat
+	if err := json.Unmarshal([]byte(wire), &hb); err != nil {
+		t.Fatal(err)
+	}
+	original := hb.Metrics
+
+	m, err := hb.MetricsTyped()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RunnerMetrics{
+		CPU: 42.5, MemoryBytes: 536870912, JobsCompleted: 17, AvgLatencyMs: 230.5,
+		Extra: map[string]interface{}{"gpuTempC": float64(61), "queue": map[string]interface{}{"depth": float64(3)}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("MetricsTyped = %+v", m)
+	}
+
+	hb.SetMetrics(m)
+	if !reflect.DeepEqual(hb.Metrics, original) {
+		t.Errorf("round trip = %v, want %v", hb.Metrics, original)
+	}
+	if err := hb.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestRunnerHeartbeatSetMetricsFromGo(t *testing.T) {
+	var hb RunnerHeartbeat
+	hb.SetMetrics(RunnerMetrics{JobsCompleted: 3, Extra: map[string]interface{}{"region": "eu", "cpu": "ignored"}})
+	got, err := hb.MetricsTyped()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.JobsCompleted != 3 || got.CPU != 0 || !reflect.DeepEqual(got.Extra, map[string]interface{}{"region": "eu"}) {
+		t.Errorf("MetricsTyped = %+v", got)
+	}
+}
+
+func TestRunnerHeartbeatValidateRejectsBadMetrics(t *testing.T) {
+	hb := RunnerHeartbeat{RunnerId: "r-1", Status: "healthy"}
+	hb.SetMetrics(RunnerMetrics{JobsCompleted: -1, AvgLatencyMs: -5})
+	var verrs *ValidationErrors
+	if !errors.As(hb.Validate(), &verrs) {
+		t.Fatal("negative counters accepted")
+	}
+	got := map[string]bool{}
+	for _, e := range verrs.Errors {
+		got[e.Field] = true
+	}
+	if !got["metrics.jobsCompleted"] || !got["metrics.avgLatencyMs"] {
+		t.Errorf("errors = %v", verrs.Errors)
+	}
+
+	hb.Metrics = map[string]interface{}{"jobsCompleted": 1.5}
+	if err := hb.Validate(); err == nil {
+		t.Error("fractional counter accepted")
+	}
+}
@@ -0,0 +1,162 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHedgeMaxInFlight is how many hedged requests may be outstanding at
+// once when HedgePolicy.MaxInFlight is zero.
+const DefaultHedgeMaxInFlight = 10
+
+// HedgePolicy enables hedged reads: when a GET or HEAD attempt has not
+// answered within Delay, an identical request is sent and whichever
+// answers first is used, the other being cancelled. It trims tail latency
+// caused by a slow server node at the cost of some duplicate reads.
+// Mutating methods, request bodies read from a stream and streaming
+// responses are never hedged.
+type HedgePolicy struct {
+	// Delay is how long an attempt may go unanswered before it is hedged.
+	// It should be around the p95 latency of the reads being hedged.
+	Delay time.Duration
+	// MaxInFlight caps the hedged requests outstanding across the client;
+	// past it, slow attempts are simply waited for. Zero selects
+	// DefaultHedgeMaxInFlight.
+	MaxInFlight int
+}
+
+// HedgeMetricsCollector is implemented by a MetricsCollector that also
+// counts hedged requests. RequestHedged is called each time a hedge is sent;
+// the hedge's own attempt is reported through RequestStarted and
+// RequestFinished like any other.
+type HedgeMetricsCollector interface {
+	RequestHedged(method, route string)
+}
+
+// hedger holds a client's hedging settings and its outstanding hedges.
+type hedger struct {
+	delay    time.Duration
+	max      int64
+	inFlight atomic.Int64
+}
+
+func newHedger(p *HedgePolicy) *hedger {
+	if p == nil || p.Delay <= 0 {
+		return nil
+	}
+	max := int64(p.MaxInFlight)
+	if max <= 0 {
+		max = DefaultHedgeMaxInFlight
+	}
+	return &hedger{delay: p.Delay, max: max}
+}
+
+// acquire reserves a hedge, reporting false when MaxInFlight are out.
+func (h *hedger) acquire() bool {
+	if h.inFlight.Add(1) > h.max {
+		h.inFlight.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (h *hedger) release() { h.inFlight.Add(-1) }
+
+// hedgeable reports whether a request is an idempotent read whose attempts
+// may be duplicated.
+func (s *requestSpec) hedgeable() bool {
+	return !isMutating(s.method) && !s.streamed && s.maxBody >= 0
+}
+
+// sendHedged sends req like sendAttempt, sending an identical hedge if it
+// has not answered within the hedge delay. The first response wins, and the
+// other request is cancelled; once a hedge is out, a transport error only
+// ends the attempt when both requests have failed.
+func (c *ControlPlaneClient) sendHedged(req *http.Request, spec *requestSpec, attempt int) (*http.Response, error) {
+	ctx := req.Context()
+	type result struct {
+		resp *http.Response
+		err  error
+		i    int
+	}
+	results := make(chan result, 2)
+	var cancels []context.CancelFunc
+	launch := func(req *http.Request, hedge bool) {
+		i := len(cancels)
+		actx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		req = req.WithContext(actx)
+		go func() {
+			if hedge {
+				defer c.hedger.release()
+				if c.config.RateLimiter != nil {
+					if err := c.config.RateLimiter.Wait(actx); err != nil {
+						results <- result{err: err, i: i}
+						return
+					}
+				}
+			}
+			resp, err := c.sendAttempt(req, spec, attempt)
+			results <- result{resp, err, i}
+		}()
+	}
+	launch(req, false)
+
+	timerCtx, stopTimer := context.WithCancel(ctx)
+	defer stopTimer()
+	timer := make(chan struct{})
+	go func() {
+		if c.config.Clock.Sleep(timerCtx, c.hedger.delay) == nil {
+			close(timer)
+		}
+	}()
+
+	pending := 1
+	for {
+		select {
+		case <-timer:
+			timer = nil
+			if !c.hedger.acquire() {
+				continue
+			}
+			hreq, err := c.newRequest(ctx, spec)
+			if err != nil {
+				c.hedger.release()
+				continue
+			}
+			if m, ok := c.config.Metrics.(HedgeMetricsCollector); ok {
+				m.RequestHedged(spec.method, spec.route)
+			}
+			c.config.Logger.Debug("controlplane: hedging request",
+				"method", spec.method, "path", spec.path, "attempt", attempt, "delay", c.hedger.delay)
+			launch(hreq, true)
+			pending++
+		case r := <-results:
+			pending--
+			if r.err != nil && pending > 0 {
+				// The other request may yet succeed.
+				cancels[r.i]()
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != r.i {
+					cancel()
+				}
+			}
+			go func(n int) {
+				for ; n > 0; n-- {
+					if l := <-results; l.resp != nil {
+						l.resp.Body.Close()
+					}
+				}
+			}(pending)
+			if r.err != nil {
+				return nil, r.err
+			}
+			r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: cancels[r.i]}
+			return r.resp, nil
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package controlplane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowFirstServer answers its first request only when that request is
+// cancelled, or after 200ms, and every later one at once.
+func slowFirstServer(t *testing.T) (*httptest.Server, *atomic.Int32, chan struct{}) {
+	var calls atomic.Int32
+	cancelled := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+			io.WriteString(w, "slow")
+			return
+		}
+		io.WriteString(w, "fast")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, cancelled
+}
+
+func TestHedgedReadTakesFasterResponse(t *testing.T) {
+	srv, calls, cancelled := slowFirstServer(t)
+	metrics := &recordingMetrics{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		Hedge:   &HedgePolicy{Delay: 20 * time.Millisecond},
+		Metrics: metrics,
+	})
+
+	resp, err := client.Request(context.Background(), http.MethodGet, "/v1/jobs/j1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "fast" || calls.Load() != 2 {
+		t.Errorf("body = %q after %d calls", body, calls.Load())
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("slow request was not cancelled")
+	}
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.hedges) != 1 || metrics.hedges[0] != "GET /v1/jobs/{id}" {
+		t.Errorf("hedges = %v", metrics.hedges)
+	}
+}
+
+func TestHedgingSkipsMutatingMethods(t *testing.T) {
+	srv, calls, _ := slowFirstServer(t)
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		Hedge:   &HedgePolicy{Delay: time.Millisecond},
+	})
+	resp, err := client.Request(context.Background(), http.MethodPost, "/v1/jobs", map[string]string{"id": "j1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "slow" || calls.Load() != 1 {
+		t.Errorf("body = %q after %d calls", body, calls.Load())
+	}
+}
+
+func TestHedgingRespectsMaxInFlight(t *testing.T) {
+	srv, calls, _ := slowFirstServer(t)
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		Hedge:   &HedgePolicy{Delay: time.Millisecond, MaxInFlight: 1},
+	})
+	// Another request's hedge holds the only slot.
+	client.hedger.acquire()
+
+	resp, err := client.Request(context.Background(), http.MethodGet, "/v1/jobs/j1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want no hedge", calls.Load())
+	}
+}
+
+func TestHedgingOffByDefault(t *testing.T) {
+	if c := mustNewClient(t, ClientConfig{BaseURL: testBaseURL}); c.hedger != nil {
+		t.Error("hedging enabled without a HedgePolicy")
+	}
+}
@@ -0,0 +1,131 @@
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// RequestInfo describes a request attempt that is about to be sent.
+type RequestInfo struct {
+	Method string
+	Path   string
+	// Route is the route template of Path, such as "/v1/jobs/{id}".
+	Route   string
+	Attempt int
+	// Header is a copy of the outgoing headers; changing it has no effect.
+	// The hooks run outside ClientConfig.Middlewares, so it holds the
+	// client's default, credential and per-request headers but not those a
+	// middleware adds.
+	Header http.Header
+}
+
+// ResponseInfo describes the outcome of a request attempt.
+type ResponseInfo struct {
+	Method   string
+	Path     string
+	Route    string
+	Attempt  int
+	Duration time.Duration
+	// StatusCode is zero when no response was received; Err is set instead.
+	StatusCode int
+	// Envelope holds the decoded error envelope of a non-2xx response, if
+	// the body contained one.
+	Envelope *ErrorEnvelope
+	// ContractMismatch is set when the response's X-Contract-Version has a
+	// different major version than the client's. Under ContractCheckStrict
+	// it is also Err.
+	ContractMismatch *ContractVersionMismatchError
+	Err              error
+}
+
+// sendAttempt passes one attempt through the middleware chain, reporting it
+// to the OnRequest and OnResponse hooks.
+func (c *ControlPlaneClient) sendAttempt(req *http.Request, spec *requestSpec, attempt int) (*http.Response, error) {
+	ctx := req.Context()
+	log := c.config.Logger
+	log.Debug("controlplane: sending request",
+		"method", spec.method, "path", spec.path, "attempt", attempt, "headers", redactHeaders(req.Header))
+	if c.config.OnRequest != nil {
+		c.config.OnRequest(ctx, &RequestInfo{
+			Method:  spec.method,
+			Path:    spec.path,
+			Route:   spec.route,
+			Attempt: attempt,
+			Header:  req.Header.Clone(),
+		})
+	}
+
+	c.config.Metrics.RequestStarted(spec.method, spec.route)
+	start := c.config.Clock.Now()
+	resp, err := c.send(req)
+	elapsed := c.config.Clock.Now().Sub(start)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	c.config.Metrics.RequestFinished(spec.method, spec.route, status, elapsed)
+
+	var mismatch *ContractVersionMismatchError
+	if err != nil {
+		log.Warn("controlplane: request failed",
+			"method", spec.method, "path", spec.path, "attempt", attempt, "duration", elapsed, "error", err)
+	} else {
+		log.Debug("controlplane: received response",
+			"method", spec.method, "path", spec.path, "attempt", attempt, "duration", elapsed, "status", resp.StatusCode)
+		mismatch = c.checkContractVersion(resp, spec)
+		if mismatch != nil && c.config.ContractCheck == ContractCheckStrict {
+			drainAndClose(resp.Body)
+			resp, err = nil, mismatch
+		}
+	}
+
+	if c.config.OnResponse != nil {
+		info := &ResponseInfo{
+			Method:   spec.method,
+			Path:     spec.path,
+			Route:    spec.route,
+			Attempt:  attempt,
+			Duration: elapsed,
+			// A response refused under ContractCheckStrict keeps its
+			// status.
+			StatusCode:       status,
+			ContractMismatch: mismatch,
+			Err:              err,
+		}
+		if resp != nil {
+			if resp.StatusCode >= 300 {
+				info.Envelope = peekErrorEnvelope(resp)
+			}
+		}
+		c.config.OnResponse(ctx, info)
+	}
+	return resp, err
+}
+
+// peekErrorEnvelope decodes an error envelope from the response body without
+// consuming it: the body is buffered and replaced with an equivalent reader.
+// A read error, such as exceeding MaxResponseBytes, is replayed after the
+// buffered data.
+func peekErrorEnvelope(resp *http.Response) *ErrorEnvelope {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		resp.Body = errReader(data, err)
+		return nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return parseErrorEnvelope(data)
+}
+
+// parseErrorEnvelope decodes data as an error envelope, returning nil when it
+// is not one.
+func parseErrorEnvelope(data []byte) *ErrorEnvelope {
+	var env ErrorEnvelope
+	if json.Unmarshal(data, &env) != nil || (env.Code == "" && env.Category == "") {
+		return nil
+	}
+	return &env
+}
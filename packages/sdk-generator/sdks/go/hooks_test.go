@@ -0,0 +1,83 @@
+package controlplane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHooksReportEachAttempt(t *testing.T) {
+	const envelope = `{"id":"e1","category":"RATE_LIMITED","severity":"warning","code":"SLOW_DOWN","message":"m","service":"api"}`
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Error("OnRequest mutated the outgoing request")
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(envelope))
+			return
+		}
+		w.Write([]byte(`ok`))
+	}))
+	defer srv.Close()
+
+	var requests []*RequestInfo
+	var responses []*ResponseInfo
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		APIKey:  "key",
+		Retry:   &RetryPolicy{MaxRetries: Int(1)},
+		OnRequest: func(ctx context.Context, info *RequestInfo) {
+			info.Header.Set("Authorization", "tampered")
+			requests = append(requests, info)
+		},
+		OnResponse: func(ctx context.Context, info *ResponseInfo) {
+			responses = append(responses, info)
+		},
+	})
+
+	resp, err := client.Request(context.Background(), http.MethodGet, "/v1/jobs/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("body = %q", body)
+	}
+
+	if len(requests) != 2 || len(responses) != 2 {
+		t.Fatalf("hooks called %d/%d times", len(requests), len(responses))
+	}
+	if requests[1].Attempt != 2 || requests[1].Path != "/v1/jobs/1" {
+		t.Errorf("request info = %+v", requests[1])
+	}
+	first := responses[0]
+	if first.StatusCode != http.StatusTooManyRequests || first.Envelope == nil || first.Envelope.Code != "SLOW_DOWN" {
+		t.Errorf("first response info = %+v", first)
+	}
+	if responses[1].StatusCode != http.StatusOK || responses[1].Envelope != nil {
+		t.Errorf("second response info = %+v", responses[1])
+	}
+}
+
+func TestOnResponseCalledOnTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	var got *ResponseInfo
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:    srv.URL,
+		OnResponse: func(ctx context.Context, info *ResponseInfo) { got = info },
+	})
+	if _, err := client.Request(context.Background(), http.MethodGet, "/health", nil); err == nil {
+		t.Fatal("expected transport error")
+	}
+	if got == nil || got.Err == nil || got.StatusCode != 0 {
+		t.Errorf("response info = %+v", got)
+	}
+}
@@ -0,0 +1,217 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Installation source types of an InstallationSpec.
+const (
+	InstallSourceOCI = "oci"
+	InstallSourceGit = "git"
+	InstallSourceNPM = "npm"
+)
+
+var installSourceValues = []string{InstallSourceOCI, InstallSourceGit, InstallSourceNPM}
+
+// legacyInstallSources maps the keys of the contract's installation object
+// to source types, in order of preference.
+var legacyInstallSources = []struct{ key, source string }{
+	{"docker", InstallSourceOCI},
+	{"source", InstallSourceGit},
+	{"npm", InstallSourceNPM},
+}
+
+var installChecksumPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// InstallationSpec is the typed form of the installation field of a
+// marketplace runner: where to fetch the runner and what it needs to run.
+type InstallationSpec struct {
+	// Type is InstallSourceOCI, InstallSourceGit or InstallSourceNPM.
+	Type string `json:"type"`
+	// Reference is the image, repository URL or package name to install.
+	Reference string `json:"reference"`
+	// Checksum, when set, is the "sha256:<hex>" digest of the artifact.
+	Checksum string `json:"checksum,omitempty"`
+	// RequiredEnv names the environment variables the runner needs.
+	RequiredEnv []string `json:"requiredEnv,omitempty"`
+	// Version is the runner version the spec installs. ResolveInstall sets
+	// it; it is empty for a spec read from the runner itself.
+	Version string `json:"version,omitempty"`
+}
+
+// Validate checks the spec, reporting errors under "installation.".
+func (s InstallationSpec) Validate() error {
+	var errs ValidationErrors
+	if s.Type == "" {
+		errs.Add("installation.type", "is required")
+	}
+	checkEnum("installation.type", s.Type, installSourceValues, &errs)
+	if s.Reference == "" {
+		errs.Add("installation.reference", "is required")
+	}
+	if s.Checksum != "" && !installChecksumPattern.MatchString(s.Checksum) {
+		errs.Add("installation.checksum", `must be "sha256:" followed by 64 lowercase hex digits`)
+	}
+	for i, name := range s.RequiredEnv {
+		if name == "" {
+			errs.Add(fmt.Sprintf("installation.requiredEnv[%d]", i), "must not be empty")
+		}
+	}
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// InstallationTyped decodes and validates the runner's installation field.
+// Besides the InstallationSpec form, it accepts the contract's form of
+// docker, source and npm references, read as oci, git and npm sources in
+// that order of preference.
+func (m MarketplaceRunner) InstallationTyped() (InstallationSpec, error) {
+	return decodeInstallation(m.Installation)
+}
+
+func decodeInstallation(raw map[string]interface{}) (InstallationSpec, error) {
+	var spec InstallationSpec
+	if len(raw) == 0 {
+		return spec, fmt.Errorf("installation is not set")
+	}
+	if _, ok := raw["type"]; ok {
+		if err := decodeMap(raw, &spec); err != nil {
+			return spec, fmt.Errorf("decode installation: %w", err)
+		}
+		return spec, spec.Validate()
+	}
+	for _, legacy := range legacyInstallSources {
+		if ref, ok := raw[legacy.key].(string); ok && ref != "" {
+			spec = InstallationSpec{Type: legacy.source, Reference: ref}
+			return spec, spec.Validate()
+		}
+	}
+	return spec, fmt.Errorf("installation has no oci, git or npm source")
+}
+
+// ErrVersionYanked is matched by errors.Is when ResolveInstall is asked for
+// a version its publisher has yanked.
+var ErrVersionYanked = errors.New("runner version is yanked")
+
+// ErrVersionNotFound is matched by errors.Is when ResolveInstall is asked
+// for a version missing from the runner's version history.
+var ErrVersionNotFound = errors.New("runner version not found")
+
+// InstallError reports a runner version ResolveInstall will not install.
+// Err is ErrVersionNotFound, ErrVersionYanked or an error matching
+// ErrIncompatibleContract.
+type InstallError struct {
+	RunnerID string
+	Version  string
+	Err      error
+}
+
+func (e *InstallError) Error() string {
+	return fmt.Sprintf("install runner %s@%s: %v", e.RunnerID, e.Version, e.Err)
+}
+
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// compatibilityInfo is the part of a marketplace runner's compatibility
+// field ResolveInstall checks.
+type compatibilityInfo struct {
+	MinContractVersion *ContractVersion `json:"minContractVersion"`
+	MaxContractVersion *ContractVersion `json:"maxContractVersion"`
+	IncompatibleWith   []string         `json:"incompatibleWith"`
+}
+
+// checkCompatible reports whether the client's contract version is within
+// the runner's compatibility bounds. A runner without them is compatible.
+func (m MarketplaceRunner) checkCompatible() error {
+	if m.Compatibility == nil {
+		return nil
+	}
+	var info compatibilityInfo
+	if err := decodeMap(m.Compatibility, &info); err != nil {
+		return fmt.Errorf("decode compatibility: %w", err)
+	}
+	if info.MinContractVersion != nil && clientContractVersion.Compare(*info.MinContractVersion) < 0 {
+		return fmt.Errorf("%w: requires contract %s or later, client has %s",
+			ErrIncompatibleContract, info.MinContractVersion, clientContractVersion)
+	}
+	if info.MaxContractVersion != nil && clientContractVersion.Compare(*info.MaxContractVersion) > 0 {
+		return fmt.Errorf("%w: requires contract %s or earlier, client has %s",
+			ErrIncompatibleContract, info.MaxContractVersion, clientContractVersion)
+	}
+	for _, s := range info.IncompatibleWith {
+		if v, err := ParseContractVersion(s); err == nil && v.Compare(clientContractVersion) == 0 {
+			return fmt.Errorf("%w: marked incompatible with contract %s", ErrIncompatibleContract, clientContractVersion)
+		}
+	}
+	return nil
+}
+
+// ResolveInstall fetches a marketplace runner and returns how to install
+// version, or its latest version that has not been yanked when version is
+// empty. A version entry in the history may carry its own installation,
+// which takes precedence over the runner's. A version that is missing,
+// yanked or incompatible with the client's contract version fails with an
+// *InstallError.
+func (c *ControlPlaneClient) ResolveInstall(ctx context.Context, runnerID, version string, opts ...RequestOption) (InstallationSpec, error) {
+	runner, err := c.GetMarketplaceRunner(ctx, runnerID, opts...)
+	if err != nil {
+		return InstallationSpec{}, err
+	}
+	entry, installation, err := runner.findVersion(version)
+	if err != nil {
+		return InstallationSpec{}, err
+	}
+	if entry == nil {
+		return InstallationSpec{}, &InstallError{RunnerID: runnerID, Version: version, Err: ErrVersionNotFound}
+	}
+	if entry.Yanked {
+		return InstallationSpec{}, &InstallError{RunnerID: runnerID, Version: entry.Version, Err: ErrVersionYanked}
+	}
+	if err := runner.checkCompatible(); err != nil {
+		return InstallationSpec{}, &InstallError{RunnerID: runnerID, Version: entry.Version, Err: err}
+	}
+	if installation == nil {
+		installation = runner.Installation
+	}
+	spec, err := decodeInstallation(installation)
+	if err != nil {
+		return InstallationSpec{}, fmt.Errorf("marketplace runner %s@%s: %w", runnerID, entry.Version, err)
+	}
+	spec.Version = entry.Version
+	return spec, nil
+}
+
+// findVersion returns the history entry for version, or the latest one that
+// has not been yanked when version is empty, with the entry's own
+// installation field. It returns a nil entry when there is no match.
+func (m MarketplaceRunner) findVersion(version string) (*VersionHistoryEntry, map[string]interface{}, error) {
+	if version == "" {
+		latest, err := m.LatestVersion()
+		if err != nil || latest == nil {
+			return nil, nil, err
+		}
+		version = latest.Version
+	}
+	want, err := ParseContractVersion(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, raw := range m.VersionHistory {
+		var e VersionHistoryEntry
+		if err := decodeMap(raw, &e); err != nil {
+			return nil, nil, fmt.Errorf("versionHistory[%d]: %w", i, err)
+		}
+		if v, err := e.ParsedVersion(); err == nil && v.Compare(want) == 0 {
+			installation, _ := raw["installation"].(map[string]interface{})
+			return &e, installation, nil
+		}
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,115 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDigest = "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// installRunner serves csv-runner with the given installation and history.
+func installRunner(t *testing.T, runner map[string]interface{}) *ControlPlaneClient {
+	runner["id"] = "csv-runner"
+	return mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/marketplace/runners/csv-runner" {
+				t.Errorf("path = %s", r.URL.Path)
+			}
+			json.NewEncoder(w).Encode(runner)
+		})),
+	})
+}
+
+func TestInstallationTyped(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]interface{}
+		want InstallationSpec
+	}{
+		{"oci", map[string]interface{}{
+			"type": "oci", "reference": "ghcr.io/acme/csv-runner:1.4.0", "checksum": testDigest,
+			"requiredEnv": []interface{}{"CSV_BUCKET"},
+		}, InstallationSpec{Type: InstallSourceOCI, Reference: "ghcr.io/acme/csv-runner:1.4.0", Checksum: testDigest, RequiredEnv: []string{"CSV_BUCKET"}}},
+		{"git", map[string]interface{}{"type": "git", "reference": "https://github.com/acme/csv-runner"},
+			InstallationSpec{Type: InstallSourceGit, Reference: "https://github.com/acme/csv-runner"}},
+		{"contract docker", map[string]interface{}{"docker": "acme/csv-runner", "npm": "@acme/csv-runner"},
+			InstallationSpec{Type: InstallSourceOCI, Reference: "acme/csv-runner"}},
+		{"contract source", map[string]interface{}{"source": "https://github.com/acme/csv-runner"},
+			InstallationSpec{Type: InstallSourceGit, Reference: "https://github.com/acme/csv-runner"}},
+	}
+	for _, tt := range tests {
+		got, err := MarketplaceRunner{Installation: tt.raw}.InstallationTyped()
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestInstallationTypedInvalid(t *testing.T) {
+	_, err := MarketplaceRunner{Installation: map[string]interface{}{
+		"type": "zip", "checksum": "md5:abc", "requiredEnv": []interface{}{""},
+	}}.InstallationTyped()
+	assertFields(t, err, "installation.type", "installation.reference", "installation.checksum", "installation.requiredEnv[0]")
+
+	for _, raw := range []map[string]interface{}{nil, {"binary": "https://example.com/csv-runner"}} {
+		if _, err := (MarketplaceRunner{Installation: raw}).InstallationTyped(); err == nil {
+			t.Errorf("%v: no error", raw)
+		}
+	}
+}
+
+func TestResolveInstall(t *testing.T) {
+	client := installRunner(t, map[string]interface{}{
+		"installation": map[string]interface{}{"type": "oci", "reference": "ghcr.io/acme/csv-runner:latest"},
+		"versionHistory": []interface{}{
+			map[string]interface{}{"version": "1.3.0", "publishedAt": "2024-01-01T00:00:00Z"},
+			map[string]interface{}{"version": "1.4.0", "publishedAt": "2024-02-01T00:00:00Z", "installation": map[string]interface{}{
+				"type": "git", "reference": "https://github.com/acme/csv-runner", "checksum": testDigest,
+			}},
+			map[string]interface{}{"version": "1.5.0", "publishedAt": "2024-03-01T00:00:00Z", "yanked": true},
+		},
+		"compatibility": map[string]interface{}{"minContractVersion": "1.0.0", "maxContractVersion": "1.9.0"},
+	})
+
+	spec, err := client.ResolveInstall(context.Background(), "csv-runner", "1.3.0")
+	if err != nil || spec.Type != InstallSourceOCI || spec.Version != "1.3.0" {
+		t.Errorf("1.3.0: %+v, %v", spec, err)
+	}
+	spec, err = client.Marketplace().ResolveInstall(context.Background(), "csv-runner", "")
+	if err != nil || spec.Type != InstallSourceGit || spec.Checksum != testDigest || spec.Version != "1.4.0" {
+		t.Errorf("latest: %+v, %v", spec, err)
+	}
+
+	_, err = client.ResolveInstall(context.Background(), "csv-runner", "1.5.0")
+	var installErr *InstallError
+	if !errors.Is(err, ErrVersionYanked) || !errors.As(err, &installErr) || installErr.Version != "1.5.0" {
+		t.Errorf("yanked: %v", err)
+	}
+	if _, err := client.ResolveInstall(context.Background(), "csv-runner", "2.0.0"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("missing: %v", err)
+	}
+}
+
+func TestResolveInstallIncompatible(t *testing.T) {
+	for _, compat := range []map[string]interface{}{
+		{"minContractVersion": "2.0.0"},
+		{"minContractVersion": "0.1.0", "maxContractVersion": "0.9.0"},
+		{"minContractVersion": "0.1.0", "incompatibleWith": []interface{}{clientContractVersion.String()}},
+	} {
+		client := installRunner(t, map[string]interface{}{
+			"installation":   map[string]interface{}{"npm": "@acme/csv-runner"},
+			"versionHistory": []interface{}{map[string]interface{}{"version": "1.0.0", "publishedAt": "2024-01-01T00:00:00Z"}},
+			"compatibility":  compat,
+		})
+		_, err := client.ResolveInstall(context.Background(), "csv-runner", "1.0.0")
+		if !errors.Is(err, ErrIncompatibleContract) || !strings.Contains(err.Error(), "csv-runner@1.0.0") {
+			t.Errorf("%v: err = %v", compat, err)
+		}
+	}
+}
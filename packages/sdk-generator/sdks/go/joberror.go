@@ -0,0 +1,57 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AsError returns the failure of an unsuccessful result, or nil when
+// Success is set. An Error holding an error envelope is returned as an
+// *APIError with a zero StatusCode, so CategoryOf and the Is helpers work on
+// it; any other shape yields a plain error carrying its message, if any.
+func (m JobResult) AsError() error {
+	if m.Success {
+		return nil
+	}
+	return jobError(m.Error)
+}
+
+// AsError returns the failure of a failed job: its Error, or the error of
+// its Result. It returns nil for jobs that have not failed.
+func (m JobResponse) AsError() error {
+	if m.Error != nil {
+		return jobError(m.Error)
+	}
+	if m.Result != nil {
+		var result JobResult
+		if err := decodeMap(m.Result, &result); err != nil {
+			return fmt.Errorf("job %s: decode result: %w", m.Id, err)
+		}
+		if err := result.AsError(); err != nil {
+			return err
+		}
+	}
+	if m.Status == JobStatusFAILED {
+		return fmt.Errorf("job %s failed", m.Id)
+	}
+	return nil
+}
+
+// jobError converts the error map of a job into an error.
+func jobError(raw map[string]interface{}) error {
+	if raw == nil {
+		return errors.New("job failed")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("job failed: encode error: %w", err)
+	}
+	if env := parseErrorEnvelope(data); env != nil {
+		return &APIError{Envelope: *env, Raw: data}
+	}
+	if msg, _ := raw["message"].(string); msg != "" {
+		return errors.New("job failed: " + msg)
+	}
+	return fmt.Errorf("job failed: %s", data)
+}
@@ -0,0 +1,57 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJobResultAsErrorEnvelope(t *testing.T) {
+	result := JobResult{Error: map[string]interface{}{
+		"id": "e1", "category": ErrorCategoryTIMEOUT, "severity": "warning",
+		"code": "RUNNER_TIMEOUT", "message": "runner did not answer", "service": "scheduler",
+	}}
+	err := result.AsError()
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.Envelope.Code != "RUNNER_TIMEOUT" || apiErr.StatusCode != 0 {
+		t.Fatalf("err = %#v", err)
+	}
+	if !IsTimeout(err) || err.Error() != "RUNNER_TIMEOUT: runner did not answer" {
+		t.Errorf("err = %v, category = %s", err, CategoryOf(err))
+	}
+}
+
+func TestJobResultAsErrorPartialMap(t *testing.T) {
+	err := JobResult{Error: map[string]interface{}{"message": "disk full"}}.AsError()
+	if _, ok := AsAPIError(err); ok || err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("err = %v", err)
+	}
+	if err := (JobResult{}).AsError(); err == nil {
+		t.Error("unsuccessful result without error returned nil")
+	}
+}
+
+func TestJobResultAsErrorSuccess(t *testing.T) {
+	if err := (JobResult{Success: true, Data: "ok"}).AsError(); err != nil {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestJobResponseAsError(t *testing.T) {
+	done := JobResponse{Id: "j1", Status: JobStatusCOMPLETED, Result: map[string]interface{}{"success": true}}
+	if err := done.AsError(); err != nil {
+		t.Errorf("completed: %v", err)
+	}
+
+	failed := JobResponse{Id: "j2", Status: JobStatusFAILED, Result: map[string]interface{}{
+		"success": false,
+		"error":   map[string]interface{}{"code": "BAD_INPUT", "category": ErrorCategoryVALIDATION_ERROR, "message": "no rows"},
+	}}
+	if err := failed.AsError(); !IsValidation(err) {
+		t.Errorf("failed result: %v", err)
+	}
+
+	bare := JobResponse{Id: "j3", Status: JobStatusFAILED}
+	if err := bare.AsError(); err == nil || !strings.Contains(err.Error(), "j3") {
+		t.Errorf("bare failure: %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultTimeoutMargin is how much of the context's remaining time is kept
+// back when a TimeoutMs field is derived from its deadline.
+const DefaultTimeoutMargin = 250 * time.Millisecond
+
+// SubmitJob submits a job to the control plane. When job.TimeoutMs is set it
+// bounds the call unless WithTimeout is passed; when it is unset and ctx has a
+// deadline, it is derived from the deadline (see WithoutTimeoutDerivation).
+// job.Id is sent as the idempotency key unless WithIdempotencyKey is passed,
+// so resubmitting the same job returns the existing one. A payload larger
+// than ClientConfig.MaxPayloadBytes is rejected without a request. The
+// request's tenant is added to job.Metadata as "tenantId" unless it is
+// already set.
+func (c *ControlPlaneClient) SubmitJob(ctx context.Context, job JobRequest, opts ...RequestOption) (*JobResponse, error) {
+	if err := c.checkPayload(job); err != nil {
+		return nil, err
+	}
+	job.Metadata = stampTenant(job.Metadata, c.tenant(ctx))
+	var defaults []RequestOption
+	if ms := FloatValue(job.TimeoutMs); ms > 0 {
+		defaults = append(defaults, WithTimeout(msDuration(ms)))
+	} else {
+		job.TimeoutMs = c.deriveTimeoutMs(ctx, opts)
+	}
+	if job.Id != "" {
+		defaults = append(defaults, WithIdempotencyKey(job.Id))
+	}
+	opts = append(defaults, opts...)
+	var resp JobResponse
+	if err := c.call(ctx, http.MethodPost, "/v1/jobs", job, &resp, opts...); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetJob fetches the current state of a job.
+func (c *ControlPlaneClient) GetJob(ctx context.Context, id string, opts ...RequestOption) (*JobResponse, error) {
+	var resp JobResponse
+	if err := c.call(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(id), nil, &resp, opts...); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ErrNotModified is returned by GetJobWait when the job did not change
+// during the wait.
+var ErrNotModified = errors.New("job not modified")
+
+// longPollMargin is added to a long poll's wait to bound the call, leaving
+// the server time to answer once the wait elapses.
+const longPollMargin = 5 * time.Second
+
+// GetJobIfModified fetches a job unless it still matches etag, the ETag of a
+// previous response. The request carries If-None-Match when etag is set; a
+// 304 answer returns changed false with a nil job and the etag unchanged,
+// without decoding a body. Otherwise it returns the job, the ETag the server
+// sent with it and changed true.
+func (c *ControlPlaneClient) GetJobIfModified(ctx context.Context, id, etag string, opts ...RequestOption) (*JobResponse, string, bool, error) {
+	if etag != "" {
+		opts = append([]RequestOption{WithHeader("If-None-Match", etag)}, opts...)
+	}
+	resp, err := c.Request(ctx, http.MethodGet, "/v1/jobs/"+url.PathEscape(id), nil, opts...)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer drainAndClose(resp.Body)
+	if resp.StatusCode == http.StatusNotModified {
+		if tag := resp.Header.Get("ETag"); tag != "" {
+			etag = tag
+		}
+		return nil, etag, false, nil
+	}
+	if err := checkStatus(resp); err != nil {
+		return nil, "", false, err
+	}
+	var job JobResponse
+	if err := c.decode(resp.Body, &job); err != nil {
+		return nil, "", false, fmt.Errorf("decode GET /v1/jobs/%s response: %w", id, err)
+	}
+	return &job, resp.Header.Get("ETag"), true, nil
+}
+
+// GetJobWait long-polls a job: the server holds the request until the job's
+// status changes or waitFor elapses, in which case ErrNotModified is
+// returned. The call is bounded by waitFor plus a margin rather than
+// ClientConfig.Timeout; a WithTimeout option or a sooner deadline on ctx
+// still applies.
+func (c *ControlPlaneClient) GetJobWait(ctx context.Context, jobID string, waitFor time.Duration, opts ...RequestOption) (*JobResponse, error) {
+	job, _, changed, err := c.getJobWait(ctx, jobID, "", waitFor, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrNotModified
+	}
+	return job, nil
+}
+
+// getJobWait is GetJobWait with a conditional request, as GetJobIfModified.
+func (c *ControlPlaneClient) getJobWait(ctx context.Context, jobID, etag string, waitFor time.Duration, opts []RequestOption) (*JobResponse, string, bool, error) {
+	opts = append([]RequestOption{
+		WithTimeout(waitFor + longPollMargin),
+		WithQueryParam("wait", strconv.FormatInt(waitFor.Milliseconds(), 10)),
+	}, opts...)
+	return c.GetJobIfModified(ctx, jobID, etag, opts...)
+}
+
+// ExecuteJob asks a runner to execute a job. An unset req.TimeoutMs is derived
+// from the deadline of ctx, as in SubmitJob. WithCapabilitySchemas and
+// WithOutputValidation check the payload and response data against the
+// capability's schemas.
+func (c *ControlPlaneClient) ExecuteJob(ctx context.Context, runnerID string, req RunnerExecutionRequest, opts ...RequestOption) (*RunnerExecutionResponse, error) {
+	if FloatValue(req.TimeoutMs) <= 0 {
+		req.TimeoutMs = c.deriveTimeoutMs(ctx, opts)
+	}
+	o := c.applyOptions(opts)
+	if o.inputSchema != nil {
+		if err := c.schemas.validateInput(*o.inputSchema, req.Payload); err != nil {
+			return nil, fmt.Errorf("execute %s: payload: %w", o.inputSchema.Id, err)
+		}
+	}
+	var resp RunnerExecutionResponse
+	if err := c.call(ctx, http.MethodPost, "/v1/runners/"+url.PathEscape(runnerID)+"/execute", req, &resp, opts...); err != nil {
+		return nil, err
+	}
+	if o.outputSchema != nil {
+		if err := c.schemas.validateResponse(*o.outputSchema, resp); err != nil {
+			return nil, err
+		}
+	}
+	return &resp, nil
+}
+
+// deriveTimeoutMs returns the time left before the deadline of ctx by the
+// client's Clock, less ClientConfig.TimeoutMargin, in milliseconds. It returns nil, leaving the
+// server default in place, when ctx has no deadline, derivation is disabled
+// or no time would be left.
+func (c *ControlPlaneClient) deriveTimeoutMs(ctx context.Context, opts []RequestOption) *float64 {
+	deadline, ok := ctx.Deadline()
+	if !ok || c.applyOptions(opts).noTimeoutDerivation {
+		return nil
+	}
+	remaining := deadline.Sub(c.config.Clock.Now()) - c.config.TimeoutMargin
+	if remaining < time.Millisecond {
+		return nil
+	}
+	return Float(float64(remaining / time.Millisecond))
+}
+
+// msDuration converts a contract millisecond field to a time.Duration.
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
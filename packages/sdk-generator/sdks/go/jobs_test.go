@@ -0,0 +1,222 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSubmitJobIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"id":"job-1","status":"pending"}`))
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Retry: &RetryPolicy{MaxRetries: Int(1)}})
+	if _, err := client.SubmitJob(context.Background(), JobRequest{Id: "job-1", Type: "noop"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "job-1" || keys[1] != "job-1" {
+		t.Errorf("keys = %v", keys)
+	}
+
+	keys = nil
+	if _, err := client.SubmitJob(context.Background(), JobRequest{Id: "job-1"}, WithIdempotencyKey("custom")); err != nil {
+		t.Fatal(err)
+	}
+	if keys[0] != "custom" {
+		t.Errorf("explicit key not used: %v", keys)
+	}
+}
+
+func TestSubmitJobConflictReturnsOriginal(t *testing.T) {
+	body := `{"id":"job-1","status":"running"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	job, err := client.SubmitJob(context.Background(), JobRequest{Id: "job-1", Type: "noop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != "running" {
+		t.Errorf("job = %+v", job)
+	}
+
+	body = `{"id":"e1","category":"CONFLICT","severity":"error","code":"IDEMPOTENCY_MISMATCH","message":"m","service":"api"}`
+	if _, err := client.SubmitJob(context.Background(), JobRequest{Id: "job-1", Type: "noop"}); err == nil {
+		t.Error("error envelope treated as replay")
+	}
+}
+
+func TestSubmitJobDerivesTimeoutFromDeadline(t *testing.T) {
+	var timeouts []float64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TimeoutMs float64 `json:"timeoutMs"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		timeouts = append(timeouts, body.TimeoutMs)
+		w.Write([]byte(`{"id":"job-1","status":"pending","jobId":"job-1"}`))
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, TimeoutMargin: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	job := JobRequest{Id: "job-1", Type: "noop"}
+	if _, err := client.SubmitJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ExecuteJob(ctx, "runner-1", RunnerExecutionRequest{JobId: "job-1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range timeouts {
+		if ms <= 8000 || ms > 9000 {
+			t.Errorf("derived timeoutMs = %v, want about 9000", ms)
+		}
+	}
+
+	timeouts = nil
+	if _, err := client.SubmitJob(ctx, job, WithoutTimeoutDerivation()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SubmitJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	job.TimeoutMs = Float(500)
+	if _, err := client.SubmitJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if timeouts[0] != 0 || timeouts[1] != 0 || timeouts[2] != 500 {
+		t.Errorf("timeouts = %v, want [0 0 500]", timeouts)
+	}
+}
+
+func TestDerivedTimeoutReadsClientClock(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{BaseURL: testBaseURL, TimeoutMargin: time.Second, Clock: clock})
+	ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(10*time.Second))
+	defer cancel()
+	if got := FloatValue(client.deriveTimeoutMs(ctx, nil)); got != 9000 {
+		t.Errorf("derived timeoutMs = %v, want 9000", got)
+	}
+}
+
+func TestGetJobWait(t *testing.T) {
+	var waits []string
+	var deadlines []time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		waits = append(waits, r.URL.Query().Get("wait"))
+		if len(waits) == 1 {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"id":"job-1","status":"completed"}`))
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		Timeout: time.Second,
+		Middlewares: []Middleware{func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				if d, ok := req.Context().Deadline(); ok {
+					deadlines = append(deadlines, time.Until(d))
+				}
+				return next(req)
+			}
+		}},
+	})
+
+	if _, err := client.GetJobWait(context.Background(), "job-1", 30*time.Second); err != ErrNotModified {
+		t.Fatalf("err = %v, want ErrNotModified", err)
+	}
+	job, err := client.GetJobWait(context.Background(), "job-1", 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobStatusCOMPLETED {
+		t.Errorf("job = %+v", job)
+	}
+	if waits[0] != "30000" {
+		t.Errorf("wait = %q", waits[0])
+	}
+	if len(deadlines) != 2 || deadlines[0] < 30*time.Second {
+		t.Errorf("deadlines = %v, want past the wait despite the 1s client timeout", deadlines)
+	}
+}
+
+func TestGetJobIfModified(t *testing.T) {
+	var sent []string
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent = append(sent, r.Header.Get("If-None-Match"))
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				w.Write([]byte("not json"))
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`{"id":"job-1","status":"running"}`))
+		})),
+	})
+
+	job, etag, changed, err := client.GetJobIfModified(context.Background(), "job-1", "")
+	if err != nil || !changed || job.Status != JobStatusRUNNING || etag != `"v1"` {
+		t.Fatalf("first = %+v, %q, %v, %v", job, etag, changed, err)
+	}
+	job, etag, changed, err = client.GetJobIfModified(context.Background(), "job-1", etag)
+	if err != nil || changed || job != nil || etag != `"v1"` {
+		t.Fatalf("304 = %+v, %q, %v, %v", job, etag, changed, err)
+	}
+	if sent[0] != "" || sent[1] != `"v1"` {
+		t.Errorf("If-None-Match = %q", sent)
+	}
+}
+
+func TestWaitForJobPollsConditionally(t *testing.T) {
+	states := []string{"running", "running", "running", "completed"}
+	var full, notModified int
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Clock:   clock,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := states[min(full+notModified, len(states)-1)]
+			etag := `"` + status + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			full++
+			w.Header().Set("ETag", etag)
+			fmt.Fprintf(w, `{"id":"j1","status":%q}`, status)
+		})),
+	})
+
+	resp, err := client.WaitForJob(context.Background(), "j1", WaitOpts{PollInterval: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != JobStatusCOMPLETED || full != 2 || notModified != 2 {
+		t.Errorf("status = %s, full = %d, 304s = %d", resp.Status, full, notModified)
+	}
+	if waited := clock.now.Sub(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); waited != 2*time.Second {
+		t.Errorf("waited %v between immediate 304s, want 2s", waited)
+	}
+}
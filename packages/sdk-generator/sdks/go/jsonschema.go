@@ -0,0 +1,99 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema returns a JSON Schema document for the type registered in
+// SchemaRegistry under typeName. Properties come from the struct's json
+// tags, required lists the fields its validator checks for presence, and
+// fields with a known value set carry an enum. Opaque map fields are plain
+// objects.
+func JSONSchema(typeName string) ([]byte, error) {
+	t, err := schemaType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	doc := structSchema(t, enumFields[typeName])
+	doc["$schema"] = jsonSchemaDialect
+	doc["title"] = typeName
+	if required := requiredFields(typeName, t); len(required) > 0 {
+		doc["required"] = required
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// requiredFields validates the zero value of t and collects the top-level
+// fields reported as missing, along with the required numeric fields, which
+// are valid at zero.
+func requiredFields(typeName string, t reflect.Type) []string {
+	fields := append([]string(nil), requiredNumericFields[typeName]...)
+	err := SchemaRegistry[typeName](reflect.Zero(t).Interface())
+	if verrs, ok := err.(*ValidationErrors); ok {
+		for _, e := range verrs.Errors {
+			if e.Message == "is required" && !strings.ContainsAny(e.Field, ".[") {
+				fields = append(fields, e.Field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func structSchema(t reflect.Type, enums map[string][]string) map[string]interface{} {
+	props := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := typeSchema(f.Type)
+		if values, ok := enums[name]; ok {
+			s["enum"] = values
+		}
+		props[name] = s
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		return structSchema(t, nil)
+	}
+	// interface{} accepts any JSON value.
+	return map[string]interface{}{}
+}
@@ -0,0 +1,9 @@
+module github.com/controlplane/sdk-go/jsonschema
+
+go 1.21
+
+require github.com/controlplane/sdk-go v1.0.0
+
+require github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+
+replace github.com/controlplane/sdk-go => ../
@@ -0,0 +1,173 @@
+// Package jsonschema provides a controlplane.SchemaCompiler backed by a
+// complete JSON Schema implementation of drafts 4 through 2020-12. It lives
+// in its own module so that the core SDK does not depend on a JSON Schema
+// library; the core's built-in compiler covers the common keywords only.
+//
+//	client, err := controlplane.NewClient(controlplane.ClientConfig{
+//		BaseURL:        baseURL,
+//		SchemaCompiler: jsonschema.Compiler{},
+//	})
+//	err = client.ValidateCapabilityInput(capability, payload)
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	controlplane "github.com/controlplane/sdk-go"
+	santhosh "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaURL names the schema being compiled; references to other documents
+// are not loaded.
+const schemaURL = "schema.json"
+
+// Compiler compiles schemas with github.com/santhosh-tekuri/jsonschema.
+// Schemas without $schema are read as draft 2020-12, and only references
+// within the schema are resolved. It is safe for concurrent use.
+type Compiler struct {
+	// AssertFormat makes the format keyword fail values that do not match,
+	// instead of only annotating them.
+	AssertFormat bool
+}
+
+var _ controlplane.SchemaCompiler = Compiler{}
+
+// Compile implements controlplane.SchemaCompiler.
+func (c Compiler) Compile(schema map[string]interface{}) (controlplane.CompiledSchema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	compiler := santhosh.NewCompiler()
+	compiler.AssertFormat = c.AssertFormat
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("loading %s: only references within the schema are resolved", url)
+	}
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	s := &compiledSchema{schema: compiled}
+	if err := json.Unmarshal(data, &s.source); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type compiledSchema struct {
+	schema *santhosh.Schema
+	// source is the decoded schema, in which required keywords are looked
+	// up to report each missing property at its own location.
+	source interface{}
+}
+
+// Validate implements controlplane.CompiledSchema, reporting the innermost
+// failures, which name the offending value and keyword, in document order.
+// A missing required property is reported at the property's location.
+func (s *compiledSchema) Validate(doc interface{}) error {
+	err := s.schema.Validate(doc)
+	var verr *santhosh.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	var errs controlplane.ValidationErrors
+	for _, e := range leafErrors(verr, nil) {
+		if missing := s.missingProperties(e, doc); len(missing) > 0 {
+			for _, name := range missing {
+				errs.Add(e.InstanceLocation+"/"+escapePointer(name), "is required")
+			}
+			continue
+		}
+		errs.Add(e.InstanceLocation, e.Message)
+	}
+	sort.SliceStable(errs.Errors, func(i, j int) bool {
+		return errs.Errors[i].Field < errs.Errors[j].Field
+	})
+	return &errs
+}
+
+func leafErrors(e *santhosh.ValidationError, leaves []*santhosh.ValidationError) []*santhosh.ValidationError {
+	if len(e.Causes) == 0 {
+		return append(leaves, e)
+	}
+	for _, cause := range e.Causes {
+		leaves = leafErrors(cause, leaves)
+	}
+	return leaves
+}
+
+// missingProperties returns the properties a failed required keyword found
+// missing, or nil when e is another failure or the keyword cannot be found
+// in the source schema.
+func (s *compiledSchema) missingProperties(e *santhosh.ValidationError, doc interface{}) []string {
+	if !strings.HasSuffix(e.KeywordLocation, "/required") {
+		return nil
+	}
+	base, fragment, _ := strings.Cut(e.AbsoluteKeywordLocation, "#")
+	if root, _, _ := strings.Cut(s.schema.Location, "#"); base != root {
+		return nil
+	}
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	required, _ := pointerValue(s.source, fragment)
+	instance, _ := pointerValue(doc, e.InstanceLocation)
+	object, ok := instance.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var missing []string
+	names, _ := required.([]interface{})
+	for _, r := range names {
+		if name, ok := r.(string); ok {
+			if _, present := object[name]; !present {
+				missing = append(missing, name)
+			}
+		}
+	}
+	return missing
+}
+
+// pointerValue returns the value at the JSON pointer ptr within v.
+func pointerValue(v interface{}, ptr string) (interface{}, bool) {
+	if ptr == "" {
+		return v, true
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, false
+	}
+	for _, token := range strings.Split(ptr[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = t[token]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			v = t[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
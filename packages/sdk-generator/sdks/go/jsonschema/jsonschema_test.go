@@ -0,0 +1,98 @@
+package jsonschema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	controlplane "github.com/controlplane/sdk-go"
+)
+
+var rowsCapability = controlplane.RunnerCapability{
+	Id: "csv.import",
+	InputSchema: map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"bucket", "rows"},
+		"properties": map[string]interface{}{
+			"bucket": map[string]interface{}{"type": "string", "format": "uri"},
+			"rows": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/$defs/row"},
+			},
+		},
+		"$defs": map[string]interface{}{
+			"row": map[string]interface{}{
+				"type":                 "object",
+				"required":             []interface{}{"amount"},
+				"properties":           map[string]interface{}{"amount": map[string]interface{}{"type": "number", "minimum": 0}},
+				"additionalProperties": false,
+			},
+		},
+	},
+}
+
+func newClient(t *testing.T, compiler Compiler) *controlplane.ControlPlaneClient {
+	t.Helper()
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: "http://cp.test", SchemaCompiler: compiler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	var verrs *controlplane.ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("err = %v", err)
+	}
+	return verrs.Fields()
+}
+
+func TestCompilerReportsPointers(t *testing.T) {
+	client := newClient(t, Compiler{AssertFormat: true})
+	if err := client.ValidateCapabilityInput(rowsCapability, map[string]interface{}{
+		"bucket": "s3://imports",
+		"rows":   []map[string]interface{}{{"amount": 3}},
+	}); err != nil {
+		t.Fatalf("valid payload: %v", err)
+	}
+
+	err := client.ValidateCapabilityInput(rowsCapability, map[string]interface{}{
+		"bucket": "not a uri",
+		"rows":   []interface{}{map[string]interface{}{"amount": -1}, map[string]interface{}{"note": "x"}},
+	})
+	want := []string{"/bucket", "/rows/0/amount", "/rows/1", "/rows/1/amount"}
+	if got := fields(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v (%v)", got, want, err)
+	}
+
+	err = client.ValidateCapabilityInput(rowsCapability, map[string]interface{}{})
+	if got, want := fields(t, err), []string{"/bucket", "/rows"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing fields = %v, want %v", got, want)
+	}
+}
+
+func TestCompilerEnforcesEveryKeyword(t *testing.T) {
+	client := newClient(t, Compiler{})
+	tagged := controlplane.RunnerCapability{InputSchema: map[string]interface{}{
+		"minProperties":     2,
+		"dependentRequired": map[string]interface{}{"region": []interface{}{"bucket"}},
+		"properties":        map[string]interface{}{"tags": map[string]interface{}{"uniqueItems": true}},
+	}}
+	err := client.ValidateCapabilityInput(tagged, map[string]interface{}{"tags": []string{"a", "a"}})
+	if got, want := fields(t, err), []string{"", "/tags"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	err = client.ValidateCapabilityInput(tagged, map[string]interface{}{"region": "eu", "tags": []string{"a"}})
+	if got, want := fields(t, err), []string{""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestCompilerRejectsRemoteReferences(t *testing.T) {
+	_, err := Compiler{}.Compile(map[string]interface{}{"$ref": "https://example.com/row.json"})
+	if err == nil {
+		t.Error("remote reference compiled")
+	}
+}
@@ -0,0 +1,77 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchemaJobRequest(t *testing.T) {
+	data, err := JSONSchema("JobRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Schema     string                            `json:"$schema"`
+		Title      string                            `json:"title"`
+		Type       string                            `json:"type"`
+		Required   []string                          `json:"required"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "JobRequest" || doc.Type != "object" || doc.Schema == "" {
+		t.Errorf("header = %q %q %q", doc.Schema, doc.Title, doc.Type)
+	}
+	if !reflect.DeepEqual(doc.Required, []string{"id", "type"}) {
+		t.Errorf("required = %v", doc.Required)
+	}
+	if got := doc.Properties["metadata"]["type"]; got != "object" {
+		t.Errorf("metadata type = %v", got)
+	}
+	if got := doc.Properties["priority"]["type"]; got != "integer" {
+		t.Errorf("priority type = %v", got)
+	}
+}
+
+func TestJSONSchemaEnumsAndFormats(t *testing.T) {
+	data, err := JSONSchema("ErrorEnvelope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Properties map[string]struct {
+			Type   string   `json:"type"`
+			Format string   `json:"format"`
+			Enum   []string `json:"enum"`
+			Items  *struct {
+				Type string `json:"type"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Properties["severity"].Enum; !reflect.DeepEqual(got, errorSeverityValues) {
+		t.Errorf("severity enum = %v", got)
+	}
+	if got := doc.Properties["timestamp"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("timestamp = %+v", got)
+	}
+	if got := doc.Properties["details"]; got.Type != "array" || got.Items == nil || got.Items.Type != "object" {
+		t.Errorf("details = %+v", got)
+	}
+}
+
+func TestJSONSchemaCoversRegistry(t *testing.T) {
+	for name := range SchemaRegistry {
+		if _, err := JSONSchema(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := JSONSchema("NoSuchType"); !errors.Is(err, ErrUnknownSchema) {
+		t.Errorf("unknown type err = %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned by requests made after Close.
+var ErrClientClosed = errors.New("client closed")
+
+// lifecycle tracks the client's in-flight requests and background
+// goroutines so Close can stop and wait for them.
+type lifecycle struct {
+	// stopping is cancelled when Close starts, stopping background work;
+	// aborting when its deadline passes, cancelling requests still in
+	// flight.
+	stopping context.Context
+	stop     context.CancelFunc
+	aborting context.Context
+	abort    context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	// work counts in-flight requests and running background goroutines.
+	// Nothing is added once closed is set, so Close can wait on it.
+	work sync.WaitGroup
+}
+
+func newLifecycle() *lifecycle {
+	l := &lifecycle{}
+	l.stopping, l.stop = context.WithCancel(context.Background())
+	l.aborting, l.abort = context.WithCancel(context.Background())
+	return l
+}
+
+// enter registers one unit of work, returning ErrClientClosed after Close.
+// done, which may be called more than once, ends it.
+func (l *lifecycle) enter() (done func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrClientClosed
+	}
+	l.work.Add(1)
+	var once sync.Once
+	return func() { once.Do(l.work.Done) }, nil
+}
+
+func (l *lifecycle) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// startRequest registers an in-flight request. Its context is also
+// cancelled if Close gives up waiting for it; release ends it.
+func (c *ControlPlaneClient) startRequest(ctx context.Context) (context.Context, func(), error) {
+	return c.track(ctx, c.life.aborting)
+}
+
+// startBackground registers background work, such as a stream or polling
+// loop, that outlives the call that started it. Its context is also
+// cancelled as soon as Close is called; release ends it and must be called
+// when the work is done.
+func (c *ControlPlaneClient) startBackground(ctx context.Context) (context.Context, func(), error) {
+	return c.track(ctx, c.life.stopping)
+}
+
+func (c *ControlPlaneClient) track(ctx, until context.Context) (context.Context, func(), error) {
+	done, err := c.life.enter()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(until, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		done()
+	}, nil
+}
+
+// Close shuts the client down. It stops its background work, such as
+// endpoint health probes, WatchHealth, heartbeat loops, truth streams,
+// streamed executions and cache refreshes, and waits for in-flight requests
+// to finish, including responses whose bodies are still open. If ctx ends
+// first, the remaining requests are cancelled and ctx.Err() is returned.
+// Idle connections of the client's transport are closed last.
+//
+// Requests made after Close fail with ErrClientClosed. Close may be called
+// more than once; later calls wait like the first.
+func (c *ControlPlaneClient) Close(ctx context.Context) error {
+	c.life.mu.Lock()
+	c.life.closed = true
+	c.life.mu.Unlock()
+	c.life.stop()
+
+	idle := make(chan struct{})
+	go func() {
+		c.life.work.Wait()
+		close(idle)
+	}()
+	var err error
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		err = ctx.Err()
+		c.life.abort()
+	}
+	c.client.CloseIdleConnections()
+	return err
+}
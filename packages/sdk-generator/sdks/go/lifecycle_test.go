@@ -0,0 +1,118 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// blockingServer holds every request until release is closed or the request
+// is cancelled, signalling entered as each one arrives.
+func blockingServer(t *testing.T) (srv *httptest.Server, entered chan struct{}, release chan struct{}) {
+	entered = make(chan struct{}, 10)
+	release = make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		select {
+		case <-release:
+			w.Write([]byte(`{"status":"healthy","timestamp":"2024-01-01T00:00:00Z","checks":[]}`))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, entered, release
+}
+
+func TestCloseWaitsForInFlightRequests(t *testing.T) {
+	srv, entered, release := blockingServer(t)
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	reqErr := make(chan error, 1)
+	go func() {
+		_, err := client.GetHealth(context.Background())
+		reqErr <- err
+	}()
+	<-entered
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close(context.Background()) }()
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned before the request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-reqErr; err != nil {
+		t.Errorf("in-flight request failed: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+
+	if _, err := client.GetHealth(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("GetHealth after Close = %v, want ErrClientClosed", err)
+	}
+	if _, err := client.NewRequest(context.Background(), http.MethodGet, "/v1/health"); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("NewRequest after Close = %v, want ErrClientClosed", err)
+	}
+	if err := client.Close(context.Background()); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+}
+
+func TestCloseDeadlineCancelsRequests(t *testing.T) {
+	srv, entered, _ := blockingServer(t)
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	reqErr := make(chan error, 1)
+	go func() {
+		_, err := client.GetHealth(context.Background())
+		reqErr <- err
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case err := <-reqErr:
+		if err == nil {
+			t.Error("aborted request succeeded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request not cancelled after the Close deadline")
+	}
+}
+
+func TestCloseStopsBackgroundWork(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy","timestamp":"2024-01-01T00:00:00Z","checks":[]}`))
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	health, _ := client.WatchHealth(context.Background(), time.Millisecond)
+	<-health
+	stop := client.StartHeartbeatLoop(context.Background(), time.Millisecond, func() RunnerHeartbeat {
+		return RunnerHeartbeat{RunnerId: "r1", Status: RunnerStatusHealthy}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	for range health {
+	}
+	if err := stop(context.Background(), true); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("stop after Close = %v, want ErrClientClosed", err)
+	}
+	if _, stopWatch := client.WatchHealth(context.Background(), time.Millisecond); stopWatch == nil {
+		t.Error("WatchHealth after Close returned a nil stop")
+	}
+}
@@ -0,0 +1,78 @@
+package controlplane
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Logger receives structured log lines from the client. Arguments after msg
+// are alternating keys and values, as with log/slog.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// NopLogger returns a Logger that discards all output. It is the default.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// NewSlogLogger adapts a *slog.Logger to the Logger interface.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debug(msg string, kv ...interface{}) { s.l.Debug(msg, kv...) }
+func (s slogLogger) Info(msg string, kv ...interface{})  { s.l.Info(msg, kv...) }
+func (s slogLogger) Warn(msg string, kv ...interface{})  { s.l.Warn(msg, kv...) }
+func (s slogLogger) Error(msg string, kv ...interface{}) { s.l.Error(msg, kv...) }
+
+// sensitiveHeaders are never written to logs in clear text.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Signature",
+}
+
+const redacted = "[REDACTED]"
+
+// redactHeaders flattens h for logging, replacing credentials with a marker.
+// Any header whose name mentions a key, token or secret is redacted as well.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if isSensitiveHeader(name) {
+			out[name] = redacted
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func isSensitiveHeader(name string) bool {
+	for _, s := range sensitiveHeaders {
+		if strings.EqualFold(name, s) {
+			return true
+		}
+	}
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "api-key") || strings.Contains(lower, "token") || strings.Contains(lower, "secret")
+}
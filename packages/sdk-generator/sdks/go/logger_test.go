@@ -0,0 +1,89 @@
+package controlplane
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (r *recordingLogger) log(level, msg string, kv ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf("%s %s %v", level, msg, kv))
+}
+
+func (r *recordingLogger) Debug(msg string, kv ...interface{}) { r.log("DEBUG", msg, kv...) }
+func (r *recordingLogger) Info(msg string, kv ...interface{})  { r.log("INFO", msg, kv...) }
+func (r *recordingLogger) Warn(msg string, kv ...interface{})  { r.log("WARN", msg, kv...) }
+func (r *recordingLogger) Error(msg string, kv ...interface{}) { r.log("ERROR", msg, kv...) }
+
+func (r *recordingLogger) contains(substr string) bool {
+	for _, l := range r.lines {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClientLogsRequestsRetriesAndMismatches(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Contract-Version", "2.0.0")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	logger := &recordingLogger{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		APIKey:  "super-secret-key",
+		Retry:   &RetryPolicy{MaxRetries: Int(1)},
+		Logger:  logger,
+	})
+	resp, err := client.Request(context.Background(), http.MethodGet, "/health", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, want := range []string{"sending request", "received response", "retrying request", "contract version mismatch", redacted} {
+		if !logger.contains(want) {
+			t.Errorf("missing log line containing %q", want)
+		}
+	}
+	if logger.contains("super-secret-key") {
+		t.Error("API key leaked into logs")
+	}
+
+	_ = client.Validate(JobRequest{})
+	if !logger.contains("validation failed") {
+		t.Error("validation failure not logged")
+	}
+}
+
+func TestSlogLoggerRedactsHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Api-Key", "abc")
+	h.Set("X-Tenant-Id", "acme")
+	logger.Debug("request", "headers", redactHeaders(h))
+
+	out := buf.String()
+	if strings.Contains(out, "abc") || !strings.Contains(out, "acme") {
+		t.Errorf("log output = %s", out)
+	}
+}
@@ -0,0 +1,140 @@
+package controlplane
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RunnerStatusHealthy is the only runner status MatchRunner accepts. An
+// empty status is treated as healthy, matching the contract default.
+const RunnerStatusHealthy = "healthy"
+
+// CapabilitiesTyped decodes Capabilities. Every entry is decoded; entries that
+// fail are omitted from the result and reported together in the returned
+// ValidationErrors.
+func (m RunnerMetadata) CapabilitiesTyped() ([]RunnerCapability, error) {
+	var errs ValidationErrors
+	caps := make([]RunnerCapability, 0, len(m.Capabilities))
+	for i, raw := range m.Capabilities {
+		var c RunnerCapability
+		if err := decodeMap(raw, &c); err != nil {
+			errs.Add(fmt.Sprintf("capabilities[%d]", i), err.Error())
+			continue
+		}
+		caps = append(caps, c)
+	}
+	if !errs.IsValid() {
+		return caps, &errs
+	}
+	return caps, nil
+}
+
+// MatchRunner returns the runners able to execute job, best candidate first.
+// See MatchRunnerWithLoad.
+func MatchRunner(job JobRequest, runners []RunnerMetadata) ([]RunnerMetadata, error) {
+	return MatchRunnerWithLoad(job, runners, nil)
+}
+
+// MatchRunnerWithLoad returns the runners that are healthy, have a capability
+// listing job.Type in SupportedJobTypes and speak a contract version that
+// satisfies the job's: the same major version, at or above the required
+// minor and patch. The required version is read from
+// job.Metadata["contractVersion"] and defaults to the SDK's own.
+//
+// Runners are ranked by concurrency headroom, the summed MaxConcurrency of
+// their matching capabilities minus activeJobs[runner.Id]; activeJobs may be
+// nil when load is unknown, e.g. before any RunnerHeartbeat is received.
+// Ties keep their input order.
+//
+// Runners whose capabilities or contract version cannot be decoded are
+// skipped. Their errors are accumulated into a ValidationErrors returned
+// alongside the runners that did match.
+func MatchRunnerWithLoad(job JobRequest, runners []RunnerMetadata, activeJobs map[string]int) ([]RunnerMetadata, error) {
+	var errs ValidationErrors
+	required, err := jobContractVersion(job)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		runner   RunnerMetadata
+		headroom int
+	}
+	var candidates []candidate
+	for i, r := range runners {
+		if r.Status != "" && r.Status != RunnerStatusHealthy {
+			continue
+		}
+		var version ContractVersion
+		if err := decodeMap(r.ContractVersion, &version); err != nil {
+			errs.Add(fmt.Sprintf("runners[%d].contractVersion", i), err.Error())
+			continue
+		}
+		if !satisfiesContract(version, required) {
+			continue
+		}
+		caps, err := r.CapabilitiesTyped()
+		if err != nil {
+			for _, e := range err.(*ValidationErrors).Errors {
+				errs.Add(fmt.Sprintf("runners[%d].%s", i, e.Field), e.Message)
+			}
+			continue
+		}
+
+		capacity, ok := 0, false
+		for _, c := range caps {
+			if !supportsJobType(c, job.Type) {
+				continue
+			}
+			ok = true
+			if n := IntValue(c.MaxConcurrency); n > 0 {
+				capacity += n
+			} else {
+				capacity++
+			}
+		}
+		if ok {
+			candidates = append(candidates, candidate{runner: r, headroom: capacity - activeJobs[r.Id]})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].headroom > candidates[j].headroom
+	})
+	matches := make([]RunnerMetadata, len(candidates))
+	for i, c := range candidates {
+		matches[i] = c.runner
+	}
+	if !errs.IsValid() {
+		return matches, &errs
+	}
+	return matches, nil
+}
+
+// jobContractVersion returns the contract version a job requires.
+func jobContractVersion(job JobRequest) (ContractVersion, error) {
+	raw, ok := job.Metadata["contractVersion"]
+	if !ok || raw == nil {
+		return clientContractVersion, nil
+	}
+	var v ContractVersion
+	if err := decodeMap(raw, &v); err != nil {
+		return v, fmt.Errorf("job %s: decode metadata.contractVersion: %w", job.Id, err)
+	}
+	return v, nil
+}
+
+// satisfiesContract reports whether a runner speaking have can serve a job
+// requiring want.
+func satisfiesContract(have, want ContractVersion) bool {
+	return have.Major == want.Major && have.Compare(want) >= 0
+}
+
+func supportsJobType(c RunnerCapability, jobType string) bool {
+	for _, t := range c.SupportedJobTypes {
+		if t == jobType {
+			return true
+		}
+	}
+	return false
+}
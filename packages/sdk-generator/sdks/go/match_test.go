@@ -0,0 +1,110 @@
+package controlplane
+
+import (
+	"testing"
+)
+
+func testRunner(id, status string, version map[string]interface{}, caps ...map[string]interface{}) RunnerMetadata {
+	return RunnerMetadata{Id: id, Status: status, ContractVersion: version, Capabilities: caps}
+}
+
+func testCapability(maxConcurrency int, jobTypes ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id": "cap", "name": "cap", "version": "1.0.0", "description": "d",
+		"supportedJobTypes": jobTypes,
+		"maxConcurrency":    float64(maxConcurrency),
+	}
+}
+
+var v1 = map[string]interface{}{"major": float64(1), "minor": float64(0), "patch": float64(0)}
+
+func matchIDs(runners []RunnerMetadata) []string {
+	ids := make([]string, len(runners))
+	for i, r := range runners {
+		ids[i] = r.Id
+	}
+	return ids
+}
+
+func TestMatchRunnerNoMatch(t *testing.T) {
+	v2 := map[string]interface{}{"major": float64(2), "minor": float64(0), "patch": float64(0)}
+	runners := []RunnerMetadata{
+		testRunner("other-type", "healthy", v1, testCapability(4, "render")),
+		testRunner("unhealthy", "degraded", v1, testCapability(4, "csv")),
+		testRunner("wrong-major", "healthy", v2, testCapability(4, "csv")),
+	}
+	got, err := MatchRunner(JobRequest{Id: "j1", Type: "csv"}, runners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("matches = %v", matchIDs(got))
+	}
+}
+
+func TestMatchRunnerSingleMatch(t *testing.T) {
+	runners := []RunnerMetadata{
+		testRunner("r1", "", v1, testCapability(1, "render"), testCapability(2, "csv")),
+		testRunner("r2", "healthy", v1, testCapability(2, "render")),
+	}
+	got, err := MatchRunner(JobRequest{Id: "j1", Type: "csv"}, runners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := matchIDs(got); len(ids) != 1 || ids[0] != "r1" {
+		t.Errorf("matches = %v", ids)
+	}
+}
+
+func TestMatchRunnerRanksByHeadroom(t *testing.T) {
+	runners := []RunnerMetadata{
+		testRunner("small", "healthy", v1, testCapability(2, "csv")),
+		testRunner("busy", "healthy", v1, testCapability(8, "csv")),
+		testRunner("large", "healthy", v1, testCapability(4, "csv"), testCapability(2, "csv", "render")),
+	}
+	got, err := MatchRunnerWithLoad(JobRequest{Id: "j1", Type: "csv"}, runners, map[string]int{"busy": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := matchIDs(got)
+	if len(ids) != 3 || ids[0] != "large" || ids[1] != "small" || ids[2] != "busy" {
+		t.Errorf("matches = %v", ids)
+	}
+}
+
+func TestMatchRunnerContractRequirement(t *testing.T) {
+	v13 := map[string]interface{}{"major": float64(1), "minor": float64(3), "patch": float64(0)}
+	runners := []RunnerMetadata{
+		testRunner("old", "healthy", v1, testCapability(1, "csv")),
+		testRunner("new", "healthy", v13, testCapability(1, "csv")),
+	}
+	job := JobRequest{Id: "j1", Type: "csv", Metadata: map[string]interface{}{"contractVersion": "1.2.0"}}
+	got, err := MatchRunner(job, runners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := matchIDs(got); len(ids) != 1 || ids[0] != "new" {
+		t.Errorf("matches = %v", ids)
+	}
+}
+
+func TestMatchRunnerAccumulatesDecodeErrors(t *testing.T) {
+	bad := testCapability(1, "csv")
+	bad["maxConcurrency"] = "many"
+	runners := []RunnerMetadata{
+		testRunner("r1", "healthy", v1, testCapability(1, "csv")),
+		testRunner("r2", "healthy", v1, bad),
+		testRunner("r3", "healthy", map[string]interface{}{"major": "one"}, testCapability(1, "csv")),
+	}
+	got, err := MatchRunner(JobRequest{Id: "j1", Type: "csv"}, runners)
+	verrs, ok := err.(*ValidationErrors)
+	if !ok || len(verrs.Errors) != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if verrs.Errors[0].Field != "runners[1].capabilities[0]" || verrs.Errors[1].Field != "runners[2].contractVersion" {
+		t.Errorf("errors = %+v", verrs.Errors)
+	}
+	if ids := matchIDs(got); len(ids) != 1 || ids[0] != "r1" {
+		t.Errorf("matches = %v", ids)
+	}
+}
@@ -0,0 +1,175 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseCache holds the responses of WithCache calls. Every invalidation
+// bumps gen, so a fetch that started before a mutation does not store what
+// may be a pre-mutation response.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	gen     uint64
+}
+
+type cachedResponse struct {
+	// root is the top-level resource the response was fetched from.
+	root     string
+	body     []byte
+	storedAt time.Time
+	// keepFor is the TTL plus stale window of the call that stored the
+	// entry, after which it is pruned.
+	keepFor    time.Duration
+	refreshing bool
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: map[string]*cachedResponse{}}
+}
+
+// store saves body under key unless the cache was invalidated since gen. It
+// also prunes entries that no caller may serve any more.
+func (rc *responseCache) store(key, path string, body []byte, now time.Time, keepFor time.Duration, gen uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if gen != rc.gen {
+		return
+	}
+	for k, e := range rc.entries {
+		if now.Sub(e.storedAt) >= e.keepFor {
+			delete(rc.entries, k)
+		}
+	}
+	rc.entries[key] = &cachedResponse{root: resourceRoot(path), body: body, storedAt: now, keepFor: keepFor}
+}
+
+// invalidate drops every entry under the top-level resource of path.
+func (rc *responseCache) invalidate(path string) {
+	root := resourceRoot(path)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.gen++
+	for k, e := range rc.entries {
+		if e.root == root {
+			delete(rc.entries, k)
+		}
+	}
+}
+
+// memoizedGet returns the body of a GET from the response cache while it is
+// within the call's TTL, and otherwise fetches and stores it. Past the TTL
+// but within the stale window, the cached body is returned and a single
+// background refresh is started. Entries are kept per tenant.
+func (c *ControlPlaneClient) memoizedGet(ctx context.Context, path string, o requestOptions, opts []RequestOption) ([]byte, error) {
+	target, err := c.resolveURL(path, o.query)
+	if err != nil {
+		return nil, err
+	}
+	key := tenantCacheKey(canonicalCacheKey(http.MethodGet, target), c.tenant(ctx))
+	keepFor := o.cacheTTL + o.staleWindow
+	rc := c.responses
+
+	rc.mu.Lock()
+	if e, ok := rc.entries[key]; ok {
+		age := c.config.Clock.Now().Sub(e.storedAt)
+		if age < o.cacheTTL {
+			rc.mu.Unlock()
+			return e.body, nil
+		}
+		if age < keepFor {
+			if !e.refreshing {
+				if bg, release, err := c.startBackground(context.WithoutCancel(ctx)); err == nil {
+					e.refreshing = true
+					go func(gen uint64) {
+						defer release()
+						c.refreshResponse(bg, key, path, keepFor, gen, opts)
+					}(rc.gen)
+				}
+			}
+			rc.mu.Unlock()
+			return e.body, nil
+		}
+	}
+	gen := rc.gen
+	rc.mu.Unlock()
+
+	body, err := c.getBody(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	rc.store(key, path, body, c.config.Clock.Now(), keepFor, gen)
+	return body, nil
+}
+
+// refreshResponse refetches a stale entry. The caller's context is detached
+// from cancellation, so the refresh outlives the call that triggered it; it
+// is still bounded by the request timeout.
+func (c *ControlPlaneClient) refreshResponse(ctx context.Context, key, path string, keepFor time.Duration, gen uint64, opts []RequestOption) {
+	body, err := c.getBody(ctx, path, opts)
+	if err != nil {
+		c.config.Logger.Warn("controlplane: cache refresh failed", "path", path, "error", err)
+		c.responses.mu.Lock()
+		if e, ok := c.responses.entries[key]; ok {
+			e.refreshing = false
+		}
+		c.responses.mu.Unlock()
+		return
+	}
+	c.responses.store(key, path, body, c.config.Clock.Now(), keepFor, gen)
+}
+
+// canonicalCacheKey identifies a request by method and URL, with the query
+// parameters sorted so their order does not matter.
+func canonicalCacheKey(method, target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return method + " " + target
+	}
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	return method + " " + u.String()
+}
+
+// resourceRoot returns the top-level resource of a request path, such as
+// "/v1/runners" for "/v1/runners/r1/execute" or "/health" for "/health".
+func resourceRoot(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	if len(segments) == 2 && !isVersionSegment(segments[0]) {
+		segments = segments[:1]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// isVersionSegment reports whether s is an API version such as "v1".
+func isVersionSegment(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isMutating reports whether method may change server state.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
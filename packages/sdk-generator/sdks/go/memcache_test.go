@@ -0,0 +1,127 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCacheServesWithinTTLAndInvalidatesOnMutation(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		n := atomic.AddInt32(&hits, 1)
+		fmt.Fprintf(w, `{"status":"healthy","version":"%d"}`, n)
+	}))
+	defer srv.Close()
+	clock := &manualClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Clock: clock})
+	ctx := context.Background()
+
+	version := func(opts ...RequestOption) string {
+		t.Helper()
+		var health HealthCheck
+		if err := client.call(ctx, http.MethodGet, "/v1/runners", nil, &health, opts...); err != nil {
+			t.Fatal(err)
+		}
+		return health.Version
+	}
+
+	cached := WithCache(time.Minute)
+	if v := version(cached, WithQueryParam("a", "1"), WithQueryParam("b", "2")); v != "1" {
+		t.Fatalf("first call = %s", v)
+	}
+	// The same query in a different order hits the cache.
+	if v := version(cached, WithQueryParam("b", "2"), WithQueryParam("a", "1")); v != "1" {
+		t.Errorf("reordered query = %s", v)
+	}
+	if v := version(); v != "2" {
+		t.Errorf("uncached call = %s", v)
+	}
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	if v := version(cached); v != "3" {
+		t.Errorf("expired call = %s", v)
+	}
+	if v := version(cached); v != "3" {
+		t.Errorf("cached call = %s", v)
+	}
+
+	if err := client.call(ctx, http.MethodPost, "/v1/runners/r1/execute", map[string]string{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(cached); v != "4" {
+		t.Errorf("call after mutation = %s", v)
+	}
+}
+
+func TestWithCacheStaleWhileRevalidate(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if n == 2 {
+			<-release
+		}
+		fmt.Fprintf(w, `{"status":"healthy","version":"%d"}`, n)
+	}))
+	defer srv.Close()
+	clock := &manualClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Clock: clock})
+	opts := []RequestOption{WithCache(time.Minute), WithStaleWhileRevalidate(time.Hour)}
+
+	get := func() string {
+		t.Helper()
+		health, err := client.GetHealth(context.Background(), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return health.Version
+	}
+
+	if v := get(); v != "1" {
+		t.Fatalf("first call = %s", v)
+	}
+	clock.now = clock.now.Add(2 * time.Minute)
+	// The stale response is served while the refresh is held up, and only
+	// one refresh is started.
+	for i := 0; i < 3; i++ {
+		if v := get(); v != "1" {
+			t.Fatalf("stale call %d = %s", i, v)
+		}
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for get() != "2" {
+		if time.Now().After(deadline) {
+			t.Fatal("refreshed response never served")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("server hits = %d", n)
+	}
+}
+
+func TestResourceRoot(t *testing.T) {
+	for path, want := range map[string]string{
+		"/health":                       "/health",
+		"/v1/runners":                   "/v1/runners",
+		"/v1/runners/r1/execute":        "/v1/runners",
+		"/v1/jobs?status=queued":        "/v1/jobs",
+		"/v1/marketplace/connectors/c1": "/v1/marketplace",
+		"/internal/jobs/j1":             "/internal",
+	} {
+		if got := resourceRoot(path); got != want {
+			t.Errorf("resourceRoot(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package controlplane
+
+import (
+	"time"
+)
+
+// MetricsCollector receives request metrics from the client. Route is the
+// route template of the request (for example "/v1/jobs/{id}"), never the raw
+// path, so it is safe to use as a metric label.
+//
+// Implementations must be safe for concurrent use. The promcollector
+// sub-package provides a Prometheus implementation.
+type MetricsCollector interface {
+	// RequestStarted and RequestFinished bracket every attempt and can be
+	// used to track requests in flight.
+	RequestStarted(method, route string)
+	// RequestFinished reports the outcome of an attempt. StatusCode is zero
+	// when the attempt failed without a response.
+	RequestFinished(method, route string, statusCode int, duration time.Duration)
+	// RequestRetried is called each time a failed attempt is retried.
+	RequestRetried(method, route string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RequestStarted(string, string)                      {}
+func (nopMetrics) RequestFinished(string, string, int, time.Duration) {}
+func (nopMetrics) RequestRetried(string, string)                      {}
@@ -0,0 +1,82 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+	finished []string
+	retries  []string
+	hedges   []string
+}
+
+func (m *recordingMetrics) RequestStarted(method, route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight++
+	if m.inFlight > m.maxSeen {
+		m.maxSeen = m.inFlight
+	}
+}
+
+func (m *recordingMetrics) RequestFinished(method, route string, status int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight--
+	m.finished = append(m.finished, fmt.Sprintf("%s %s %d", method, route, status))
+}
+
+func (m *recordingMetrics) RequestRetried(method, route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries = append(m.retries, method+" "+route)
+}
+
+func (m *recordingMetrics) RequestHedged(method, route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hedges = append(m.hedges, method+" "+route)
+}
+
+func TestMetricsUseRouteTemplate(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	metrics := &recordingMetrics{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: srv.URL,
+		Retry:   &RetryPolicy{MaxRetries: Int(1)},
+		Metrics: metrics,
+	})
+	resp, err := client.Request(context.Background(), http.MethodGet, "/v1/jobs/550e8400-e29b-41d4-a716-446655440000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := []string{"GET /v1/jobs/{id} 503", "GET /v1/jobs/{id} 200"}
+	if fmt.Sprint(metrics.finished) != fmt.Sprint(want) {
+		t.Errorf("finished = %v, want %v", metrics.finished, want)
+	}
+	if len(metrics.retries) != 1 || metrics.retries[0] != "GET /v1/jobs/{id}" {
+		t.Errorf("retries = %v", metrics.retries)
+	}
+	if metrics.inFlight != 0 || metrics.maxSeen != 1 {
+		t.Errorf("in flight = %d, max = %d", metrics.inFlight, metrics.maxSeen)
+	}
+}
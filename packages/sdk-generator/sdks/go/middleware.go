@@ -0,0 +1,75 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+)
+
+// RoundTripFunc sends a single request attempt and returns its response.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper, so a RoundTripFunc can be passed
+// to code that decorates transports.
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Middleware wraps a RoundTripFunc to observe or alter request attempts.
+//
+// Middlewares run once per attempt, after the client has set its default
+// headers, so they see the request exactly as it will be sent. A middleware
+// may short-circuit the chain by returning a response without calling next.
+//
+// Each attempt passes through the client in this order:
+//
+//  1. Retries, endpoint failover and hedging, which start the attempt.
+//  2. Default headers and credentials, then per-request headers.
+//  3. Logging, the OnRequest and OnResponse hooks and MetricsCollector,
+//     which therefore do not see headers set by a middleware.
+//  4. ClientConfig.Middlewares, Middlewares[0] outermost.
+//  5. The MaxResponseBytes limit, gzip decompression and the DebugWriter
+//     dump.
+//  6. The http.Client, which follows redirects, and its transport:
+//     ClientConfig.Transport or the one NewClient builds.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WrapRoundTripper adapts a transport decorator, such as an instrumentation
+// library's func(http.RoundTripper) http.RoundTripper, into a Middleware.
+func WrapRoundTripper(wrap func(http.RoundTripper) http.RoundTripper) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return wrap(next).RoundTrip
+	}
+}
+
+// chainMiddlewares wraps send so that mws[0] is the outermost middleware.
+func chainMiddlewares(send RoundTripFunc, mws []Middleware) RoundTripFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		send = mws[i](send)
+	}
+	return send
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the 1-based attempt number of the request whose
+// context is ctx, or 0 when ctx did not originate from the client.
+func AttemptFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+type routeKey struct{}
+
+// RouteFromContext returns the route template, such as "/v1/jobs/{id}", of
+// the request whose context is ctx, or "" when ctx did not originate from
+// the client. Middlewares can use it as a low-cardinality span or metric name.
+func RouteFromContext(ctx context.Context) string {
+	route, _ := ctx.Value(routeKey{}).(string)
+	return route
+}
+
+func withRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
@@ -0,0 +1,170 @@
+package controlplane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareOrderAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Tenant")))
+	}))
+	defer srv.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				if req.Header.Get("Authorization") != "Bearer key" {
+					t.Errorf("%s: default headers not set", name)
+				}
+				req.Header.Set("X-Tenant", req.Header.Get("X-Tenant")+name)
+				return next(req)
+			}
+		}
+	}
+
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:     srv.URL,
+		APIKey:      "key",
+		Middlewares: []Middleware{tag("a"), tag("b")},
+	})
+	resp, err := client.Request(context.Background(), http.MethodGet, "/health", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ab" || strings.Join(order, ",") != "a,b" {
+		t.Errorf("order = %v, header = %q", order, body)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	synthetic := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusTeapot,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader("cached")),
+				Request:    req,
+			}, nil
+		}
+	}
+
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:     "http://127.0.0.1:0",
+		Middlewares: []Middleware{synthetic},
+	})
+	resp, err := client.Request(context.Background(), http.MethodGet, "/health", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+}
+
+func TestMiddlewareRunsOnEveryRetryAttempt(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var attempts []int
+	record := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			attempts = append(attempts, AttemptFromContext(req.Context()))
+			if route := RouteFromContext(req.Context()); route != "/v1/jobs" {
+				t.Errorf("route = %q", route)
+			}
+			return next(req)
+		}
+	}
+
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:     srv.URL,
+		Retry:       &RetryPolicy{MaxRetries: Int(3)},
+		Middlewares: []Middleware{record},
+	})
+	resp, err := client.Request(context.Background(), http.MethodPost, "/v1/jobs", map[string]string{"id": "1"}, WithIdempotencyKey("job-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Errorf("attempts = %v", attempts)
+	}
+}
+
+// headerWrapper is a transport decorator in the style of instrumentation
+// libraries: it records the order it ran in and marks the request.
+func headerWrapper(order *[]string, name string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			*order = append(*order, name)
+			req.Header.Add("X-Via", name)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func TestCustomTransportKeepsSDKLayers(t *testing.T) {
+	var order []string
+	var seen http.Header
+	stub := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "transport")
+		seen = req.Header.Clone()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"status":"healthy","timestamp":"2024-01-01T00:00:00Z","checks":[]}`)),
+			Request:    req,
+		}, nil
+	})
+	metrics := &recordingMetrics{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:   testBaseURL,
+		APIKey:    "key",
+		Transport: stub,
+		Metrics:   metrics,
+		Middlewares: []Middleware{
+			WrapRoundTripper(headerWrapper(&order, "outer")),
+			WrapRoundTripper(headerWrapper(&order, "inner")),
+		},
+	})
+
+	if _, err := client.GetHealth(context.Background(), WithHeader("X-Request-Tag", "t1")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "outer,inner,transport" {
+		t.Errorf("order = %v", order)
+	}
+	if seen.Get("Authorization") != "Bearer key" || !strings.HasPrefix(seen.Get("User-Agent"), "controlplane-go-sdk/") ||
+		seen.Get("X-Contract-Version") == "" || seen.Get("X-Request-Tag") != "t1" {
+		t.Errorf("SDK headers missing: %v", seen)
+	}
+	if via := seen.Values("X-Via"); strings.Join(via, ",") != "outer,inner" {
+		t.Errorf("X-Via = %v", via)
+	}
+	if len(metrics.finished) != 1 {
+		t.Errorf("metrics = %v", metrics.finished)
+	}
+}
@@ -0,0 +1,138 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// nestedSchemas maps a schema name and JSON field name to the schema of the
+// document the field holds, for the map fields whose shape the contract
+// defines. A slice field holds a list of such documents. Validate checks
+// each document against its schema, so nested errors are reported with
+// paths such as "capabilities[2].description".
+var nestedSchemas = map[string]map[string]string{
+	"ErrorEnvelope": {"contractVersion": "ContractVersion"},
+	"JobRequest": {
+		"payload":     "JobPayload",
+		"metadata":    "JobMetadata",
+		"retryPolicy": "RetryPolicy",
+	},
+	"RunnerMetadata":            {"contractVersion": "ContractVersion", "capabilities": "RunnerCapability"},
+	"RunnerRegistrationRequest": {"contractVersion": "ContractVersion", "capabilities": "RunnerCapability"},
+	"ModuleManifest":            {"contractVersion": "ContractVersion", "capabilities": "RunnerCapability"},
+	"RegisteredRunner":          {"metadata": "RunnerMetadata", "capabilities": "RunnerCapability"},
+	"TruthQueryResult":          {"assertions": "TruthAssertion"},
+}
+
+func init() {
+	for schema, fields := range nestedSchemas {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields := fields
+		validationRules[schema] = append(validationRules[schema], func(m interface{}, errs *ValidationErrors) {
+			v := reflect.ValueOf(m)
+			for _, name := range names {
+				if f, ok := fieldByJSONName(v, name); ok {
+					validateNested(fields[name], f.Interface(), name, errs)
+				}
+			}
+		})
+	}
+
+	// RegisteredRunner.health has no schema of its own; its status takes
+	// the values of RunnerMetadata.status.
+	registerRule("RegisteredRunner", func(m RegisteredRunner, errs *ValidationErrors) {
+		if m.Health == nil {
+			return
+		}
+		var health struct {
+			Status     string `json:"status"`
+			ActiveJobs *int   `json:"activeJobs"`
+			QueuedJobs *int   `json:"queuedJobs"`
+		}
+		if err := decodeMap(m.Health, &health); err != nil {
+			addDecodeError(errs, "health", err)
+			return
+		}
+		if health.Status == "" {
+			errs.Add("health.status", "is required")
+		}
+		checkEnum("health.status", health.Status, runnerMetadataStatusValues, errs)
+		if IntValue(health.ActiveJobs) < 0 {
+			errs.Add("health.activeJobs", "must not be negative")
+		}
+		if IntValue(health.QueuedJobs) < 0 {
+			errs.Add("health.queuedJobs", "must not be negative")
+		}
+	})
+}
+
+// validateNested validates v, a document or list of documents of the
+// schema, reporting errors under path.
+func validateNested(schema string, v interface{}, path string, errs *ValidationErrors) {
+	switch doc := v.(type) {
+	case map[string]interface{}:
+		if doc != nil {
+			validateNestedDocument(schema, doc, path, errs)
+		}
+	case []map[string]interface{}:
+		for i, d := range doc {
+			validateNestedDocument(schema, d, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// validateNestedDocument checks that doc has the fields the contract marks
+// as required for the schema, the same list JSONSchema publishes, and then
+// validates it as the schema's type, which recurses into the type's own
+// nested documents.
+func validateNestedDocument(schema string, doc map[string]interface{}, path string, errs *ValidationErrors) {
+	t, err := schemaType(schema)
+	if err != nil {
+		return
+	}
+	for _, name := range requiredFields(schema, t) {
+		if doc[name] == nil {
+			errs.Add(path+"."+name, "is required")
+		}
+	}
+	typed := reflect.New(t)
+	if err := decodeMap(doc, typed.Interface()); err != nil {
+		addDecodeError(errs, path, err)
+		return
+	}
+	addNested(errs, path+".", SchemaRegistry[schema](typed.Elem().Interface()))
+}
+
+// addDecodeError reports a failure to decode the document at path, on the
+// offending field when the decoder names it.
+func addDecodeError(errs *ValidationErrors, path string, err error) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		want, _ := typeSchema(typeErr.Type)["type"].(string)
+		if want == "" {
+			want = typeErr.Type.String()
+		}
+		errs.Add(path+"."+typeErr.Field, fmt.Sprintf("must be of type %s, not %s", want, typeErr.Value))
+		return
+	}
+	errs.Add(path, err.Error())
+}
+
+// fieldByJSONName returns the field of the struct v tagged with name.
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); tag == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,122 @@
+package controlplane
+
+import (
+	"errors"
+	"testing"
+)
+
+func nestedCapability(id string) map[string]interface{} {
+	return map[string]interface{}{
+		"id": id, "name": id, "version": "1.0.0", "description": "test",
+		"inputSchema": map[string]interface{}{}, "outputSchema": map[string]interface{}{},
+		"supportedJobTypes": []interface{}{"csv"},
+	}
+}
+
+func validationErrorMap(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("err = %v, want ValidationErrors", err)
+	}
+	got := map[string]string{}
+	for _, e := range verrs.Errors {
+		got[e.Field] = e.Message
+	}
+	return got
+}
+
+func TestValidateRecursesIntoNestedDocuments(t *testing.T) {
+	bad := nestedCapability("c3")
+	delete(bad, "description")
+	manifest := ModuleManifest{
+		Id: "m", Name: "m", Version: "1.0.0", Description: "d", EntryPoint: "index.js",
+		ContractVersion: map[string]interface{}{"major": 1, "minor": 0},
+		Capabilities:    []map[string]interface{}{nestedCapability("c1"), nestedCapability("c2"), bad},
+	}
+	got := validationErrorMap(t, manifest.Validate())
+	want := map[string]string{
+		"contractVersion.patch":       "is required",
+		"capabilities[2].description": "is required",
+	}
+	if len(got) != len(want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Errorf("%s: %q, want %q", field, got[field], msg)
+		}
+	}
+
+	manifest.ContractVersion["patch"] = 0
+	manifest.Capabilities[2] = nestedCapability("c3")
+	if err := manifest.Validate(); err != nil {
+		t.Errorf("valid manifest: %v", err)
+	}
+}
+
+func TestValidateReportsNestedTypeErrors(t *testing.T) {
+	job := JobRequest{
+		Id: "job-1", Type: "csv",
+		Payload:     map[string]interface{}{"type": 5, "data": map[string]interface{}{}},
+		Metadata:    map[string]interface{}{"source": "test"},
+		RetryPolicy: map[string]interface{}{"maxRetries": -1},
+	}
+	got := validationErrorMap(t, job.Validate())
+	if got["payload.type"] != "must be of type string, not number" {
+		t.Errorf("payload.type: %q", got["payload.type"])
+	}
+	if _, ok := got["metadata.source"]; ok {
+		t.Errorf("valid metadata reported: %v", got)
+	}
+}
+
+func TestValidateAcceptsMinimalNestedDocuments(t *testing.T) {
+	job := JobRequest{
+		Id: "job-1", Type: "csv",
+		Payload:  map[string]interface{}{"type": "csv"},
+		Metadata: map[string]interface{}{"source": "test"},
+	}
+	if err := job.Validate(); err != nil {
+		t.Errorf("minimal job: %v", err)
+	}
+
+	capability := nestedCapability("c1")
+	delete(capability, "inputSchema")
+	delete(capability, "outputSchema")
+	delete(capability, "supportedJobTypes")
+	manifest := ModuleManifest{
+		Id: "m", Name: "m", Version: "1.0.0", Description: "d", EntryPoint: "index.js",
+		ContractVersion: map[string]interface{}{"major": 1, "minor": 0, "patch": 0},
+		Capabilities:    []map[string]interface{}{capability},
+	}
+	if err := manifest.Validate(); err != nil {
+		t.Errorf("minimal capability: %v", err)
+	}
+}
+
+func TestValidateRecursesThroughRegisteredRunner(t *testing.T) {
+	capability := nestedCapability("c1")
+	capability["resourceRequirements"] = map[string]interface{}{"cpu": -1}
+	runner := RegisteredRunner{
+		Metadata: map[string]interface{}{
+			"id": "r1", "name": "r1", "version": "1.0.0",
+			"contractVersion":     map[string]interface{}{"major": 1, "minor": 0, "patch": 0},
+			"capabilities":        []interface{}{capability},
+			"supportedContracts":  []interface{}{"1.0.0"},
+			"healthCheckEndpoint": "https://r1.test/health",
+			"registeredAt":        "2024-01-01T00:00:00Z",
+			"lastHeartbeatAt":     "2024-01-01T00:00:00Z",
+		},
+		Category:   RunnerCategoryOPS,
+		Connectors: []string{},
+		Health:     map[string]interface{}{"status": "banana"},
+	}
+	got := validationErrorMap(t, runner.Validate())
+	if _, ok := got["metadata.capabilities[0].resourceRequirements.cpu"]; !ok {
+		t.Errorf("no error two levels down in %v", got)
+	}
+	if got["health.status"] != "must be one of [healthy, degraded, unhealthy, offline]" {
+		t.Errorf("health.status: %q", got["health.status"])
+	}
+}
@@ -0,0 +1,9 @@
+module github.com/controlplane/sdk-go/oauth2
+
+go 1.21
+
+require github.com/controlplane/sdk-go v1.0.0
+
+require golang.org/x/oauth2 v0.21.0
+
+replace github.com/controlplane/sdk-go => ../
@@ -0,0 +1,144 @@
+// Package oauth2 provides a controlplane.TokenProvider backed by the OAuth2
+// client-credentials flow. It lives in its own module so that the core SDK
+// does not depend on golang.org/x/oauth2.
+//
+//	provider, err := oauth2.New(oauth2.Config{
+//		TokenURL:     "https://auth.example.com/oauth/token",
+//		ClientID:     id,
+//		ClientSecret: secret,
+//		Scopes:       []string{"controlplane.jobs"},
+//	})
+//	client, err := controlplane.NewClient(controlplane.ClientConfig{
+//		BaseURL:       baseURL,
+//		TokenProvider: provider,
+//	})
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+	xoauth2 "golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Config describes an OAuth2 client-credentials grant.
+type Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// EndpointParams are extra form parameters sent to the token endpoint,
+	// such as an audience.
+	EndpointParams url.Values
+	// HTTPClient is used to call the token endpoint. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+
+	// RenewBefore is how long before expiry a token is renewed. Defaults to
+	// one minute.
+	RenewBefore time.Duration
+	// Jitter adds a random extra amount, up to Jitter, to RenewBefore so
+	// that many clients started together do not renew at the same moment.
+	// Defaults to 30 seconds.
+	Jitter time.Duration
+}
+
+// Provider is a controlplane.TokenProvider that fetches tokens with the
+// client-credentials flow and caches them until shortly before expiry. It
+// is safe for concurrent use; concurrent callers share a single fetch.
+type Provider struct {
+	cfg    clientcredentials.Config
+	client *http.Client
+	before time.Duration
+	jitter time.Duration
+
+	mu      sync.Mutex
+	token   string
+	renewAt time.Time
+}
+
+var _ controlplane.TokenProvider = (*Provider)(nil)
+
+// New returns a Provider for cfg.
+func New(cfg Config) (*Provider, error) {
+	switch {
+	case cfg.TokenURL == "":
+		return nil, errors.New("oauth2: TokenURL is required")
+	case cfg.ClientID == "":
+		return nil, errors.New("oauth2: ClientID is required")
+	}
+	if _, err := url.Parse(cfg.TokenURL); err != nil {
+		return nil, fmt.Errorf("oauth2: invalid TokenURL: %w", err)
+	}
+	if cfg.RenewBefore <= 0 {
+		cfg.RenewBefore = time.Minute
+	}
+	if cfg.Jitter < 0 {
+		cfg.Jitter = 0
+	} else if cfg.Jitter == 0 {
+		cfg.Jitter = 30 * time.Second
+	}
+	return &Provider{
+		cfg: clientcredentials.Config{
+			ClientID:       cfg.ClientID,
+			ClientSecret:   cfg.ClientSecret,
+			TokenURL:       cfg.TokenURL,
+			Scopes:         cfg.Scopes,
+			EndpointParams: cfg.EndpointParams,
+		},
+		client: cfg.HTTPClient,
+		before: cfg.RenewBefore,
+		jitter: cfg.Jitter,
+	}, nil
+}
+
+// Token returns the cached access token, fetching a new one once the
+// current one is due for renewal. Fetch failures are returned as-is; the
+// client reports them as *controlplane.TokenError.
+func (p *Provider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.renewAt) {
+		return p.token, nil
+	}
+
+	if p.client != nil {
+		ctx = context.WithValue(ctx, xoauth2.HTTPClient, p.client)
+	}
+	tok, err := p.cfg.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("oauth2: fetch token: %w", err)
+	}
+	p.token = tok.AccessToken
+	p.renewAt = p.renewalTime(tok.Expiry)
+	return p.token, nil
+}
+
+// Invalidate drops the cached token. The client calls it when the server
+// rejects a token, so the next request fetches a fresh one.
+func (p *Provider) Invalidate() {
+	p.mu.Lock()
+	p.token = ""
+	p.mu.Unlock()
+}
+
+// renewalTime picks when a token expiring at expiry should be replaced. A
+// token without an expiry is kept until invalidated.
+func (p *Provider) renewalTime(expiry time.Time) time.Time {
+	if expiry.IsZero() {
+		return time.Unix(1<<62, 0)
+	}
+	early := p.before
+	if p.jitter > 0 {
+		early += time.Duration(rand.Int63n(int64(p.jitter)))
+	}
+	return expiry.Add(-early)
+}
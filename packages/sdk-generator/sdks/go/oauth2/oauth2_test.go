@@ -0,0 +1,105 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+)
+
+func tokenServer(t *testing.T, expiresIn int, fail *atomic.Bool) (*httptest.Server, *int32) {
+	t.Helper()
+	var issued int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" {
+			t.Errorf("bad token request: %v %v", err, r.Form)
+		}
+		if fail != nil && fail.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &issued
+}
+
+func TestProviderCachesAcrossGoroutines(t *testing.T) {
+	srv, issued := tokenServer(t, 3600, nil)
+	p, err := New(Config{TokenURL: srv.URL, ClientID: "id", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tok, err := p.Token(context.Background()); err != nil || tok != "tok-1" {
+				t.Errorf("token = %q, %v", tok, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if *issued != 1 {
+		t.Errorf("issued %d tokens", *issued)
+	}
+
+	p.Invalidate()
+	if tok, _ := p.Token(context.Background()); tok != "tok-2" {
+		t.Errorf("after Invalidate token = %q", tok)
+	}
+}
+
+func TestProviderRenewsEarly(t *testing.T) {
+	// A token valid for 90s is renewed after at most 30s given a one minute
+	// lead and 30s of jitter.
+	srv, _ := tokenServer(t, 90, nil)
+	p, _ := New(Config{TokenURL: srv.URL, ClientID: "id"})
+	if _, err := p.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	until := time.Until(p.renewAt)
+	if until > 30*time.Second || until < 0 {
+		t.Errorf("renewal in %v", until)
+	}
+}
+
+func TestProviderFailureSurfacesAsTokenError(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	tokens, _ := tokenServer(t, 3600, &fail)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer api.Close()
+
+	p, _ := New(Config{TokenURL: tokens.URL, ClientID: "id"})
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: api.URL, TokenProvider: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Request(context.Background(), http.MethodGet, "/health", nil)
+	var tokenErr *controlplane.TokenError
+	if !errors.As(err, &tokenErr) {
+		t.Errorf("err = %v, want *controlplane.TokenError", err)
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(Config{ClientID: "id"}); err == nil {
+		t.Error("missing TokenURL accepted")
+	}
+	if _, err := New(Config{TokenURL: "https://auth"}); err == nil {
+		t.Error("missing ClientID accepted")
+	}
+}
@@ -0,0 +1,41 @@
+package controlplane
+
+// Optional numeric and boolean model fields, such as RunnerHeartbeat.ActiveJobs
+// or RegistryQuery.IncludeConnectors, are pointers: nil leaves the field out
+// of the document, while a pointer to zero sends an explicit 0 or false.
+// Int, Float and Bool build such pointers inline:
+//
+//	hb := controlplane.RunnerHeartbeat{RunnerId: id, Status: "healthy", ActiveJobs: controlplane.Int(0)}
+
+// Int returns a pointer to v.
+func Int(v int) *int { return &v }
+
+// Float returns a pointer to v.
+func Float(v float64) *float64 { return &v }
+
+// Bool returns a pointer to v.
+func Bool(v bool) *bool { return &v }
+
+// IntValue returns *p, or 0 when p is nil.
+func IntValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// FloatValue returns *p, or 0 when p is nil.
+func FloatValue(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// BoolValue returns *p, or false when p is nil.
+func BoolValue(p *bool) bool {
+	if p == nil {
+		return false
+	}
+	return *p
+}
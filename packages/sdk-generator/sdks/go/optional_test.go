@@ -0,0 +1,53 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptionalFieldsSendExplicitZero(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in   interface{}
+		want string
+	}{
+		{RunnerHeartbeat{RunnerId: "r1", Timestamp: ts, Status: "healthy"},
+			`{"runnerId":"r1","timestamp":"2026-01-01T00:00:00Z","status":"healthy"}`},
+		{RunnerHeartbeat{RunnerId: "r1", Timestamp: ts, Status: "healthy", ActiveJobs: Int(0)},
+			`{"runnerId":"r1","timestamp":"2026-01-01T00:00:00Z","status":"healthy","activeJobs":0}`},
+		{RetryPolicy{MaxRetries: Int(0)}, `{"maxRetries":0}`},
+		// Set values encode as the plain fields did.
+		{RetryPolicy{MaxRetries: Int(3), BackoffMs: Float(1000), BackoffMultiplier: Float(1.5)},
+			`{"maxRetries":3,"backoffMs":1000,"backoffMultiplier":1.5}`},
+		{RegistryQuery{IncludeCapabilities: Bool(false)}, `{"includeCapabilities":false}`},
+	} {
+		data, err := json.Marshal(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want {
+			t.Errorf("marshal %+v = %s, want %s", tc.in, data, tc.want)
+		}
+	}
+}
+
+func TestOptionalFieldsDecodePresence(t *testing.T) {
+	var hb RunnerHeartbeat
+	if err := json.NewDecoder(strings.NewReader(`{"runnerId":"r1","status":"healthy","activeJobs":0}`)).Decode(&hb); err != nil {
+		t.Fatal(err)
+	}
+	if hb.ActiveJobs == nil || *hb.ActiveJobs != 0 || hb.QueuedJobs != nil {
+		t.Errorf("activeJobs = %v, queuedJobs = %v", hb.ActiveJobs, hb.QueuedJobs)
+	}
+	if IntValue(hb.QueuedJobs) != 0 || FloatValue(nil) != 0 || BoolValue(nil) || !BoolValue(Bool(true)) {
+		t.Error("value helpers do not treat nil as zero")
+	}
+
+	cp := Clone(hb)
+	*cp.ActiveJobs = 5
+	if *hb.ActiveJobs != 0 {
+		t.Error("Clone shares optional fields")
+	}
+}
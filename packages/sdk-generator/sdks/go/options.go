@@ -0,0 +1,198 @@
+package controlplane
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RequestOption customizes a single call without changing the client.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	timeout        time.Duration
+	idempotencyKey string
+	headers        http.Header
+	query          url.Values
+
+	// maxItems and progress configure the ListAll methods.
+	maxItems int
+	progress func(fetched, total int)
+
+	// clientName is appended to the User-Agent header.
+	clientName string
+
+	// cacheable lets a GET be served through cache, or ClientConfig.Cache
+	// when cache is nil.
+	cacheable bool
+	cache     Cache
+
+	// cacheTTL and staleWindow configure the in-memory cache of WithCache.
+	cacheTTL    time.Duration
+	staleWindow time.Duration
+
+	// noTimeoutDerivation leaves TimeoutMs fields unset.
+	noTimeoutDerivation bool
+
+	// getBody reopens a RequestStream body for retries.
+	getBody func() (io.ReadCloser, error)
+
+	// emptyBody lets a successful response have an empty body; see Do.
+	emptyBody bool
+
+	// consistency is the level of a truth operation, sent in
+	// ConsistencyHeader.
+	consistency string
+
+	// unlimitedBody lifts MaxResponseBytes for streams that enforce it per
+	// frame instead.
+	unlimitedBody bool
+
+	// endpoint pins a call to endpoint-1 of ClientConfig.BaseURLs.
+	endpoint int
+
+	// inputSchema and outputSchema hold the capabilities whose schemas
+	// execution payloads and results are checked against; see
+	// WithCapabilitySchemas and WithOutputValidation.
+	inputSchema  *RunnerCapability
+	outputSchema *RunnerCapability
+}
+
+// WithTimeout bounds a single call, including its retries, by d instead of
+// ClientConfig.Timeout. A deadline already set on the caller's context is
+// kept when it is sooner.
+func WithTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) { o.timeout = d }
+}
+
+// WithHeader sets a header on every attempt of a single call, replacing any
+// value the client would send by default.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = http.Header{}
+		}
+		o.headers.Set(key, value)
+	}
+}
+
+// WithQueryParam adds a query parameter to a single call. Parameters added
+// this way are appended to any the method sets itself.
+func WithQueryParam(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.query == nil {
+			o.query = url.Values{}
+		}
+		o.query.Add(key, value)
+	}
+}
+
+// WithClientName identifies the calling component of a shared client by
+// appending "name/version" to the User-Agent header of a single call, after
+// ClientConfig.UserAgentSuffix. version may be empty.
+func WithClientName(name, version string) RequestOption {
+	return func(o *requestOptions) {
+		o.clientName = name
+		if version != "" {
+			o.clientName += "/" + version
+		}
+	}
+}
+
+// WithIdempotencyKey sends key in the Idempotency-Key header. The same key is
+// sent on every retry, so the server performs the operation at most once. If
+// the server answers 409 Conflict with the resource created by an earlier
+// use of the key, the call returns that resource instead of an error.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) { o.idempotencyKey = key }
+}
+
+// WithCache serves a GET from an in-memory cache for ttl after it was last
+// fetched, keyed by the method, path and sorted query parameters. Any
+// mutating call on the same top-level resource, such as a POST to
+// /v1/runners, drops the cached responses under it. The cache is separate
+// from ClientConfig.Cache and needs no ETags from the server.
+func WithCache(ttl time.Duration) RequestOption {
+	return func(o *requestOptions) { o.cacheTTL = ttl }
+}
+
+// WithStaleWhileRevalidate lets a WithCache call serve a response up to
+// window past its TTL while it is refetched in the background, so callers
+// never wait on a refresh. A failed refresh is logged and the stale
+// response keeps being served until the window closes.
+func WithStaleWhileRevalidate(window time.Duration) RequestOption {
+	return func(o *requestOptions) { o.staleWindow = window }
+}
+
+// WithoutTimeoutDerivation stops SubmitJob and ExecuteJob from filling in a
+// unset TimeoutMs from the context deadline, so the server applies its own
+// default.
+func WithoutTimeoutDerivation() RequestOption {
+	return func(o *requestOptions) { o.noTimeoutDerivation = true }
+}
+
+// WithGetBody lets RequestStream retry by reopening the body for every
+// attempt after the first, e.g. by reopening the file being uploaded.
+func WithGetBody(getBody func() (io.ReadCloser, error)) RequestOption {
+	return func(o *requestOptions) { o.getBody = getBody }
+}
+
+// withUnlimitedBody exempts a streaming call from the whole-body limit.
+func withUnlimitedBody() RequestOption {
+	return func(o *requestOptions) { o.unlimitedBody = true }
+}
+
+// withEndpoint sends every attempt of a call to the i-th endpoint, e.g. to
+// probe its health, without retries or failover.
+func withEndpoint(i int) RequestOption {
+	return func(o *requestOptions) { o.endpoint = i + 1 }
+}
+
+// header returns the extra headers the options add to every attempt.
+func (o requestOptions) header() http.Header {
+	h := o.headers.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	if o.idempotencyKey != "" {
+		h.Set("Idempotency-Key", o.idempotencyKey)
+	}
+	if o.consistency != "" {
+		h.Set(ConsistencyHeader, o.consistency)
+	}
+	return h
+}
+
+func (c *ControlPlaneClient) applyOptions(opts []RequestOption) requestOptions {
+	o := requestOptions{timeout: c.config.Timeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// withDeadline bounds ctx by the timeout, unless ctx already has a sooner
+// deadline as measured by clock.
+func (o requestOptions) withDeadline(ctx context.Context, clock Clock) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(clock.Now()) <= o.timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// cancelOnClose releases a request's deadline once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,102 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.Write([]byte(`{"id":"job-1","status":"pending"}`))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithTimeoutOverridesClientTimeout(t *testing.T) {
+	srv := slowServer(t, 300*time.Millisecond)
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Timeout: time.Minute})
+
+	start := time.Now()
+	_, err := client.GetJob(context.Background(), "job-1", WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("call took %v", elapsed)
+	}
+
+	// The shared client is unchanged.
+	if _, err := client.GetJob(context.Background(), "job-1", WithTimeout(5*time.Second)); err != nil {
+		t.Errorf("longer timeout: %v", err)
+	}
+}
+
+func TestSubmitJobUsesJobTimeout(t *testing.T) {
+	srv := slowServer(t, 300*time.Millisecond)
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Timeout: time.Minute})
+
+	job := JobRequest{Id: "job-1", Type: "noop", TimeoutMs: Float(50)}
+	if _, err := client.SubmitJob(context.Background(), job); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if _, err := client.SubmitJob(context.Background(), job, WithTimeout(5*time.Second)); err != nil {
+		t.Errorf("explicit WithTimeout should win: %v", err)
+	}
+}
+
+func TestWithTimeoutKeepsSoonerContextDeadline(t *testing.T) {
+	srv := slowServer(t, 300*time.Millisecond)
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetJob(ctx, "job-1", WithTimeout(5*time.Second))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("caller deadline not honored: call took %v", elapsed)
+	}
+}
+
+func TestWithHeaderAndQueryParam(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "key"})
+
+	resp, err := client.Request(context.Background(), http.MethodGet, "/v1/jobs?limit=5", nil,
+		WithHeader("X-Tenant", "acme"),
+		WithHeader("Content-Type", "application/merge-patch+json"),
+		WithQueryParam("status", "running"),
+		WithQueryParam("status", "pending"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Header.Get("X-Tenant") != "acme" || got.Header.Get("Content-Type") != "application/merge-patch+json" {
+		t.Errorf("headers = %v", got.Header)
+	}
+	if got.Header.Get("Authorization") != "Bearer key" {
+		t.Error("default headers dropped")
+	}
+	if got.URL.RawQuery != "limit=5&status=running&status=pending" {
+		t.Errorf("query = %q", got.URL.RawQuery)
+	}
+}
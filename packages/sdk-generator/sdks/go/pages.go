@@ -0,0 +1,227 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxItems caps the number of items a ListAll method collects unless
+// WithMaxItems is passed.
+const DefaultMaxItems = 10000
+
+// listAllPageSize is the page size ListAll methods request, the contract's
+// maximum.
+const listAllPageSize = 1000
+
+// Page is one page of a paginated listing.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// nextPage returns the request for the page after p, preferring the cursor
+// when the server sent one and otherwise advancing the offset past p's items.
+func (p *Page[T]) nextPage(prev PaginatedRequest) (PaginatedRequest, bool) {
+	return nextPageRequest(prev, p.NextCursor, len(p.Items), p.HasMore)
+}
+
+// NextRequest returns the request for the page after m, preferring the cursor
+// when the server sent one and otherwise advancing the offset past m's items.
+// The bool reports whether there is a next page to fetch.
+func (m PaginatedResponse) NextRequest(prev PaginatedRequest) (PaginatedRequest, bool) {
+	return nextPageRequest(prev, m.NextCursor, len(m.Items), m.HasMore)
+}
+
+// nextPageRequest derives the next page request from prev. A page without a
+// cursor or items cannot be advanced past, so it ends the listing even when
+// hasMore is set.
+func nextPageRequest(prev PaginatedRequest, cursor string, n int, hasMore bool) (PaginatedRequest, bool) {
+	next := prev
+	if cursor != "" {
+		next.Cursor = cursor
+	} else {
+		next.Cursor = ""
+		next.Offset = Int(IntValue(prev.Offset) + n)
+	}
+	return next, hasMore && (cursor != "" || n > 0)
+}
+
+// PageIterator walks a paginated listing one page at a time.
+//
+//	it := client.IterateJobs(filters)
+//	for it.Next(ctx) {
+//		for _, job := range it.Page().Items {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil { ... }
+type PageIterator[T any] struct {
+	fetch func(ctx context.Context, page PaginatedRequest) (*Page[T], error)
+	req   PaginatedRequest
+	page  *Page[T]
+	done  bool
+	err   error
+}
+
+func newPageIterator[T any](page PaginatedRequest, fetch func(context.Context, PaginatedRequest) (*Page[T], error)) *PageIterator[T] {
+	return &PageIterator[T]{fetch: fetch, req: page}
+}
+
+// Next fetches the next page, returning false when there are no more pages
+// or a request fails.
+func (it *PageIterator[T]) Next(ctx context.Context) bool {
+	if it.done {
+		return false
+	}
+	if it.page != nil {
+		next, more := it.page.nextPage(it.req)
+		if !more {
+			it.done = true
+			return false
+		}
+		it.req = next
+	}
+	page, err := it.fetch(ctx, it.req)
+	if err != nil {
+		it.err, it.done = err, true
+		return false
+	}
+	it.page = page
+	return true
+}
+
+// Page returns the page fetched by the last call to Next.
+func (it *PageIterator[T]) Page() *Page[T] { return it.page }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PageIterator[T]) Err() error { return it.err }
+
+// ErrTooManyResults is matched by errors.Is when a ListAll method stops at
+// its item cap. See TooManyResultsError.
+var ErrTooManyResults = errors.New("too many results")
+
+// TooManyResultsError is returned by ListAll methods when the listing holds
+// more items than the cap set with WithMaxItems.
+type TooManyResultsError struct {
+	// Limit is the cap that was exceeded.
+	Limit int
+	// Total is the number of items the server reported, or the number
+	// fetched when the server did not report a total.
+	Total int
+}
+
+func (e *TooManyResultsError) Error() string {
+	return fmt.Sprintf("too many results: %d items exceed the limit of %d", e.Total, e.Limit)
+}
+
+// Is reports whether target is ErrTooManyResults.
+func (e *TooManyResultsError) Is(target error) bool { return target == ErrTooManyResults }
+
+// WithMaxItems caps the number of items a ListAll method collects; exceeding
+// it fails with a *TooManyResultsError. Values of zero or less select
+// DefaultMaxItems. Other calls ignore it.
+func WithMaxItems(n int) RequestOption {
+	return func(o *requestOptions) { o.maxItems = n }
+}
+
+// WithProgress registers a callback that ListAll methods invoke after each
+// page with the number of items fetched so far and the total the server
+// reported. Other calls ignore it.
+func WithProgress(fn func(fetched, total int)) RequestOption {
+	return func(o *requestOptions) { o.progress = fn }
+}
+
+// drainPages collects every item from it, enforcing the item cap.
+func drainPages[T any](ctx context.Context, it *PageIterator[T], o requestOptions) ([]T, error) {
+	limit := o.maxItems
+	if limit <= 0 {
+		limit = DefaultMaxItems
+	}
+	var items []T
+	for it.Next(ctx) {
+		page := it.Page()
+		if page.Total > limit {
+			return nil, &TooManyResultsError{Limit: limit, Total: page.Total}
+		}
+		if len(items)+len(page.Items) > limit {
+			return nil, &TooManyResultsError{Limit: limit, Total: len(items) + len(page.Items)}
+		}
+		items = append(items, page.Items...)
+		if o.progress != nil {
+			o.progress(len(items), page.Total)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// listPage fetches one page of path, merging the encoded filter struct with
+// the pagination parameters.
+func listPage[T any](ctx context.Context, c *ControlPlaneClient, path string, filter interface{}, page PaginatedRequest, opts []RequestOption) (*Page[T], error) {
+	path, err := withQuery(path, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	var out Page[T]
+	if err := c.call(ctx, http.MethodGet, path, nil, &out, opts...); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRunners fetches one page of registered runners matching q.
+func (c *ControlPlaneClient) ListRunners(ctx context.Context, q RegistryQuery, page PaginatedRequest, opts ...RequestOption) (*Page[RegisteredRunner], error) {
+	return listPage[RegisteredRunner](ctx, c, "/v1/runners", q, page, opts)
+}
+
+// IterateRunners returns an iterator over the pages of runners matching q.
+func (c *ControlPlaneClient) IterateRunners(q RegistryQuery, page PaginatedRequest, opts ...RequestOption) *PageIterator[RegisteredRunner] {
+	return newPageIterator(page, func(ctx context.Context, p PaginatedRequest) (*Page[RegisteredRunner], error) {
+		return c.ListRunners(ctx, q, p, opts...)
+	})
+}
+
+// ListAllRunners fetches every runner matching q, up to DefaultMaxItems or
+// the cap set with WithMaxItems. See WithProgress to observe progress.
+func (c *ControlPlaneClient) ListAllRunners(ctx context.Context, q RegistryQuery, opts ...RequestOption) ([]RegisteredRunner, error) {
+	it := c.IterateRunners(q, PaginatedRequest{Limit: Int(listAllPageSize)}, opts...)
+	return drainPages(ctx, it, c.applyOptions(opts))
+}
+
+// JobListFilters narrows a job listing. Empty fields match every job.
+type JobListFilters struct {
+	Status        []string  `json:"status,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	Source        string    `json:"source,omitempty"`
+	CorrelationId string    `json:"correlationId,omitempty"`
+	CreatedAfter  time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore time.Time `json:"createdBefore,omitempty"`
+}
+
+// ListJobs fetches one page of jobs matching filters.
+func (c *ControlPlaneClient) ListJobs(ctx context.Context, filters JobListFilters, page PaginatedRequest, opts ...RequestOption) (*Page[JobResponse], error) {
+	return listPage[JobResponse](ctx, c, "/v1/jobs", filters, page, opts)
+}
+
+// IterateJobs returns an iterator over the pages of jobs matching filters.
+func (c *ControlPlaneClient) IterateJobs(filters JobListFilters, page PaginatedRequest, opts ...RequestOption) *PageIterator[JobResponse] {
+	return newPageIterator(page, func(ctx context.Context, p PaginatedRequest) (*Page[JobResponse], error) {
+		return c.ListJobs(ctx, filters, p, opts...)
+	})
+}
+
+// ListAllJobs fetches every job matching filters, up to DefaultMaxItems or
+// the cap set with WithMaxItems. See WithProgress to observe progress.
+func (c *ControlPlaneClient) ListAllJobs(ctx context.Context, filters JobListFilters, opts ...RequestOption) ([]JobResponse, error) {
+	it := c.IterateJobs(filters, PaginatedRequest{Limit: Int(listAllPageSize)}, opts...)
+	return drainPages(ctx, it, c.applyOptions(opts))
+}
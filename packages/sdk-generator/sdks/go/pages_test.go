@@ -0,0 +1,125 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// pagedJobs serves n jobs from /v1/jobs in offset mode, reporting the total
+// unless hideTotal is set.
+func pagedJobs(t *testing.T, n int, hideTotal bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("type") != "csv" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		page := Page[JobResponse]{Limit: limit, Offset: offset, Total: n}
+		if hideTotal {
+			page.Total = 0
+		}
+		for i := offset; i < n && i < offset+limit; i++ {
+			page.Items = append(page.Items, JobResponse{Id: fmt.Sprintf("j%d", i)})
+		}
+		page.HasMore = offset+limit < n
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestListAllJobsDrainsOffsetPages(t *testing.T) {
+	srv := pagedJobs(t, 2500, false)
+	defer srv.Close()
+
+	var progress [][2]int
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	jobs, err := client.ListAllJobs(context.Background(), JobListFilters{Type: "csv"},
+		WithProgress(func(fetched, total int) { progress = append(progress, [2]int{fetched, total}) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2500 || jobs[0].Id != "j0" || jobs[2499].Id != "j2499" {
+		t.Fatalf("got %d jobs", len(jobs))
+	}
+	want := [][2]int{{1000, 2500}, {2000, 2500}, {2500, 2500}}
+	if fmt.Sprint(progress) != fmt.Sprint(want) {
+		t.Errorf("progress = %v", progress)
+	}
+}
+
+func TestListAllRunnersFollowsCursor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/runners" || r.URL.Query().Get("category") != "ops" {
+			t.Errorf("request = %s", r.URL)
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"items":[{"category":"ops"},{"category":"ops"}],"total":3,"hasMore":true,"nextCursor":"c2"}`))
+		case "c2":
+			w.Write([]byte(`{"items":[{"category":"ops"}],"total":3,"hasMore":false}`))
+		default:
+			t.Errorf("cursor = %q", r.URL.Query().Get("cursor"))
+		}
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	runners, err := client.ListAllRunners(context.Background(), RegistryQuery{Category: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runners) != 3 {
+		t.Errorf("got %d runners", len(runners))
+	}
+}
+
+func TestListAllStopsAtCap(t *testing.T) {
+	for _, hideTotal := range []bool{false, true} {
+		srv := pagedJobs(t, 1500, hideTotal)
+		client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+		_, err := client.ListAllJobs(context.Background(), JobListFilters{Type: "csv"}, WithMaxItems(1200))
+		srv.Close()
+
+		var tooMany *TooManyResultsError
+		if !errors.Is(err, ErrTooManyResults) || !errors.As(err, &tooMany) || tooMany.Limit != 1200 {
+			t.Errorf("hideTotal=%v: err = %v", hideTotal, err)
+		}
+	}
+}
+
+func TestPaginatedResponseNextRequestOffset(t *testing.T) {
+	resp := PaginatedResponse{Items: []interface{}{1, 2, 3}, Total: 5, Limit: 3, Offset: 0, HasMore: true}
+	if err := resp.Validate(); err != nil {
+		t.Fatalf("first page rejected: %v", err)
+	}
+	prev := PaginatedRequest{Limit: Int(3), SortBy: "createdAt"}
+	next, more := resp.NextRequest(prev)
+	if !more || IntValue(next.Offset) != 3 || IntValue(next.Limit) != 3 || next.SortBy != "createdAt" || next.Cursor != "" {
+		t.Fatalf("next = %+v, more = %v", next, more)
+	}
+
+	last := PaginatedResponse{Items: []interface{}{4, 5}, Total: 5, Limit: 3, Offset: 3}
+	if _, more := last.NextRequest(next); more {
+		t.Fatal("last page reported more")
+	}
+}
+
+func TestPaginatedResponseNextRequestCursor(t *testing.T) {
+	resp := PaginatedResponse{Items: []interface{}{1}, Total: 2, Limit: 1, HasMore: true, NextCursor: "c2"}
+	next, more := resp.NextRequest(PaginatedRequest{Limit: Int(1), Offset: Int(4), Cursor: "c1"})
+	if !more || next.Cursor != "c2" || IntValue(next.Offset) != 4 {
+		t.Fatalf("next = %+v, more = %v", next, more)
+	}
+
+	// An empty page without a cursor cannot be advanced past.
+	stuck := PaginatedResponse{Total: 2, Limit: 1, HasMore: true}
+	if _, more := stuck.NextRequest(next); more {
+		t.Fatal("empty page without cursor reported more")
+	}
+}
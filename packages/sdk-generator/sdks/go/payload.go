@@ -0,0 +1,53 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultMaxPayloadBytes is the largest JSON-encoded JobRequest payload
+// accepted by Validate and, unless configured otherwise, by
+// JobRequestBuilder.Build and SubmitJob. Larger payloads fail locally
+// instead of being sent only to be rejected by the server.
+const DefaultMaxPayloadBytes = 4 << 20
+
+func init() {
+	registerRule("JobRequest", func(m JobRequest, errs *ValidationErrors) {
+		checkPayloadSize(m.Payload, DefaultMaxPayloadBytes, errs)
+	})
+}
+
+// payloadLimit resolves a configured payload limit: zero selects
+// DefaultMaxPayloadBytes and negative disables the check.
+func payloadLimit(n int) int {
+	if n == 0 {
+		return DefaultMaxPayloadBytes
+	}
+	return n
+}
+
+// checkPayloadSize reports a payload whose JSON encoding is longer than
+// limit bytes. A negative limit disables the check.
+func checkPayloadSize(payload map[string]interface{}, limit int, errs *ValidationErrors) {
+	if limit < 0 || payload == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		// Reported when the request body is encoded.
+		return
+	}
+	if len(data) > limit {
+		errs.Add("payload", fmt.Sprintf("is %d bytes, more than the %d-byte limit", len(data), limit))
+	}
+}
+
+// checkPayload checks job's payload against ClientConfig.MaxPayloadBytes.
+func (c *ControlPlaneClient) checkPayload(job JobRequest) error {
+	var errs ValidationErrors
+	checkPayloadSize(job.Payload, payloadLimit(c.config.MaxPayloadBytes), &errs)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
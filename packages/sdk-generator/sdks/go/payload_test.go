@@ -0,0 +1,95 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// sizedPayload returns a valid payload whose JSON encoding is exactly n
+// bytes.
+func sizedPayload(n int) map[string]interface{} {
+	// {"data":{"d":""},"type":"t"} is 28 bytes.
+	return map[string]interface{}{"type": "t", "data": map[string]interface{}{"d": strings.Repeat("x", n-28)}}
+}
+
+func payloadError(err error) string {
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		return ""
+	}
+	for _, e := range verrs.Errors {
+		if e.Field == "payload" {
+			return e.Message
+		}
+	}
+	return ""
+}
+
+func TestValidateJobRequestPayloadSize(t *testing.T) {
+	job := JobRequest{Id: "job-1", Type: "csv.import", Payload: sizedPayload(DefaultMaxPayloadBytes)}
+	if err := job.Validate(); err != nil {
+		t.Fatalf("payload at the limit: %v", err)
+	}
+
+	job.Payload = sizedPayload(DefaultMaxPayloadBytes + 1)
+	want := "is 4194305 bytes, more than the 4194304-byte limit"
+	if got := payloadError(job.Validate()); got != want {
+		t.Errorf("payload over the limit: message = %q, want %q", got, want)
+	}
+}
+
+func TestJobRequestBuilderMaxPayloadBytes(t *testing.T) {
+	meta := JobMetadata{Source: "billing-worker"}
+	data := map[string]interface{}{"url": strings.Repeat("x", 100)}
+	job, err := NewJobRequestBuilder("csv.import").WithMetadata(meta).WithPayload(data).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(job.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := len(encoded)
+
+	if _, err := NewJobRequestBuilder("csv.import").WithMetadata(meta).WithPayload(data).WithMaxPayloadBytes(size).Build(); err != nil {
+		t.Errorf("payload at the limit: %v", err)
+	}
+	_, err = NewJobRequestBuilder("csv.import").WithMetadata(meta).WithPayload(data).WithMaxPayloadBytes(size - 1).Build()
+	if got := payloadError(err); !strings.HasPrefix(got, "is ") || !strings.Contains(got, "more than the") {
+		t.Errorf("payload over the limit: err = %v", err)
+	}
+
+	// A limit above the default is not undercut by Validate.
+	big := map[string]interface{}{"blob": strings.Repeat("x", DefaultMaxPayloadBytes)}
+	if _, err := NewJobRequestBuilder("csv.import").WithMetadata(meta).WithPayload(big).WithMaxPayloadBytes(2 * DefaultMaxPayloadBytes).Build(); err != nil {
+		t.Errorf("raised limit: %v", err)
+	}
+	if _, err := NewJobRequestBuilder("csv.import").WithMetadata(meta).WithPayload(big).WithMaxPayloadBytes(-1).Build(); err != nil {
+		t.Errorf("disabled limit: %v", err)
+	}
+}
+
+func TestSubmitJobRejectsOversizedPayload(t *testing.T) {
+	var calls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jobId":"job-1","status":"queued"}`))
+	})
+	client := mustNewClient(t, ClientConfig{BaseURL: testBaseURL, Transport: HandlerTransport(handler), MaxPayloadBytes: 64})
+
+	if _, err := client.SubmitJob(context.Background(), JobRequest{Id: "job-1", Type: "t", Payload: sizedPayload(64)}); err != nil {
+		t.Fatalf("payload at the limit: %v", err)
+	}
+	_, err := client.SubmitJob(context.Background(), JobRequest{Id: "job-2", Type: "t", Payload: sizedPayload(65)})
+	if got, want := payloadError(err), "is 65 bytes, more than the 64-byte limit"; got != want {
+		t.Errorf("payload over the limit: message = %q, want %q", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("requests = %d, want 1", calls)
+	}
+}
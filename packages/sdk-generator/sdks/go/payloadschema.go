@@ -0,0 +1,637 @@
+package controlplane
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// SchemaCompiler compiles the JSON Schemas of runner capabilities, module
+// manifests and connectors. The SDK uses a built-in compiler by default;
+// ClientConfig.SchemaCompiler selects another for a client's requests, such
+// as the complete implementation in the jsonschema module.
+type SchemaCompiler interface {
+	Compile(schema map[string]interface{}) (CompiledSchema, error)
+}
+
+// CompiledSchema validates documents against one compiled schema. Validate
+// receives a document in its decoded JSON form and reports failures as a
+// *ValidationErrors whose fields are JSON pointers into the document, such
+// as "/rows/3/amount"; the document itself is "".
+type CompiledSchema interface {
+	Validate(doc interface{}) error
+}
+
+// DefaultSchemaCacheSize is the number of compiled schemas a client keeps
+// when ClientConfig.SchemaCacheSize is zero.
+const DefaultSchemaCacheSize = 256
+
+// defaultSchemas compiles the schemas used outside a client: those of
+// RunnerCapability.ValidateInput and ValidateOutput, ModuleManifest and
+// ConnectorConfig.
+var defaultSchemas = newSchemaCache(builtinSchemaCompiler{}, DefaultSchemaCacheSize, RealClock())
+
+// schemaCache compiles schemas and keeps the most recently used, keyed by
+// their JSON form, so a schema is compiled once however often it is used.
+type schemaCache struct {
+	compiler SchemaCompiler
+	// clock stamps the envelopes of OutputMismatchErrors.
+	clock Clock
+	// size is the number of schemas kept; zero keeps none.
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	recent  *list.List
+}
+
+type schemaEntry struct {
+	key      string
+	compiled CompiledSchema
+}
+
+// newSchemaCache returns a cache of size schemas compiled by compiler,
+// stamping envelopes with clock. A nil compiler selects the built-in
+// compiler, a zero size DefaultSchemaCacheSize and a negative size disables
+// caching.
+func newSchemaCache(compiler SchemaCompiler, size int, clock Clock) *schemaCache {
+	if compiler == nil {
+		compiler = builtinSchemaCompiler{}
+	}
+	if size == 0 {
+		size = DefaultSchemaCacheSize
+	}
+	if size < 0 {
+		size = 0
+	}
+	return &schemaCache{compiler: compiler, clock: clock, size: size, entries: map[string]*list.Element{}, recent: list.New()}
+}
+
+func (c *schemaCache) get(schema map[string]interface{}) (CompiledSchema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	key := string(data)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.recent.MoveToFront(e)
+		return e.Value.(*schemaEntry).compiled, nil
+	}
+	compiled, err := c.compiler.Compile(schema)
+	if err != nil || c.size == 0 {
+		return compiled, err
+	}
+	c.entries[key] = c.recent.PushFront(&schemaEntry{key: key, compiled: compiled})
+	if c.recent.Len() > c.size {
+		oldest := c.recent.Remove(c.recent.Back()).(*schemaEntry)
+		delete(c.entries, oldest.key)
+	}
+	return compiled, nil
+}
+
+// validate checks doc against schema. A nil schema accepts any document.
+func (c *schemaCache) validate(schema map[string]interface{}, doc interface{}) error {
+	if schema == nil {
+		return nil
+	}
+	compiled, err := c.get(schema)
+	if err != nil {
+		return fmt.Errorf("compile schema: %w", err)
+	}
+	// Documents built in Go may hold []string, int and the like; validators
+	// see the types encoding/json decodes.
+	var decoded interface{}
+	if err := decodeMap(doc, &decoded); err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+	return compiled.Validate(decoded)
+}
+
+func (c *schemaCache) validateInput(m RunnerCapability, payload map[string]interface{}) error {
+	if err := c.validate(m.InputSchema, payload); err != nil {
+		if _, ok := err.(*ValidationErrors); ok {
+			return err
+		}
+		return fmt.Errorf("capability %s inputSchema: %w", m.Id, err)
+	}
+	return nil
+}
+
+func (c *schemaCache) validateOutput(m RunnerCapability, data interface{}) error {
+	if err := c.validate(m.OutputSchema, data); err != nil {
+		if _, ok := err.(*ValidationErrors); ok {
+			return err
+		}
+		return fmt.Errorf("capability %s outputSchema: %w", m.Id, err)
+	}
+	return nil
+}
+
+// ValidateInput checks payload against the capability's InputSchema. A
+// capability without one accepts any payload. Failures are returned as a
+// *ValidationErrors with JSON pointer fields; a schema that does not
+// compile is reported as a plain error.
+func (m RunnerCapability) ValidateInput(payload map[string]interface{}) error {
+	return defaultSchemas.validateInput(m, payload)
+}
+
+// ValidateOutput checks the data of an execution response against the
+// capability's OutputSchema, like ValidateInput.
+func (m RunnerCapability) ValidateOutput(data interface{}) error {
+	return defaultSchemas.validateOutput(m, data)
+}
+
+// ErrCodeOutputSchemaMismatch is the error envelope code of an
+// OutputMismatchError.
+const ErrCodeOutputSchemaMismatch = "OUTPUT_SCHEMA_MISMATCH"
+
+// OutputMismatchError reports a successful execution response whose data
+// does not match the capability's OutputSchema. Envelope is a
+// SCHEMA_MISMATCH envelope with a detail for each failing element, and
+// Errs holds the same failures with JSON pointer fields.
+type OutputMismatchError struct {
+	Envelope ErrorEnvelope
+	Errs     *ValidationErrors
+	Response *RunnerExecutionResponse
+}
+
+func (e *OutputMismatchError) Error() string {
+	return e.Envelope.Code + ": " + e.Envelope.Message
+}
+
+// Unwrap returns Errs.
+func (e *OutputMismatchError) Unwrap() error { return e.Errs }
+
+// ValidateResponse checks the data of a successful response against the
+// capability's OutputSchema, returning an *OutputMismatchError when it does
+// not match. Unsuccessful responses carry no data to check.
+func (m RunnerCapability) ValidateResponse(resp RunnerExecutionResponse) error {
+	return defaultSchemas.validateResponse(m, resp)
+}
+
+func (c *schemaCache) validateResponse(m RunnerCapability, resp RunnerExecutionResponse) error {
+	if !resp.Success {
+		return nil
+	}
+	err := c.validateOutput(m, resp.Data)
+	verrs, ok := err.(*ValidationErrors)
+	if !ok {
+		return err
+	}
+	message := fmt.Sprintf("response data does not match the output schema of capability %s: %s", m.Id, verrs.Errors[0].Error())
+	if n := len(verrs.Errors); n > 1 {
+		message += fmt.Sprintf(" (and %d more)", n-1)
+	}
+	env := NewErrorEnvelope(ErrorCategorySCHEMA_MISMATCH, ErrCodeOutputSchemaMismatch, message, resp.RunnerId,
+		WithDetails(verrs.ToErrorDetails()...), WithTimestamp(c.clock.Now()))
+	env.Operation = "execute"
+	return &OutputMismatchError{Envelope: env, Errs: verrs, Response: &resp}
+}
+
+// ValidateCapabilityInput is RunnerCapability.ValidateInput with the
+// client's SchemaCompiler and schema cache.
+func (c *ControlPlaneClient) ValidateCapabilityInput(capability RunnerCapability, payload map[string]interface{}) error {
+	return c.schemas.validateInput(capability, payload)
+}
+
+// ValidateCapabilityResponse is RunnerCapability.ValidateResponse with the
+// client's SchemaCompiler and schema cache.
+func (c *ControlPlaneClient) ValidateCapabilityResponse(capability RunnerCapability, resp RunnerExecutionResponse) error {
+	return c.schemas.validateResponse(capability, resp)
+}
+
+// WithCapabilitySchemas makes ExecuteJob and ExecuteStreaming check the
+// request payload against the capability's InputSchema before sending it,
+// failing with its *ValidationErrors, and ExecuteJob validate the response
+// as WithOutputValidation does.
+func WithCapabilitySchemas(capability RunnerCapability) RequestOption {
+	return func(o *requestOptions) {
+		o.inputSchema = &capability
+		o.outputSchema = &capability
+	}
+}
+
+// WithOutputValidation makes ExecuteJob check the data of a successful
+// response against the capability's OutputSchema, failing with an
+// *OutputMismatchError when it does not match.
+func WithOutputValidation(capability RunnerCapability) RequestOption {
+	return func(o *requestOptions) {
+		o.outputSchema = &capability
+	}
+}
+
+// builtinSchemaCompiler supports the JSON Schema keywords capabilities
+// commonly use: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, allOf,
+// anyOf, oneOf, not and $ref to a location in the same schema. Other
+// keywords are ignored.
+type builtinSchemaCompiler struct{}
+
+func (builtinSchemaCompiler) Compile(schema map[string]interface{}) (CompiledSchema, error) {
+	c := &nodeCompiler{root: schema, refs: map[string]*schemaNode{}}
+	root, err := c.compile(schema, "#")
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// schemaNode is one compiled schema or subschema.
+type schemaNode struct {
+	// never is set for the false schema, which matches nothing.
+	never bool
+
+	types      []string
+	enum       []interface{}
+	constValue interface{}
+	hasConst   bool
+
+	required             []string
+	properties           map[string]*schemaNode
+	propertyNames        []string
+	additionalProperties *schemaNode
+
+	items              *schemaNode
+	minItems, maxItems *int
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+
+	allOf, anyOf, oneOf []*schemaNode
+	not                 *schemaNode
+	ref                 *schemaNode
+}
+
+type nodeCompiler struct {
+	root map[string]interface{}
+	// refs holds the nodes compiled for each $ref target, so recursive
+	// schemas compile once.
+	refs map[string]*schemaNode
+}
+
+func (c *nodeCompiler) compile(raw interface{}, loc string) (*schemaNode, error) {
+	switch s := raw.(type) {
+	case bool:
+		return &schemaNode{never: !s}, nil
+	case map[string]interface{}:
+		return c.compileObject(s, loc)
+	}
+	return nil, fmt.Errorf("%s: schema must be an object or a boolean", loc)
+}
+
+func (c *nodeCompiler) compileObject(s map[string]interface{}, loc string) (*schemaNode, error) {
+	n := &schemaNode{types: schemaTypes(s["type"])}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		n.enum = enum
+	}
+	n.constValue, n.hasConst = s["const"]
+	for _, r := range asSlice(s["required"]) {
+		if name, ok := r.(string); ok {
+			n.required = append(n.required, name)
+		}
+	}
+
+	var err error
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		n.properties = make(map[string]*schemaNode, len(props))
+		for name, sub := range props {
+			if n.properties[name], err = c.compile(sub, loc+"/properties/"+escapePointer(name)); err != nil {
+				return nil, err
+			}
+			n.propertyNames = append(n.propertyNames, name)
+		}
+		sort.Strings(n.propertyNames)
+	}
+	if n.additionalProperties, err = c.compileOptional(s, "additionalProperties", loc); err != nil {
+		return nil, err
+	}
+	if n.items, err = c.compileOptional(s, "items", loc); err != nil {
+		return nil, err
+	}
+	if n.not, err = c.compileOptional(s, "not", loc); err != nil {
+		return nil, err
+	}
+	for keyword, dst := range map[string]*[]*schemaNode{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf} {
+		for i, sub := range asSlice(s[keyword]) {
+			node, err := c.compile(sub, fmt.Sprintf("%s/%s/%d", loc, keyword, i))
+			if err != nil {
+				return nil, err
+			}
+			*dst = append(*dst, node)
+		}
+	}
+
+	n.minItems, n.maxItems = schemaInt(s["minItems"]), schemaInt(s["maxItems"])
+	n.minLength, n.maxLength = schemaInt(s["minLength"]), schemaInt(s["maxLength"])
+	n.minimum, n.maximum = schemaFloat(s["minimum"]), schemaFloat(s["maximum"])
+	n.exclusiveMinimum, n.exclusiveMaximum = schemaFloat(s["exclusiveMinimum"]), schemaFloat(s["exclusiveMaximum"])
+	if p, ok := s["pattern"].(string); ok {
+		if n.pattern, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("%s/pattern: %w", loc, err)
+		}
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		if n.ref, err = c.resolve(ref); err != nil {
+			return nil, fmt.Errorf("%s/$ref: %w", loc, err)
+		}
+	}
+	return n, nil
+}
+
+func (c *nodeCompiler) compileOptional(s map[string]interface{}, keyword, loc string) (*schemaNode, error) {
+	sub, ok := s[keyword]
+	if !ok {
+		return nil, nil
+	}
+	return c.compile(sub, loc+"/"+keyword)
+}
+
+// resolve compiles the target of a $ref, which must be a JSON pointer
+// fragment into the root schema such as "#/$defs/row".
+func (c *nodeCompiler) resolve(ref string) (*schemaNode, error) {
+	if n, ok := c.refs[ref]; ok {
+		return n, nil
+	}
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q: only local references are resolved", ref)
+	}
+	var target interface{} = c.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		obj, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("reference %q not found", ref)
+		}
+		if target, ok = obj[unescapePointer(token)]; !ok {
+			return nil, fmt.Errorf("reference %q not found", ref)
+		}
+	}
+	// Register the node before compiling the target, which may refer back
+	// to it.
+	n := &schemaNode{}
+	c.refs[ref] = n
+	compiled, err := c.compile(target, ref)
+	if err != nil {
+		return nil, err
+	}
+	*n = *compiled
+	return n, nil
+}
+
+// Validate implements CompiledSchema.
+func (n *schemaNode) Validate(doc interface{}) error {
+	var errs ValidationErrors
+	n.validate(doc, "", &errs, 0)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// maxSchemaDepth stops a recursive schema from recursing without end on a
+// document it does not constrain.
+const maxSchemaDepth = 64
+
+func (n *schemaNode) validate(v interface{}, ptr string, errs *ValidationErrors, depth int) {
+	if depth > maxSchemaDepth {
+		errs.Add(ptr, "is nested too deeply")
+		return
+	}
+	if n.never {
+		errs.Add(ptr, "is not allowed")
+		return
+	}
+	if n.ref != nil {
+		n.ref.validate(v, ptr, errs, depth+1)
+	}
+	if len(n.types) > 0 && !matchesAnyType(v, n.types) {
+		errs.Add(ptr, fmt.Sprintf("must be of type %s", strings.Join(n.types, " or ")))
+		return
+	}
+	if n.enum != nil && !inEnum(v, n.enum) {
+		errs.Add(ptr, fmt.Sprintf("must be one of %s", formatEnum(n.enum)))
+	}
+	if n.hasConst && !inEnum(v, []interface{}{n.constValue}) {
+		errs.Add(ptr, fmt.Sprintf("must be %v", n.constValue))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n.validateObject(v, ptr, errs, depth)
+	case []interface{}:
+		if n.minItems != nil && len(v) < *n.minItems {
+			errs.Add(ptr, fmt.Sprintf("must have at least %d items", *n.minItems))
+		}
+		if n.maxItems != nil && len(v) > *n.maxItems {
+			errs.Add(ptr, fmt.Sprintf("must have at most %d items", *n.maxItems))
+		}
+		if n.items != nil {
+			for i, item := range v {
+				n.items.validate(item, fmt.Sprintf("%s/%d", ptr, i), errs, depth+1)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			errs.Add(ptr, fmt.Sprintf("must be at least %d characters", *n.minLength))
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			errs.Add(ptr, fmt.Sprintf("must be at most %d characters", *n.maxLength))
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			errs.Add(ptr, fmt.Sprintf("must match pattern %q", n.pattern.String()))
+		}
+	case float64:
+		n.validateNumber(v, ptr, errs)
+	}
+
+	for _, sub := range n.allOf {
+		sub.validate(v, ptr, errs, depth+1)
+	}
+	if len(n.anyOf) > 0 && n.countMatches(n.anyOf, v, depth) == 0 {
+		errs.Add(ptr, "must match at least one schema in anyOf")
+	}
+	if len(n.oneOf) > 0 {
+		if matched := n.countMatches(n.oneOf, v, depth); matched != 1 {
+			errs.Add(ptr, fmt.Sprintf("must match exactly one schema in oneOf, matched %d", matched))
+		}
+	}
+	if n.not != nil && n.countMatches([]*schemaNode{n.not}, v, depth) == 1 {
+		errs.Add(ptr, "must not match the schema in not")
+	}
+}
+
+func (n *schemaNode) validateObject(v map[string]interface{}, ptr string, errs *ValidationErrors, depth int) {
+	for _, name := range n.required {
+		if _, ok := v[name]; !ok {
+			errs.Add(ptr+"/"+escapePointer(name), "is required")
+		}
+	}
+	for _, name := range n.propertyNames {
+		if val, ok := v[name]; ok {
+			n.properties[name].validate(val, ptr+"/"+escapePointer(name), errs, depth+1)
+		}
+	}
+	if n.additionalProperties == nil {
+		return
+	}
+	extra := make([]string, 0, len(v))
+	for name := range v {
+		if _, declared := n.properties[name]; !declared {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		n.additionalProperties.validate(v[name], ptr+"/"+escapePointer(name), errs, depth+1)
+	}
+}
+
+func (n *schemaNode) validateNumber(v float64, ptr string, errs *ValidationErrors) {
+	if n.minimum != nil && v < *n.minimum {
+		errs.Add(ptr, fmt.Sprintf("must be at least %v", *n.minimum))
+	}
+	if n.maximum != nil && v > *n.maximum {
+		errs.Add(ptr, fmt.Sprintf("must be at most %v", *n.maximum))
+	}
+	if n.exclusiveMinimum != nil && v <= *n.exclusiveMinimum {
+		errs.Add(ptr, fmt.Sprintf("must be greater than %v", *n.exclusiveMinimum))
+	}
+	if n.exclusiveMaximum != nil && v >= *n.exclusiveMaximum {
+		errs.Add(ptr, fmt.Sprintf("must be less than %v", *n.exclusiveMaximum))
+	}
+}
+
+// countMatches returns how many of the schemas v is valid against.
+func (n *schemaNode) countMatches(subs []*schemaNode, v interface{}, depth int) int {
+	matched := 0
+	for _, sub := range subs {
+		var errs ValidationErrors
+		sub.validate(v, "", &errs, depth+1)
+		if errs.IsValid() {
+			matched++
+		}
+	}
+	return matched
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// schemaInt reads a non-negative integer keyword.
+func schemaInt(v interface{}) *int {
+	f, ok := toFloat(v)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil
+	}
+	n := int(f)
+	return &n
+}
+
+func schemaFloat(v interface{}) *float64 {
+	f, ok := toFloat(v)
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// escapePointer escapes a JSON pointer reference token.
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func unescapePointer(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
+
+// schemaTypes returns the type keyword as a list; it may be a string or an
+// array of strings.
+func schemaTypes(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	// Unknown types are not enforced.
+	return true
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if ef, ok := toFloat(e); ok {
+			if vf, ok := toFloat(value); ok && ef == vf {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = fmt.Sprintf("%v", e)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
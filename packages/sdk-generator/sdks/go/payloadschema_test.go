@@ -0,0 +1,226 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+var importCapability = RunnerCapability{
+	Id: "csv.import",
+	InputSchema: map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"bucket", "rows"},
+		"properties": map[string]interface{}{
+			"bucket": map[string]interface{}{"type": "string", "pattern": "^s3://"},
+			"mode":   map[string]interface{}{"enum": []interface{}{"append", "replace"}},
+			"rows": map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items":    map[string]interface{}{"$ref": "#/$defs/row"},
+			},
+		},
+		"$defs": map[string]interface{}{
+			"row": map[string]interface{}{
+				"type":                 "object",
+				"required":             []interface{}{"amount"},
+				"properties":           map[string]interface{}{"amount": map[string]interface{}{"type": "number", "minimum": 0}},
+				"additionalProperties": false,
+			},
+		},
+	},
+	OutputSchema: map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer"},
+			map[string]interface{}{"type": "string", "maxLength": 3},
+		},
+	},
+}
+
+func TestValidateInput(t *testing.T) {
+	if err := importCapability.ValidateInput(map[string]interface{}{
+		"bucket": "s3://imports",
+		"rows":   []map[string]interface{}{{"amount": 3}},
+	}); err != nil {
+		t.Fatalf("valid payload: %v", err)
+	}
+
+	err := importCapability.ValidateInput(map[string]interface{}{
+		"bucket": "gs://imports",
+		"mode":   "merge",
+		"rows":   []interface{}{map[string]interface{}{"amount": -1}, map[string]interface{}{"amount": 1, "a/b": true}},
+	})
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("err = %v", err)
+	}
+	want := []string{"/bucket", "/mode", "/rows/0/amount", "/rows/1/a~1b"}
+	if !reflect.DeepEqual(verrs.Fields(), want) {
+		t.Errorf("fields = %v, want %v (%v)", verrs.Fields(), want, err)
+	}
+
+	err = importCapability.ValidateInput(map[string]interface{}{"rows": []interface{}{}})
+	assertFields(t, err, "/bucket", "/rows")
+	if (RunnerCapability{}).ValidateInput(map[string]interface{}{"any": 1}) != nil {
+		t.Error("capability without a schema rejected a payload")
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	for data, valid := range map[interface{}]bool{7: true, "abc": true, "abcd": false, 1.5: false, true: false} {
+		if err := importCapability.ValidateOutput(data); (err == nil) != valid {
+			t.Errorf("%v: err = %v", data, err)
+		}
+	}
+}
+
+func TestBuiltinCompilerErrors(t *testing.T) {
+	for _, schema := range []map[string]interface{}{
+		{"pattern": "("},
+		{"$ref": "#/$defs/missing"},
+		{"$ref": "https://example.com/schema.json"},
+		{"items": "nope"},
+	} {
+		if _, err := (builtinSchemaCompiler{}).Compile(schema); err == nil {
+			t.Errorf("%v compiled", schema)
+		}
+	}
+
+	recursive := RunnerCapability{InputSchema: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"child": map[string]interface{}{"$ref": "#"}, "n": map[string]interface{}{"type": "integer"}},
+	}}
+	err := recursive.ValidateInput(map[string]interface{}{"child": map[string]interface{}{"child": map[string]interface{}{"n": "x"}}})
+	assertFields(t, err, "/child/child/n")
+
+}
+
+// countingCompiler counts compilations and delegates to the built-in
+// compiler.
+type countingCompiler struct{ compiles int }
+
+func (c *countingCompiler) Compile(schema map[string]interface{}) (CompiledSchema, error) {
+	c.compiles++
+	return builtinSchemaCompiler{}.Compile(schema)
+}
+
+func TestClientSchemaCompiler(t *testing.T) {
+	compiler := &countingCompiler{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:        testBaseURL,
+		SchemaCompiler: compiler,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"jobId":"j1","success":true,"data":7,"executionTimeMs":5,"runnerId":"r1"}`)
+		})),
+	})
+	payload := map[string]interface{}{"bucket": "s3://imports", "rows": []interface{}{map[string]interface{}{"amount": 1}}}
+	for i := 0; i < 3; i++ {
+		req := RunnerExecutionRequest{JobId: "j1", Payload: payload}
+		if _, err := client.ExecuteJob(context.Background(), "r1", req, WithCapabilitySchemas(importCapability.DeepCopy())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if compiler.compiles != 2 {
+		t.Errorf("compiled %d times, want once per schema", compiler.compiles)
+	}
+	importCapability.ValidateOutput(1)
+	if compiler.compiles != 2 {
+		t.Error("capability validation outside the client used the client's compiler")
+	}
+}
+
+func TestSchemaCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	compiler := &countingCompiler{}
+	cache := newSchemaCache(compiler, 2, RealClock())
+	a := map[string]interface{}{"type": "string"}
+	b := map[string]interface{}{"type": "integer"}
+	c := map[string]interface{}{"type": "boolean"}
+	for _, schema := range []map[string]interface{}{a, b, a, c, a, b} {
+		if _, err := cache.get(schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// b is evicted by c, as a was used more recently.
+	if compiler.compiles != 4 || cache.recent.Len() != 2 {
+		t.Errorf("compiles = %d, cached = %d", compiler.compiles, cache.recent.Len())
+	}
+
+	uncached := newSchemaCache(compiler, -1, RealClock())
+	uncached.get(a)
+	uncached.get(a)
+	if compiler.compiles != 6 || uncached.recent.Len() != 0 {
+		t.Errorf("uncached: compiles = %d", compiler.compiles)
+	}
+}
+
+func TestExecuteJobWithCapabilitySchemas(t *testing.T) {
+	requests := 0
+	client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"jobId":"j1","success":true,"data":"too long","executionTimeMs":5,"runnerId":"r1"}`)
+	}))
+	ctx := context.Background()
+
+	_, err := client.ExecuteJob(ctx, "r1", RunnerExecutionRequest{JobId: "j1", Payload: map[string]interface{}{}}, WithCapabilitySchemas(importCapability))
+	if !errors.Is(err, ErrValidation) || requests != 0 {
+		t.Errorf("invalid payload: err = %v, requests = %d", err, requests)
+	}
+
+	payload := map[string]interface{}{"bucket": "s3://imports", "rows": []interface{}{map[string]interface{}{"amount": 1}}}
+	_, err = client.ExecuteJob(ctx, "r1", RunnerExecutionRequest{JobId: "j1", Payload: payload}, WithCapabilitySchemas(importCapability))
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || requests != 1 {
+		t.Errorf("invalid output: err = %v, requests = %d", err, requests)
+	}
+
+	if _, err := client.ExecuteJob(ctx, "r1", RunnerExecutionRequest{JobId: "j1", Payload: map[string]interface{}{}}); err != nil {
+		t.Errorf("unchecked: %v", err)
+	}
+
+	// WithOutputValidation leaves the payload alone.
+	_, err = client.ExecuteJob(ctx, "r1", RunnerExecutionRequest{JobId: "j1", Payload: map[string]interface{}{}}, WithOutputValidation(importCapability))
+	var mismatch *OutputMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Response.Data != "too long" || CategoryOf(err) != ErrorCategorySCHEMA_MISMATCH {
+		t.Errorf("output only: err = %v", err)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	capability := RunnerCapability{Id: "csv.summary", OutputSchema: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"rows": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}}},
+		"required":   []interface{}{"total"},
+	}}
+	err := capability.ValidateResponse(RunnerExecutionResponse{
+		JobId: "j1", Success: true, RunnerId: "r1",
+		Data: map[string]interface{}{"rows": []interface{}{1, "two", 3.5}},
+	})
+	var mismatch *OutputMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("err = %v", err)
+	}
+	env := mismatch.Envelope
+	if env.Category != ErrorCategorySCHEMA_MISMATCH || env.Code != ErrCodeOutputSchemaMismatch || env.Service != "r1" || env.Operation != "execute" {
+		t.Errorf("envelope = %+v", env)
+	}
+	if got := mismatch.Errs.Fields(); !reflect.DeepEqual(got, []string{"/total", "/rows/1", "/rows/2"}) {
+		t.Errorf("fields = %v", got)
+	}
+	var details []string
+	for _, d := range env.Details {
+		details = append(details, fmt.Sprint(d["path"]))
+	}
+	if want := []string{"[total]", "[rows 1]", "[rows 2]"}; !reflect.DeepEqual(details, want) {
+		t.Errorf("detail paths = %v, want %v", details, want)
+	}
+	if !IsValidation(err) || !errors.Is(err, ErrValidation) {
+		t.Errorf("mismatch not reported as a validation failure: %v", err)
+	}
+
+	if err := capability.ValidateResponse(RunnerExecutionResponse{Success: false, Data: "partial"}); err != nil {
+		t.Errorf("failed response checked: %v", err)
+	}
+}
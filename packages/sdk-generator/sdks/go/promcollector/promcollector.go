@@ -0,0 +1,158 @@
+// Package promcollector provides a Prometheus implementation of
+// controlplane.MetricsCollector. It lives in its own module so that the core
+// SDK does not depend on the Prometheus client.
+package promcollector
+
+import (
+	"strconv"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector records client metrics in Prometheus. Register it with a
+// prometheus.Registerer and pass it as ClientConfig.Metrics.
+type Collector struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	hedges   *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	conns    *prometheus.GaugeVec
+	acquired *prometheus.CounterVec
+}
+
+var _ controlplane.MetricsCollector = (*Collector)(nil)
+var _ controlplane.HedgeMetricsCollector = (*Collector)(nil)
+var _ controlplane.PoolMetricsCollector = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes every metric name. Defaults to "controlplane".
+	Namespace string
+	// Buckets are the latency histogram buckets in seconds. Defaults to
+	// prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// New returns a Collector with the given options.
+func New(opts Options) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = "controlplane"
+	}
+	if opts.Buckets == nil {
+		opts.Buckets = prometheus.DefBuckets
+	}
+	labels := []string{"method", "route", "status"}
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "requests_total",
+			Help:      "Request attempts sent by the ControlPlane client.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of request attempts sent by the ControlPlane client.",
+			Buckets:   opts.Buckets,
+		}, labels),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "retries_total",
+			Help:      "Request attempts retried by the ControlPlane client.",
+		}, []string{"method", "route"}),
+		hedges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "hedges_total",
+			Help:      "Hedged requests sent by the ControlPlane client.",
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "requests_in_flight",
+			Help:      "Request attempts currently in flight.",
+		}, []string{"method", "route"}),
+		conns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "open_connections",
+			Help:      "Connections currently open by the ControlPlane client's transport.",
+		}, []string{"host"}),
+		acquired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Subsystem: "client",
+			Name:      "connections_acquired_total",
+			Help:      "Connections obtained by request attempts, by whether they were reused.",
+		}, []string{"host", "reused"}),
+	}
+}
+
+// RequestStarted implements controlplane.MetricsCollector.
+func (c *Collector) RequestStarted(method, route string) {
+	c.inFlight.WithLabelValues(method, route).Inc()
+}
+
+// RequestFinished implements controlplane.MetricsCollector. Attempts that
+// failed without a response are labeled with status "error".
+func (c *Collector) RequestFinished(method, route string, statusCode int, duration time.Duration) {
+	c.inFlight.WithLabelValues(method, route).Dec()
+	status := "error"
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	c.requests.WithLabelValues(method, route, status).Inc()
+	c.duration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// RequestRetried implements controlplane.MetricsCollector.
+func (c *Collector) RequestRetried(method, route string) {
+	c.retries.WithLabelValues(method, route).Inc()
+}
+
+// RequestHedged implements controlplane.HedgeMetricsCollector.
+func (c *Collector) RequestHedged(method, route string) {
+	c.hedges.WithLabelValues(method, route).Inc()
+}
+
+// ConnectionOpened implements controlplane.PoolMetricsCollector.
+func (c *Collector) ConnectionOpened(host string) {
+	c.conns.WithLabelValues(host).Inc()
+}
+
+// ConnectionClosed implements controlplane.PoolMetricsCollector.
+func (c *Collector) ConnectionClosed(host string) {
+	c.conns.WithLabelValues(host).Dec()
+}
+
+// ConnectionAcquired implements controlplane.PoolMetricsCollector.
+func (c *Collector) ConnectionAcquired(host string, reused bool) {
+	c.acquired.WithLabelValues(host, strconv.FormatBool(reused)).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.retries.Describe(ch)
+	c.hedges.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.conns.Describe(ch)
+	c.acquired.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.retries.Collect(ch)
+	c.hedges.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.conns.Collect(ch)
+	c.acquired.Collect(ch)
+}
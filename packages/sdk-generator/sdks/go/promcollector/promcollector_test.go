@@ -0,0 +1,53 @@
+package promcollector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	collector := New(Options{})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collector)
+
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: srv.URL, Metrics: collector})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"job-1", "job-2"} {
+		resp, err := client.Request(context.Background(), http.MethodGet, "/v1/jobs/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	want := `
+# HELP controlplane_client_requests_total Request attempts sent by the ControlPlane client.
+# TYPE controlplane_client_requests_total counter
+controlplane_client_requests_total{method="GET",route="/v1/jobs/{id}",status="200"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "controlplane_client_requests_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.ToFloat64(collector.inFlight.WithLabelValues("GET", "/v1/jobs/{id}")); n != 0 {
+		t.Errorf("in flight = %v", n)
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+	if n := testutil.ToFloat64(collector.conns.WithLabelValues(host)); n != 1 {
+		t.Errorf("open connections = %v", n)
+	}
+	if n := testutil.ToFloat64(collector.acquired.WithLabelValues(host, "true")); n != 1 {
+		t.Errorf("reused connections = %v", n)
+	}
+}
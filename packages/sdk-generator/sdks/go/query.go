@@ -0,0 +1,159 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeQuery serializes a query struct such as PaginatedRequest or
+// MarketplaceQuery into URL parameters named after its json tags.
+//
+// Zero values of omitempty fields are skipped; optional numbers and
+// booleans are pointers, sent whenever set, so Bool(false) sends false.
+// Slices become repeated parameters and times are sent in RFC 3339 form.
+// Version maps and ContractVersion values are sent as semver strings;
+// other maps are sent as JSON. A nil pointer encodes to no parameters.
+func encodeQuery(v interface{}) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode query: %s is not a struct", rv.Type())
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitEmpty := parseJSONTag(field)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+			for j := 0; j < fv.Len(); j++ {
+				s, err := queryString(fv.Index(j))
+				if err != nil {
+					return nil, fmt.Errorf("encode query field %s: %w", name, err)
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+		s, err := queryString(fv)
+		if err != nil {
+			return nil, fmt.Errorf("encode query field %s: %w", name, err)
+		}
+		values.Set(name, s)
+	}
+	return values, nil
+}
+
+// withQuery appends the encoded query structs qs to path. A parameter set
+// by a later struct replaces one of the same name from an earlier struct.
+func withQuery(path string, qs ...interface{}) (string, error) {
+	values := url.Values{}
+	for _, q := range qs {
+		encoded, err := encodeQuery(q)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range encoded {
+			values[k] = v
+		}
+	}
+	if len(values) == 0 {
+		return path, nil
+	}
+	return path + "?" + values.Encode(), nil
+}
+
+func parseJSONTag(field reflect.StructField) (name string, omitEmpty bool) {
+	tag := field.Tag.Get("json")
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func queryString(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Map:
+		if m, ok := v.Interface().(map[string]interface{}); ok && isVersionMap(m) {
+			var cv ContractVersion
+			if err := decodeMap(m, &cv); err != nil {
+				return "", err
+			}
+			return cv.String(), nil
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// isVersionMap reports whether m is the object form of a ContractVersion.
+func isVersionMap(m map[string]interface{}) bool {
+	for _, k := range []string{"major", "minor", "patch"} {
+		if _, ok := m[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package controlplane
+
+import (
+	"testing"
+)
+
+func TestEncodeQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"paginated empty", PaginatedRequest{}, ""},
+		{"paginated", &PaginatedRequest{Limit: Int(20), Cursor: "abc", SortOrder: "desc"}, "cursor=abc&limit=20&sortOrder=desc"},
+		{"registry bools", RegistryQuery{Category: "data", IncludeConnectors: Bool(true)}, "category=data&includeConnectors=true"},
+		{"explicit zeros", RegistryQuery{IncludeCapabilities: Bool(false)}, "includeCapabilities=false"},
+		{"explicit zero offset", PaginatedRequest{Limit: Int(20), Offset: Int(0)}, "limit=20&offset=0"},
+		{
+			"marketplace",
+			MarketplaceQuery{
+				Type:                 "runner",
+				Keywords:             []string{"etl", "csv"},
+				CompatibilityVersion: map[string]interface{}{"major": 1, "minor": 2, "patch": 0},
+				Limit:                Float(25),
+			},
+			"compatibilityVersion=1.2.0&keywords=etl&keywords=csv&limit=25&type=runner",
+		},
+		{
+			"truth",
+			TruthQuery{Id: "q-1", Pattern: map[string]interface{}{"subject": "svc"}, Limit: Int(5)},
+			"id=q-1&limit=5&pattern=%7B%22subject%22%3A%22svc%22%7D",
+		},
+		{"nil pointer", (*PaginatedRequest)(nil), ""},
+	}
+	for _, tt := range tests {
+		values, err := encodeQuery(tt.in)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := values.Encode(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeQueryRejectsNonStruct(t *testing.T) {
+	if _, err := encodeQuery("limit=1"); err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
+
+func TestWithQuery(t *testing.T) {
+	got, err := withQuery("/v1/jobs", PaginatedRequest{Limit: Int(10)})
+	if err != nil || got != "/v1/jobs?limit=10" {
+		t.Errorf("withQuery = %q, %v", got, err)
+	}
+	got, _ = withQuery("/v1/jobs", PaginatedRequest{})
+	if got != "/v1/jobs" {
+		t.Errorf("withQuery empty = %q", got)
+	}
+	filter := struct {
+		Status string `json:"status,omitempty"`
+		Limit  int    `json:"limit,omitempty"`
+	}{Status: "queued", Limit: 5}
+	got, _ = withQuery("/v1/jobs", filter, PaginatedRequest{Limit: Int(10)})
+	if got != "/v1/jobs?limit=10&status=queued" {
+		t.Errorf("withQuery merged = %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package controlplane
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Redirect policies for ClientConfig.RedirectPolicy.
+const (
+	// RedirectFollow follows every redirect, the default.
+	RedirectFollow = "follow"
+	// RedirectFollowSameHost follows redirects to the host of the original
+	// request or to ClientConfig.RedirectAllowedHosts and refuses others.
+	RedirectFollowSameHost = "follow-same-host"
+	// RedirectNever returns redirect responses to the caller as they are;
+	// typed methods report them as an *APIError.
+	RedirectNever = "never"
+)
+
+// DefaultMaxRedirects is how many redirects a request may follow when
+// ClientConfig.MaxRedirects is zero.
+const DefaultMaxRedirects = 10
+
+// ErrRedirectRefused is matched by errors.Is when the client refused to
+// follow a redirect. See RedirectError.
+var ErrRedirectRefused = errors.New("redirect refused")
+
+// RedirectError reports a redirect the client refused to follow, either
+// because the chain grew past ClientConfig.MaxRedirects or because
+// RedirectFollowSameHost forbade its target. Such requests are not retried.
+type RedirectError struct {
+	// Chain lists the URLs of the request and every redirect, ending with
+	// the one refused.
+	Chain  []string
+	Reason string
+}
+
+func (e *RedirectError) Error() string {
+	return "redirect refused, " + e.Reason + ": " + strings.Join(e.Chain, " -> ")
+}
+
+// Is reports whether target is ErrRedirectRefused.
+func (e *RedirectError) Is(target error) bool { return target == ErrRedirectRefused }
+
+var errHTTPClientAndRedirects = errors.New("invalid config: HTTPClient cannot be combined with RedirectPolicy, RedirectAllowedHosts or MaxRedirects; set CheckRedirect on the HTTPClient instead")
+
+// usesRedirectSettings reports whether config sets any option applied by
+// checkRedirect.
+func usesRedirectSettings(config ClientConfig) bool {
+	return config.RedirectPolicy != "" || len(config.RedirectAllowedHosts) > 0 || config.MaxRedirects != 0
+}
+
+func validateRedirectPolicy(policy string) error {
+	switch policy {
+	case "", RedirectFollow, RedirectFollowSameHost, RedirectNever:
+		return nil
+	}
+	return fmt.Errorf("invalid config: unknown RedirectPolicy %q", policy)
+}
+
+// checkRedirect is the CheckRedirect of the http.Client NewClient builds.
+// net/http drops the Authorization header when a redirect leaves the
+// original domain; for hosts in RedirectAllowedHosts the default headers
+// and credentials are applied again.
+func (c *ControlPlaneClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.config.RedirectPolicy == RedirectNever {
+		return http.ErrUseLastResponse
+	}
+	chain := func() []string {
+		urls := make([]string, 0, len(via)+1)
+		for _, r := range via {
+			urls = append(urls, r.URL.String())
+		}
+		return append(urls, req.URL.String())
+	}
+	limit := c.config.MaxRedirects
+	if limit == 0 {
+		limit = DefaultMaxRedirects
+	}
+	if len(via) > limit {
+		return &RedirectError{Chain: chain(), Reason: fmt.Sprintf("more than %d redirects", limit)}
+	}
+	allowed := c.redirectHostAllowed(req.URL.Host)
+	if c.config.RedirectPolicy == RedirectFollowSameHost && !allowed && req.URL.Host != via[0].URL.Host {
+		return &RedirectError{Chain: chain(), Reason: "host " + req.URL.Host + " differs from " + via[0].URL.Host}
+	}
+	if allowed {
+		for key, value := range c.defaultHeaders() {
+			if req.Header.Get(key) == "" {
+				req.Header.Set(key, value)
+			}
+		}
+		if c.config.TokenProvider != nil {
+			token, err := c.config.TokenProvider.Token(req.Context())
+			if err != nil {
+				return &TokenError{Err: err}
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	c.config.Logger.Debug("controlplane: following redirect",
+		"from", via[len(via)-1].URL.String(), "to", req.URL.String(), "credentials", allowed)
+	return nil
+}
+
+// redirectHostAllowed reports whether host, which may carry a port, is in
+// RedirectAllowedHosts, either exactly or by hostname.
+func (c *ControlPlaneClient) redirectHostAllowed(host string) bool {
+	name := host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+		name = host[:i]
+	}
+	for _, h := range c.config.RedirectAllowedHosts {
+		if strings.EqualFold(h, host) || strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
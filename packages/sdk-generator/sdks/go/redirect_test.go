@@ -0,0 +1,150 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const healthBody = `{"status":"healthy","timestamp":"2024-01-01T00:00:00Z","checks":[]}`
+
+// authRecorder answers with a healthy report and records the Authorization
+// header of the last request. Its URL uses "localhost" so that redirects to
+// it from a 127.0.0.1 server cross hosts.
+func authRecorder(t *testing.T) (url string, auth *atomic.Value) {
+	auth = &atomic.Value{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(healthBody))
+	}))
+	t.Cleanup(srv.Close)
+	return strings.Replace(srv.URL, "127.0.0.1", "localhost", 1), auth
+}
+
+func redirectingServer(t *testing.T, location string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Redirect(w, r, location, http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRedirectRelativeLocation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.Header().Set("Location", "v2/health")
+			w.WriteHeader(http.StatusTemporaryRedirect)
+			return
+		}
+		if r.URL.Path != "/v2/health" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "wrong target", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(healthBody))
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "key"})
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedirectReappliesAuthToAllowedHosts(t *testing.T) {
+	target, auth := authRecorder(t)
+	srv, _ := redirectingServer(t, target+"/health")
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "key"})
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := auth.Load(); got != "" {
+		t.Errorf("credentials sent to a host not allowed: %q", got)
+	}
+
+	client = mustNewClient(t, ClientConfig{BaseURL: srv.URL, APIKey: "key", RedirectAllowedHosts: []string{"localhost"}})
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := auth.Load(); got != "Bearer key" {
+		t.Errorf("Authorization = %q, want the client's credentials", got)
+	}
+}
+
+func TestRedirectFollowSameHostRefusesOtherHosts(t *testing.T) {
+	target, _ := authRecorder(t)
+	srv, calls := redirectingServer(t, target+"/health")
+
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:        srv.URL,
+		RedirectPolicy: RedirectFollowSameHost,
+		Retry:          &RetryPolicy{MaxRetries: Int(2)},
+	})
+	_, err := client.GetHealth(context.Background())
+	var redirectErr *RedirectError
+	if !errors.Is(err, ErrRedirectRefused) || !errors.As(err, &redirectErr) {
+		t.Fatalf("err = %v, want a *RedirectError", err)
+	}
+	if len(redirectErr.Chain) != 2 || redirectErr.Chain[1] != target+"/health" {
+		t.Errorf("chain = %v", redirectErr.Chain)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("refused redirect was retried: %d calls", calls.Load())
+	}
+
+	client = mustNewClient(t, ClientConfig{
+		BaseURL:              srv.URL,
+		RedirectPolicy:       RedirectFollowSameHost,
+		RedirectAllowedHosts: []string{strings.TrimPrefix(target, "http://")},
+	})
+	if _, err := client.GetHealth(context.Background()); err != nil {
+		t.Errorf("redirect to an allowed host: %v", err)
+	}
+}
+
+func TestRedirectNever(t *testing.T) {
+	srv, _ := redirectingServer(t, "/elsewhere")
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, RedirectPolicy: RedirectNever})
+
+	resp, err := client.Request(context.Background(), http.MethodGet, "/v1/health", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/elsewhere" {
+		t.Errorf("status = %d, Location = %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	var apiErr *APIError
+	if _, err := client.GetHealth(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusFound {
+		t.Errorf("GetHealth() error = %v", err)
+	}
+}
+
+func TestRedirectLimitReportsChain(t *testing.T) {
+	srv, _ := redirectingServer(t, "/health")
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, MaxRedirects: 3})
+
+	_, err := client.GetHealth(context.Background())
+	var redirectErr *RedirectError
+	if !errors.As(err, &redirectErr) {
+		t.Fatalf("err = %v, want a *RedirectError", err)
+	}
+	if len(redirectErr.Chain) != 5 || !strings.Contains(err.Error(), "more than 3 redirects") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestRedirectConfigErrors(t *testing.T) {
+	if _, err := NewClient(ClientConfig{BaseURL: testBaseURL, HTTPClient: &http.Client{}, RedirectPolicy: RedirectNever}); !errors.Is(err, errHTTPClientAndRedirects) {
+		t.Errorf("HTTPClient with RedirectPolicy: %v", err)
+	}
+	if _, err := NewClient(ClientConfig{BaseURL: testBaseURL, RedirectPolicy: "sometimes"}); err == nil {
+		t.Error("unknown RedirectPolicy accepted")
+	}
+}
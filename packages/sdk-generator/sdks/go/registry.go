@@ -0,0 +1,29 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+)
+
+// GetCapabilityRegistry fetches the registry of runners, connectors and
+// their capabilities. With ClientConfig.Cache set, an unchanged registry is
+// served from the cache after an ETag revalidation.
+func (c *ControlPlaneClient) GetCapabilityRegistry(ctx context.Context, opts ...RequestOption) (*CapabilityRegistry, error) {
+	var reg CapabilityRegistry
+	opts = append([]RequestOption{withETagCache()}, opts...)
+	if err := c.call(ctx, http.MethodGet, "/v1/registry", nil, &reg, opts...); err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
+
+// GetMarketplaceIndex fetches the marketplace index of published runners and
+// connectors. It is cached like GetCapabilityRegistry.
+func (c *ControlPlaneClient) GetMarketplaceIndex(ctx context.Context, opts ...RequestOption) (*MarketplaceIndex, error) {
+	var index MarketplaceIndex
+	opts = append([]RequestOption{withETagCache()}, opts...)
+	if err := c.call(ctx, http.MethodGet, "/v1/marketplace/index", nil, &index, opts...); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
@@ -0,0 +1,173 @@
+package controlplane
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ResourceRequirements is the typed form of
+// RunnerCapability.ResourceRequirements.
+type ResourceRequirements struct {
+	// CPUMillis is the CPU requirement in millicores.
+	CPUMillis int64
+	// MemoryBytes is the memory requirement in bytes.
+	MemoryBytes int64
+	// GPUs is the number of GPUs required.
+	GPUs int
+	// Labels are optional placement hints, e.g. {"zone": "eu-west-1a"}.
+	Labels map[string]string
+}
+
+// Validate rejects negative requirements.
+func (r ResourceRequirements) Validate() error {
+	var errs ValidationErrors
+	r.validate("", &errs)
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+func (r ResourceRequirements) validate(prefix string, errs *ValidationErrors) {
+	if r.CPUMillis < 0 {
+		errs.Add(prefix+"cpu", "must not be negative")
+	}
+	if r.MemoryBytes < 0 {
+		errs.Add(prefix+"memory", "must not be negative")
+	}
+	if r.GPUs < 0 {
+		errs.Add(prefix+"gpu", "must not be negative")
+	}
+}
+
+func init() {
+	registerRule("RunnerCapability", func(m RunnerCapability, errs *ValidationErrors) {
+		r, err := m.Resources()
+		if err != nil {
+			errs.Add("resourceRequirements", err.Error())
+			return
+		}
+		r.validate("resourceRequirements.", errs)
+	})
+}
+
+// Resources decodes ResourceRequirements. CPU and memory may be numbers or
+// quantity strings such as "500m", "1.5", "512Mi" or "2G"; gpu may be a
+// boolean or a count. Missing fields are zero.
+func (m RunnerCapability) Resources() (ResourceRequirements, error) {
+	var r ResourceRequirements
+	raw := m.ResourceRequirements
+	var err error
+	if v, ok := raw["cpu"]; ok && v != nil {
+		if r.CPUMillis, err = parseCPU(v); err != nil {
+			return r, fmt.Errorf("cpu: %w", err)
+		}
+	}
+	if v, ok := raw["memory"]; ok && v != nil {
+		if r.MemoryBytes, err = parseMemory(v); err != nil {
+			return r, fmt.Errorf("memory: %w", err)
+		}
+	}
+	if v, ok := raw["gpuCount"]; ok && v != nil {
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return r, fmt.Errorf("gpuCount: want an integer, got %v", v)
+		}
+		r.GPUs = int(n)
+	} else if v, ok := raw["gpu"]; ok && v != nil {
+		switch g := v.(type) {
+		case bool:
+			if g {
+				r.GPUs = 1
+			}
+		case float64:
+			r.GPUs = int(g)
+		default:
+			return r, fmt.Errorf("gpu: want a boolean or count, got %T", v)
+		}
+	}
+	if v, ok := raw["labels"].(map[string]interface{}); ok {
+		r.Labels = make(map[string]string, len(v))
+		for k, lv := range v {
+			r.Labels[k] = fmt.Sprint(lv)
+		}
+	}
+	return r, nil
+}
+
+// SetResources stores r in ResourceRequirements using the contract's
+// string quantities: cpu as millicores ("500m") and memory in bytes. The
+// contract's gpu flag is set when any GPU is required; counts above one are
+// also recorded as gpuCount.
+func (m *RunnerCapability) SetResources(r ResourceRequirements) {
+	raw := map[string]interface{}{"gpu": r.GPUs > 0}
+	if r.CPUMillis != 0 {
+		raw["cpu"] = strconv.FormatInt(r.CPUMillis, 10) + "m"
+	}
+	if r.MemoryBytes != 0 {
+		raw["memory"] = strconv.FormatInt(r.MemoryBytes, 10)
+	}
+	if r.GPUs > 1 {
+		raw["gpuCount"] = float64(r.GPUs)
+	}
+	if len(r.Labels) > 0 {
+		labels := make(map[string]interface{}, len(r.Labels))
+		for k, v := range r.Labels {
+			labels[k] = v
+		}
+		raw["labels"] = labels
+	}
+	m.ResourceRequirements = raw
+}
+
+// parseCPU converts a core count or millicore quantity to millicores.
+func parseCPU(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case float64:
+		return int64(math.Round(v * 1000)), nil
+	case string:
+		s := strings.TrimSpace(v)
+		if strings.HasSuffix(s, "m") {
+			return strconv.ParseInt(strings.TrimSuffix(s, "m"), 10, 64)
+		}
+		cores, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantity %q", v)
+		}
+		return int64(math.Round(cores * 1000)), nil
+	}
+	return 0, fmt.Errorf("want a number or string, got %T", v)
+}
+
+var memorySuffixes = []struct {
+	suffix string
+	factor float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// parseMemory converts a byte count or quantity such as "512Mi" to bytes.
+func parseMemory(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case float64:
+		return int64(v), nil
+	case string:
+		s := strings.TrimSpace(v)
+		factor := 1.0
+		for _, sf := range memorySuffixes {
+			if strings.HasSuffix(s, sf.suffix) {
+				s, factor = strings.TrimSuffix(s, sf.suffix), sf.factor
+				break
+			}
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantity %q", v)
+		}
+		return int64(math.Round(n * factor)), nil
+	}
+	return 0, fmt.Errorf("want a number or string, got %T", v)
+}
@@ -0,0 +1,67 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourcesPartial(t *testing.T) {
+	c := RunnerCapability{ResourceRequirements: map[string]interface{}{"cpu": "250m"}}
+	r, err := c.Resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.CPUMillis != 250 || r.MemoryBytes != 0 || r.GPUs != 0 || r.Labels != nil {
+		t.Errorf("resources = %+v", r)
+	}
+}
+
+func TestResourcesQuantities(t *testing.T) {
+	c := RunnerCapability{ResourceRequirements: map[string]interface{}{
+		"cpu":    "1.5",
+		"memory": "512Mi",
+		"gpu":    true,
+		"labels": map[string]interface{}{"zone": "eu"},
+	}}
+	r, err := c.Resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.CPUMillis != 1500 || r.MemoryBytes != 512<<20 || r.GPUs != 1 || r.Labels["zone"] != "eu" {
+		t.Errorf("resources = %+v", r)
+	}
+
+	var out RunnerCapability
+	want := ResourceRequirements{CPUMillis: 500, MemoryBytes: 2 << 30, GPUs: 4, Labels: map[string]string{"zone": "eu"}}
+	out.SetResources(want)
+	got, err := out.Resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CPUMillis != want.CPUMillis || got.MemoryBytes != want.MemoryBytes || got.GPUs != 4 || got.Labels["zone"] != "eu" {
+		t.Errorf("round trip = %+v", got)
+	}
+	if out.ResourceRequirements["cpu"] != "500m" || out.ResourceRequirements["gpu"] != true {
+		t.Errorf("encoded = %v", out.ResourceRequirements)
+	}
+}
+
+func TestResourcesRejectNegative(t *testing.T) {
+	if err := (ResourceRequirements{CPUMillis: -1}).Validate(); err == nil {
+		t.Error("negative cpu accepted")
+	}
+
+	c := RunnerCapability{
+		Id: "c1", Name: "csv", Version: "1.0.0", Description: "d",
+		ResourceRequirements: map[string]interface{}{"memory": "-1Gi"},
+	}
+	err := c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "resourceRequirements.memory") {
+		t.Errorf("err = %v", err)
+	}
+
+	c.ResourceRequirements = map[string]interface{}{"cpu": "lots"}
+	if err := c.Validate(); err == nil {
+		t.Error("malformed cpu accepted")
+	}
+}
@@ -0,0 +1,414 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRetryPolicy returns the retry defaults used by the jobs contract:
+// three retries with exponential backoff from one second up to thirty.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:        Int(3),
+		BackoffMs:         Float(1000),
+		MaxBackoffMs:      Float(30000),
+		BackoffMultiplier: Float(2),
+	}
+}
+
+// retryableCategories are the contract's default retryable categories plus
+// RATE_LIMITED, which clients retry after the advertised delay.
+var retryableCategories = map[string]bool{
+	ErrorCategoryTIMEOUT:             true,
+	ErrorCategoryNETWORK_ERROR:       true,
+	ErrorCategorySERVICE_UNAVAILABLE: true,
+	ErrorCategoryRUNTIME_ERROR:       true,
+	ErrorCategoryRATE_LIMITED:        true,
+}
+
+// IsRetryableCategory reports whether errors of the category are worth
+// retrying by default: TIMEOUT, NETWORK_ERROR, SERVICE_UNAVAILABLE,
+// RATE_LIMITED and RUNTIME_ERROR are; validation, authorization, not-found
+// and every other category are not.
+func IsRetryableCategory(category string) bool {
+	return retryableCategories[category]
+}
+
+// IsRetryable reports whether the policy retries errors of the category.
+// NonRetryableCategories is consulted first and wins over
+// RetryableCategories; categories in neither list fall back to
+// IsRetryableCategory.
+func (p *RetryPolicy) IsRetryable(category string) bool {
+	if containsString(p.NonRetryableCategories, category) {
+		return false
+	}
+	if containsString(p.RetryableCategories, category) {
+		return true
+	}
+	return IsRetryableCategory(category)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// doWithRetry sends a request, retrying transient failures according to the
+// client's retry policy. Every attempt is built from scratch and passes
+// through the middleware chain.
+func (c *ControlPlaneClient) doWithRetry(ctx context.Context, spec *requestSpec) (*http.Response, error) {
+	policy := c.config.Retry
+	reqCtx := withBodyLimit(withRoute(ctx, spec.route), spec.maxBody)
+	if spec.endpoint > 0 {
+		policy = nil
+	}
+	// refreshed counts the attempt repeated with a fresh token, which does
+	// not use up the retry policy.
+	refreshed := 0
+	// tried marks the endpoints that failed since the last retry.
+	var tried []bool
+	if c.retryBudget != nil {
+		c.retryBudget.deposit()
+	}
+	for attempt := 1; ; attempt++ {
+		if c.config.RateLimiter != nil {
+			if err := c.config.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		endpoint, err := c.routeAttempt(spec, tried)
+		if err != nil {
+			return nil, err
+		}
+		req, err := c.newRequest(withAttempt(reqCtx, attempt), spec)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp *http.Response
+		if c.hedger != nil && spec.hedgeable() {
+			resp, err = c.sendHedged(req, spec, attempt)
+		} else {
+			resp, err = c.sendAttempt(req, spec, attempt)
+		}
+		if err == nil && refreshed == 0 && spec.replayable() && c.shouldRefreshToken(resp) {
+			refreshed = 1
+			drainAndClose(resp.Body)
+			c.invalidateToken()
+			c.config.Logger.Info("controlplane: token rejected, refreshing",
+				"method", spec.method, "path", spec.path, "attempt", attempt)
+			continue
+		}
+		if endpoint >= 0 && isEndpointFailure(ctx, resp, err) {
+			c.endpoints.markDown(endpoint)
+			if tried == nil {
+				tried = make([]bool, len(c.endpoints.bases))
+			}
+			tried[endpoint] = true
+			if untried(tried) && c.mayFailOver(ctx, spec, policy, attempt-refreshed, resp, err) {
+				reason := "transport error"
+				if resp != nil {
+					reason = resp.Status
+					drainAndClose(resp.Body)
+				}
+				c.config.Logger.Warn("controlplane: endpoint failed, failing over",
+					"method", spec.method, "path", spec.path, "attempt", attempt,
+					"endpoint", c.endpoints.bases[endpoint].String(), "reason", reason)
+				continue
+			}
+		}
+		retry := attempt - refreshed
+		if policy == nil || retry > IntValue(policy.MaxRetries) || !spec.replayable() || !shouldRetry(ctx, spec, resp, err) {
+			return resp, err
+		}
+		tried = nil
+
+		if c.retryBudget != nil && !c.retryBudget.take() {
+			if resp != nil {
+				err = checkStatus(resp)
+				drainAndClose(resp.Body)
+			}
+			c.config.Logger.Warn("controlplane: retry budget exhausted",
+				"method", spec.method, "path", spec.path, "attempt", attempt, "error", err)
+			return nil, &RetryBudgetError{Err: err}
+		}
+
+		delay := policy.backoff(retry)
+		if c.config.RetryJitter {
+			delay = fullJitter(delay)
+		}
+		reason := "transport error"
+		if resp != nil {
+			if d, ok := retryAfter(resp, c.config.Clock.Now()); ok {
+				delay = d
+			}
+			reason = resp.Status
+			drainAndClose(resp.Body)
+		}
+		c.config.Metrics.RequestRetried(spec.method, spec.route)
+		c.config.Logger.Info("controlplane: retrying request",
+			"method", spec.method, "path", spec.path, "attempt", attempt, "delay", delay, "reason", reason)
+		if err := c.config.Clock.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// mayFailOver reports whether a request whose endpoint failed may be sent to
+// another one at once. Failing over is a retry without the backoff: it needs
+// a request that is safe to repeat, uses up one of the policy's retries,
+// when there is a policy, and spends from the retry budget.
+func (c *ControlPlaneClient) mayFailOver(ctx context.Context, spec *requestSpec, policy *RetryPolicy, retry int, resp *http.Response, err error) bool {
+	if !spec.replayable() || !shouldRetry(ctx, spec, resp, err) {
+		return false
+	}
+	if policy != nil && retry > IntValue(policy.MaxRetries) {
+		return false
+	}
+	return c.retryBudget == nil || c.retryBudget.take()
+}
+
+// routeAttempt points spec at the endpoint for the next attempt when the
+// client has several, skipping those in tried, and returns its index, or -1
+// when there is nothing to choose.
+func (c *ControlPlaneClient) routeAttempt(spec *requestSpec, tried []bool) (int, error) {
+	if !c.endpoints.multi() {
+		return -1, nil
+	}
+	endpoint := spec.endpoint - 1
+	if endpoint < 0 {
+		endpoint = c.endpoints.pick(tried)
+	}
+	target, err := resolveURLOn(c.endpoints.bases[endpoint], spec.path, spec.query)
+	if err != nil {
+		return -1, err
+	}
+	spec.url = target
+	if spec.endpoint > 0 {
+		// Pinned attempts leave endpoint health to the caller.
+		return -1, nil
+	}
+	return endpoint, nil
+}
+
+// backoff returns the delay before the retry that follows the given attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := FloatValue(p.BackoffMultiplier)
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	ms := FloatValue(p.BackoffMs) * math.Pow(multiplier, float64(attempt-1))
+	if max := FloatValue(p.MaxBackoffMs); max > 0 && ms > max {
+		ms = max
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// fullJitter returns a random delay between zero and d.
+func fullJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// ErrRetryBudgetExhausted is matched by errors.Is when a request failed and
+// was not retried because ClientConfig.RetryBudget was spent. See
+// RetryBudgetError.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudgetError is returned in place of a retry that the client's retry
+// budget did not allow. Err is the failure that would have been retried, so
+// errors.As still finds its *APIError.
+type RetryBudgetError struct {
+	Err error
+}
+
+func (e *RetryBudgetError) Error() string {
+	return "retry budget exhausted: " + e.Err.Error()
+}
+
+// Is reports whether target is ErrRetryBudgetExhausted.
+func (e *RetryBudgetError) Is(target error) bool { return target == ErrRetryBudgetExhausted }
+
+// Unwrap returns the failure that would have been retried.
+func (e *RetryBudgetError) Unwrap() error { return e.Err }
+
+// RetryBudget caps the retries a client makes across all of its requests,
+// so a degraded server is not swamped with retried traffic.
+type RetryBudget struct {
+	// MaxRetries is how many retries may start within any Window. When
+	// Ratio is set, zero leaves the window uncapped.
+	MaxRetries int
+	// Window is the sliding period the budget covers. Zero selects one
+	// minute.
+	Window time.Duration
+
+	// Ratio, when positive, also makes retries draw on a token bucket that
+	// every request adds Ratio tokens to and every retry takes one from, so
+	// retries stay within that fraction of the request rate: 0.1 allows one
+	// retry for every ten requests.
+	Ratio float64
+	// Burst is the capacity of the token bucket, which starts full: how
+	// many retries may run before requests have earned any. Zero selects
+	// 10.
+	Burst int
+}
+
+// defaultRetryBurst is the capacity of a ratio budget without Burst.
+const defaultRetryBurst = 10
+
+// retryBudget tracks the retries spent against a RetryBudget.
+type retryBudget struct {
+	max    int
+	window time.Duration
+	ratio  float64
+	burst  float64
+	clock  Clock
+
+	mu sync.Mutex
+	// spent holds the start times of the retries within the window,
+	// oldest first.
+	spent []time.Time
+	// tokens is the balance of the ratio bucket.
+	tokens float64
+}
+
+func newRetryBudget(b *RetryBudget, clock Clock) *retryBudget {
+	if b == nil {
+		return nil
+	}
+	window := b.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	burst := b.Burst
+	if burst <= 0 {
+		burst = defaultRetryBurst
+	}
+	return &retryBudget{
+		max:    b.MaxRetries,
+		window: window,
+		ratio:  b.Ratio,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		clock:  clock,
+	}
+}
+
+// deposit credits the ratio bucket for a new request.
+func (b *retryBudget) deposit() {
+	if b.ratio <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+b.ratio)
+}
+
+// take spends one retry, reporting false when none is left.
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	windowed := b.ratio <= 0 || b.max > 0
+	now := b.clock.Now()
+	if windowed {
+		cutoff := now.Add(-b.window)
+		i := 0
+		for i < len(b.spent) && !b.spent[i].After(cutoff) {
+			i++
+		}
+		b.spent = b.spent[i:]
+		if len(b.spent) >= b.max {
+			return false
+		}
+	}
+	if b.ratio > 0 {
+		if b.tokens < 1 {
+			return false
+		}
+		b.tokens--
+	}
+	if windowed {
+		b.spent = append(b.spent, now)
+	}
+	return true
+}
+
+// shouldRetry reports whether a failed attempt may be repeated. A request
+// whose effect a repeat could duplicate, such as a POST without an
+// Idempotency-Key, is only repeated when it provably never reached the
+// server.
+func shouldRetry(ctx context.Context, spec *requestSpec, resp *http.Response, err error) bool {
+	if err != nil {
+		// The caller gave up, a redirect was refused or the server speaks
+		// another contract; retrying would only fail again.
+		if ctx.Err() != nil || errors.Is(err, ErrRedirectRefused) || errors.Is(err, ErrContractVersionMismatch) {
+			return false
+		}
+		return spec.idempotent() || notSent(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return spec.idempotent()
+	}
+	return false
+}
+
+// idempotent reports whether sending the request twice has the effect of
+// sending it once: its method is idempotent or it carries an
+// Idempotency-Key.
+func (s *requestSpec) idempotent() bool {
+	switch s.method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return s.header.Get("Idempotency-Key") != ""
+}
+
+// notSent reports whether err shows that the request never reached the
+// server because no connection could be made to it.
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || (errors.As(err, &opErr) && opErr.Op == "dial")
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date,
+// which is measured from now.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// drainAndClose discards the rest of a body so the connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
@@ -0,0 +1,220 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableCategory(t *testing.T) {
+	for _, c := range []string{ErrorCategoryTIMEOUT, ErrorCategoryNETWORK_ERROR, ErrorCategorySERVICE_UNAVAILABLE, ErrorCategoryRATE_LIMITED} {
+		if !IsRetryableCategory(c) {
+			t.Errorf("%s not retryable", c)
+		}
+	}
+	for _, c := range []string{ErrorCategoryVALIDATION_ERROR, ErrorCategoryAUTHORIZATION_ERROR, ErrorCategoryRESOURCE_NOT_FOUND, "UNKNOWN"} {
+		if IsRetryableCategory(c) {
+			t.Errorf("%s retryable", c)
+		}
+	}
+}
+
+func TestRetryPolicyIsRetryableOverrides(t *testing.T) {
+	p := &RetryPolicy{
+		RetryableCategories:    []string{ErrorCategoryRESOURCE_NOT_FOUND, ErrorCategoryRESOURCE_CONFLICT},
+		NonRetryableCategories: []string{ErrorCategoryRATE_LIMITED, ErrorCategoryRESOURCE_CONFLICT},
+	}
+	cases := map[string]bool{
+		ErrorCategoryRESOURCE_NOT_FOUND: true,  // explicitly retryable
+		ErrorCategoryRATE_LIMITED:       false, // explicitly non-retryable
+		ErrorCategoryRESOURCE_CONFLICT:  false, // in both lists: non-retryable wins
+		ErrorCategoryTIMEOUT:            true,  // default
+		ErrorCategoryVALIDATION_ERROR:   false, // default
+	}
+	for category, want := range cases {
+		if got := p.IsRetryable(category); got != want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", category, got, want)
+		}
+	}
+	if !DefaultRetryPolicy().IsRetryable(ErrorCategoryNETWORK_ERROR) {
+		t.Error("default policy does not retry NETWORK_ERROR")
+	}
+}
+
+func TestFullJitterStaysWithinBackoff(t *testing.T) {
+	const d = 800 * time.Millisecond
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 200; i++ {
+		j := fullJitter(d)
+		if j < 0 || j > d {
+			t.Fatalf("fullJitter(%v) = %v", d, j)
+		}
+		seen[j] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jittered delays are all equal")
+	}
+	if fullJitter(0) != 0 {
+		t.Error("fullJitter(0) != 0")
+	}
+}
+
+func TestRetryBudgetRatio(t *testing.T) {
+	b := newRetryBudget(&RetryBudget{Ratio: 0.5, Burst: 2}, &manualClock{})
+	if !b.take() || !b.take() || b.take() {
+		t.Fatal("full bucket did not allow exactly Burst retries")
+	}
+	b.deposit()
+	if b.take() {
+		t.Error("half a token allowed a retry")
+	}
+	b.deposit()
+	if !b.take() {
+		t.Error("two requests did not earn a retry")
+	}
+	for i := 0; i < 10; i++ {
+		b.deposit()
+	}
+	if b.tokens != 2 {
+		t.Errorf("tokens = %v, want capped at Burst", b.tokens)
+	}
+
+	capped := newRetryBudget(&RetryBudget{Ratio: 1, MaxRetries: 1}, &manualClock{})
+	if !capped.take() || capped.take() {
+		t.Error("MaxRetries not enforced alongside Ratio")
+	}
+}
+
+func TestRetryBudgetRatioUnderLoad(t *testing.T) {
+	const workers, requestsEach = 20, 10
+	var attempts atomic.Int64
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})),
+		Retry:       &RetryPolicy{MaxRetries: Int(3), BackoffMs: Float(1)},
+		RetryBudget: &RetryBudget{Ratio: 0.1, Burst: 5},
+	})
+
+	var exhausted atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < requestsEach; i++ {
+				_, err := client.GetJob(context.Background(), "j1")
+				if errors.Is(err, ErrRetryBudgetExhausted) {
+					exhausted.Add(1)
+				} else if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusServiceUnavailable {
+					t.Errorf("err = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	requests := int64(workers * requestsEach)
+	retries := attempts.Load() - requests
+	// Without the budget every request would be retried three times.
+	if limit := int64(5 + 0.1*float64(requests)); retries > limit {
+		t.Errorf("retries = %d, want at most %d", retries, limit)
+	}
+	if exhausted.Load() == 0 {
+		t.Error("no request reported the budget exhausted")
+	}
+}
+
+func TestRetryBudgetExhausted(t *testing.T) {
+	hits := 0
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: "http://controlplane.test",
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})),
+		Retry:       &RetryPolicy{MaxRetries: Int(5), BackoffMs: Float(10)},
+		RetryJitter: true,
+		RetryBudget: &RetryBudget{MaxRetries: 2, Window: time.Minute},
+		Clock:       clock,
+	})
+	ctx := context.Background()
+
+	_, err := client.GetJob(ctx, "j1")
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("err = %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("underlying failure lost: %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("attempts = %d, want the first plus two budgeted retries", hits)
+	}
+
+	if _, err := client.GetJob(ctx, "j1"); !errors.Is(err, ErrRetryBudgetExhausted) || hits != 4 {
+		t.Errorf("spent budget: err = %v, attempts = %d", err, hits)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	client.GetJob(ctx, "j1")
+	if hits != 7 {
+		t.Errorf("after the window: attempts = %d", hits)
+	}
+}
+
+func TestRetryOnlyIdempotentRequests(t *testing.T) {
+	var hits int
+	failures := []error{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: "http://controlplane.test",
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})),
+		Middlewares: []Middleware{func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				if len(failures) > 0 {
+					err := failures[0]
+					failures = failures[1:]
+					return nil, err
+				}
+				return next(req)
+			}
+		}},
+		Retry: &RetryPolicy{MaxRetries: Int(2)},
+		Clock: &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	ctx := context.Background()
+	post := func(opts ...RequestOption) error {
+		return client.call(ctx, http.MethodPost, "/v1/truth/assertions", map[string]string{"subject": "s"}, nil, opts...)
+	}
+
+	for name, tt := range map[string]struct {
+		send  func() error
+		hits  int
+		fails []error
+	}{
+		"POST":                      {func() error { return post() }, 1, nil},
+		"POST with Idempotency-Key": {func() error { return post(WithIdempotencyKey("a1")) }, 3, nil},
+		"PUT":                       {func() error { return client.call(ctx, http.MethodPut, "/v1/x", nil, nil) }, 3, nil},
+		"POST after reset":          {func() error { return post() }, 0, []error{errors.New("connection reset by peer")}},
+		"POST after dial failure":   {func() error { return post() }, 1, []error{&net.OpError{Op: "dial", Err: errors.New("refused")}}},
+	} {
+		hits, failures = 0, tt.fails
+		if err := tt.send(); err == nil {
+			t.Errorf("%s: succeeded", name)
+		}
+		if hits != tt.hits {
+			t.Errorf("%s: attempts reaching the server = %d, want %d", name, hits, tt.hits)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package controlplane
+
+import (
+	"strings"
+)
+
+// routeTemplates lists the API routes the client knows about. They are used
+// as low-cardinality labels for metrics and tracing; parameters are written
+// as {name} and match exactly one path segment.
+var routeTemplates = []string{
+	"/health",
+	"/v1/jobs",
+	"/v1/jobs/{id}",
+	"/v1/marketplace/connectors/{id}",
+	"/v1/marketplace/index",
+	"/v1/marketplace/runners/{id}",
+	"/v1/metadata",
+	"/v1/registry",
+	"/v1/runners",
+	"/v1/runners/{id}",
+	"/v1/runners/{id}/execute",
+	"/v1/runners/{id}/heartbeat",
+	"/v1/runners/{id}/ws",
+	"/v1/truth/assertions",
+	"/v1/truth/query",
+	"/v1/truth/stream",
+	"/v1/truth/subscribe",
+}
+
+// routeTemplate maps a request path to the route template it was built from,
+// e.g. "/v1/jobs/42?x=1" to "/v1/jobs/{id}". Paths that match no known route
+// have every segment that looks like an identifier replaced with {id}.
+func routeTemplate(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segments := splitPath(path)
+	for _, tmpl := range routeTemplates {
+		if matchRoute(splitPath(tmpl), segments) {
+			return tmpl
+		}
+	}
+
+	for i, s := range segments {
+		if looksLikeID(s) {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func matchRoute(tmpl, segments []string) bool {
+	if len(tmpl) != len(segments) {
+		return false
+	}
+	for i, t := range tmpl {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			continue
+		}
+		if t != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// looksLikeID reports whether a path segment is a value rather than a fixed
+// name: anything but lowercase words and API version markers such as "v1".
+func looksLikeID(s string) bool {
+	if len(s) >= 2 && s[0] == 'v' && strings.Trim(s[1:], "0123456789") == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && r != '-' && r != '_' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package controlplane
+
+import "testing"
+
+func TestRouteTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/health":                      "/health",
+		"/v1/jobs":                     "/v1/jobs",
+		"/v1/jobs/":                    "/v1/jobs",
+		"/v1/jobs/job-abc?expand=true": "/v1/jobs/{id}",
+		"/v1/widgets/42/parts":         "/v1/widgets/{id}/parts",
+		"/v2/widgets/9f1c0a7e-b2d1":    "/v2/widgets/{id}",
+	}
+	for path, want := range tests {
+		if got := routeTemplate(path); got != want {
+			t.Errorf("routeTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatInterval is the heartbeat interval StartHeartbeatLoop uses
+// when given none.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// SendHeartbeat reports a runner's status. A zero Timestamp is set to the
+// current time.
+func (c *ControlPlaneClient) SendHeartbeat(ctx context.Context, hb RunnerHeartbeat, opts ...RequestOption) error {
+	if hb.Timestamp.IsZero() {
+		hb.Timestamp = c.config.Clock.Now().UTC()
+	}
+	return c.call(ctx, http.MethodPost, "/v1/runners/"+url.PathEscape(hb.RunnerId)+"/heartbeat", hb, nil, opts...)
+}
+
+// DeregisterRunner removes a runner from the registry, e.g. on shutdown,
+// rather than leaving it listed until its heartbeats time out. A runner that
+// is already gone is not an error.
+func (c *ControlPlaneClient) DeregisterRunner(ctx context.Context, runnerID string, opts ...RequestOption) error {
+	err := c.call(ctx, http.MethodDelete, "/v1/runners/"+url.PathEscape(runnerID), nil, nil, opts...)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// HeartbeatOption configures StartHeartbeatLoop.
+type HeartbeatOption func(*heartbeatOptions)
+
+type heartbeatOptions struct {
+	drain        func(ctx context.Context) error
+	drainTimeout time.Duration
+}
+
+// WithDrain makes a deregistering stop call drain between the final
+// unhealthy heartbeat and DeregisterRunner, so in-flight jobs can finish
+// before the runner leaves the registry. drain runs under stop's ctx,
+// cancelled after timeout by the client's Clock when timeout is positive.
+// The runner is deregistered however drain ends; its error is returned with
+// the others.
+func WithDrain(timeout time.Duration, drain func(ctx context.Context) error) HeartbeatOption {
+	return func(o *heartbeatOptions) {
+		o.drain = drain
+		o.drainTimeout = timeout
+	}
+}
+
+// StartHeartbeatLoop sends the heartbeat returned by report right away and
+// then every interval, until ctx is cancelled, stop is called or the client
+// is closed. Failed heartbeats are logged and retried at the next interval.
+//
+// stop ends the loop and waits for it to exit. With deregister set, it then
+// shuts the runner down gracefully: it sends a final heartbeat with status
+// unhealthy, so the control plane stops assigning jobs, waits for the drain
+// set by WithDrain, if any, and calls DeregisterRunner. All three run under
+// stop's ctx and their errors are returned together.
+func (c *ControlPlaneClient) StartHeartbeatLoop(ctx context.Context, interval time.Duration, report func() RunnerHeartbeat, opts ...HeartbeatOption) (stop func(ctx context.Context, deregister bool) error) {
+	var o heartbeatOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ctx, release, err := c.startBackground(ctx)
+		if err != nil {
+			return
+		}
+		defer release()
+		for {
+			hb := report()
+			if err := c.SendHeartbeat(ctx, hb); err != nil && ctx.Err() == nil {
+				c.config.Logger.Warn("controlplane: heartbeat failed", "runner", hb.RunnerId, "error", err)
+			}
+			if c.config.Clock.Sleep(ctx, interval) != nil {
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func(stopCtx context.Context, deregister bool) error {
+		var err error
+		once.Do(func() {
+			cancel()
+			<-done
+			if !deregister {
+				return
+			}
+			hb := report()
+			hb.Status = HeartbeatStatusUNHEALTHY
+			hb.Timestamp = time.Time{}
+			if herr := c.SendHeartbeat(stopCtx, hb); herr != nil {
+				err = fmt.Errorf("final heartbeat: %w", herr)
+			}
+			if o.drain != nil {
+				if derr := c.drain(stopCtx, o); derr != nil {
+					err = errors.Join(err, fmt.Errorf("drain: %w", derr))
+				}
+			}
+			if derr := c.DeregisterRunner(stopCtx, hb.RunnerId); derr != nil {
+				err = errors.Join(err, fmt.Errorf("deregister: %w", derr))
+			}
+		})
+		return err
+	}
+}
+
+// drain runs o.drain, cancelling its context once o.drainTimeout passes.
+func (c *ControlPlaneClient) drain(ctx context.Context, o heartbeatOptions) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if o.drainTimeout > 0 {
+		go func() {
+			if c.config.Clock.Sleep(ctx, o.drainTimeout) == nil {
+				cancel(context.DeadlineExceeded)
+			}
+		}()
+	}
+	if err := o.drain(ctx); err != nil {
+		if cause := context.Cause(ctx); cause == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s: %w", o.drainTimeout, err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type runnerServer struct {
+	mu        sync.Mutex
+	calls     []string
+	beats     chan RunnerHeartbeat
+	beatsSent []RunnerHeartbeat
+	deregCode int
+}
+
+func (s *runnerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+	switch r.Method {
+	case http.MethodPost:
+		var hb RunnerHeartbeat
+		json.NewDecoder(r.Body).Decode(&hb)
+		s.beatsSent = append(s.beatsSent, hb)
+		if s.beats != nil {
+			select {
+			case s.beats <- hb:
+			default:
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		w.WriteHeader(s.deregCode)
+	}
+}
+
+func TestStartHeartbeatLoopDrainsThenDeregisters(t *testing.T) {
+	srv := &runnerServer{beats: make(chan RunnerHeartbeat, 1), deregCode: http.StatusNoContent}
+	client := NewTestClient(srv)
+	report := func() RunnerHeartbeat {
+		return RunnerHeartbeat{RunnerId: "r 1", Status: "healthy", ActiveJobs: Int(2)}
+	}
+
+	drain := func(ctx context.Context) error {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		srv.calls = append(srv.calls, "drain")
+		return nil
+	}
+	stop := client.StartHeartbeatLoop(context.Background(), time.Hour, report, WithDrain(time.Minute, drain))
+	select {
+	case hb := <-srv.beats:
+		if hb.Timestamp.IsZero() {
+			t.Error("heartbeat sent without timestamp")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no heartbeat sent")
+	}
+	if err := stop(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	wantCalls := []string{
+		"POST /v1/runners/r 1/heartbeat", "POST /v1/runners/r 1/heartbeat", "drain", "DELETE /v1/runners/r 1",
+	}
+	if !reflect.DeepEqual(srv.calls, wantCalls) {
+		t.Errorf("calls = %v", srv.calls)
+	}
+	var statuses []string
+	for _, hb := range srv.beatsSent {
+		statuses = append(statuses, hb.Status)
+	}
+	if !reflect.DeepEqual(statuses, []string{"healthy", HeartbeatStatusUNHEALTHY}) {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if final := srv.beatsSent[len(srv.beatsSent)-1]; final.Validate() != nil {
+		t.Errorf("final heartbeat breaks the contract: %v", final.Validate())
+	}
+	if err := stop(context.Background(), true); err != nil {
+		t.Errorf("second stop: %v", err)
+	}
+	if len(srv.calls) != 4 {
+		t.Errorf("second stop made calls: %v", srv.calls)
+	}
+}
+
+func TestStartHeartbeatLoopDrainTimeout(t *testing.T) {
+	srv := &runnerServer{beats: make(chan RunnerHeartbeat, 1), deregCode: http.StatusNoContent}
+	client := NewTestClient(srv)
+	stop := client.StartHeartbeatLoop(context.Background(), time.Hour, func() RunnerHeartbeat {
+		return RunnerHeartbeat{RunnerId: "r1", Status: "healthy"}
+	}, WithDrain(10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	<-srv.beats
+	err := stop(context.Background(), true)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "drain: timed out after 10ms") {
+		t.Errorf("err = %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if last := srv.calls[len(srv.calls)-1]; last != "DELETE /v1/runners/r1" {
+		t.Errorf("runner not deregistered after the drain timed out: %v", srv.calls)
+	}
+}
+
+func TestStartHeartbeatLoopStopWithoutDeregister(t *testing.T) {
+	srv := &runnerServer{beats: make(chan RunnerHeartbeat, 1)}
+	client := NewTestClient(srv)
+	stop := client.StartHeartbeatLoop(context.Background(), time.Hour, func() RunnerHeartbeat {
+		return RunnerHeartbeat{RunnerId: "r1", Status: "healthy"}
+	})
+	<-srv.beats
+	if err := stop(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.calls) != 1 {
+		t.Errorf("calls = %v", srv.calls)
+	}
+}
+
+func TestDeregisterRunnerTreatsNotFoundAsSuccess(t *testing.T) {
+	for _, tt := range []struct {
+		code    int
+		wantErr bool
+	}{
+		{http.StatusNoContent, false},
+		{http.StatusNotFound, false},
+		{http.StatusForbidden, true},
+	} {
+		client := NewTestClient(&runnerServer{deregCode: tt.code})
+		if err := client.DeregisterRunner(context.Background(), "r1"); (err != nil) != tt.wantErr {
+			t.Errorf("status %d: err = %v", tt.code, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package controlplane
+
+import (
+	"strings"
+	"time"
+)
+
+// scheduleSkew is how far in the past ScheduledAt may be before a job is
+// rejected, allowing for clock differences between client and server.
+const scheduleSkew = time.Minute
+
+func init() {
+	registerRule("JobMetadata", func(m JobMetadata, errs *ValidationErrors) {
+		validateJobTimes(m, "", time.Now(), errs)
+	})
+	// A JobRequest's metadata is checked as a nested JobMetadata; see
+	// nestedSchemas.
+}
+
+// Messages of the checks that depend on the current time.
+const (
+	scheduledInPast = "is in the past"
+	alreadyExpired  = "job has already expired at "
+)
+
+// validateJobTimes checks that the schedule and expiry of a job are
+// consistent and still ahead of now. prefix is prepended to field names.
+func validateJobTimes(m JobMetadata, prefix string, now time.Time, errs *ValidationErrors) {
+	if !m.ScheduledAt.IsZero() && m.ScheduledAt.Before(now.Add(-scheduleSkew)) {
+		errs.Add(prefix+"scheduledAt", scheduledInPast)
+	}
+	if m.ExpiresAt.IsZero() {
+		return
+	}
+	if !m.CreatedAt.IsZero() && !m.ExpiresAt.After(m.CreatedAt) {
+		errs.Add(prefix+"expiresAt", "must be after createdAt")
+	}
+	if !m.ScheduledAt.IsZero() && !m.ExpiresAt.After(m.ScheduledAt) {
+		errs.Add(prefix+"expiresAt", "must be after scheduledAt")
+	}
+	if m.IsExpired(now) {
+		errs.Add(prefix+"expiresAt", alreadyExpired+m.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+// ValidateAt is Validate with ScheduledAt and ExpiresAt checked against
+// now, such as a Clock's Now, rather than the system time.
+func (m JobMetadata) ValidateAt(now time.Time) error {
+	return revalidateJobTimes(m.Validate(), m, "", now)
+}
+
+// ValidateAt is Validate with the schedule and expiry of the job's metadata
+// checked against now, such as a Clock's Now, rather than the system time.
+func (m JobRequest) ValidateAt(now time.Time) error {
+	err := m.Validate()
+	var meta JobMetadata
+	if m.Metadata == nil || decodeMap(m.Metadata, &meta) != nil {
+		return err
+	}
+	return revalidateJobTimes(err, meta, "metadata.", now)
+}
+
+// revalidateJobTimes replaces the failures in err of the checks
+// validateJobTimes made against the system time with the same checks
+// against now.
+func revalidateJobTimes(err error, m JobMetadata, prefix string, now time.Time) error {
+	verrs, ok := err.(*ValidationErrors)
+	if err != nil && !ok {
+		return err
+	}
+	var errs ValidationErrors
+	if ok {
+		for _, e := range verrs.Errors {
+			timeDependent := (e.Field == prefix+"scheduledAt" && e.Message == scheduledInPast) ||
+				(e.Field == prefix+"expiresAt" && strings.HasPrefix(e.Message, alreadyExpired))
+			if !timeDependent {
+				errs.Add(e.Field, e.Message)
+			}
+		}
+	}
+	var timed ValidationErrors
+	validateJobTimes(m, prefix, now, &timed)
+	for _, e := range timed.Errors {
+		if e.Message == scheduledInPast || strings.HasPrefix(e.Message, alreadyExpired) {
+			errs.Add(e.Field, e.Message)
+		}
+	}
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// IsExpired reports whether the job's ExpiresAt has passed at now. A job
+// without an expiry never expires.
+func (m JobMetadata) IsExpired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// TimeUntilScheduled returns how long after now the job is scheduled to
+// run, or zero if it is unscheduled or already due.
+func (m JobMetadata) TimeUntilScheduled(now time.Time) time.Duration {
+	if m.ScheduledAt.IsZero() || !m.ScheduledAt.After(now) {
+		return 0
+	}
+	return m.ScheduledAt.Sub(now)
+}
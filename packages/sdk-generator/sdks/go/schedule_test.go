@@ -0,0 +1,103 @@
+package controlplane
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobMetadataTimes(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		meta JobMetadata
+		want string // substring of the first error, "" for valid
+	}{
+		{"valid", JobMetadata{Source: "api", CreatedAt: now, ScheduledAt: now.Add(time.Hour), ExpiresAt: now.Add(2 * time.Hour)}, ""},
+		{"within skew", JobMetadata{Source: "api", ScheduledAt: now.Add(-30 * time.Second)}, ""},
+		{"past schedule", JobMetadata{Source: "api", ScheduledAt: now.Add(-time.Hour)}, "scheduledAt: is in the past"},
+		{"expires before created", JobMetadata{Source: "api", CreatedAt: now.Add(2 * time.Hour), ExpiresAt: now.Add(time.Hour)}, "expiresAt: must be after createdAt"},
+		{"expires before scheduled", JobMetadata{Source: "api", ScheduledAt: now.Add(2 * time.Hour), ExpiresAt: now.Add(time.Hour)}, "expiresAt: must be after scheduledAt"},
+		{"expired", JobMetadata{Source: "api", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}, "expiresAt: job has already expired"},
+	}
+	for _, tt := range tests {
+		err := tt.meta.Validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestJobRequestMetadataTimes(t *testing.T) {
+	job := JobRequest{
+		Id:      "job-1",
+		Type:    "noop",
+		Payload: map[string]interface{}{},
+		Metadata: map[string]interface{}{
+			"source":      "api",
+			"createdAt":   time.Now().Format(time.RFC3339),
+			"scheduledAt": time.Now().Add(2 * time.Hour).Format(time.RFC3339),
+			"expiresAt":   time.Now().Add(time.Hour).Format(time.RFC3339),
+		},
+	}
+	err := job.Validate()
+	if err == nil || !strings.Contains(err.Error(), "metadata.expiresAt") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestValidateAt(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	meta := JobMetadata{Source: "api", CreatedAt: now.Add(-time.Hour), ScheduledAt: now.Add(time.Hour), ExpiresAt: now.Add(2 * time.Hour)}
+	if err := meta.ValidateAt(now); err != nil {
+		t.Errorf("valid at now: %v", err)
+	}
+	err := meta.ValidateAt(now.Add(3 * time.Hour))
+	if got := fieldsOf(err); !reflect.DeepEqual(got, []string{"scheduledAt", "expiresAt"}) {
+		t.Errorf("fields = %v (%v)", got, err)
+	}
+
+	job := JobRequest{Id: "job-1", Type: "noop", Payload: map[string]interface{}{"type": "noop", "data": map[string]interface{}{}}}
+	if err := decodeMap(meta, &job.Metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.ValidateAt(now); err != nil {
+		t.Errorf("job valid at now: %v", err)
+	}
+	err = job.ValidateAt(now.Add(3 * time.Hour))
+	if got := fieldsOf(err); !reflect.DeepEqual(got, []string{"metadata.scheduledAt", "metadata.expiresAt"}) {
+		t.Errorf("job fields = %v (%v)", got, err)
+	}
+
+	// The system time is past the expiry; the client's clock is not.
+	client := mustNewClient(t, ClientConfig{BaseURL: testBaseURL, Clock: &manualClock{now: now}})
+	if err := client.Validate(job); err != nil {
+		t.Errorf("client.Validate ignored the client's clock: %v", err)
+	}
+}
+
+func fieldsOf(err error) []string {
+	verrs, _ := err.(*ValidationErrors)
+	return verrs.Fields()
+}
+
+func TestJobMetadataHelpers(t *testing.T) {
+	now := time.Now()
+	m := JobMetadata{ScheduledAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)}
+	if m.IsExpired(now) || !m.IsExpired(now.Add(time.Hour)) {
+		t.Error("IsExpired boundary wrong")
+	}
+	if d := m.TimeUntilScheduled(now); d != time.Minute {
+		t.Errorf("TimeUntilScheduled = %v", d)
+	}
+	if d := m.TimeUntilScheduled(now.Add(time.Hour)); d != 0 {
+		t.Errorf("TimeUntilScheduled after due = %v", d)
+	}
+	if (JobMetadata{}).IsExpired(now) {
+		t.Error("job without expiry expired")
+	}
+}
@@ -264,13 +264,128 @@ var SchemaRegistry = map[string]SchemaValidator{
 	},
 }
 
+// requiredNumericFields lists, by schema, the required numeric fields. Zero
+// is a legal value for them, so Validate cannot tell a missing field from a
+// zero one; UnmarshalValidate and DecodeValidate check them for presence.
+var requiredNumericFields = map[string][]string{
+	"ContractVersion":         {"major", "minor", "patch"},
+	"RunnerExecutionResponse": {"executionTimeMs"},
+	"TruthQueryResult":        {"totalCount", "queryTimeMs"},
+	"HealthCheck":             {"uptime"},
+	"PaginatedResponse":       {"total", "limit", "offset"},
+	"ApiResponse":             {"statusCode"},
+	"MarketplaceQueryResult":  {"total"},
+}
+
+// Value sets of the contract enums, in declaration order. An enum declared
+// inline on a field shares the set of the enum schema with the same values,
+// or else has its own, named after its schema and field.
+var (
+	errorSeverityValues                               = []string{ErrorSeverityFATAL, ErrorSeverityERROR, ErrorSeverityWARNING, ErrorSeverityINFO}
+	errorCategoryValues                               = []string{ErrorCategoryVALIDATION_ERROR, ErrorCategorySCHEMA_MISMATCH, ErrorCategoryRUNTIME_ERROR, ErrorCategoryTIMEOUT, ErrorCategoryNETWORK_ERROR, ErrorCategoryAUTHENTICATION_ERROR, ErrorCategoryAUTHORIZATION_ERROR, ErrorCategoryRESOURCE_NOT_FOUND, ErrorCategoryRESOURCE_CONFLICT, ErrorCategoryRATE_LIMITED, ErrorCategorySERVICE_UNAVAILABLE, ErrorCategoryRUNNER_ERROR, ErrorCategoryTRUTHCORE_ERROR, ErrorCategoryINTERNAL_ERROR}
+	jobStatusValues                                   = []string{JobStatusPENDING, JobStatusQUEUED, JobStatusRUNNING, JobStatusCOMPLETED, JobStatusFAILED, JobStatusCANCELLED, JobStatusRETRYING}
+	consistencyLevelValues                            = []string{ConsistencyLevelSTRICT, ConsistencyLevelEVENTUAL, ConsistencyLevelBEST_EFFORT}
+	healthStatusValues                                = []string{HealthStatusHEALTHY, HealthStatusDEGRADED, HealthStatusUNHEALTHY, HealthStatusUNKNOWN}
+	connectorTypeValues                               = []string{ConnectorTypeDATABASE, ConnectorTypeQUEUE, ConnectorTypeSTORAGE, ConnectorTypeAPI, ConnectorTypeWEBHOOK, ConnectorTypeSTREAM, ConnectorTypeCACHE, ConnectorTypeMESSAGING}
+	runnerCategoryValues                              = []string{RunnerCategoryOPS, RunnerCategoryFINOPS, RunnerCategorySUPPORT, RunnerCategoryGROWTH, RunnerCategoryANALYTICS, RunnerCategorySECURITY, RunnerCategoryINFRASTRUCTURE, RunnerCategoryCUSTOM}
+	trustStatusValues                                 = []string{TrustStatusVERIFIED, TrustStatusPENDING, TrustStatusFAILED, TrustStatusUNVERIFIED}
+	securityScanStatusValues                          = []string{SecurityScanStatusPASSED, SecurityScanStatusFAILED, SecurityScanStatusPENDING, SecurityScanStatusNOT_SCANNED}
+	contractTestStatusValues                          = []string{ContractTestStatusPASSING, ContractTestStatusFAILING, ContractTestStatusNOT_TESTED, ContractTestStatusSTALE}
+	verificationMethodValues                          = []string{VerificationMethodAUTOMATED_CI, VerificationMethodMANUAL_REVIEW, VerificationMethodCOMMUNITY_VERIFIED, VerificationMethodOFFICIAL_PUBLISHER}
+	runnerMetadataStatusValues                        = []string{"healthy", "degraded", "unhealthy", "offline"}
+	runnerHeartbeatStatusValues                       = []string{"healthy", "degraded", "unhealthy"}
+	truthCoreRequestTypeValues                        = []string{"assert", "query", "subscribe", "unsubscribe"}
+	serviceMetadataEnvironmentValues                  = []string{"development", "staging", "production"}
+	paginatedRequestSortOrderValues                   = []string{"asc", "desc"}
+	apiRequestMethodValues                            = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	connectorInstanceStatusValues                     = []string{"connected", "disconnected", "error", "unknown"}
+	registryQueryHealthStatusValues                   = []string{"healthy", "degraded", "unhealthy", "offline", "any"}
+	marketplaceRunnerStatusValues                     = []string{"active", "deprecated", "pending_review", "rejected", "delisted"}
+	marketplaceConnectorStatusValues                  = []string{"active", "deprecated", "pending_review", "rejected", "delisted"}
+	marketplaceQueryTypeValues                        = []string{"runner", "connector", "all"}
+	marketplaceQueryStatusValues                      = []string{"active", "deprecated", "pending_review", "all"}
+	marketplaceQueryTrustLevelValues                  = []string{"verified", "community", "all"}
+	marketplaceQuerySortByValues                      = []string{"relevance", "name", "published", "updated", "rating", "downloads"}
+	marketplaceQuerySortOrderValues                   = []string{"asc", "desc"}
+	marketplaceTrustSignalsMaintainerReputationValues = []string{"official", "verified", "community", "unknown"}
+)
+
+// enumFields maps a schema name and JSON field name to the values the field
+// may take. Validate checks each field against them.
+var enumFields = map[string]map[string][]string{
+	"ErrorEnvelope": {
+		"category": errorCategoryValues,
+		"severity": errorSeverityValues,
+	},
+	"JobResponse": {
+		"status": jobStatusValues,
+	},
+	"RunnerMetadata": {
+		"status": runnerMetadataStatusValues,
+	},
+	"RunnerHeartbeat": {
+		"status": runnerHeartbeatStatusValues,
+	},
+	"TruthCoreRequest": {
+		"type": truthCoreRequestTypeValues,
+	},
+	"HealthCheck": {
+		"status": healthStatusValues,
+	},
+	"ServiceMetadata": {
+		"environment": serviceMetadataEnvironmentValues,
+	},
+	"PaginatedRequest": {
+		"sortOrder": paginatedRequestSortOrderValues,
+	},
+	"ApiRequest": {
+		"method": apiRequestMethodValues,
+	},
+	"RegisteredRunner": {
+		"category": runnerCategoryValues,
+	},
+	"ConnectorConfig": {
+		"type": connectorTypeValues,
+	},
+	"ConnectorInstance": {
+		"status": connectorInstanceStatusValues,
+	},
+	"RegistryQuery": {
+		"category":      runnerCategoryValues,
+		"connectorType": connectorTypeValues,
+		"healthStatus":  registryQueryHealthStatusValues,
+	},
+	"MarketplaceRunner": {
+		"category": runnerCategoryValues,
+		"status":   marketplaceRunnerStatusValues,
+	},
+	"MarketplaceConnector": {
+		"status": marketplaceConnectorStatusValues,
+	},
+	"MarketplaceQuery": {
+		"type":       marketplaceQueryTypeValues,
+		"status":     marketplaceQueryStatusValues,
+		"trustLevel": marketplaceQueryTrustLevelValues,
+		"sortBy":     marketplaceQuerySortByValues,
+		"sortOrder":  marketplaceQuerySortOrderValues,
+	},
+	"MarketplaceTrustSignals": {
+		"overallTrust":         trustStatusValues,
+		"contractTestStatus":   contractTestStatusValues,
+		"verificationMethod":   verificationMethodValues,
+		"securityScanStatus":   securityScanStatusValues,
+		"maintainerReputation": marketplaceTrustSignalsMaintainerReputationValues,
+	},
+}
+
 // validateRetryPolicy validates a RetryPolicy instance
 func validateRetryPolicy(m RetryPolicy) error {
 	var errs ValidationErrors
 
+	applyRules("RetryPolicy", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -282,9 +397,10 @@ func validateErrorDetail(m ErrorDetail) error {
 	if m.Message == "" {
 		errs.Add("message", "is required")
 	}
+	applyRules("ErrorDetail", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -311,9 +427,12 @@ func validateErrorEnvelope(m ErrorEnvelope) error {
 	if m.Service == "" {
 		errs.Add("service", "is required")
 	}
+	checkEnum("category", m.Category, errorCategoryValues, &errs)
+	checkEnum("severity", m.Severity, errorSeverityValues, &errs)
+	applyRules("ErrorEnvelope", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -322,18 +441,10 @@ func validateErrorEnvelope(m ErrorEnvelope) error {
 func validateContractVersion(m ContractVersion) error {
 	var errs ValidationErrors
 
-	if m.Major == 0 {
-		errs.Add("major", "is required")
-	}
-	if m.Minor == 0 {
-		errs.Add("minor", "is required")
-	}
-	if m.Patch == 0 {
-		errs.Add("patch", "is required")
-	}
+	applyRules("ContractVersion", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -342,9 +453,10 @@ func validateContractVersion(m ContractVersion) error {
 func validateContractRange(m ContractRange) error {
 	var errs ValidationErrors
 
+	applyRules("ContractRange", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -356,9 +468,10 @@ func validateJobMetadata(m JobMetadata) error {
 	if m.Source == "" {
 		errs.Add("source", "is required")
 	}
+	applyRules("JobMetadata", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -370,9 +483,10 @@ func validateJobPayload(m JobPayload) error {
 	if m.Type == "" {
 		errs.Add("type", "is required")
 	}
+	applyRules("JobPayload", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -387,9 +501,10 @@ func validateJobRequest(m JobRequest) error {
 	if m.Type == "" {
 		errs.Add("type", "is required")
 	}
+	applyRules("JobRequest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -398,9 +513,10 @@ func validateJobRequest(m JobRequest) error {
 func validateJobResult(m JobResult) error {
 	var errs ValidationErrors
 
+	applyRules("JobResult", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -415,9 +531,11 @@ func validateJobResponse(m JobResponse) error {
 	if m.Status == "" {
 		errs.Add("status", "is required")
 	}
+	checkEnum("status", m.Status, jobStatusValues, &errs)
+	applyRules("JobResponse", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -438,9 +556,10 @@ func validateRunnerCapability(m RunnerCapability) error {
 	if m.Description == "" {
 		errs.Add("description", "is required")
 	}
+	applyRules("RunnerCapability", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -461,9 +580,11 @@ func validateRunnerMetadata(m RunnerMetadata) error {
 	if m.HealthCheckEndpoint == "" {
 		errs.Add("healthCheckEndpoint", "is required")
 	}
+	checkEnum("status", m.Status, runnerMetadataStatusValues, &errs)
+	applyRules("RunnerMetadata", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -481,9 +602,10 @@ func validateRunnerRegistrationRequest(m RunnerRegistrationRequest) error {
 	if m.HealthCheckEndpoint == "" {
 		errs.Add("healthCheckEndpoint", "is required")
 	}
+	applyRules("RunnerRegistrationRequest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -495,9 +617,10 @@ func validateRunnerRegistrationResponse(m RunnerRegistrationResponse) error {
 	if m.RunnerId == "" {
 		errs.Add("runnerId", "is required")
 	}
+	applyRules("RunnerRegistrationResponse", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -512,9 +635,11 @@ func validateRunnerHeartbeat(m RunnerHeartbeat) error {
 	if m.Status == "" {
 		errs.Add("status", "is required")
 	}
+	checkEnum("status", m.Status, runnerHeartbeatStatusValues, &errs)
+	applyRules("RunnerHeartbeat", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -538,9 +663,10 @@ func validateModuleManifest(m ModuleManifest) error {
 	if m.EntryPoint == "" {
 		errs.Add("entryPoint", "is required")
 	}
+	applyRules("ModuleManifest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -558,9 +684,10 @@ func validateRunnerExecutionRequest(m RunnerExecutionRequest) error {
 	if m.CapabilityId == "" {
 		errs.Add("capabilityId", "is required")
 	}
+	applyRules("RunnerExecutionRequest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -572,15 +699,13 @@ func validateRunnerExecutionResponse(m RunnerExecutionResponse) error {
 	if m.JobId == "" {
 		errs.Add("jobId", "is required")
 	}
-	if m.ExecutionTimeMs == 0 {
-		errs.Add("executionTimeMs", "is required")
-	}
 	if m.RunnerId == "" {
 		errs.Add("runnerId", "is required")
 	}
+	applyRules("RunnerExecutionResponse", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -601,9 +726,10 @@ func validateTruthAssertion(m TruthAssertion) error {
 	if m.Source == "" {
 		errs.Add("source", "is required")
 	}
+	applyRules("TruthAssertion", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -615,9 +741,10 @@ func validateTruthQuery(m TruthQuery) error {
 	if m.Id == "" {
 		errs.Add("id", "is required")
 	}
+	applyRules("TruthQuery", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -629,15 +756,10 @@ func validateTruthQueryResult(m TruthQueryResult) error {
 	if m.QueryId == "" {
 		errs.Add("queryId", "is required")
 	}
-	if m.TotalCount == 0 {
-		errs.Add("totalCount", "is required")
-	}
-	if m.QueryTimeMs == 0 {
-		errs.Add("queryTimeMs", "is required")
-	}
+	applyRules("TruthQueryResult", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -649,9 +771,10 @@ func validateTruthSubscription(m TruthSubscription) error {
 	if m.Id == "" {
 		errs.Add("id", "is required")
 	}
+	applyRules("TruthSubscription", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -666,9 +789,11 @@ func validateTruthCoreRequest(m TruthCoreRequest) error {
 	if m.Type == "" {
 		errs.Add("type", "is required")
 	}
+	checkEnum("type", m.Type, truthCoreRequestTypeValues, &errs)
+	applyRules("TruthCoreRequest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -680,9 +805,10 @@ func validateTruthCoreResponse(m TruthCoreResponse) error {
 	if m.RequestId == "" {
 		errs.Add("requestId", "is required")
 	}
+	applyRules("TruthCoreResponse", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -700,12 +826,11 @@ func validateHealthCheck(m HealthCheck) error {
 	if m.Version == "" {
 		errs.Add("version", "is required")
 	}
-	if m.Uptime == 0 {
-		errs.Add("uptime", "is required")
-	}
+	checkEnum("status", m.Status, healthStatusValues, &errs)
+	applyRules("HealthCheck", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -723,9 +848,11 @@ func validateServiceMetadata(m ServiceMetadata) error {
 	if m.ContractVersion == "" {
 		errs.Add("contractVersion", "is required")
 	}
+	checkEnum("environment", m.Environment, serviceMetadataEnvironmentValues, &errs)
+	applyRules("ServiceMetadata", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -734,9 +861,11 @@ func validateServiceMetadata(m ServiceMetadata) error {
 func validatePaginatedRequest(m PaginatedRequest) error {
 	var errs ValidationErrors
 
+	checkEnum("sortOrder", m.SortOrder, paginatedRequestSortOrderValues, &errs)
+	applyRules("PaginatedRequest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -745,18 +874,10 @@ func validatePaginatedRequest(m PaginatedRequest) error {
 func validatePaginatedResponse(m PaginatedResponse) error {
 	var errs ValidationErrors
 
-	if m.Total == 0 {
-		errs.Add("total", "is required")
-	}
-	if m.Limit == 0 {
-		errs.Add("limit", "is required")
-	}
-	if m.Offset == 0 {
-		errs.Add("offset", "is required")
-	}
+	applyRules("PaginatedResponse", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -774,9 +895,11 @@ func validateApiRequest(m ApiRequest) error {
 	if m.Path == "" {
 		errs.Add("path", "is required")
 	}
+	checkEnum("method", m.Method, apiRequestMethodValues, &errs)
+	applyRules("ApiRequest", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -788,12 +911,10 @@ func validateApiResponse(m ApiResponse) error {
 	if m.RequestId == "" {
 		errs.Add("requestId", "is required")
 	}
-	if m.StatusCode == 0 {
-		errs.Add("statusCode", "is required")
-	}
+	applyRules("ApiResponse", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -805,9 +926,10 @@ func validateCapabilityRegistry(m CapabilityRegistry) error {
 	if m.Version == "" {
 		errs.Add("version", "is required")
 	}
+	applyRules("CapabilityRegistry", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -819,9 +941,11 @@ func validateRegisteredRunner(m RegisteredRunner) error {
 	if m.Category == "" {
 		errs.Add("category", "is required")
 	}
+	checkEnum("category", m.Category, runnerCategoryValues, &errs)
+	applyRules("RegisteredRunner", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -845,9 +969,11 @@ func validateConnectorConfig(m ConnectorConfig) error {
 	if m.Description == "" {
 		errs.Add("description", "is required")
 	}
+	checkEnum("type", m.Type, connectorTypeValues, &errs)
+	applyRules("ConnectorConfig", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -859,9 +985,11 @@ func validateConnectorInstance(m ConnectorInstance) error {
 	if m.Status == "" {
 		errs.Add("status", "is required")
 	}
+	checkEnum("status", m.Status, connectorInstanceStatusValues, &errs)
+	applyRules("ConnectorInstance", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -870,9 +998,13 @@ func validateConnectorInstance(m ConnectorInstance) error {
 func validateRegistryQuery(m RegistryQuery) error {
 	var errs ValidationErrors
 
+	checkEnum("category", m.Category, runnerCategoryValues, &errs)
+	checkEnum("connectorType", m.ConnectorType, connectorTypeValues, &errs)
+	checkEnum("healthStatus", m.HealthStatus, registryQueryHealthStatusValues, &errs)
+	applyRules("RegistryQuery", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -887,9 +1019,10 @@ func validateRegistryDiff(m RegistryDiff) error {
 	if m.CurrentChecksum == "" {
 		errs.Add("currentChecksum", "is required")
 	}
+	applyRules("RegistryDiff", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -901,9 +1034,10 @@ func validateMarketplaceIndex(m MarketplaceIndex) error {
 	if m.Version == "" {
 		errs.Add("version", "is required")
 	}
+	applyRules("MarketplaceIndex", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -924,9 +1058,12 @@ func validateMarketplaceRunner(m MarketplaceRunner) error {
 	if m.License == "" {
 		errs.Add("license", "is required")
 	}
+	checkEnum("category", m.Category, runnerCategoryValues, &errs)
+	checkEnum("status", m.Status, marketplaceRunnerStatusValues, &errs)
+	applyRules("MarketplaceRunner", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -944,9 +1081,11 @@ func validateMarketplaceConnector(m MarketplaceConnector) error {
 	if m.License == "" {
 		errs.Add("license", "is required")
 	}
+	checkEnum("status", m.Status, marketplaceConnectorStatusValues, &errs)
+	applyRules("MarketplaceConnector", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -955,9 +1094,15 @@ func validateMarketplaceConnector(m MarketplaceConnector) error {
 func validateMarketplaceQuery(m MarketplaceQuery) error {
 	var errs ValidationErrors
 
+	checkEnum("type", m.Type, marketplaceQueryTypeValues, &errs)
+	checkEnum("status", m.Status, marketplaceQueryStatusValues, &errs)
+	checkEnum("trustLevel", m.TrustLevel, marketplaceQueryTrustLevelValues, &errs)
+	checkEnum("sortBy", m.SortBy, marketplaceQuerySortByValues, &errs)
+	checkEnum("sortOrder", m.SortOrder, marketplaceQuerySortOrderValues, &errs)
+	applyRules("MarketplaceQuery", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -966,12 +1111,10 @@ func validateMarketplaceQuery(m MarketplaceQuery) error {
 func validateMarketplaceQueryResult(m MarketplaceQueryResult) error {
 	var errs ValidationErrors
 
-	if m.Total == 0 {
-		errs.Add("total", "is required")
-	}
+	applyRules("MarketplaceQueryResult", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -992,9 +1135,51 @@ func validateMarketplaceTrustSignals(m MarketplaceTrustSignals) error {
 	if m.SecurityScanStatus == "" {
 		errs.Add("securityScanStatus", "is required")
 	}
+	checkEnum("overallTrust", m.OverallTrust, trustStatusValues, &errs)
+	checkEnum("contractTestStatus", m.ContractTestStatus, contractTestStatusValues, &errs)
+	checkEnum("verificationMethod", m.VerificationMethod, verificationMethodValues, &errs)
+	checkEnum("securityScanStatus", m.SecurityScanStatus, securityScanStatusValues, &errs)
+	checkEnum("maintainerReputation", m.MaintainerReputation, marketplaceTrustSignalsMaintainerReputationValues, &errs)
+	applyRules("MarketplaceTrustSignals", m, &errs)
+
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// validateJobId validates a JobId instance
+func validateJobId(m JobId) error {
+	var errs ValidationErrors
+
+	applyRules("JobId", m, &errs)
+
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// validateJobPriority validates a JobPriority instance
+func validateJobPriority(m JobPriority) error {
+	var errs ValidationErrors
+
+	applyRules("JobPriority", m, &errs)
+
+	if !errs.IsValid() {
+		return &errs
+	}
+	return nil
+}
+
+// validateTruthValue validates a TruthValue instance
+func validateTruthValue(m TruthValue) error {
+	var errs ValidationErrors
+
+	applyRules("TruthValue", m, &errs)
 
 	if !errs.IsValid() {
-		return errs
+		return &errs
 	}
 	return nil
 }
@@ -0,0 +1,66 @@
+package controlplane
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUnknownSchema is returned for a schema name that is not in
+// SchemaRegistry.
+var ErrUnknownSchema = errors.New("unknown schema")
+
+// registeredTypes maps each SchemaRegistry name to its Go type.
+var registeredTypes = map[string]reflect.Type{
+	"RetryPolicy":                reflect.TypeOf(RetryPolicy{}),
+	"ErrorDetail":                reflect.TypeOf(ErrorDetail{}),
+	"ErrorEnvelope":              reflect.TypeOf(ErrorEnvelope{}),
+	"ContractVersion":            reflect.TypeOf(ContractVersion{}),
+	"ContractRange":              reflect.TypeOf(ContractRange{}),
+	"JobMetadata":                reflect.TypeOf(JobMetadata{}),
+	"JobPayload":                 reflect.TypeOf(JobPayload{}),
+	"JobRequest":                 reflect.TypeOf(JobRequest{}),
+	"JobResult":                  reflect.TypeOf(JobResult{}),
+	"JobResponse":                reflect.TypeOf(JobResponse{}),
+	"RunnerCapability":           reflect.TypeOf(RunnerCapability{}),
+	"RunnerMetadata":             reflect.TypeOf(RunnerMetadata{}),
+	"RunnerRegistrationRequest":  reflect.TypeOf(RunnerRegistrationRequest{}),
+	"RunnerRegistrationResponse": reflect.TypeOf(RunnerRegistrationResponse{}),
+	"RunnerHeartbeat":            reflect.TypeOf(RunnerHeartbeat{}),
+	"ModuleManifest":             reflect.TypeOf(ModuleManifest{}),
+	"RunnerExecutionRequest":     reflect.TypeOf(RunnerExecutionRequest{}),
+	"RunnerExecutionResponse":    reflect.TypeOf(RunnerExecutionResponse{}),
+	"TruthAssertion":             reflect.TypeOf(TruthAssertion{}),
+	"TruthQuery":                 reflect.TypeOf(TruthQuery{}),
+	"TruthQueryResult":           reflect.TypeOf(TruthQueryResult{}),
+	"TruthSubscription":          reflect.TypeOf(TruthSubscription{}),
+	"TruthCoreRequest":           reflect.TypeOf(TruthCoreRequest{}),
+	"TruthCoreResponse":          reflect.TypeOf(TruthCoreResponse{}),
+	"HealthCheck":                reflect.TypeOf(HealthCheck{}),
+	"ServiceMetadata":            reflect.TypeOf(ServiceMetadata{}),
+	"PaginatedRequest":           reflect.TypeOf(PaginatedRequest{}),
+	"PaginatedResponse":          reflect.TypeOf(PaginatedResponse{}),
+	"ApiRequest":                 reflect.TypeOf(ApiRequest{}),
+	"ApiResponse":                reflect.TypeOf(ApiResponse{}),
+	"CapabilityRegistry":         reflect.TypeOf(CapabilityRegistry{}),
+	"RegisteredRunner":           reflect.TypeOf(RegisteredRunner{}),
+	"ConnectorConfig":            reflect.TypeOf(ConnectorConfig{}),
+	"ConnectorInstance":          reflect.TypeOf(ConnectorInstance{}),
+	"RegistryQuery":              reflect.TypeOf(RegistryQuery{}),
+	"RegistryDiff":               reflect.TypeOf(RegistryDiff{}),
+	"MarketplaceIndex":           reflect.TypeOf(MarketplaceIndex{}),
+	"MarketplaceRunner":          reflect.TypeOf(MarketplaceRunner{}),
+	"MarketplaceConnector":       reflect.TypeOf(MarketplaceConnector{}),
+	"MarketplaceQuery":           reflect.TypeOf(MarketplaceQuery{}),
+	"MarketplaceQueryResult":     reflect.TypeOf(MarketplaceQueryResult{}),
+	"MarketplaceTrustSignals":    reflect.TypeOf(MarketplaceTrustSignals{}),
+}
+
+// schemaType returns the Go type registered under name.
+func schemaType(name string) (reflect.Type, error) {
+	t, ok := registeredTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSchema, name)
+	}
+	return t, nil
+}
@@ -0,0 +1,8 @@
+// Auto-generated ControlPlane SDK version
+// DO NOT EDIT MANUALLY - regenerate from source
+
+package controlplane
+
+// SDKVersion is the version of this SDK. It is reported in the User-Agent
+// header of every request.
+const SDKVersion = "1.0.0"
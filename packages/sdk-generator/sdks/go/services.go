@@ -0,0 +1,231 @@
+package controlplane
+
+import (
+	"context"
+	"time"
+)
+
+// The client's endpoint methods are also grouped by API area. Jobs, Runners,
+// Truth, Registry and Marketplace return services that share the client's
+// transport, retries, auth and options; the flat methods remain and behave
+// identically. Code that depends on one area can accept its interface, such
+// as JobsAPI, and be tested against a fake instead of a whole client.
+
+// JobsAPI submits and inspects jobs. *JobsService implements it.
+type JobsAPI interface {
+	Submit(ctx context.Context, job JobRequest, opts ...RequestOption) (*JobResponse, error)
+	Get(ctx context.Context, id string, opts ...RequestOption) (*JobResponse, error)
+	GetWait(ctx context.Context, id string, waitFor time.Duration, opts ...RequestOption) (*JobResponse, error)
+	List(ctx context.Context, filters JobListFilters, page PaginatedRequest, opts ...RequestOption) (*Page[JobResponse], error)
+	Iterate(filters JobListFilters, page PaginatedRequest, opts ...RequestOption) *PageIterator[JobResponse]
+	ListAll(ctx context.Context, filters JobListFilters, opts ...RequestOption) ([]JobResponse, error)
+	SubmitAndWait(ctx context.Context, job JobRequest, wait WaitOpts, opts ...RequestOption) (*JobResponse, error)
+	Wait(ctx context.Context, id string, wait WaitOpts, opts ...RequestOption) (*JobResponse, error)
+}
+
+// RunnersAPI lists registered runners, executes jobs on them and reports
+// their heartbeats. *RunnersService implements it.
+type RunnersAPI interface {
+	List(ctx context.Context, q RegistryQuery, page PaginatedRequest, opts ...RequestOption) (*Page[RegisteredRunner], error)
+	Iterate(q RegistryQuery, page PaginatedRequest, opts ...RequestOption) *PageIterator[RegisteredRunner]
+	ListAll(ctx context.Context, q RegistryQuery, opts ...RequestOption) ([]RegisteredRunner, error)
+	Execute(ctx context.Context, runnerID string, req RunnerExecutionRequest, opts ...RequestOption) (*RunnerExecutionResponse, error)
+	ExecuteStreaming(ctx context.Context, runnerID string, req RunnerExecutionRequest, opts ...RequestOption) (<-chan ExecutionChunk, <-chan error, error)
+	Heartbeat(ctx context.Context, hb RunnerHeartbeat, opts ...RequestOption) error
+	Deregister(ctx context.Context, runnerID string, opts ...RequestOption) error
+}
+
+// TruthAPI records, queries and subscribes to truth assertions.
+// *TruthService implements it.
+type TruthAPI interface {
+	Assert(ctx context.Context, assertion TruthAssertion, opts ...RequestOption) (*TruthAssertion, error)
+	QueryStream(ctx context.Context, query TruthQuery, opts ...RequestOption) (*AssertionStream, error)
+	Subscribe(ctx context.Context, sub TruthSubscription, opts ...RequestOption) (<-chan TruthAssertion, <-chan error, error)
+	StreamAssertions(ctx context.Context, pattern TruthPattern, filters map[string]interface{}, opts ...RequestOption) (<-chan TruthAssertion, <-chan error)
+}
+
+// RegistryAPI reads the capability registry. *RegistryService implements
+// it.
+type RegistryAPI interface {
+	Get(ctx context.Context, opts ...RequestOption) (*CapabilityRegistry, error)
+}
+
+// MarketplaceAPI resolves marketplace runners and connectors.
+// *MarketplaceService implements it.
+type MarketplaceAPI interface {
+	GetRunner(ctx context.Context, id string, opts ...RequestOption) (*MarketplaceRunner, error)
+	GetConnector(ctx context.Context, id string, opts ...RequestOption) (*MarketplaceConnector, error)
+	GetIndex(ctx context.Context, opts ...RequestOption) (*MarketplaceIndex, error)
+}
+
+var (
+	_ JobsAPI        = (*JobsService)(nil)
+	_ RunnersAPI     = (*RunnersService)(nil)
+	_ TruthAPI       = (*TruthService)(nil)
+	_ RegistryAPI    = (*RegistryService)(nil)
+	_ MarketplaceAPI = (*MarketplaceService)(nil)
+)
+
+// JobsService holds the job methods of a client. See ControlPlaneClient.Jobs.
+type JobsService struct{ c *ControlPlaneClient }
+
+// Jobs returns the client's job methods.
+func (c *ControlPlaneClient) Jobs() *JobsService { return &JobsService{c} }
+
+// Submit is SubmitJob.
+func (s *JobsService) Submit(ctx context.Context, job JobRequest, opts ...RequestOption) (*JobResponse, error) {
+	return s.c.SubmitJob(ctx, job, opts...)
+}
+
+// Get is GetJob.
+func (s *JobsService) Get(ctx context.Context, id string, opts ...RequestOption) (*JobResponse, error) {
+	return s.c.GetJob(ctx, id, opts...)
+}
+
+// GetIfModified is GetJobIfModified.
+func (s *JobsService) GetIfModified(ctx context.Context, id, etag string, opts ...RequestOption) (*JobResponse, string, bool, error) {
+	return s.c.GetJobIfModified(ctx, id, etag, opts...)
+}
+
+// GetWait is GetJobWait.
+func (s *JobsService) GetWait(ctx context.Context, id string, waitFor time.Duration, opts ...RequestOption) (*JobResponse, error) {
+	return s.c.GetJobWait(ctx, id, waitFor, opts...)
+}
+
+// List is ListJobs.
+func (s *JobsService) List(ctx context.Context, filters JobListFilters, page PaginatedRequest, opts ...RequestOption) (*Page[JobResponse], error) {
+	return s.c.ListJobs(ctx, filters, page, opts...)
+}
+
+// Iterate is IterateJobs.
+func (s *JobsService) Iterate(filters JobListFilters, page PaginatedRequest, opts ...RequestOption) *PageIterator[JobResponse] {
+	return s.c.IterateJobs(filters, page, opts...)
+}
+
+// ListAll is ListAllJobs.
+func (s *JobsService) ListAll(ctx context.Context, filters JobListFilters, opts ...RequestOption) ([]JobResponse, error) {
+	return s.c.ListAllJobs(ctx, filters, opts...)
+}
+
+// SubmitAndWait is ControlPlaneClient.SubmitAndWait.
+func (s *JobsService) SubmitAndWait(ctx context.Context, job JobRequest, wait WaitOpts, opts ...RequestOption) (*JobResponse, error) {
+	return s.c.SubmitAndWait(ctx, job, wait, opts...)
+}
+
+// Wait is WaitForJob.
+func (s *JobsService) Wait(ctx context.Context, id string, wait WaitOpts, opts ...RequestOption) (*JobResponse, error) {
+	return s.c.WaitForJob(ctx, id, wait, opts...)
+}
+
+// RunnersService holds the runner methods of a client. See
+// ControlPlaneClient.Runners.
+type RunnersService struct{ c *ControlPlaneClient }
+
+// Runners returns the client's runner methods.
+func (c *ControlPlaneClient) Runners() *RunnersService { return &RunnersService{c} }
+
+// List is ListRunners.
+func (s *RunnersService) List(ctx context.Context, q RegistryQuery, page PaginatedRequest, opts ...RequestOption) (*Page[RegisteredRunner], error) {
+	return s.c.ListRunners(ctx, q, page, opts...)
+}
+
+// Iterate is IterateRunners.
+func (s *RunnersService) Iterate(q RegistryQuery, page PaginatedRequest, opts ...RequestOption) *PageIterator[RegisteredRunner] {
+	return s.c.IterateRunners(q, page, opts...)
+}
+
+// ListAll is ListAllRunners.
+func (s *RunnersService) ListAll(ctx context.Context, q RegistryQuery, opts ...RequestOption) ([]RegisteredRunner, error) {
+	return s.c.ListAllRunners(ctx, q, opts...)
+}
+
+// Execute is ExecuteJob.
+func (s *RunnersService) Execute(ctx context.Context, runnerID string, req RunnerExecutionRequest, opts ...RequestOption) (*RunnerExecutionResponse, error) {
+	return s.c.ExecuteJob(ctx, runnerID, req, opts...)
+}
+
+// ExecuteStreaming is ControlPlaneClient.ExecuteStreaming.
+func (s *RunnersService) ExecuteStreaming(ctx context.Context, runnerID string, req RunnerExecutionRequest, opts ...RequestOption) (<-chan ExecutionChunk, <-chan error, error) {
+	return s.c.ExecuteStreaming(ctx, runnerID, req, opts...)
+}
+
+// Heartbeat is SendHeartbeat.
+func (s *RunnersService) Heartbeat(ctx context.Context, hb RunnerHeartbeat, opts ...RequestOption) error {
+	return s.c.SendHeartbeat(ctx, hb, opts...)
+}
+
+// Deregister is DeregisterRunner.
+func (s *RunnersService) Deregister(ctx context.Context, runnerID string, opts ...RequestOption) error {
+	return s.c.DeregisterRunner(ctx, runnerID, opts...)
+}
+
+// TruthService holds the truth methods of a client. See
+// ControlPlaneClient.Truth.
+type TruthService struct{ c *ControlPlaneClient }
+
+// Truth returns the client's truth methods.
+func (c *ControlPlaneClient) Truth() *TruthService { return &TruthService{c} }
+
+// Assert is AssertTruth.
+func (s *TruthService) Assert(ctx context.Context, assertion TruthAssertion, opts ...RequestOption) (*TruthAssertion, error) {
+	return s.c.AssertTruth(ctx, assertion, opts...)
+}
+
+// Query is QueryTruth.
+func (s *TruthService) Query(ctx context.Context, query TruthQuery, opts ...RequestOption) (*TruthQueryResult, error) {
+	return s.c.QueryTruth(ctx, query, opts...)
+}
+
+// QueryStream is QueryTruthStream.
+func (s *TruthService) QueryStream(ctx context.Context, query TruthQuery, opts ...RequestOption) (*AssertionStream, error) {
+	return s.c.QueryTruthStream(ctx, query, opts...)
+}
+
+// Subscribe is SubscribeTruth.
+func (s *TruthService) Subscribe(ctx context.Context, sub TruthSubscription, opts ...RequestOption) (<-chan TruthAssertion, <-chan error, error) {
+	return s.c.SubscribeTruth(ctx, sub, opts...)
+}
+
+// StreamAssertions is StreamTruthAssertions.
+func (s *TruthService) StreamAssertions(ctx context.Context, pattern TruthPattern, filters map[string]interface{}, opts ...RequestOption) (<-chan TruthAssertion, <-chan error) {
+	return s.c.StreamTruthAssertions(ctx, pattern, filters, opts...)
+}
+
+// RegistryService holds the registry methods of a client. See
+// ControlPlaneClient.Registry.
+type RegistryService struct{ c *ControlPlaneClient }
+
+// Registry returns the client's registry methods.
+func (c *ControlPlaneClient) Registry() *RegistryService { return &RegistryService{c} }
+
+// Get is GetCapabilityRegistry.
+func (s *RegistryService) Get(ctx context.Context, opts ...RequestOption) (*CapabilityRegistry, error) {
+	return s.c.GetCapabilityRegistry(ctx, opts...)
+}
+
+// MarketplaceService holds the marketplace methods of a client. See
+// ControlPlaneClient.Marketplace.
+type MarketplaceService struct{ c *ControlPlaneClient }
+
+// Marketplace returns the client's marketplace methods.
+func (c *ControlPlaneClient) Marketplace() *MarketplaceService { return &MarketplaceService{c} }
+
+// GetRunner is GetMarketplaceRunner.
+func (s *MarketplaceService) GetRunner(ctx context.Context, id string, opts ...RequestOption) (*MarketplaceRunner, error) {
+	return s.c.GetMarketplaceRunner(ctx, id, opts...)
+}
+
+// GetConnector is GetMarketplaceConnector.
+func (s *MarketplaceService) GetConnector(ctx context.Context, id string, opts ...RequestOption) (*MarketplaceConnector, error) {
+	return s.c.GetMarketplaceConnector(ctx, id, opts...)
+}
+
+// ResolveInstall is ResolveInstall.
+func (s *MarketplaceService) ResolveInstall(ctx context.Context, runnerID, version string, opts ...RequestOption) (InstallationSpec, error) {
+	return s.c.ResolveInstall(ctx, runnerID, version, opts...)
+}
+
+// GetIndex is GetMarketplaceIndex.
+func (s *MarketplaceService) GetIndex(ctx context.Context, opts ...RequestOption) (*MarketplaceIndex, error) {
+	return s.c.GetMarketplaceIndex(ctx, opts...)
+}
@@ -0,0 +1,74 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestServicesShareClient(t *testing.T) {
+	var paths []string
+	client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/jobs":
+			w.Write([]byte(`{"id":"j1","status":"queued"}`))
+		case "/v1/jobs/j1":
+			w.Write([]byte(`{"id":"j1","status":"running"}`))
+		case "/v1/runners":
+			w.Write([]byte(`{"items":[{"id":"r1"}],"total":1,"limit":10,"offset":0,"hasMore":false}`))
+		case "/v1/registry":
+			w.Write([]byte(`{"version":"1.0.0","capabilities":[]}`))
+		case "/v1/marketplace/index":
+			w.Write([]byte(`{"version":"1.0.0","runners":[],"connectors":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	if resp, err := client.Jobs().Submit(ctx, JobRequest{Id: "j1", Type: "csv"}); err != nil || resp.Status != "queued" {
+		t.Errorf("Jobs().Submit = %+v, %v", resp, err)
+	}
+	if resp, err := client.Jobs().Get(ctx, "j1"); err != nil || resp.Status != "running" {
+		t.Errorf("Jobs().Get = %+v, %v", resp, err)
+	}
+	if page, err := client.Runners().List(ctx, RegistryQuery{}, PaginatedRequest{Limit: Int(10)}); err != nil || len(page.Items) != 1 {
+		t.Errorf("Runners().List = %+v, %v", page, err)
+	}
+	if _, err := client.Registry().Get(ctx); err != nil {
+		t.Errorf("Registry().Get: %v", err)
+	}
+	if _, err := client.Marketplace().GetIndex(ctx); err != nil {
+		t.Errorf("Marketplace().GetIndex: %v", err)
+	}
+
+	want := []string{
+		"POST /v1/jobs", "GET /v1/jobs/j1", "GET /v1/runners", "GET /v1/registry", "GET /v1/marketplace/index",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("requests = %v", paths)
+	}
+}
+
+type fakeJobs struct {
+	JobsAPI
+	submitted []JobRequest
+}
+
+func (f *fakeJobs) Submit(ctx context.Context, job JobRequest, opts ...RequestOption) (*JobResponse, error) {
+	f.submitted = append(f.submitted, job)
+	return &JobResponse{Id: job.Id, Status: "queued"}, nil
+}
+
+func TestJobsAPIIsMockable(t *testing.T) {
+	enqueue := func(jobs JobsAPI) error {
+		_, err := jobs.Submit(context.Background(), JobRequest{Id: "j1", Type: "csv"})
+		return err
+	}
+	fake := &fakeJobs{}
+	if err := enqueue(fake); err != nil || len(fake.submitted) != 1 {
+		t.Errorf("fake not used: %v, %v", err, fake.submitted)
+	}
+}
@@ -0,0 +1,102 @@
+package controlplane
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseReader parses a server-sent events stream.
+type sseReader struct {
+	// limit caps the data of one event; zero or negative means no cap
+	// beyond the scanner's.
+	limit       int64
+	contentType string
+	// onRetry, when set, receives the reconnect delay of a retry field.
+	onRetry func(time.Duration)
+}
+
+// read calls dispatch for every event with data until the stream ends or
+// dispatch fails. A clean end of stream is reported as a transient error
+// wrapping io.ErrUnexpectedEOF, since the server is expected to keep it
+// open.
+func (r sseReader) read(body io.Reader, dispatch func(event, id, data string) error) error {
+	limit := r.limit
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	tooLarge := func(n int) error {
+		return &ResponseTooLargeError{Limit: limit, BytesRead: int64(n), ContentType: r.contentType}
+	}
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 64<<10), int(min64(limit+1, math.MaxInt32)))
+
+	var event, id string
+	var data strings.Builder
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if data.Len() > 0 {
+				if err := dispatch(event, id, data.String()); err != nil {
+					return err
+				}
+			}
+			event, id = "", ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "id":
+			id = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			if int64(data.Len()) > limit {
+				return tooLarge(data.Len())
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 && r.onRetry != nil {
+				r.onRetry(time.Duration(ms) * time.Millisecond)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return tooLarge(int(limit + 1))
+		}
+		return transientError{err}
+	}
+	return transientError{io.ErrUnexpectedEOF}
+}
+
+// sseError converts the data of an error event to an *APIError when it
+// carries an ErrorEnvelope, and returns nil otherwise.
+func sseError(data string) error {
+	apiErr := &APIError{StatusCode: http.StatusOK, Raw: []byte(data)}
+	if env := parseErrorEnvelope(apiErr.Raw); env != nil {
+		apiErr.Envelope = *env
+		return apiErr
+	}
+	return nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,272 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// AssertionStream decodes TruthAssertions one at a time from a query result,
+// so large results need not be held in memory. It accepts either a
+// TruthQueryResult object or a bare JSON array of assertions, or, from
+// NewNDJSONAssertionDecoder, newline-delimited assertions.
+//
+//	for stream.Next() {
+//		a := stream.Assertion()
+//		...
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type AssertionStream struct {
+	body   io.Closer
+	dec    *json.Decoder
+	result TruthQueryResult
+	// ctx, when set, ends the stream with its error once it is done.
+	ctx context.Context
+
+	ndjson  bool
+	started bool
+	bare    bool
+	inArray bool
+	done    bool
+	cur     TruthAssertion
+	err     error
+}
+
+// NewAssertionDecoder returns a stream reading from r. If r is an
+// io.Closer, Close closes it.
+func NewAssertionDecoder(r io.Reader) *AssertionStream {
+	s := &AssertionStream{dec: json.NewDecoder(r)}
+	if c, ok := r.(io.Closer); ok {
+		s.body = c
+	}
+	return s
+}
+
+// NewNDJSONAssertionDecoder returns a stream reading newline-delimited JSON
+// from r: one assertion per line, optionally followed by a summary record
+// carrying totalCount and queryTimeMs, which is reported by Result. If r is
+// an io.Closer, Close closes it.
+func NewNDJSONAssertionDecoder(r io.Reader) *AssertionStream {
+	s := NewAssertionDecoder(r)
+	s.ndjson = true
+	return s
+}
+
+// ndjsonMediaType is the media type of newline-delimited query results.
+const ndjsonMediaType = "application/x-ndjson"
+
+// QueryTruthStream runs a truth query and streams the matching assertions.
+// It asks for newline-delimited JSON, so a server that supports it never
+// builds the whole result as one document, and falls back to decoding a
+// TruthQueryResult. Cancelling ctx aborts the stream mid-body and Err
+// reports ctx's error. The caller must Close the stream. Consistency levels
+// apply as in QueryTruth.
+func (c *ControlPlaneClient) QueryTruthStream(ctx context.Context, query TruthQuery, opts ...RequestOption) (*AssertionStream, error) {
+	const path = "/v1/truth/query"
+	opts, level, err := c.consistencyOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]RequestOption{WithHeader("Accept", ndjsonMediaType+", application/json;q=0.9")}, opts...)
+	var resp *http.Response
+	err = c.readConsistently(ctx, level, func() error {
+		var err error
+		if resp, err = c.Request(ctx, http.MethodPost, path, query, opts...); err != nil {
+			return err
+		}
+		if err := checkStatus(resp); err != nil {
+			drainAndClose(resp.Body)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var stream *AssertionStream
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == ndjsonMediaType {
+		stream = NewNDJSONAssertionDecoder(resp.Body)
+	} else {
+		stream = NewAssertionDecoder(resp.Body)
+	}
+	stream.ctx = ctx
+	return stream, nil
+}
+
+// Next advances to the next assertion, returning false at the end of the
+// stream or on error.
+func (s *AssertionStream) Next() bool {
+	if s.done {
+		return false
+	}
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.err = s.ctx.Err()
+		s.done = true
+		return false
+	}
+	advance := s.advance
+	if s.ndjson {
+		advance = s.advanceNDJSON
+	}
+	if err := advance(); err != nil {
+		// A cancelled context surfaces as a read error on the body; report
+		// the cancellation itself.
+		if s.ctx != nil && s.ctx.Err() != nil {
+			err = s.ctx.Err()
+		}
+		s.err = err
+		s.done = true
+		return false
+	}
+	return !s.done
+}
+
+// Assertion returns the assertion Next advanced to.
+func (s *AssertionStream) Assertion() TruthAssertion {
+	return s.cur
+}
+
+// Err returns the first error encountered while decoding.
+func (s *AssertionStream) Err() error {
+	return s.err
+}
+
+// Result returns the query result fields other than the assertions. Fields
+// that follow the assertions in the document are only set once Next has
+// returned false.
+func (s *AssertionStream) Result() TruthQueryResult {
+	return s.result
+}
+
+// Close releases the underlying response body. The remainder of the body
+// is drained so the connection can be reused.
+func (s *AssertionStream) Close() error {
+	s.done = true
+	if s.body == nil {
+		return nil
+	}
+	if rc, ok := s.body.(io.ReadCloser); ok {
+		drainAndClose(rc)
+		return nil
+	}
+	return s.body.Close()
+}
+
+func (s *AssertionStream) advance() error {
+	if !s.started {
+		s.started = true
+		tok, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decode assertions: %w", err)
+		}
+		switch tok {
+		case json.Delim('['):
+			s.bare, s.inArray = true, true
+		case json.Delim('{'):
+		default:
+			return fmt.Errorf("decode assertions: unexpected %v", tok)
+		}
+	}
+
+	for {
+		if s.inArray {
+			if s.dec.More() {
+				s.cur = TruthAssertion{}
+				if err := s.dec.Decode(&s.cur); err != nil {
+					return fmt.Errorf("decode assertion: %w", err)
+				}
+				return nil
+			}
+			if _, err := s.dec.Token(); err != nil { // ']'
+				return fmt.Errorf("decode assertions: %w", err)
+			}
+			s.inArray = false
+			if s.bare {
+				s.done = true
+				return nil
+			}
+		}
+
+		if !s.dec.More() {
+			s.dec.Token() // '}'
+			s.done = true
+			return nil
+		}
+		tok, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decode assertions: %w", err)
+		}
+		key, _ := tok.(string)
+		if key == "assertions" {
+			if tok, err := s.dec.Token(); err != nil || tok != json.Delim('[') {
+				return fmt.Errorf("decode assertions: expected array, got %v (%v)", tok, err)
+			}
+			s.inArray = true
+			continue
+		}
+		if err := s.decodeResultField(key); err != nil {
+			return err
+		}
+	}
+}
+
+// advanceNDJSON decodes the next line, recording a summary record in the
+// result and moving on to the line after it.
+func (s *AssertionStream) advanceNDJSON() error {
+	for {
+		var raw json.RawMessage
+		if err := s.dec.Decode(&raw); err == io.EOF {
+			s.done = true
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decode assertion: %w", err)
+		}
+		var record struct {
+			Subject     *string          `json:"subject"`
+			TotalCount  *json.RawMessage `json:"totalCount"`
+			QueryTimeMs *json.RawMessage `json:"queryTimeMs"`
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("decode assertion: %w", err)
+		}
+		if record.Subject == nil && (record.TotalCount != nil || record.QueryTimeMs != nil) {
+			if err := json.Unmarshal(raw, &s.result); err != nil {
+				return fmt.Errorf("decode summary: %w", err)
+			}
+			continue
+		}
+		s.cur = TruthAssertion{}
+		if err := json.Unmarshal(raw, &s.cur); err != nil {
+			return fmt.Errorf("decode assertion: %w", err)
+		}
+		return nil
+	}
+}
+
+// decodeResultField decodes one non-assertion field into the result.
+func (s *AssertionStream) decodeResultField(key string) error {
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		return fmt.Errorf("decode %s: %w", key, err)
+	}
+	var dst interface{}
+	switch key {
+	case "queryId":
+		dst = &s.result.QueryId
+	case "totalCount":
+		dst = &s.result.TotalCount
+	case "hasMore":
+		dst = &s.result.HasMore
+	case "queryTimeMs":
+		dst = &s.result.QueryTimeMs
+	default:
+		return nil
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("decode %s: %w", key, err)
+	}
+	return nil
+}
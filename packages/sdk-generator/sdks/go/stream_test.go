@@ -0,0 +1,161 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssertionStream(t *testing.T) {
+	doc := `{"queryId":"q-1","assertions":[
+		{"id":"a1","subject":"svc","predicate":"is","object":"up","timestamp":"2026-01-01T00:00:00Z","source":"probe"},
+		{"id":"a2","subject":"svc","predicate":"has","object":{"n":2},"timestamp":"2026-01-01T00:00:00Z","source":"probe"}
+	],"totalCount":2,"hasMore":true,"queryTimeMs":4.5}`
+	stream := NewAssertionDecoder(strings.NewReader(doc))
+
+	var ids []string
+	for stream.Next() {
+		ids = append(ids, stream.Assertion().Id)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ids, ",") != "a1,a2" {
+		t.Errorf("ids = %v", ids)
+	}
+	if r := stream.Result(); r.QueryId != "q-1" || r.TotalCount != 2 || !BoolValue(r.HasMore) || r.QueryTimeMs != 4.5 {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+func TestAssertionStreamBareArray(t *testing.T) {
+	stream := NewAssertionDecoder(strings.NewReader(`[{"id":"a1"},{"id":"a2"},{"id":"a3"}]`))
+	n := 0
+	for stream.Next() {
+		n++
+	}
+	if stream.Err() != nil || n != 3 {
+		t.Errorf("n = %d, err = %v", n, stream.Err())
+	}
+}
+
+func TestQueryTruthStreamNDJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Accept"), "application/x-ndjson") {
+			t.Errorf("accept = %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+		fmt.Fprintln(w, `{"id":"a1","subject":"svc","predicate":"is","object":"up","timestamp":"2026-01-01T00:00:00Z","source":"probe"}`)
+		fmt.Fprintln(w, `{"id":"a2","subject":"svc","predicate":"is","object":"down","timestamp":"2026-01-01T00:00:00Z","source":"probe"}`)
+		fmt.Fprintln(w, `{"totalCount":2,"queryTimeMs":12.5}`)
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	stream, err := client.QueryTruthStream(context.Background(), TruthQuery{Id: "q-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var objects []string
+	for stream.Next() {
+		objects = append(objects, fmt.Sprint(stream.Assertion().Object))
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(objects, ",") != "up,down" {
+		t.Errorf("objects = %v", objects)
+	}
+	if r := stream.Result(); r.TotalCount != 2 || r.QueryTimeMs != 12.5 {
+		t.Errorf("summary = %+v", r)
+	}
+}
+
+func TestQueryTruthStreamAbortsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"id":"a1","subject":"svc","predicate":"is","object":"up"}`)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.QueryTruthStream(ctx, TruthQuery{Id: "q-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if !stream.Next() || stream.Assertion().Id != "a1" {
+		t.Fatalf("first assertion: %v", stream.Err())
+	}
+
+	done := make(chan bool)
+	go func() { done <- stream.Next() }()
+	cancel()
+	select {
+	case more := <-done:
+		if more || !errors.Is(stream.Err(), context.Canceled) {
+			t.Errorf("more = %v, err = %v", more, stream.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Next blocked after cancel")
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`{"queryId":"q","assertions":[`)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":"a%d","subject":"s","predicate":"p","object":%d,"source":"x"}`, i, i)
+	}
+	sb.WriteString(`]}`)
+	body := sb.String()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, MaxResponseBytes: 4096})
+	stream, err := client.QueryTruthStream(context.Background(), TruthQuery{Id: "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	n := 0
+	for stream.Next() {
+		n++
+	}
+	if !errors.Is(stream.Err(), ErrResponseTooLarge) {
+		t.Fatalf("err = %v, want ErrResponseTooLarge", stream.Err())
+	}
+	if n == 0 || n >= 1000 {
+		t.Errorf("decoded %d assertions before the limit", n)
+	}
+
+	var out TruthQueryResult
+	err = client.call(context.Background(), http.MethodPost, "/v1/truth/query", TruthQuery{Id: "q"}, &out)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("call err = %v", err)
+	}
+
+	client = mustNewClient(t, ClientConfig{BaseURL: srv.URL, MaxResponseBytes: int64(len(body))})
+	if err := client.call(context.Background(), http.MethodPost, "/v1/truth/query", TruthQuery{Id: "q"}, &out); err != nil {
+		t.Errorf("body of exactly the limit rejected: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// UnknownFieldError reports a field of a JSON document that the type it is
+// decoded into does not define, as found by UnmarshalStrict and
+// ClientConfig.StrictDecoding.
+type UnknownFieldError struct {
+	// Field is the path of the field, such as "tiemoutMs" or
+	// "capabilities[2].inputSchem".
+	Field string
+	// Offset is the byte offset of the field's name in the document.
+	Offset int64
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q at offset %d", e.Field, e.Offset)
+}
+
+// UnmarshalStrict is UnmarshalValidate, except that a field T does not
+// define, such as a misspelled "tiemoutMs", is a decoding failure: a
+// *DecodeError wrapping an *UnknownFieldError. Fields of the generated
+// map-typed fields are free-form and not checked.
+func UnmarshalStrict[T Validatable](data []byte) (T, error) {
+	var v T
+	if err := unmarshalStrict(data, &v); err != nil {
+		return v, &DecodeError{Type: typeName(v), Err: err}
+	}
+	return validateDecoded(v, data)
+}
+
+// unmarshalStrict decodes the single JSON document in data into out,
+// rejecting unknown fields.
+func unmarshalStrict(data []byte, out interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			if field, offset, ok := locateUnknownField(data, reflect.TypeOf(out)); ok {
+				return &UnknownFieldError{Field: field, Offset: offset}
+			}
+		}
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON document")
+	}
+	return nil
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// fieldLocator walks a JSON document alongside the Go type it decodes into
+// to find the first object key the type does not define.
+type fieldLocator struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+func locateUnknownField(data []byte, t reflect.Type) (string, int64, bool) {
+	l := &fieldLocator{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	field, offset, err := l.value(t, "")
+	return field, offset, err == nil && field != ""
+}
+
+// value consumes one JSON value decoded into t, which is nil for values
+// whose contents are not checked. It returns the path and offset of the
+// first unknown field within it, if any.
+func (l *fieldLocator) value(t reflect.Type, path string) (string, int64, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types that decode themselves decide which fields they accept.
+	if t != nil && reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		t = nil
+	}
+	tok, err := l.dec.Token()
+	if err != nil {
+		return "", 0, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		return l.object(t, path)
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := 0; l.dec.More(); i++ {
+			if field, offset, err := l.value(elem, fmt.Sprintf("%s[%d]", path, i)); err != nil || field != "" {
+				return field, offset, err
+			}
+		}
+		_, err := l.dec.Token()
+		return "", 0, err
+	}
+	return "", 0, nil
+}
+
+func (l *fieldLocator) object(t reflect.Type, path string) (string, int64, error) {
+	var fields map[string]reflect.Type
+	var elem reflect.Type
+	if t != nil {
+		switch t.Kind() {
+		case reflect.Struct:
+			fields = jsonFields(t)
+		case reflect.Map:
+			elem = t.Elem()
+		}
+	}
+	for l.dec.More() {
+		// The key is the first string after the previous value.
+		offset := l.dec.InputOffset()
+		if i := bytes.IndexByte(l.data[offset:], '"'); i >= 0 {
+			offset += int64(i)
+		}
+		tok, err := l.dec.Token()
+		if err != nil {
+			return "", 0, err
+		}
+		key, _ := tok.(string)
+		child := joinField(path, key)
+		valueType := elem
+		if fields != nil {
+			ft, ok := lookupJSONField(fields, key)
+			if !ok {
+				return child, offset, nil
+			}
+			valueType = ft
+		}
+		if field, offset, err := l.value(valueType, child); err != nil || field != "" {
+			return field, offset, err
+		}
+	}
+	_, err := l.dec.Token()
+	return "", 0, err
+}
+
+// jsonFields returns the JSON field names of struct t, including those
+// promoted from embedded structs, with their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for n, ft := range jsonFields(f.Type) {
+				fields[n] = ft
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// lookupJSONField matches key to a field name as encoding/json does,
+// preferring an exact match to a case-insensitive one.
+func lookupJSONField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+// decode decodes a response body into out, strictly when
+// ClientConfig.StrictDecoding is set. An empty body is io.EOF either way.
+func (c *ControlPlaneClient) decode(r io.Reader, out interface{}) error {
+	if !c.config.StrictDecoding {
+		return json.NewDecoder(r).Decode(out)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return io.EOF
+	}
+	return unmarshalStrict(data, out)
+}
+
+// unmarshal is decode for a body already read.
+func (c *ControlPlaneClient) unmarshal(data []byte, out interface{}) error {
+	if c.config.StrictDecoding {
+		return unmarshalStrict(data, out)
+	}
+	return json.Unmarshal(data, out)
+}
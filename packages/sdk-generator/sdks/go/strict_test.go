@@ -0,0 +1,74 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalStrict(t *testing.T) {
+	valid := `{"id":"j1","type":"csv","payload":{"type":"csv","data":{"any":1}},"metadata":{"source":"api","createdAt":"2026-01-02T03:04:05Z"}}`
+	if _, err := UnmarshalStrict[JobRequest]([]byte(valid)); err != nil {
+		t.Fatalf("valid: %v", err)
+	}
+
+	data := `{"id":"j1","type":"csv","payload":{},"tiemoutMs":5}`
+	_, err := UnmarshalStrict[JobRequest]([]byte(data))
+	var decodeErr *DecodeError
+	var unknown *UnknownFieldError
+	if !errors.As(err, &decodeErr) || !errors.As(err, &unknown) {
+		t.Fatalf("err = %v", err)
+	}
+	if unknown.Field != "tiemoutMs" || unknown.Offset != int64(strings.Index(data, `"tiemoutMs"`)) {
+		t.Errorf("unknown = %+v", unknown)
+	}
+
+	// Decoding succeeds before validation runs.
+	if _, err := UnmarshalStrict[JobRequest]([]byte(`{"id":"j1"}`)); !errors.Is(err, ErrValidation) {
+		t.Errorf("invalid: err = %v", err)
+	}
+	if _, err := UnmarshalStrict[JobRequest]([]byte(valid + `{}`)); !errors.As(err, &decodeErr) {
+		t.Errorf("trailing data: err = %v", err)
+	}
+}
+
+func TestUnknownFieldPath(t *testing.T) {
+	type step struct {
+		Name string `json:"name"`
+	}
+	type plan struct {
+		Steps  []step                 `json:"steps"`
+		Labels map[string]step        `json:"labels"`
+		Extra  map[string]interface{} `json:"extra"`
+	}
+	for data, want := range map[string]string{
+		`{"steps":[{"name":"a"},{"name":"b","nmae":"c"}]}`:                "steps[1].nmae",
+		`{"extra":{"free":{"form":1}},"labels":{"x":{"Name":"a","n":1}}}`: "labels.x.n",
+	} {
+		err := unmarshalStrict([]byte(data), &plan{})
+		var unknown *UnknownFieldError
+		if !errors.As(err, &unknown) || unknown.Field != want {
+			t.Errorf("%s: err = %v, want field %q", data, err, want)
+		}
+	}
+}
+
+func TestStrictDecodingClient(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"j1","status":"queued","request":{},"updatedAt":"2026-01-02T03:04:05Z","queuePosition":3}`)
+	})
+	ctx := context.Background()
+	if _, err := NewTestClient(h).GetJob(ctx, "j1"); err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+
+	client := mustNewClient(t, ClientConfig{BaseURL: testBaseURL, Transport: HandlerTransport(h), StrictDecoding: true})
+	_, err := client.GetJob(ctx, "j1")
+	var unknown *UnknownFieldError
+	if !errors.As(err, &unknown) || unknown.Field != "queuePosition" {
+		t.Errorf("strict: err = %v", err)
+	}
+}
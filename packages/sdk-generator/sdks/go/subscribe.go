@@ -0,0 +1,256 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultReconnectPolicy is used by truth streams when
+// ClientConfig.Reconnect is nil.
+func defaultReconnectPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:        Int(10),
+		BackoffMs:         Float(500),
+		MaxBackoffMs:      Float(30000),
+		BackoffMultiplier: Float(2),
+	}
+}
+
+// SubscribeTruth opens a server-sent events stream of assertions matching
+// sub and delivers them on the returned channel until ctx is cancelled.
+//
+// The first connection is made before SubscribeTruth returns, and its
+// failure is returned directly. Afterwards, dropped connections and transient
+// failures (transport errors, 429 and 5xx responses) are retried with
+// backoff according to ClientConfig.Reconnect. Each reconnect sends the id of
+// the last assertion received in the Last-Event-ID header so servers that
+// support it can resume the stream without gaps. A terminal error, such as a
+// 4xx response or running out of reconnect attempts, is sent on the error
+// channel. Both channels are closed when the subscription ends.
+//
+// The stream is not bounded by ClientConfig.Timeout; pass WithTimeout to
+// limit its lifetime. ClientConfig.MaxResponseBytes applies to each event
+// rather than to the stream as a whole.
+func (c *ControlPlaneClient) SubscribeTruth(ctx context.Context, sub TruthSubscription, opts ...RequestOption) (<-chan TruthAssertion, <-chan error, error) {
+	ctx, release, err := c.startBackground(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := c.newTruthSubscriber(truthSubscribePath, sub, "truth subscription "+sub.Id, opts)
+	body, err := s.connect(ctx)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	assertions := make(chan TruthAssertion)
+	errc := make(chan error, 1)
+	go func() {
+		defer release()
+		s.run(ctx, body, assertions, errc)
+	}()
+	return assertions, errc, nil
+}
+
+// TruthPattern selects assertions by subject, predicate and object. Empty
+// fields match anything.
+type TruthPattern struct {
+	Subject   string      `json:"subject,omitempty"`
+	Predicate string      `json:"predicate,omitempty"`
+	Object    interface{} `json:"object,omitempty"`
+}
+
+// StreamTruthAssertions streams assertions matching pattern and filters
+// over server-sent events from /v1/truth/stream, without registering a
+// subscription. It behaves like SubscribeTruth, including reconnecting with
+// Last-Event-ID, except that the first connection is also made in the
+// background: every failure, including the first, is reported on the error
+// channel. Heartbeat comments and non-assertion events are skipped. Both
+// channels are closed when ctx is cancelled or the stream fails for good.
+func (c *ControlPlaneClient) StreamTruthAssertions(ctx context.Context, pattern TruthPattern, filters map[string]interface{}, opts ...RequestOption) (<-chan TruthAssertion, <-chan error) {
+	req := struct {
+		Pattern TruthPattern           `json:"pattern"`
+		Filters map[string]interface{} `json:"filters,omitempty"`
+	}{pattern, filters}
+	s := c.newTruthSubscriber(truthStreamPath, req, "truth stream", opts)
+
+	assertions := make(chan TruthAssertion)
+	errc := make(chan error, 1)
+	ctx, release, err := c.startBackground(ctx)
+	if err != nil {
+		close(assertions)
+		errc <- err
+		close(errc)
+		return assertions, errc
+	}
+	go func() {
+		defer release()
+		body, err := s.connect(ctx)
+		if err != nil && !isTransient(err) {
+			close(assertions)
+			errc <- err
+			close(errc)
+			return
+		}
+		// After a transient failure body is nil and run backs off before
+		// connecting again.
+		s.run(ctx, body, assertions, errc)
+	}()
+	return assertions, errc
+}
+
+type truthSubscriber struct {
+	client *ControlPlaneClient
+	path   string
+	body   interface{}
+	// name identifies the stream in logs and errors.
+	name   string
+	opts   []RequestOption
+	policy *RetryPolicy
+
+	lastID      string
+	contentType string
+	// retryDelay is the reconnect delay requested by the server with an
+	// SSE retry field; zero means use the policy's backoff.
+	retryDelay time.Duration
+}
+
+const (
+	truthSubscribePath = "/v1/truth/subscribe"
+	truthStreamPath    = "/v1/truth/stream"
+)
+
+func (c *ControlPlaneClient) newTruthSubscriber(path string, body interface{}, name string, opts []RequestOption) *truthSubscriber {
+	opts = append([]RequestOption{WithTimeout(0), WithHeader("Accept", "text/event-stream"), withUnlimitedBody()}, opts...)
+	s := &truthSubscriber{client: c, path: path, body: body, name: name, opts: opts, policy: c.config.Reconnect}
+	if s.policy == nil {
+		s.policy = defaultReconnectPolicy()
+	}
+	return s
+}
+
+// connect opens one stream. Errors wrapped in a transientError may be
+// retried.
+func (s *truthSubscriber) connect(ctx context.Context) (io.ReadCloser, error) {
+	opts := s.opts
+	if s.lastID != "" {
+		opts = append(opts, WithHeader("Last-Event-ID", s.lastID))
+	}
+	resp, err := s.client.Request(ctx, http.MethodPost, s.path, s.body, opts...)
+	if err != nil {
+		return nil, transientError{err}
+	}
+	if err := checkStatus(resp); err != nil {
+		drainAndClose(resp.Body)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, transientError{err}
+		}
+		return nil, err
+	}
+	s.contentType = resp.Header.Get("Content-Type")
+	return resp.Body, nil
+}
+
+func (s *truthSubscriber) run(ctx context.Context, body io.ReadCloser, out chan<- TruthAssertion, errc chan<- error) {
+	defer close(out)
+	defer close(errc)
+
+	failures := 0
+	for {
+		if body != nil {
+			err := s.read(ctx, body, out)
+			body.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && !isTransient(err) {
+				errc <- err
+				return
+			}
+			s.client.config.Logger.Info("controlplane: truth stream dropped, reconnecting",
+				"stream", s.name, "lastEventId", s.lastID, "error", err)
+		}
+
+		failures++
+		if max := IntValue(s.policy.MaxRetries); failures > max {
+			errc <- fmt.Errorf("%s: giving up after %d reconnect attempts", s.name, max)
+			return
+		}
+		delay := s.policy.backoff(failures)
+		if s.retryDelay > 0 {
+			delay = s.retryDelay
+		}
+		if err := s.client.config.Clock.Sleep(ctx, delay); err != nil {
+			return
+		}
+
+		var err error
+		body, err = s.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !isTransient(err) {
+				errc <- err
+				return
+			}
+			continue
+		}
+		failures = 0
+	}
+}
+
+// read dispatches events from one connection until it ends. A clean end of
+// stream is reported as a transient error so the caller reconnects.
+func (s *truthSubscriber) read(ctx context.Context, body io.Reader, out chan<- TruthAssertion) error {
+	r := sseReader{
+		limit:       s.client.config.MaxResponseBytes,
+		contentType: s.contentType,
+		onRetry:     func(d time.Duration) { s.retryDelay = d },
+	}
+	return r.read(body, func(event, id, data string) error {
+		return s.dispatch(ctx, event, id, data, out)
+	})
+}
+
+// dispatch handles one event. Events other than assertions are ignored, and
+// an error event carrying an ErrorEnvelope ends the subscription.
+func (s *truthSubscriber) dispatch(ctx context.Context, event, id, data string, out chan<- TruthAssertion) error {
+	switch event {
+	case "", "message", "assertion":
+	case "error":
+		return sseError(data)
+	default:
+		return nil
+	}
+
+	var a TruthAssertion
+	if err := json.Unmarshal([]byte(data), &a); err != nil {
+		return fmt.Errorf("%s: decode assertion: %w", s.name, err)
+	}
+	select {
+	case out <- a:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if id == "" {
+		id = a.Id
+	}
+	s.lastID = id
+	return nil
+}
+
+// transientError marks a subscription failure that warrants a reconnect.
+type transientError struct{ err error }
+
+func (e transientError) Error() string { return e.err.Error() }
+func (e transientError) Unwrap() error { return e.err }
+
+func isTransient(err error) bool {
+	var t transientError
+	return errors.As(err, &t)
+}
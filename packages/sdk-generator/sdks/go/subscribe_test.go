@@ -0,0 +1,221 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sseAssertion(w http.ResponseWriter, id string) {
+	fmt.Fprintf(w, "id: %s\nevent: assertion\ndata: {\"id\":%q,\"subject\":\"svc\",\"predicate\":\"up\",\"object\":true,\n", id, id)
+	fmt.Fprintf(w, "data: \"timestamp\":\"2024-01-01T00:00:00Z\",\"source\":\"probe\"}\n\n")
+	w.(http.Flusher).Flush()
+}
+
+var fastReconnect = &RetryPolicy{MaxRetries: Int(2), BackoffMs: Float(1)}
+
+func TestSubscribeTruthResumesAfterDisconnect(t *testing.T) {
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/truth/subscribe" || r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("request = %s %s", r.URL.Path, r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&conns, 1) {
+		case 1:
+			fmt.Fprint(w, ": keepalive\n\n")
+			sseAssertion(w, "a1")
+			sseAssertion(w, "a2")
+		case 2:
+			if got := r.Header.Get("Last-Event-ID"); got != "a2" {
+				t.Errorf("Last-Event-ID = %q", got)
+			}
+			sseAssertion(w, "a3")
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Reconnect: fastReconnect, Timeout: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assertions, errc, err := client.SubscribeTruth(ctx, TruthSubscription{Id: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"a1", "a2", "a3"} {
+		select {
+		case a := <-assertions:
+			if a.Id != want || a.Subject != "svc" {
+				t.Fatalf("assertion = %+v, want %s", a, want)
+			}
+		case err := <-errc:
+			t.Fatalf("unexpected error: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	cancel()
+	if _, ok := <-assertions; ok {
+		t.Error("assertions channel still open after cancel")
+	}
+	if err, ok := <-errc; ok {
+		t.Errorf("error after cancel: %v", err)
+	}
+}
+
+func TestSubscribeTruthInitialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL})
+	_, _, err := client.SubscribeTruth(context.Background(), TruthSubscription{Id: "s1"})
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSubscribeTruthTerminalError(t *testing.T) {
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&conns, 1) == 1 {
+			sseAssertion(w, "a1")
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Reconnect: fastReconnect})
+	assertions, errc, err := client.SubscribeTruth(context.Background(), TruthSubscription{Id: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := <-assertions; a.Id != "a1" {
+		t.Errorf("assertion = %+v", a)
+	}
+	select {
+	case err := <-errc:
+		if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("err = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no terminal error")
+	}
+	if _, ok := <-assertions; ok {
+		t.Error("assertions channel still open")
+	}
+}
+
+func TestSubscribeTruthGivesUp(t *testing.T) {
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&conns, 1) == 1 {
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Reconnect: fastReconnect})
+	_, errc, err := client.SubscribeTruth(context.Background(), TruthSubscription{Id: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Error("nil terminal error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no terminal error")
+	}
+	if n := atomic.LoadInt32(&conns); n != 3 {
+		t.Errorf("connections = %d, want 3", n)
+	}
+}
+
+func TestStreamTruthAssertions(t *testing.T) {
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/truth/stream" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body struct {
+			Pattern TruthPattern           `json:"pattern"`
+			Filters map[string]interface{} `json:"filters"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Pattern.Subject != "svc" || body.Filters["minConfidence"] != 0.5 {
+			t.Errorf("body = %+v", body)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&conns, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			fmt.Fprint(w, ": heartbeat\n\nevent: heartbeat\ndata: {}\n\n")
+			sseAssertion(w, "a1")
+		default:
+			if got := r.Header.Get("Last-Event-ID"); got != "a1" {
+				t.Errorf("Last-Event-ID = %q", got)
+			}
+			sseAssertion(w, "a2")
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Reconnect: fastReconnect})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assertions, errc := client.StreamTruthAssertions(ctx, TruthPattern{Subject: "svc"}, map[string]interface{}{"minConfidence": 0.5})
+
+	for _, want := range []string{"a1", "a2"} {
+		select {
+		case a := <-assertions:
+			if a.Id != want {
+				t.Fatalf("assertion = %+v, want %s", a, want)
+			}
+		case err := <-errc:
+			t.Fatalf("unexpected error: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	cancel()
+	if _, ok := <-assertions; ok {
+		t.Error("assertions channel still open after cancel")
+	}
+	if err, ok := <-errc; ok {
+		t.Errorf("error after cancel: %v", err)
+	}
+}
+
+func TestStreamTruthAssertionsTerminalError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Reconnect: fastReconnect})
+	assertions, errc := client.StreamTruthAssertions(context.Background(), TruthPattern{}, nil)
+	if err := <-errc; err == nil || CategoryOf(err) != ErrorCategoryAUTHORIZATION_ERROR {
+		t.Errorf("err = %v", err)
+	}
+	if _, ok := <-assertions; ok {
+		t.Error("assertions channel still open")
+	}
+}
@@ -0,0 +1,66 @@
+package controlplane
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest JobMetadata tag Validate accepts, in
+// characters.
+const MaxTagLength = 64
+
+// TagFormat selects the form JobMetadata tags must take.
+type TagFormat int
+
+const (
+	// TagFormatAny accepts any tag that is not empty or too long.
+	TagFormatAny TagFormat = iota
+	// TagFormatKeyValue requires "key:value" tags whose key is a lowercase
+	// slug, such as "team:billing" or "cost-center:42".
+	TagFormatKeyValue
+)
+
+// JobTagFormat controls the format Validate enforces on JobMetadata tags.
+var JobTagFormat = TagFormatAny
+
+var keyValueTagPattern = regexp.MustCompile(`^[a-z0-9]+(?:[-_.][a-z0-9]+)*:\S+$`)
+
+func init() {
+	registerRule("JobMetadata", func(m JobMetadata, errs *ValidationErrors) {
+		for i, tag := range m.Tags {
+			field := fmt.Sprintf("tags[%d]", i)
+			switch {
+			case strings.TrimSpace(tag) == "":
+				errs.Add(field, "must not be empty")
+			case utf8.RuneCountInString(tag) > MaxTagLength:
+				errs.Add(field, fmt.Sprintf("must be at most %d characters", MaxTagLength))
+			case JobTagFormat == TagFormatKeyValue && !keyValueTagPattern.MatchString(tag):
+				errs.Add(field, `must be of the form "key:value" with a lowercase slug key`)
+			}
+		}
+	})
+}
+
+// NormalizeTags returns a copy of the metadata whose tags are trimmed of
+// surrounding space, with empty tags dropped and duplicates that differ
+// only in case removed. The first spelling of each tag is kept, in order.
+func (m JobMetadata) NormalizeTags() JobMetadata {
+	if m.Tags == nil {
+		return m
+	}
+	tags := make([]string, 0, len(m.Tags))
+	seen := make(map[string]bool, len(m.Tags))
+	for _, tag := range m.Tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	m.Tags = tags
+	return m
+}
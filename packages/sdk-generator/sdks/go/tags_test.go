@@ -0,0 +1,59 @@
+package controlplane
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	m := JobMetadata{Source: "api", Tags: []string{" Nightly", "team:billing", "", "nightly ", "TEAM:Billing", "  "}}
+	got := m.NormalizeTags()
+	if want := []string{"Nightly", "team:billing"}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("tags = %q, want %q", got.Tags, want)
+	}
+	if m.Tags[0] != " Nightly" || len(m.Tags) != 6 {
+		t.Errorf("original modified: %q", m.Tags)
+	}
+	if (JobMetadata{}).NormalizeTags().Tags != nil {
+		t.Error("nil tags became non-nil")
+	}
+
+	job, err := NewJobRequestBuilder("csv.import").
+		WithPayload(map[string]interface{}{"rows": 1}).
+		WithMetadata(JobMetadata{Source: "api", Tags: []string{"a", "A"}}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags, _ := job.Metadata["tags"].([]interface{}); len(tags) != 1 {
+		t.Errorf("builder tags = %v", job.Metadata["tags"])
+	}
+}
+
+func TestJobMetadataTagValidation(t *testing.T) {
+	err := JobMetadata{Source: "api", CreatedAt: time.Now(), Tags: []string{"ok", " ", strings.Repeat("x", MaxTagLength+1), strings.Repeat("é", MaxTagLength)}}.Validate()
+	assertFields(t, err, "tags[1]", "tags[2]")
+	if !strings.Contains(err.Error(), "at most 64 characters") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestJobMetadataTagFormat(t *testing.T) {
+	m := JobMetadata{Source: "api", CreatedAt: time.Now(), Tags: []string{"team:billing", "nightly", "Team:billing", "env:", "cost-center:42"}}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("any format: %v", err)
+	}
+
+	JobTagFormat = TagFormatKeyValue
+	defer func() { JobTagFormat = TagFormatAny }()
+	assertFields(t, m.Validate(), "tags[1]", "tags[2]", "tags[3]")
+
+	job := JobRequest{Id: "j1", Type: "csv", Payload: map[string]interface{}{}, Metadata: map[string]interface{}{
+		"source": "api", "createdAt": time.Now().Format(time.RFC3339), "tags": []interface{}{"nightly"},
+	}}
+	if verrs, _ := job.Validate().(*ValidationErrors); len(verrs.ByField("metadata.tags[0]")) != 1 {
+		t.Errorf("nested tags not checked: %v", verrs)
+	}
+}
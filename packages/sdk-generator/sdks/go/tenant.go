@@ -0,0 +1,63 @@
+package controlplane
+
+import "context"
+
+// TenantHeader carries the tenant every request is scoped to. See
+// WithTenant and ClientConfig.DefaultTenant.
+const TenantHeader = "X-Tenant-Id"
+
+// tenantMetadataKey is the metadata entry SubmitJob and AssertTruth stamp
+// with the request's tenant.
+const tenantMetadataKey = "tenantId"
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose requests are scoped to tenantID. It
+// takes precedence over ClientConfig.DefaultTenant and applies to every
+// request made with the context, including retries, hedges, streams and
+// the reconnects of subscriptions. An empty tenantID falls back to the
+// default.
+//
+//	ctx = controlplane.WithTenant(ctx, "acme")
+//	resp, err := client.SubmitJob(ctx, job)
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set on ctx by WithTenant, or "" when
+// there is none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// tenant returns the tenant requests made with ctx are scoped to.
+func (c *ControlPlaneClient) tenant(ctx context.Context) string {
+	if tenant := TenantFromContext(ctx); tenant != "" {
+		return tenant
+	}
+	return c.config.DefaultTenant
+}
+
+// stampTenant returns metadata with its tenantId entry set to tenant when
+// it is absent. The caller's map is copied rather than modified.
+func stampTenant(metadata map[string]interface{}, tenant string) map[string]interface{} {
+	if tenant == "" {
+		return metadata
+	}
+	if _, ok := metadata[tenantMetadataKey]; ok {
+		return metadata
+	}
+	stamped := copyMap(metadata)
+	stamped[tenantMetadataKey] = tenant
+	return stamped
+}
+
+// tenantCacheKey scopes a response cache key to tenant, so a shared client
+// never serves one tenant's cached response to another.
+func tenantCacheKey(key, tenant string) string {
+	if tenant == "" {
+		return key
+	}
+	return key + " " + TenantHeader + "=" + tenant
+}
@@ -0,0 +1,158 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tenantServer records the tenant header of every request it serves. The
+// first GET of a job fails so retries can be observed.
+type tenantServer struct {
+	mu      sync.Mutex
+	tenants []string
+	bodies  []map[string]interface{}
+	failed  bool
+}
+
+func (s *tenantServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants = append(s.tenants, r.Header.Get(TenantHeader))
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.bodies = append(s.bodies, body)
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/v1/jobs/j1":
+		if !s.failed {
+			s.failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"jobId":"j1","status":"queued"}`))
+	case "/v1/truth/query":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"id":"a1"}` + "\n"))
+	case "/v1/truth/assertions":
+		w.Write([]byte(`{"id":"a1","subject":"svc","predicate":"is","object":"up","source":"probe"}`))
+	default:
+		w.Write([]byte(`{"jobId":"j1","status":"queued"}`))
+	}
+}
+
+func newTenantClient(t *testing.T, srv *tenantServer) *ControlPlaneClient {
+	return mustNewClient(t, ClientConfig{
+		BaseURL:       testBaseURL,
+		Transport:     HandlerTransport(srv),
+		DefaultTenant: "default-co",
+		Retry:         &RetryPolicy{MaxRetries: Int(2), BackoffMs: Float(10)},
+		Clock:         &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+}
+
+func TestTenantHeader(t *testing.T) {
+	srv := &tenantServer{}
+	client := newTenantClient(t, srv)
+	ctx := context.Background()
+
+	if _, err := client.GetJob(ctx, "j1"); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.QueryTruthStream(WithTenant(ctx, "acme"), TruthQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Next() {
+	}
+	stream.Close()
+	if _, err := client.GetJob(WithTenant(ctx, ""), "j1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed attempt and its retry both carry the default tenant.
+	want := []string{"default-co", "default-co", "acme", "default-co"}
+	if len(srv.tenants) != len(want) {
+		t.Fatalf("tenants = %q, want %q", srv.tenants, want)
+	}
+	for i := range want {
+		if srv.tenants[i] != want[i] {
+			t.Errorf("request %d: tenant = %q, want %q", i, srv.tenants[i], want[i])
+		}
+	}
+}
+
+func TestTenantStampedIntoMetadata(t *testing.T) {
+	srv := &tenantServer{}
+	client := newTenantClient(t, srv)
+	ctx := WithTenant(context.Background(), "acme")
+
+	meta := map[string]interface{}{"source": "billing"}
+	if _, err := client.SubmitJob(ctx, JobRequest{Id: "j2", Type: "t", Metadata: meta}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := meta[tenantMetadataKey]; ok {
+		t.Error("caller's metadata was modified")
+	}
+	if _, err := client.SubmitJob(ctx, JobRequest{Id: "j3", Type: "t", Metadata: map[string]interface{}{"tenantId": "other"}}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := client.AssertTruth(ctx, TruthAssertion{Subject: "svc", Predicate: "is", Object: "up", Source: "probe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Id != "a1" {
+		t.Errorf("stored = %+v", stored)
+	}
+
+	tenantOf := func(i int) interface{} {
+		m, _ := srv.bodies[i]["metadata"].(map[string]interface{})
+		return m[tenantMetadataKey]
+	}
+	if got := tenantOf(0); got != "acme" {
+		t.Errorf("submitted tenantId = %v, want acme", got)
+	}
+	if got := tenantOf(1); got != "other" {
+		t.Errorf("explicit tenantId = %v, want it kept", got)
+	}
+	if got := tenantOf(2); got != "acme" {
+		t.Errorf("asserted tenantId = %v, want acme", got)
+	}
+}
+
+func TestResponseCachesAreScopedToTenant(t *testing.T) {
+	var tenants []string
+	client := mustNewClient(t, ClientConfig{
+		BaseURL: testBaseURL,
+		Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenants = append(tenants, r.Header.Get(TenantHeader))
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Write([]byte(`{"status":"healthy","version":"` + r.Header.Get(TenantHeader) + `"}`))
+		})),
+		Clock: &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+
+	for _, opt := range []RequestOption{WithCache(time.Minute), WithResponseCache(NewLRUCache(8))} {
+		tenants = nil
+		for _, ctx := range []context.Context{acme, globex, acme} {
+			var health HealthCheck
+			if err := client.call(ctx, http.MethodGet, "/health", nil, &health, opt); err != nil {
+				t.Fatal(err)
+			}
+			if want := TenantFromContext(ctx); health.Version != want {
+				t.Errorf("%s got the response of %s", want, health.Version)
+			}
+		}
+		if want := []string{"acme", "globex"}; !reflect.DeepEqual(tenants, want) {
+			t.Errorf("server saw tenants %q, want %q", tenants, want)
+		}
+	}
+}
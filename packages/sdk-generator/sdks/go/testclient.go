@@ -0,0 +1,53 @@
+package controlplane
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// testBaseURL is the BaseURL of clients made by NewTestClient.
+const testBaseURL = "http://controlplane.test"
+
+// HandlerTransport returns a RoundTripper that serves every request with h
+// in memory, without a network listener. The handler sees the request as a
+// server would, with RequestURI and RemoteAddr set. Responses are buffered,
+// so a streaming handler must return before the client sees any of it.
+func HandlerTransport(h http.Handler) http.RoundTripper {
+	return handlerTransport{h}
+}
+
+type handlerTransport struct {
+	h http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	server := req.Clone(req.Context())
+	server.RequestURI = req.URL.RequestURI()
+	server.RemoteAddr = "192.0.2.1:1234"
+	if server.Body == nil {
+		server.Body = http.NoBody
+	}
+	if server.Host == "" {
+		server.Host = req.URL.Host
+	}
+
+	rec := httptest.NewRecorder()
+	t.h.ServeHTTP(rec, server)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// NewTestClient returns a client whose requests, including those of every
+// typed method, are served in memory by handler. It is meant for unit
+// tests that assert on the paths, headers and bodies the client sends.
+func NewTestClient(handler http.Handler) *ControlPlaneClient {
+	c, err := NewClient(ClientConfig{BaseURL: testBaseURL, Transport: HandlerTransport(handler)})
+	if err != nil {
+		panic("controlplane: NewTestClient: " + err.Error())
+	}
+	return c
+}
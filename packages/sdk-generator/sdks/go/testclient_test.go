@@ -0,0 +1,50 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNewTestClientServesTypedMethods(t *testing.T) {
+	var got JobRequest
+	client := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.RequestURI != "/v1/jobs" || r.Header.Get("Idempotency-Key") != "j1" {
+			t.Errorf("request = %s %s %v", r.Method, r.RequestURI, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"id":"j1","status":"queued"}`))
+	}))
+
+	resp, err := client.SubmitJob(context.Background(), JobRequest{Id: "j1", Type: "csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "queued" || got.Type != "csv" {
+		t.Errorf("resp = %+v, sent = %+v", resp, got)
+	}
+}
+
+func TestTransportConfig(t *testing.T) {
+	called := false
+	transport := RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		called = true
+		return nil, errors.New("offline")
+	})
+	client := mustNewClient(t, ClientConfig{BaseURL: "http://example.com", Transport: roundTripper(transport)})
+	if _, err := client.GetHealth(context.Background()); err == nil || !called {
+		t.Errorf("transport not used: called = %v, err = %v", called, err)
+	}
+
+	if _, err := NewClient(ClientConfig{BaseURL: "http://example.com", Transport: roundTripper(transport), HTTPClient: http.DefaultClient}); err == nil {
+		t.Error("Transport and HTTPClient accepted together")
+	}
+}
+
+type roundTripper RoundTripFunc
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
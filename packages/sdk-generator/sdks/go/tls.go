@@ -0,0 +1,184 @@
+package controlplane
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// newHTTPClient builds the client used when ClientConfig.HTTPClient is not
+// set, using config.Transport or a transport with the TLS settings from
+// config.
+func newHTTPClient(config ClientConfig) (*http.Client, error) {
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Transport != nil {
+		if tlsConfig != nil {
+			return nil, errTransportAndTLS
+		}
+		if config.TransportOptions != (TransportOptions{}) {
+			return nil, errTransportAndTransportOptions
+		}
+		return &http.Client{Transport: config.Transport}, nil
+	}
+	// Timeouts are applied per call through the request context so that
+	// WithTimeout can extend them.
+	return &http.Client{Transport: newTransport(config.TransportOptions, tlsConfig, config.Metrics)}, nil
+}
+
+// usesTLSSettings reports whether config sets any option that NewClient
+// applies to the transport it builds.
+func usesTLSSettings(config ClientConfig) bool {
+	return config.TLSConfig != nil || config.TLSCertFile != "" || config.TLSKeyFile != "" || config.TLSCAFile != ""
+}
+
+// buildTLSConfig combines TLSConfig with the TLS file settings, returning nil
+// when neither is set.
+func buildTLSConfig(config ClientConfig) (*tls.Config, error) {
+	if !usesTLSSettings(config) {
+		return nil, nil
+	}
+	cfg := &tls.Config{}
+	if config.TLSConfig != nil {
+		if err := validateTLSConfig(config.TLSConfig); err != nil {
+			return nil, err
+		}
+		cfg = config.TLSConfig.Clone()
+	}
+
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+			return nil, errors.New("invalid TLS config: TLSCertFile and TLSKeyFile must be set together")
+		}
+		reloader, err := newCertReloader(config.TLSCertFile, config.TLSKeyFile, config.Logger)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = reloader.GetClientCertificate
+	}
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS config: read TLSCAFile: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid TLS config: TLSCAFile %s contains no certificates", config.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+func validateTLSConfig(cfg *tls.Config) error {
+	for i, cert := range cfg.Certificates {
+		if err := validateCertificate(cert); err != nil {
+			return fmt.Errorf("invalid TLSConfig: certificate %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateCertificate rejects client certificates that could never complete
+// a handshake: those without a key and those outside their validity period.
+func validateCertificate(cert tls.Certificate) error {
+	if len(cert.Certificate) == 0 {
+		return errors.New("certificate is empty")
+	}
+	if cert.PrivateKey == nil {
+		return errors.New("certificate has no private key")
+	}
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("parse certificate: %w", err)
+		}
+	}
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return fmt.Errorf("certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+var errHTTPClientAndTLS = errors.New("invalid config: HTTPClient cannot be combined with TLSConfig or TLS files; set TLS on the HTTPClient's transport instead")
+
+var errTransportAndTLS = errors.New("invalid config: Transport cannot be combined with TLSConfig or TLS files; set TLS on the Transport instead")
+
+var errHTTPClientAndTransport = errors.New("invalid config: set either HTTPClient or Transport, not both")
+
+// certReloader serves a client certificate from disk, reloading it when the
+// certificate or key file changes so rotated certificates are picked up
+// without restarting.
+type certReloader struct {
+	certFile, keyFile string
+	log               Logger
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+func newCertReloader(certFile, keyFile string, log Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, log: log}
+	if err := r.reload(); err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %w", err)
+	}
+	return r, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate. If the
+// files changed but cannot be loaded, for example while they are being
+// rewritten, the previous certificate is kept.
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.changed() {
+		if err := r.reload(); err != nil {
+			r.log.Warn("controlplane: keeping previous client certificate", "error", err)
+		}
+	}
+	return r.cert, nil
+}
+
+func (r *certReloader) changed() bool {
+	certInfo, err1 := os.Stat(r.certFile)
+	keyInfo, err2 := os.Stat(r.keyFile)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(r.certMod) || !keyInfo.ModTime().Equal(r.keyMod)
+}
+
+func (r *certReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("client certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("client key: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load client certificate: %w", err)
+	}
+	if err := validateCertificate(cert); err != nil {
+		return fmt.Errorf("client certificate %s: %w", r.certFile, err)
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return nil
+}
@@ -0,0 +1,187 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSignedClientCert returns a client certificate and a pool trusting it.
+func selfSignedClientCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	cert, pool, _, _ := newClientCert(t, "runner", time.Now().Add(time.Hour))
+	return cert, pool
+}
+
+// newClientCert creates a self-signed client certificate valid until
+// notAfter, returning it, a pool trusting it, and its PEM-encoded
+// certificate and key.
+func newClientCert(t *testing.T, cn string, notAfter time.Time) (tls.Certificate, *x509.CertPool, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notAfter.Add(-2 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool, certPEM, keyPEM
+}
+
+func TestTLSConfigMutualTLS(t *testing.T) {
+	cert, clientCAs := selfSignedClientCert(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:   srv.URL,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: roots},
+	})
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err != nil {
+		t.Fatalf("mTLS request failed: %v", err)
+	}
+
+	// Without the pinned root the server certificate is not trusted.
+	client = mustNewClient(t, ClientConfig{
+		BaseURL:   srv.URL,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	})
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, nil); err == nil {
+		t.Error("untrusted server accepted")
+	}
+}
+
+func TestTLSConfigConflicts(t *testing.T) {
+	_, err := NewClient(ClientConfig{
+		BaseURL:    "https://cp.example.com",
+		HTTPClient: &http.Client{},
+		TLSConfig:  &tls.Config{},
+	})
+	if err != errHTTPClientAndTLS {
+		t.Errorf("err = %v", err)
+	}
+
+	cert, _ := selfSignedClientCert(t)
+	cert.PrivateKey = nil
+	if _, err := NewClient(ClientConfig{
+		BaseURL:   "https://cp.example.com",
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}); err == nil {
+		t.Error("certificate without key accepted")
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSCertFilesReloadOnRotation(t *testing.T) {
+	_, poolA, certA, keyA := newClientCert(t, "runner-a", time.Now().Add(time.Hour))
+	_, _, certB, keyB := newClientCert(t, "runner-b", time.Now().Add(time.Hour))
+	clientCAs := poolA.Clone()
+	clientCAs.AddCert(mustParsePEM(t, certB))
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.Config.SetKeepAlivesEnabled(false)
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.pem")
+	start := time.Now().Add(-time.Minute)
+	writeFile(t, certFile, certA, start)
+	writeFile(t, keyFile, keyA, start)
+	writeFile(t, caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), start)
+
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, TLSCertFile: certFile, TLSKeyFile: keyFile, TLSCAFile: caFile})
+	var cn string
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, &cn); err != nil || cn != "runner-a" {
+		t.Fatalf("cn = %q, err = %v", cn, err)
+	}
+
+	writeFile(t, certFile, certB, time.Now())
+	writeFile(t, keyFile, keyB, time.Now())
+	if err := client.call(context.Background(), http.MethodGet, "/health", nil, &cn); err != nil || cn != "runner-b" {
+		t.Fatalf("after rotation cn = %q, err = %v", cn, err)
+	}
+}
+
+func mustParsePEM(t *testing.T, data []byte) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode(data)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestTLSFilesMisconfiguration(t *testing.T) {
+	dir := t.TempDir()
+	_, _, certPEM, keyPEM := newClientCert(t, "old", time.Now().Add(-time.Hour))
+	expiredCert, expiredKey := filepath.Join(dir, "old.crt"), filepath.Join(dir, "old.key")
+	writeFile(t, expiredCert, certPEM, time.Now())
+	writeFile(t, expiredKey, keyPEM, time.Now())
+
+	tests := map[string]ClientConfig{
+		"cert without key": {TLSCertFile: expiredCert},
+		"missing key file": {TLSCertFile: expiredCert, TLSKeyFile: filepath.Join(dir, "missing.key")},
+		"expired cert":     {TLSCertFile: expiredCert, TLSKeyFile: expiredKey},
+		"bad CA file":      {TLSCAFile: expiredKey},
+		"with HTTPClient":  {TLSCAFile: expiredCert, HTTPClient: &http.Client{}},
+	}
+	for name, cfg := range tests {
+		cfg.BaseURL = "https://cp.example.com"
+		if _, err := NewClient(cfg); err == nil {
+			t.Errorf("%s: NewClient succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package tracing adds OpenTelemetry tracing to the ControlPlane client. It
+// lives in its own module so that the core SDK does not depend on
+// OpenTelemetry.
+//
+//	client, err := controlplane.NewClient(controlplane.ClientConfig{
+//		BaseURL:     baseURL,
+//		Middlewares: []controlplane.Middleware{tracing.Middleware(tracing.WithTracerProvider(tp))},
+//	})
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/controlplane/sdk-go/tracing"
+
+// Span attribute keys specific to the ControlPlane API.
+const (
+	AttrAttempt               = attribute.Key("controlplane.attempt")
+	AttrContractVersion       = attribute.Key("controlplane.contract_version")
+	AttrServerContractVersion = attribute.Key("controlplane.server_contract_version")
+	AttrCorrelationID         = attribute.Key("controlplane.correlation_id")
+	AttrErrorCode             = attribute.Key("controlplane.error.code")
+	AttrErrorCategory         = attribute.Key("controlplane.error.category")
+)
+
+type config struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// Option configures the tracing middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the provider spans are created from. Defaults to
+// the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.provider = tp }
+}
+
+// WithPropagator sets the propagator used to inject trace context into
+// outgoing headers. Defaults to W3C Trace Context.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagator = p }
+}
+
+// Middleware returns a client middleware that wraps every request attempt in
+// a client span named "<METHOD> <route>" and propagates its context to the
+// control plane.
+func Middleware(opts ...Option) controlplane.Middleware {
+	cfg := config{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	tracer := cfg.provider.Tracer(instrumentationName)
+
+	return func(next controlplane.RoundTripFunc) controlplane.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			route := controlplane.RouteFromContext(req.Context())
+			if route == "" {
+				route = req.URL.Path
+			}
+			attempt := controlplane.AttemptFromContext(req.Context())
+			ctx, span := tracer.Start(req.Context(), req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.full", req.URL.String()),
+					attribute.String("server.address", req.URL.Hostname()),
+					AttrAttempt.Int(attempt),
+					AttrContractVersion.String(req.Header.Get("X-Contract-Version")),
+				))
+			defer span.End()
+			if attempt > 1 {
+				span.SetAttributes(attribute.Int("http.request.resend_count", attempt-1))
+			}
+			if id := requestCorrelationID(req); id != "" {
+				span.SetAttributes(AttrCorrelationID.String(id))
+			}
+
+			req = req.WithContext(ctx)
+			cfg.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if v := resp.Header.Get("X-Contract-Version"); v != "" {
+				span.SetAttributes(AttrServerContractVersion.String(v))
+			}
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, resp.Status)
+				if env := peekErrorEnvelope(resp); env != nil {
+					span.SetAttributes(AttrErrorCode.String(env.Code), AttrErrorCategory.String(env.Category))
+					if env.CorrelationId != "" {
+						span.SetAttributes(AttrCorrelationID.String(env.CorrelationId))
+					}
+				}
+			}
+			return resp, nil
+		}
+	}
+}
+
+// requestCorrelationID reads metadata.correlationId from a job request body
+// without consuming it.
+func requestCorrelationID(req *http.Request) string {
+	if req.GetBody == nil || req.ContentLength == 0 {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	var doc struct {
+		Metadata struct {
+			CorrelationId string `json:"correlationId"`
+		} `json:"metadata"`
+	}
+	if json.NewDecoder(body).Decode(&doc) != nil {
+		return ""
+	}
+	return doc.Metadata.CorrelationId
+}
+
+// peekErrorEnvelope decodes an error envelope from the response body and
+// replaces the body with an equivalent reader. A read error, such as an
+// oversized body, is replayed after the buffered data.
+func peekErrorEnvelope(resp *http.Response) *controlplane.ErrorEnvelope {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), errorReader{err}))
+		return nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	var env controlplane.ErrorEnvelope
+	if json.Unmarshal(data, &env) != nil || env.Code == "" {
+		return nil
+	}
+	return &env
+}
+
+type errorReader struct{ err error }
+
+func (r errorReader) Read([]byte) (int, error) { return 0, r.err }
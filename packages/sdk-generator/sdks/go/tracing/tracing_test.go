@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareRecordsSpans(t *testing.T) {
+	var traceparents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		w.Header().Set("X-Contract-Version", "1.0.0")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"id":"e1","category":"CONFLICT","severity":"error","code":"JOB_EXISTS","message":"m","service":"api","correlationId":"corr-2"}`))
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client, err := controlplane.NewClient(controlplane.ClientConfig{
+		BaseURL:     srv.URL,
+		Middlewares: []controlplane.Middleware{Middleware(WithTracerProvider(tp))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := map[string]interface{}{"id": "job-1", "metadata": map[string]string{"correlationId": "corr-1"}}
+	resp, err := client.Request(context.Background(), http.MethodPut, "/v1/jobs/job-1", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(data) == 0 {
+		t.Error("response body consumed by middleware")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "PUT /v1/jobs/{id}" {
+		t.Errorf("span name = %q", span.Name())
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("status = %v", span.Status())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	for key, want := range map[attribute.Key]string{
+		"http.response.status_code": "409",
+		AttrAttempt:                 "1",
+		AttrServerContractVersion:   "1.0.0",
+		AttrErrorCode:               "JOB_EXISTS",
+		AttrCorrelationID:           "corr-2",
+	} {
+		if got := attrs[key].Emit(); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if len(traceparents) != 1 || traceparents[0] != want {
+		t.Errorf("traceparent = %v, want %s", traceparents, want)
+	}
+}
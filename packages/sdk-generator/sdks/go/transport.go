@@ -0,0 +1,149 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Defaults of the transport NewClient builds when HTTPClient and Transport
+// are nil. They favor many concurrent requests to one control plane host,
+// where Go's default of two idle connections per host would force most
+// requests to dial.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 64
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultDialTimeout         = 10 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// TransportOptions tunes the connection pool of the transport NewClient
+// builds. Zero fields select the defaults above; see http.Transport for
+// their meaning.
+type TransportOptions struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits dialing, active and idle connections per
+	// host. Zero means no limit.
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	// DisableHTTP2 keeps the transport on HTTP/1.1, which it otherwise
+	// upgrades to HTTP/2 when the server offers it.
+	DisableHTTP2 bool
+}
+
+// PoolMetricsCollector is implemented by a MetricsCollector that also
+// tracks the connection pool of the transport NewClient builds. It is not
+// used with HTTPClient or Transport. Host is the host:port dialed.
+type PoolMetricsCollector interface {
+	// ConnectionOpened and ConnectionClosed bracket every connection the
+	// transport dials and can be used to track open connections.
+	ConnectionOpened(host string)
+	ConnectionClosed(host string)
+	// ConnectionAcquired is called each time an attempt obtains a
+	// connection; reused reports whether it was already open, as opposed
+	// to freshly dialed.
+	ConnectionAcquired(host string, reused bool)
+}
+
+var errHTTPClientAndTransportOptions = errors.New("invalid config: HTTPClient cannot be combined with TransportOptions; tune the HTTPClient's transport instead")
+
+var errTransportAndTransportOptions = errors.New("invalid config: Transport cannot be combined with TransportOptions")
+
+// newTransport builds the client's default transport.
+func newTransport(opts TransportOptions, tlsConfig *tls.Config, metrics MetricsCollector) http.RoundTripper {
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost == 0 {
+		opts.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = DefaultDialTimeout
+	}
+	if opts.TLSHandshakeTimeout == 0 {
+		opts.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	dialer := &net.Dialer{Timeout: opts.DialTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if opts.DisableHTTP2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	pm, ok := metrics.(PoolMetricsCollector)
+	if !ok {
+		return transport
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		pm.ConnectionOpened(addr)
+		return &countedConn{Conn: conn, closed: func() { pm.ConnectionClosed(addr) }}, nil
+	}
+	return &pooledTransport{Transport: transport, metrics: pm}
+}
+
+// pooledTransport reports the connection each request obtains.
+type pooledTransport struct {
+	*http.Transport
+	metrics PoolMetricsCollector
+}
+
+func (t *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := canonicalAddr(req)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.metrics.ConnectionAcquired(host, info.Reused)
+		},
+	}
+	return t.Transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// canonicalAddr returns the host:port a request is sent to, matching the
+// address the transport dials.
+func canonicalAddr(req *http.Request) string {
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// countedConn reports its first Close.
+type countedConn struct {
+	net.Conn
+	once   sync.Once
+	closed func()
+}
+
+func (c *countedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.closed)
+	return err
+}
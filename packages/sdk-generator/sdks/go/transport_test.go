@@ -0,0 +1,111 @@
+package controlplane
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDefaultTransportPooling(t *testing.T) {
+	client := mustNewClient(t, ClientConfig{BaseURL: testBaseURL})
+	tr, ok := client.client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T", client.client.Transport)
+	}
+	if tr.MaxIdleConns != DefaultMaxIdleConns || tr.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost ||
+		tr.IdleConnTimeout != DefaultIdleConnTimeout || tr.TLSHandshakeTimeout != DefaultTLSHandshakeTimeout {
+		t.Errorf("pooling = %d/%d/%v/%v", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout, tr.TLSHandshakeTimeout)
+	}
+	if !tr.ForceAttemptHTTP2 || tr.TLSNextProto != nil {
+		t.Error("HTTP/2 not enabled")
+	}
+}
+
+func TestTransportOptionsOverride(t *testing.T) {
+	client := mustNewClient(t, ClientConfig{BaseURL: testBaseURL, TransportOptions: TransportOptions{
+		MaxIdleConnsPerHost: 8,
+		MaxConnsPerHost:     16,
+		IdleConnTimeout:     time.Minute,
+		DisableHTTP2:        true,
+	}})
+	tr := client.client.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != 8 || tr.MaxConnsPerHost != 16 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("pooling = %d/%d/%v", tr.MaxIdleConnsPerHost, tr.MaxConnsPerHost, tr.IdleConnTimeout)
+	}
+	if tr.MaxIdleConns != DefaultMaxIdleConns {
+		t.Errorf("MaxIdleConns = %d", tr.MaxIdleConns)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil {
+		t.Error("HTTP/2 not disabled")
+	}
+}
+
+func TestTransportOptionsConflicts(t *testing.T) {
+	opts := TransportOptions{MaxIdleConnsPerHost: 8}
+	for _, tc := range []struct {
+		config ClientConfig
+		want   error
+	}{
+		{ClientConfig{BaseURL: testBaseURL, HTTPClient: &http.Client{}, TransportOptions: opts}, errHTTPClientAndTransportOptions},
+		{ClientConfig{BaseURL: testBaseURL, Transport: http.DefaultTransport, TransportOptions: opts}, errTransportAndTransportOptions},
+	} {
+		if _, err := NewClient(tc.config); !errors.Is(err, tc.want) {
+			t.Errorf("NewClient() error = %v, want %v", err, tc.want)
+		}
+	}
+}
+
+type poolMetrics struct {
+	recordingMetrics
+	mu       sync.Mutex
+	open     int
+	acquired []bool
+}
+
+func (m *poolMetrics) ConnectionOpened(host string) { m.mu.Lock(); m.open++; m.mu.Unlock() }
+func (m *poolMetrics) ConnectionClosed(host string) { m.mu.Lock(); m.open--; m.mu.Unlock() }
+
+func (m *poolMetrics) ConnectionAcquired(host string, reused bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acquired = append(m.acquired, reused)
+}
+
+func TestPoolMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	metrics := &poolMetrics{}
+	client := mustNewClient(t, ClientConfig{BaseURL: srv.URL, Metrics: metrics})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Request(context.Background(), http.MethodGet, "/v1/health", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drainAndClose(resp.Body)
+	}
+	metrics.mu.Lock()
+	if metrics.open != 1 || len(metrics.acquired) != 2 || metrics.acquired[0] || !metrics.acquired[1] {
+		t.Errorf("open = %d, acquired (reused) = %v", metrics.open, metrics.acquired)
+	}
+	metrics.mu.Unlock()
+
+	client.client.CloseIdleConnections()
+	deadline := time.Now().Add(time.Second)
+	for {
+		metrics.mu.Lock()
+		open := metrics.open
+		metrics.mu.Unlock()
+		if open == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("open = %d after closing idle connections", open)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
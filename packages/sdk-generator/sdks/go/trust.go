@@ -0,0 +1,82 @@
+package controlplane
+
+import (
+	"fmt"
+	"time"
+)
+
+// trustRank orders TrustStatus values from least to most trusted.
+var trustRank = map[string]int{
+	TrustStatusFAILED:     0,
+	TrustStatusUNVERIFIED: 1,
+	TrustStatusPENDING:    2,
+	TrustStatusVERIFIED:   3,
+}
+
+// TrustPolicy is the minimum trust a marketplace runner needs before it may
+// be installed. Zero fields impose no requirement.
+type TrustPolicy struct {
+	// MinOverallTrust is the lowest acceptable TrustStatus; statuses rank
+	// failed < unverified < pending < verified.
+	MinOverallTrust string
+	// RequirePassingContractTests requires ContractTestStatus "passing".
+	RequirePassingContractTests bool
+	// MaxSecurityScanAge is how old LastSecurityScanAt may be. A runner that
+	// was never scanned is blocked.
+	MaxSecurityScanAge time.Duration
+	// MinCodeQualityScore is the lowest acceptable CodeQualityScore (0-100).
+	MinCodeQualityScore float64
+}
+
+// Allows reports whether signals satisfy the policy now. When they do not,
+// the returned reasons list every requirement that failed.
+func (p TrustPolicy) Allows(signals MarketplaceTrustSignals) (bool, []string) {
+	return p.AllowsAt(signals, time.Now())
+}
+
+// AllowsAt is Allows with MaxSecurityScanAge measured at now, such as a
+// Clock's Now, rather than the system time.
+func (p TrustPolicy) AllowsAt(signals MarketplaceTrustSignals, now time.Time) (bool, []string) {
+	var reasons []string
+	if p.MinOverallTrust != "" {
+		have, known := trustRank[signals.OverallTrust]
+		switch {
+		case !known:
+			reasons = append(reasons, fmt.Sprintf("overall trust %q is not a known trust status", signals.OverallTrust))
+		case have < trustRank[p.MinOverallTrust]:
+			reasons = append(reasons, fmt.Sprintf("overall trust %q is below %q", signals.OverallTrust, p.MinOverallTrust))
+		}
+	}
+	if p.RequirePassingContractTests && signals.ContractTestStatus != ContractTestStatusPASSING {
+		reasons = append(reasons, fmt.Sprintf("contract tests are %q, not passing", signals.ContractTestStatus))
+	}
+	if p.MaxSecurityScanAge > 0 {
+		if signals.LastSecurityScanAt.IsZero() {
+			reasons = append(reasons, "no security scan recorded")
+		} else if age := now.Sub(signals.LastSecurityScanAt); age > p.MaxSecurityScanAge {
+			reasons = append(reasons, fmt.Sprintf("last security scan is %s old, more than %s",
+				age.Round(time.Second), p.MaxSecurityScanAge))
+		}
+	}
+	if p.MinCodeQualityScore > 0 {
+		if signals.CodeQualityScore == nil {
+			reasons = append(reasons, "no code quality score recorded")
+		} else if score := *signals.CodeQualityScore; score < p.MinCodeQualityScore {
+			reasons = append(reasons, fmt.Sprintf("code quality score %g is below %g",
+				score, p.MinCodeQualityScore))
+		}
+	}
+	return len(reasons) == 0, reasons
+}
+
+// TrustSignalsTyped decodes the runner's trustSignals field.
+func (m MarketplaceRunner) TrustSignalsTyped() (MarketplaceTrustSignals, error) {
+	var s MarketplaceTrustSignals
+	if m.TrustSignals == nil {
+		return s, fmt.Errorf("trustSignals is not set")
+	}
+	if err := decodeMap(m.TrustSignals, &s); err != nil {
+		return s, fmt.Errorf("decode trustSignals: %w", err)
+	}
+	return s, nil
+}
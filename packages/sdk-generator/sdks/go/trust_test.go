@@ -0,0 +1,94 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var strictTrust = TrustPolicy{
+	MinOverallTrust:             TrustStatusVERIFIED,
+	RequirePassingContractTests: true,
+	MaxSecurityScanAge:          30 * 24 * time.Hour,
+	MinCodeQualityScore:         80,
+}
+
+func trustedSignals() MarketplaceTrustSignals {
+	return MarketplaceTrustSignals{
+		OverallTrust:       TrustStatusVERIFIED,
+		ContractTestStatus: ContractTestStatusPASSING,
+		LastSecurityScanAt: time.Now().Add(-24 * time.Hour),
+		CodeQualityScore:   Float(92),
+	}
+}
+
+func TestTrustPolicyAllows(t *testing.T) {
+	if ok, reasons := strictTrust.Allows(trustedSignals()); !ok {
+		t.Errorf("blocked: %v", reasons)
+	}
+	if ok, _ := (TrustPolicy{}).Allows(MarketplaceTrustSignals{}); !ok {
+		t.Error("zero policy blocked")
+	}
+}
+
+func TestTrustPolicyAllowsAt(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	signals := trustedSignals()
+	signals.LastSecurityScanAt = now.Add(-29 * 24 * time.Hour)
+	if ok, reasons := strictTrust.AllowsAt(signals, now); !ok {
+		t.Errorf("blocked: %v", reasons)
+	}
+	ok, reasons := strictTrust.AllowsAt(signals, now.Add(48*time.Hour))
+	if ok || len(reasons) != 1 || !strings.Contains(reasons[0], "last security scan is 744h0m0s old") {
+		t.Errorf("ok = %v, reasons = %v", ok, reasons)
+	}
+}
+
+func TestTrustPolicyBlockingReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*MarketplaceTrustSignals)
+		reason string
+	}{
+		{"low trust", func(s *MarketplaceTrustSignals) { s.OverallTrust = TrustStatusPENDING }, "overall trust"},
+		{"unknown trust", func(s *MarketplaceTrustSignals) { s.OverallTrust = "bogus" }, "not a known trust status"},
+		{"failing tests", func(s *MarketplaceTrustSignals) { s.ContractTestStatus = ContractTestStatusSTALE }, "contract tests"},
+		{"stale scan", func(s *MarketplaceTrustSignals) { s.LastSecurityScanAt = time.Now().Add(-60 * 24 * time.Hour) }, "security scan is"},
+		{"never scanned", func(s *MarketplaceTrustSignals) { s.LastSecurityScanAt = time.Time{} }, "no security scan"},
+		{"low quality", func(s *MarketplaceTrustSignals) { s.CodeQualityScore = Float(79.5) }, "code quality"},
+	}
+	for _, tt := range tests {
+		s := trustedSignals()
+		tt.mutate(&s)
+		ok, reasons := strictTrust.Allows(s)
+		if ok || len(reasons) != 1 || !strings.Contains(reasons[0], tt.reason) {
+			t.Errorf("%s: ok = %v, reasons = %v", tt.name, ok, reasons)
+		}
+	}
+
+	ok, reasons := strictTrust.Allows(MarketplaceTrustSignals{OverallTrust: TrustStatusFAILED})
+	if ok || len(reasons) != 4 {
+		t.Errorf("all failing: reasons = %v", reasons)
+	}
+}
+
+func TestTrustSignalsTyped(t *testing.T) {
+	r := MarketplaceRunner{TrustSignals: map[string]interface{}{
+		"overallTrust":       "verified",
+		"contractTestStatus": "passing",
+		"lastSecurityScanAt": "2024-01-01T00:00:00Z",
+		"codeQualityScore":   88.0,
+	}}
+	s, err := r.TrustSignalsTyped()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.OverallTrust != TrustStatusVERIFIED || FloatValue(s.CodeQualityScore) != 88 || s.LastSecurityScanAt.Year() != 2024 {
+		t.Errorf("signals = %+v", s)
+	}
+
+	r.TrustSignals["codeQualityScore"] = "high"
+	if _, err := r.TrustSignalsTyped(); err == nil {
+		t.Error("malformed signals decoded")
+	}
+}
@@ -0,0 +1,45 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+)
+
+// AssertTruth records an assertion and returns it as stored by the control
+// plane, with its id and timestamp filled in when they were left empty.
+// The request's tenant is added to the assertion's metadata as "tenantId"
+// unless it is already set. WithConsistency sets how durably the write is
+// acknowledged; it defaults to DefaultConsistencyLevel.
+func (c *ControlPlaneClient) AssertTruth(ctx context.Context, assertion TruthAssertion, opts ...RequestOption) (*TruthAssertion, error) {
+	opts, _, err := c.consistencyOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	assertion.Metadata = stampTenant(assertion.Metadata, c.tenant(ctx))
+	var stored TruthAssertion
+	if err := c.call(ctx, http.MethodPost, "/v1/truth/assertions", assertion, &stored, opts...); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// QueryTruth runs a truth query and returns the whole result; see
+// QueryTruthStream for large results. WithConsistency sets the read's
+// consistency level, DefaultConsistencyLevel by default; a strict read that
+// the server reports as not yet consistent (ErrCodeNotYetConsistent) is
+// retried a few times with a short backoff.
+func (c *ControlPlaneClient) QueryTruth(ctx context.Context, query TruthQuery, opts ...RequestOption) (*TruthQueryResult, error) {
+	opts, level, err := c.consistencyOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	var result TruthQueryResult
+	err = c.readConsistently(ctx, level, func() error {
+		result = TruthQueryResult{}
+		return c.call(ctx, http.MethodPost, "/v1/truth/query", query, &result, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,168 @@
+package controlplane
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TruthPatternBuilder assembles a TruthQuery.Pattern. Methods record
+// invalid values, which Build returns as ValidationErrors with "pattern."
+// fields.
+//
+//	pattern, err := controlplane.NewTruthPatternBuilder().
+//		Subject("svc:billing").
+//		Predicate("deployed_version").
+//		Build()
+type TruthPatternBuilder struct {
+	pattern map[string]interface{}
+	errs    ValidationErrors
+}
+
+// NewTruthPatternBuilder starts a pattern that matches every assertion.
+func NewTruthPatternBuilder() *TruthPatternBuilder {
+	return &TruthPatternBuilder{pattern: map[string]interface{}{}}
+}
+
+// Subject matches assertions about subject.
+func (b *TruthPatternBuilder) Subject(subject string) *TruthPatternBuilder {
+	return b.setString("subject", subject)
+}
+
+// Predicate matches assertions with predicate.
+func (b *TruthPatternBuilder) Predicate(predicate string) *TruthPatternBuilder {
+	return b.setString("predicate", predicate)
+}
+
+// ObjectEquals matches assertions whose object equals v: a string, number,
+// bool, nil, slice, map or struct, compared in its JSON form.
+func (b *TruthPatternBuilder) ObjectEquals(v interface{}) *TruthPatternBuilder {
+	var object interface{}
+	if err := decodeMap(v, &object); err != nil {
+		b.errs.Add("pattern.object", "is not a JSON value: "+err.Error())
+		return b
+	}
+	b.pattern["object"] = object
+	return b
+}
+
+func (b *TruthPatternBuilder) setString(field, value string) *TruthPatternBuilder {
+	if value == "" {
+		b.errs.Add("pattern."+field, "must not be empty")
+		return b
+	}
+	b.pattern[field] = value
+	return b
+}
+
+// Build returns the pattern, or the ValidationErrors of every invalid value
+// given.
+func (b *TruthPatternBuilder) Build() (map[string]interface{}, error) {
+	if !b.errs.IsValid() {
+		errs := b.errs
+		return nil, &errs
+	}
+	return copyMap(b.pattern), nil
+}
+
+// TruthFilterBuilder assembles TruthQuery.Filters. Like TruthPatternBuilder,
+// it collects invalid values for Build, as "filters." fields.
+type TruthFilterBuilder struct {
+	filters map[string]interface{}
+	sources []string
+	after   time.Time
+	before  time.Time
+	errs    ValidationErrors
+}
+
+// NewTruthFilterBuilder starts an empty set of filters.
+func NewTruthFilterBuilder() *TruthFilterBuilder {
+	return &TruthFilterBuilder{filters: map[string]interface{}{}}
+}
+
+// ConfidenceAtLeast keeps assertions with a confidence of at least min,
+// which must be between 0 and 1.
+func (b *TruthFilterBuilder) ConfidenceAtLeast(min float64) *TruthFilterBuilder {
+	if math.IsNaN(min) || min < 0 || min > 1 {
+		b.errs.Add("filters.minConfidence", fmt.Sprintf("must be between 0 and 1, not %v", min))
+		return b
+	}
+	b.filters["minConfidence"] = min
+	return b
+}
+
+// Source keeps assertions from source. Calling it again adds another
+// source; an assertion from any of them matches.
+func (b *TruthFilterBuilder) Source(source string) *TruthFilterBuilder {
+	if source == "" {
+		b.errs.Add("filters.sources", "must not contain an empty source")
+		return b
+	}
+	b.sources = append(b.sources, source)
+	return b
+}
+
+// CreatedAfter keeps assertions made after t.
+func (b *TruthFilterBuilder) CreatedAfter(t time.Time) *TruthFilterBuilder {
+	if t.IsZero() {
+		b.errs.Add("filters.after", "must not be the zero time")
+		return b
+	}
+	b.after = t
+	return b
+}
+
+// CreatedBefore keeps assertions made before t.
+func (b *TruthFilterBuilder) CreatedBefore(t time.Time) *TruthFilterBuilder {
+	if t.IsZero() {
+		b.errs.Add("filters.before", "must not be the zero time")
+		return b
+	}
+	b.before = t
+	return b
+}
+
+// Build returns the filters, with times in UTC RFC 3339, or the
+// ValidationErrors of every invalid value given. A CreatedAfter time that
+// is not before the CreatedBefore time is reported too, as no assertion
+// could match.
+func (b *TruthFilterBuilder) Build() (map[string]interface{}, error) {
+	errs := ValidationErrors{Errors: append([]ValidationError(nil), b.errs.Errors...)}
+	if !b.after.IsZero() && !b.before.IsZero() && !b.after.Before(b.before) {
+		errs.Add("filters.after", "must be before filters.before")
+	}
+	if !errs.IsValid() {
+		return nil, &errs
+	}
+	filters := copyMap(b.filters)
+	if len(b.sources) > 0 {
+		filters["sources"] = append([]string(nil), b.sources...)
+	}
+	if !b.after.IsZero() {
+		filters["after"] = b.after.UTC().Format(time.RFC3339Nano)
+	}
+	if !b.before.IsZero() {
+		filters["before"] = b.before.UTC().Format(time.RFC3339Nano)
+	}
+	return filters, nil
+}
+
+// WithLimit returns a copy of the query limited to n results. A negative n
+// is reported as a ValidationError on "limit" and leaves the query as is.
+func (m TruthQuery) WithLimit(n int) (TruthQuery, error) {
+	if n < 0 {
+		return m, &ValidationErrors{Errors: []ValidationError{{Field: "limit", Message: "must not be negative"}}}
+	}
+	m.Limit = Int(n)
+	return m, nil
+}
+
+// WithOffset returns a copy of the query skipping its first n results,
+// rejecting a negative n like WithLimit.
+func (m TruthQuery) WithOffset(n int) (TruthQuery, error) {
+	if n < 0 {
+		return m, &ValidationErrors{Errors: []ValidationError{{Field: "offset", Message: "must not be negative"}}}
+	}
+	m.Offset = Int(n)
+	return m, nil
+}
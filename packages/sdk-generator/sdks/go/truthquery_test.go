@@ -0,0 +1,87 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTruthQueryBuilders(t *testing.T) {
+	pattern, err := NewTruthPatternBuilder().
+		Subject("svc:billing").
+		Predicate("deployed_version").
+		ObjectEquals(struct {
+			Major int `json:"major"`
+		}{2}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	filters, err := NewTruthFilterBuilder().
+		ConfidenceAtLeast(0.8).
+		Source("ci").
+		Source("deployer").
+		CreatedAfter(after).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := TruthQuery{Id: "q-1", Pattern: pattern, Filters: filters}.WithLimit(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := json.Marshal(q)
+	want := `{"id":"q-1",` +
+		`"pattern":{"object":{"major":2},"predicate":"deployed_version","subject":"svc:billing"},` +
+		`"filters":{"after":"2024-01-01T11:00:00Z","minConfidence":0.8,"sources":["ci","deployer"]},` +
+		`"limit":10}`
+	if string(got) != want {
+		t.Errorf("query:\n got %s\nwant %s", got, want)
+	}
+	if err := q.Validate(); err != nil {
+		t.Errorf("built query invalid: %v", err)
+	}
+}
+
+func TestTruthQueryBuildersRejectInvalidValues(t *testing.T) {
+	_, err := NewTruthPatternBuilder().Subject("").ObjectEquals(func() {}).Build()
+	assertFields(t, err, "pattern.subject", "pattern.object")
+
+	now := time.Now()
+	_, err = NewTruthFilterBuilder().
+		ConfidenceAtLeast(1.5).
+		ConfidenceAtLeast(math.NaN()).
+		Source("").
+		CreatedAfter(now).
+		CreatedBefore(now.Add(-time.Hour)).
+		Build()
+	assertFields(t, err, "filters.minConfidence", "filters.minConfidence", "filters.sources", "filters.after")
+
+	q := TruthQuery{Id: "q-1", Limit: Int(5)}
+	if got, err := q.WithLimit(-1); err == nil || IntValue(got.Limit) != 5 {
+		t.Errorf("WithLimit(-1) = %v, %v", got.Limit, err)
+	}
+	if _, err := q.WithOffset(-1); err == nil {
+		t.Error("WithOffset(-1) accepted")
+	}
+	if got, err := q.WithOffset(0); err != nil || got.Offset == nil || IntValue(q.Offset) != 0 {
+		t.Errorf("WithOffset(0) = %v, %v", got.Offset, err)
+	}
+}
+
+func assertFields(t *testing.T, err error, fields ...string) {
+	t.Helper()
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs.Errors) != len(fields) {
+		t.Fatalf("err = %v, want errors on %v", err, fields)
+	}
+	for i, e := range verrs.Errors {
+		if e.Field != fields[i] {
+			t.Errorf("error %d on %q, want %q", i, e.Field, fields[i])
+		}
+	}
+}
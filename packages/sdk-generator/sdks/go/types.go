@@ -4,7 +4,6 @@
 package controlplane
 
 import (
-	"encoding/json"
 	"time"
 )
 
@@ -48,10 +47,10 @@ const (
 
 // RetryPolicy represents a errors schema
 type RetryPolicy struct {
-	MaxRetries int `json:"maxRetries,omitempty"`
-	BackoffMs float64 `json:"backoffMs,omitempty"`
-	MaxBackoffMs float64 `json:"maxBackoffMs,omitempty"`
-	BackoffMultiplier float64 `json:"backoffMultiplier,omitempty"`
+	MaxRetries *int `json:"maxRetries,omitempty"`
+	BackoffMs *float64 `json:"backoffMs,omitempty"`
+	MaxBackoffMs *float64 `json:"maxBackoffMs,omitempty"`
+	BackoffMultiplier *float64 `json:"backoffMultiplier,omitempty"`
 	RetryableCategories []string `json:"retryableCategories,omitempty"`
 	NonRetryableCategories []string `json:"nonRetryableCategories,omitempty"`
 }
@@ -87,8 +86,8 @@ type ErrorEnvelope struct {
 	Operation string `json:"operation,omitempty"`
 	CorrelationId string `json:"correlationId,omitempty"`
 	CausationId string `json:"causationId,omitempty"`
-	Retryable bool `json:"retryable,omitempty"`
-	RetryAfter float64 `json:"retryAfter,omitempty"`
+	Retryable *bool `json:"retryable,omitempty"`
+	RetryAfter *float64 `json:"retryAfter,omitempty"`
 	ContractVersion map[string]interface{} `json:"contractVersion"`
 }
 
@@ -197,11 +196,11 @@ func (m JobPayload) Validate() error {
 type JobRequest struct {
 	Id string `json:"id"`
 	Type string `json:"type"`
-	Priority int `json:"priority,omitempty"`
+	Priority *int `json:"priority,omitempty"`
 	Payload map[string]interface{} `json:"payload"`
 	Metadata map[string]interface{} `json:"metadata"`
 	RetryPolicy map[string]interface{} `json:"retryPolicy,omitempty"`
-	TimeoutMs float64 `json:"timeoutMs,omitempty"`
+	TimeoutMs *float64 `json:"timeoutMs,omitempty"`
 }
 
 // Validate checks if the JobRequest is valid
@@ -246,8 +245,8 @@ type RunnerCapability struct {
 	InputSchema map[string]interface{} `json:"inputSchema"`
 	OutputSchema map[string]interface{} `json:"outputSchema"`
 	SupportedJobTypes []string `json:"supportedJobTypes"`
-	MaxConcurrency int `json:"maxConcurrency,omitempty"`
-	TimeoutMs float64 `json:"timeoutMs,omitempty"`
+	MaxConcurrency *int `json:"maxConcurrency,omitempty"`
+	TimeoutMs *float64 `json:"timeoutMs,omitempty"`
 	ResourceRequirements map[string]interface{} `json:"resourceRequirements,omitempty"`
 }
 
@@ -295,7 +294,7 @@ func (m RunnerRegistrationRequest) Validate() error {
 type RunnerRegistrationResponse struct {
 	RunnerId string `json:"runnerId"`
 	RegisteredAt time.Time `json:"registeredAt"`
-	HeartbeatIntervalMs float64 `json:"heartbeatIntervalMs,omitempty"`
+	HeartbeatIntervalMs *float64 `json:"heartbeatIntervalMs,omitempty"`
 }
 
 // Validate checks if the RunnerRegistrationResponse is valid
@@ -308,8 +307,8 @@ type RunnerHeartbeat struct {
 	RunnerId string `json:"runnerId"`
 	Timestamp time.Time `json:"timestamp"`
 	Status string `json:"status"`
-	ActiveJobs int `json:"activeJobs,omitempty"`
-	QueuedJobs int `json:"queuedJobs,omitempty"`
+	ActiveJobs *int `json:"activeJobs,omitempty"`
+	QueuedJobs *int `json:"queuedJobs,omitempty"`
 	Metrics map[string]interface{} `json:"metrics,omitempty"`
 }
 
@@ -343,7 +342,7 @@ type RunnerExecutionRequest struct {
 	ModuleId string `json:"moduleId"`
 	CapabilityId string `json:"capabilityId"`
 	Payload map[string]interface{} `json:"payload"`
-	TimeoutMs float64 `json:"timeoutMs,omitempty"`
+	TimeoutMs *float64 `json:"timeoutMs,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -373,7 +372,7 @@ type TruthAssertion struct {
 	Subject string `json:"subject"`
 	Predicate string `json:"predicate"`
 	Object interface{} `json:"object"`
-	Confidence float64 `json:"confidence,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 	Source string `json:"source"`
 	ExpiresAt time.Time `json:"expiresAt,omitempty"`
@@ -390,8 +389,8 @@ type TruthQuery struct {
 	Id string `json:"id"`
 	Pattern map[string]interface{} `json:"pattern"`
 	Filters map[string]interface{} `json:"filters,omitempty"`
-	Limit int `json:"limit,omitempty"`
-	Offset int `json:"offset,omitempty"`
+	Limit *int `json:"limit,omitempty"`
+	Offset *int `json:"offset,omitempty"`
 }
 
 // Validate checks if the TruthQuery is valid
@@ -404,7 +403,7 @@ type TruthQueryResult struct {
 	QueryId string `json:"queryId"`
 	Assertions []map[string]interface{} `json:"assertions"`
 	TotalCount int `json:"totalCount"`
-	HasMore bool `json:"hasMore,omitempty"`
+	HasMore *bool `json:"hasMore,omitempty"`
 	QueryTimeMs float64 `json:"queryTimeMs"`
 }
 
@@ -521,8 +520,8 @@ func (m ServiceMetadata) Validate() error {
 
 // PaginatedRequest represents a types schema
 type PaginatedRequest struct {
-	Limit int `json:"limit,omitempty"`
-	Offset int `json:"offset,omitempty"`
+	Limit *int `json:"limit,omitempty"`
+	Offset *int `json:"offset,omitempty"`
 	Cursor string `json:"cursor,omitempty"`
 	SortBy string `json:"sortBy,omitempty"`
 	SortOrder string `json:"sortOrder,omitempty"`
@@ -617,8 +616,8 @@ type ConnectorConfig struct {
 	Version string `json:"version"`
 	Description string `json:"description"`
 	ConfigSchema map[string]interface{} `json:"configSchema"`
-	Required bool `json:"required,omitempty"`
-	HealthCheckable bool `json:"healthCheckable,omitempty"`
+	Required *bool `json:"required,omitempty"`
+	HealthCheckable *bool `json:"healthCheckable,omitempty"`
 }
 
 // Validate checks if the ConnectorConfig is valid
@@ -680,8 +679,8 @@ type RegistryQuery struct {
 	Category string `json:"category,omitempty"`
 	ConnectorType string `json:"connectorType,omitempty"`
 	HealthStatus string `json:"healthStatus,omitempty"`
-	IncludeCapabilities bool `json:"includeCapabilities,omitempty"`
-	IncludeConnectors bool `json:"includeConnectors,omitempty"`
+	IncludeCapabilities *bool `json:"includeCapabilities,omitempty"`
+	IncludeConnectors *bool `json:"includeConnectors,omitempty"`
 }
 
 // Validate checks if the RegistryQuery is valid
@@ -790,8 +789,8 @@ type MarketplaceQuery struct {
 	Keywords []string `json:"keywords,omitempty"`
 	SortBy string `json:"sortBy,omitempty"`
 	SortOrder string `json:"sortOrder,omitempty"`
-	Limit float64 `json:"limit,omitempty"`
-	Offset float64 `json:"offset,omitempty"`
+	Limit *float64 `json:"limit,omitempty"`
+	Offset *float64 `json:"offset,omitempty"`
 }
 
 // Validate checks if the MarketplaceQuery is valid
@@ -823,9 +822,9 @@ type MarketplaceTrustSignals struct {
 	SecurityScanStatus string `json:"securityScanStatus"`
 	LastSecurityScanAt time.Time `json:"lastSecurityScanAt,omitempty"`
 	SecurityScanDetails map[string]interface{} `json:"securityScanDetails,omitempty"`
-	CodeQualityScore float64 `json:"codeQualityScore,omitempty"`
+	CodeQualityScore *float64 `json:"codeQualityScore,omitempty"`
 	MaintainerReputation string `json:"maintainerReputation,omitempty"`
-	DownloadCount float64 `json:"downloadCount,omitempty"`
+	DownloadCount *float64 `json:"downloadCount,omitempty"`
 	Rating map[string]interface{} `json:"rating,omitempty"`
 }
 
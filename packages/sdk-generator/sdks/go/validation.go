@@ -6,8 +6,12 @@ package controlplane
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
+// ErrValidation matches every *ValidationErrors with errors.Is.
+var ErrValidation = errors.New("validation failed")
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
@@ -18,24 +22,111 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
-// ValidationErrors collects multiple validation errors
+// ValidationErrors collects multiple validation errors. Validators return
+// it as a *ValidationErrors; its methods are safe to call on nil, which
+// holds no errors.
 type ValidationErrors struct {
 	Errors []ValidationError
 }
 
-func (e ValidationErrors) Error() string {
-	if len(e.Errors) == 0 {
-		return "validation failed"
+// Error reports every error, separated by semicolons.
+func (e *ValidationErrors) Error() string {
+	if e.IsValid() {
+		return ErrValidation.Error()
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, ve := range e.Errors {
+		msgs[i] = ve.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationErrors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Unwrap returns each ValidationError, so errors.As can find one.
+func (e *ValidationErrors) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	errs := make([]error, len(e.Errors))
+	for i, ve := range e.Errors {
+		errs[i] = ve
+	}
+	return errs
+}
+
+// Fields returns the fields that have errors, each once, in the order they
+// were first reported.
+func (e *ValidationErrors) Fields() []string {
+	if e == nil {
+		return nil
+	}
+	var fields []string
+	seen := map[string]bool{}
+	for _, ve := range e.Errors {
+		if !seen[ve.Field] {
+			seen[ve.Field] = true
+			fields = append(fields, ve.Field)
+		}
+	}
+	return fields
+}
+
+// ByField returns the errors reported for the field.
+func (e *ValidationErrors) ByField(name string) []ValidationError {
+	if e == nil {
+		return nil
 	}
-	return e.Errors[0].Error()
+	var errs []ValidationError
+	for _, ve := range e.Errors {
+		if ve.Field == name {
+			errs = append(errs, ve)
+		}
+	}
+	return errs
 }
 
 // IsValid checks if there are no validation errors
-func (e ValidationErrors) IsValid() bool {
-	return len(e.Errors) == 0
+func (e *ValidationErrors) IsValid() bool {
+	return e == nil || len(e.Errors) == 0
 }
 
 // Add adds a validation error
 func (e *ValidationErrors) Add(field, message string) {
 	e.Errors = append(e.Errors, ValidationError{Field: field, Message: message})
 }
+
+// validationRules holds checks that go beyond what the contract schemas can
+// express, keyed by schema name. Hand-written files register them in init.
+var validationRules = map[string][]func(interface{}, *ValidationErrors){}
+
+// registerRule adds a rule that every validate call for the schema runs.
+func registerRule[T any](schema string, rule func(m T, errs *ValidationErrors)) {
+	validationRules[schema] = append(validationRules[schema], func(m interface{}, errs *ValidationErrors) {
+		rule(m.(T), errs)
+	})
+}
+
+// applyRules runs the registered rules for the schema.
+func applyRules(schema string, m interface{}, errs *ValidationErrors) {
+	for _, rule := range validationRules[schema] {
+		rule(m, errs)
+	}
+}
+
+// checkEnum reports a value outside values. An empty value is left to the
+// required check, so optional enum fields may be unset.
+func checkEnum(field, value string, values []string, errs *ValidationErrors) {
+	if value == "" {
+		return
+	}
+	for _, v := range values {
+		if v == value {
+			return
+		}
+	}
+	errs.Add(field, "must be one of ["+strings.Join(values, ", ")+"]")
+}
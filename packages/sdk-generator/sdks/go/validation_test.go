@@ -0,0 +1,60 @@
+package controlplane
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestValidationErrorsReportsEveryError(t *testing.T) {
+	err := JobRequest{Metadata: map[string]interface{}{"source": "test", "createdAt": "2024-01-01T00:00:00Z"}}.Validate()
+	verrs, ok := err.(*ValidationErrors)
+	if !ok {
+		t.Fatalf("err = %v", err)
+	}
+	if got := err.Error(); got != "id: is required; type: is required" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := (&ValidationErrors{}).Error(); got != "validation failed" {
+		t.Errorf("empty Error() = %q", got)
+	}
+
+	verrs.Add("type", "must be lowercase")
+	if got := verrs.Fields(); !reflect.DeepEqual(got, []string{"id", "type"}) {
+		t.Errorf("Fields() = %v", got)
+	}
+	if got := verrs.ByField("type"); len(got) != 2 || got[1].Message != "must be lowercase" {
+		t.Errorf("ByField(type) = %v", got)
+	}
+	if got := verrs.ByField("priority"); got != nil {
+		t.Errorf("ByField(priority) = %v", got)
+	}
+}
+
+func TestValidationErrorsWithErrorsPackage(t *testing.T) {
+	err := fmt.Errorf("submit: %w", JobRequest{Id: "job-1"}.Validate())
+
+	if !errors.Is(err, ErrValidation) || CategoryOf(err) != ErrorCategoryVALIDATION_ERROR {
+		t.Errorf("errors.Is(%v, ErrValidation) = false", err)
+	}
+	var verrs *ValidationErrors
+	if !errors.As(err, &verrs) || verrs.Fields()[0] != "type" {
+		t.Errorf("errors.As *ValidationErrors = %v", verrs)
+	}
+	var first ValidationError
+	if !errors.As(err, &first) || first.Field != "type" {
+		t.Errorf("errors.As ValidationError = %+v", first)
+	}
+	if errors.Is(errors.New("validation failed"), ErrValidation) {
+		t.Error("unrelated error matched ErrValidation")
+	}
+}
+
+func TestNilValidationErrors(t *testing.T) {
+	var e *ValidationErrors
+	if !e.IsValid() || e.Error() != "validation failed" || e.Fields() != nil ||
+		e.ByField("id") != nil || e.Unwrap() != nil || len(e.ToErrorDetails()) != 0 {
+		t.Error("nil *ValidationErrors is not empty")
+	}
+}
@@ -0,0 +1,270 @@
+package controlplane
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContractVersionFormat selects the JSON encoding of a ContractVersion.
+type ContractVersionFormat int
+
+const (
+	// ContractVersionFormatString encodes versions as semver strings ("1.2.3-rc.1").
+	ContractVersionFormatString ContractVersionFormat = iota
+	// ContractVersionFormatObject encodes versions as {"major":1,"minor":2,"patch":3}.
+	ContractVersionFormatObject
+)
+
+// ContractVersionEncoding controls how ContractVersion values are marshaled.
+// Unmarshaling always accepts both the string and the object form.
+var ContractVersionEncoding = ContractVersionFormatString
+
+// ParseContractVersion parses a semver string such as "1.2.3" or
+// "1.2.3-rc.1". A leading "v" and trailing build metadata are ignored.
+func ParseContractVersion(s string) (ContractVersion, error) {
+	var v ContractVersion
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(core, '+'); i >= 0 {
+		core = core[:i]
+	}
+	if i := strings.IndexByte(core, '-'); i >= 0 {
+		v.PreRelease = core[i+1:]
+		core = core[:i]
+		if v.PreRelease == "" {
+			return ContractVersion{}, fmt.Errorf("invalid contract version %q: empty prerelease", s)
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return ContractVersion{}, fmt.Errorf("invalid contract version %q: want MAJOR.MINOR.PATCH", s)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return ContractVersion{}, fmt.Errorf("invalid contract version %q: bad component %q", s, p)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// String returns the semver form of the version.
+func (m ContractVersion) String() string {
+	core := fmt.Sprintf("%d.%d.%d", m.Major, m.Minor, m.Patch)
+	if m.PreRelease != "" {
+		return core + "-" + m.PreRelease
+	}
+	return core
+}
+
+// Compare orders versions by semver precedence, returning -1, 0 or 1.
+func (m ContractVersion) Compare(other ContractVersion) int {
+	for _, d := range []int{m.Major - other.Major, m.Minor - other.Minor, m.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return comparePreRelease(m.PreRelease, other.PreRelease)
+}
+
+// comparePreRelease implements semver prerelease precedence: a release sorts
+// after its prereleases, and identifiers compare numerically when possible.
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// MarshalJSON encodes the version according to ContractVersionEncoding.
+func (m ContractVersion) MarshalJSON() ([]byte, error) {
+	if ContractVersionEncoding == ContractVersionFormatObject {
+		type plain ContractVersion
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts both the semver string and the object form.
+func (m *ContractVersion) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v, err := ParseContractVersion(s)
+		if err != nil {
+			return err
+		}
+		*m = v
+		return nil
+	}
+
+	type plain ContractVersion
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = ContractVersion(v)
+	return nil
+}
+
+// toMap returns the object form used by the map-typed contractVersion fields.
+func (m ContractVersion) toMap() map[string]interface{} {
+	out := map[string]interface{}{
+		"major": float64(m.Major),
+		"minor": float64(m.Minor),
+		"patch": float64(m.Patch),
+	}
+	if m.PreRelease != "" {
+		out["preRelease"] = m.PreRelease
+	}
+	return out
+}
+
+// ContractVersionTyped decodes the envelope's contractVersion field.
+func (m ErrorEnvelope) ContractVersionTyped() (ContractVersion, error) {
+	var v ContractVersion
+	if m.ContractVersion == nil {
+		return v, fmt.Errorf("contractVersion is not set")
+	}
+	if err := decodeMap(m.ContractVersion, &v); err != nil {
+		return v, fmt.Errorf("decode contractVersion: %w", err)
+	}
+	return v, nil
+}
+
+// UnmarshalJSON decodes an ErrorEnvelope, accepting contractVersion in either
+// the object or the semver string form.
+func (m *ErrorEnvelope) UnmarshalJSON(data []byte) error {
+	type plain ErrorEnvelope
+	aux := struct {
+		*plain
+		ContractVersion json.RawMessage `json:"contractVersion"`
+	}{plain: (*plain)(m)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	m.ContractVersion = nil
+	if len(aux.ContractVersion) == 0 || bytes.Equal(aux.ContractVersion, []byte("null")) {
+		return nil
+	}
+	var v ContractVersion
+	if err := json.Unmarshal(aux.ContractVersion, &v); err != nil {
+		return fmt.Errorf("decode contractVersion: %w", err)
+	}
+	m.ContractVersion = v.toMap()
+	return nil
+}
+
+// Contract checks for ClientConfig.ContractCheck.
+const (
+	// ContractCheckLenient logs a warning and reports the mismatch to
+	// OnResponse, the default.
+	ContractCheckLenient = "lenient"
+	// ContractCheckStrict also fails the request with a
+	// *ContractVersionMismatchError, which is not retried.
+	ContractCheckStrict = "strict"
+)
+
+// ErrContractVersionMismatch is matched by errors.Is when a response carries
+// an X-Contract-Version whose major version differs from the client's. See
+// ContractVersionMismatchError.
+var ErrContractVersionMismatch = errors.New("contract version mismatch")
+
+// ContractVersionMismatchError reports a response from a server speaking a
+// different major contract version than the client, whose documents may not
+// decode as expected.
+type ContractVersionMismatchError struct {
+	Client ContractVersion
+	Server ContractVersion
+}
+
+func (e *ContractVersionMismatchError) Error() string {
+	return fmt.Sprintf("server contract version %s differs from client contract %s in its major version",
+		e.Server, e.Client)
+}
+
+// Is reports whether target is ErrContractVersionMismatch.
+func (e *ContractVersionMismatchError) Is(target error) bool {
+	return target == ErrContractVersionMismatch
+}
+
+func validateContractCheck(check string) error {
+	switch check {
+	case "", ContractCheckLenient, ContractCheckStrict:
+		return nil
+	}
+	return fmt.Errorf("invalid config: unknown ContractCheck %q", check)
+}
+
+// checkContractVersion warns when the server reports a contract version whose
+// major component differs from the client's, returning the mismatch.
+func (c *ControlPlaneClient) checkContractVersion(resp *http.Response, spec *requestSpec) *ContractVersionMismatchError {
+	header := resp.Header.Get("X-Contract-Version")
+	if header == "" {
+		return nil
+	}
+	server, err := ParseContractVersion(header)
+	if err != nil {
+		c.config.Logger.Warn("controlplane: unparseable server contract version",
+			"path", spec.path, "version", header)
+		return nil
+	}
+	client := c.GetContractVersion()
+	if server.Major == client.Major {
+		return nil
+	}
+	c.config.Logger.Warn("controlplane: contract version mismatch",
+		"path", spec.path, "client", client.String(), "server", server.String())
+	return &ContractVersionMismatchError{Client: client, Server: server}
+}
@@ -0,0 +1,141 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestContractVersionRoundTrip(t *testing.T) {
+	want := ContractVersion{Major: 1, Minor: 2, Patch: 3, PreRelease: "rc.1"}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"1.2.3-rc.1"` {
+		t.Errorf("marshal = %s", data)
+	}
+
+	for _, in := range []string{
+		`"1.2.3-rc.1"`,
+		`{"major":1,"minor":2,"patch":3,"preRelease":"rc.1"}`,
+	} {
+		var got ContractVersion
+		if err := json.Unmarshal([]byte(in), &got); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("unmarshal %s = %+v", in, got)
+		}
+	}
+}
+
+func TestContractVersionObjectEncoding(t *testing.T) {
+	ContractVersionEncoding = ContractVersionFormatObject
+	defer func() { ContractVersionEncoding = ContractVersionFormatString }()
+
+	data, err := json.Marshal(ContractVersion{Major: 1, Minor: 2, Patch: 3, PreRelease: "rc.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"major":1,"minor":2,"patch":3,"preRelease":"rc.1"}` {
+		t.Errorf("marshal = %s", data)
+	}
+}
+
+func TestParseContractVersionRejectsMalformed(t *testing.T) {
+	for _, in := range []string{"", "1.2", "1.2.x", "1.2.3-", "-1.0.0"} {
+		if _, err := ParseContractVersion(in); err == nil {
+			t.Errorf("ParseContractVersion(%q) succeeded", in)
+		}
+	}
+}
+
+func TestContractVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-beta", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		a, _ := ParseContractVersion(ordered[i-1])
+		b, _ := ParseContractVersion(ordered[i])
+		if a.Compare(b) != -1 || b.Compare(a) != 1 {
+			t.Errorf("expected %s < %s", a, b)
+		}
+	}
+}
+
+func TestErrorEnvelopeContractVersionTyped(t *testing.T) {
+	for _, cv := range []string{`"1.2.3-rc.1"`, `{"major":1,"minor":2,"patch":3,"preRelease":"rc.1"}`} {
+		var env ErrorEnvelope
+		data := []byte(`{"id":"e1","category":"TIMEOUT","severity":"error","code":"T","message":"m","service":"s","contractVersion":` + cv + `}`)
+		if err := json.Unmarshal(data, &env); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if env.Code != "T" {
+			t.Errorf("other fields not decoded: %+v", env)
+		}
+		v, err := env.ContractVersionTyped()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.String() != "1.2.3-rc.1" {
+			t.Errorf("ContractVersionTyped() = %s", v)
+		}
+	}
+}
+
+func TestContractVersionCheck(t *testing.T) {
+	for _, tc := range []struct {
+		server   string
+		check    string
+		mismatch bool
+		fails    bool
+	}{
+		{server: "1.0.0", check: ContractCheckStrict},
+		{server: "1.4.2", check: ContractCheckStrict},
+		{server: "2.0.0", check: ContractCheckLenient, mismatch: true},
+		{server: "2.0.0", check: "", mismatch: true},
+		{server: "2.0.0", check: ContractCheckStrict, mismatch: true, fails: true},
+	} {
+		hits := 0
+		var infos []*ResponseInfo
+		logger := &recordingLogger{}
+		client := mustNewClient(t, ClientConfig{
+			BaseURL: testBaseURL,
+			Transport: HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits++
+				w.Header().Set("X-Contract-Version", tc.server)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"jobId":"j1","status":"queued"}`))
+			})),
+			ContractCheck: tc.check,
+			Retry:         &RetryPolicy{MaxRetries: Int(2), BackoffMs: Float(1)},
+			Logger:        logger,
+			OnResponse:    func(ctx context.Context, info *ResponseInfo) { infos = append(infos, info) },
+		})
+
+		_, err := client.GetJob(context.Background(), "j1")
+		var mismatch *ContractVersionMismatchError
+		if tc.fails {
+			if !errors.Is(err, ErrContractVersionMismatch) || !errors.As(err, &mismatch) || mismatch.Server.Major != 2 || mismatch.Client.Major != 1 {
+				t.Errorf("%s %s: err = %v", tc.server, tc.check, err)
+			}
+			if hits != 1 {
+				t.Errorf("%s %s: attempts = %d, want no retry", tc.server, tc.check, hits)
+			}
+		} else if err != nil {
+			t.Errorf("%s %q: %v", tc.server, tc.check, err)
+		}
+		if got := logger.contains("contract version mismatch"); got != tc.mismatch {
+			t.Errorf("%s %q: warned = %v", tc.server, tc.check, got)
+		}
+		if len(infos) != 1 || (infos[0].ContractMismatch != nil) != tc.mismatch || infos[0].StatusCode != http.StatusOK {
+			t.Errorf("%s %q: response infos = %+v", tc.server, tc.check, infos)
+		}
+	}
+
+	if _, err := NewClient(ClientConfig{BaseURL: testBaseURL, ContractCheck: "loose"}); err == nil {
+		t.Error("unknown ContractCheck accepted")
+	}
+}
@@ -0,0 +1,193 @@
+package controlplane
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// VersionHistoryEntry is the typed form of one entry in the versionHistory
+// of a marketplace runner or connector.
+type VersionHistoryEntry struct {
+	Version         string    `json:"version"`
+	PublishedAt     time.Time `json:"publishedAt"`
+	Changelog       string    `json:"changelog,omitempty"`
+	BreakingChanges bool      `json:"breakingChanges,omitempty"`
+	// Yanked marks a version withdrawn by its publisher; it should not be
+	// installed.
+	Yanked bool `json:"yanked,omitempty"`
+}
+
+// ParsedVersion parses Version for ordering.
+func (e VersionHistoryEntry) ParsedVersion() (ContractVersion, error) {
+	return ParseContractVersion(e.Version)
+}
+
+// Includes reports whether v lies within the range: equal to Exact when it
+// is set, otherwise at least Min and, when set, at most Max.
+func (m ContractRange) Includes(v ContractVersion) (bool, error) {
+	bound := func(name string, raw map[string]interface{}) (ContractVersion, error) {
+		var b ContractVersion
+		if err := decodeMap(raw, &b); err != nil {
+			return b, fmt.Errorf("decode range %s: %w", name, err)
+		}
+		return b, nil
+	}
+	if m.Exact != nil {
+		exact, err := bound("exact", m.Exact)
+		return err == nil && v.Compare(exact) == 0, err
+	}
+	min, err := bound("min", m.Min)
+	if err != nil {
+		return false, err
+	}
+	if v.Compare(min) < 0 {
+		return false, nil
+	}
+	if m.Max != nil {
+		max, err := bound("max", m.Max)
+		if err != nil {
+			return false, err
+		}
+		if v.Compare(max) > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// versionHistory is the raw versionHistory field shared by marketplace
+// runners and connectors.
+type versionHistory []map[string]interface{}
+
+type parsedEntry struct {
+	entry   VersionHistoryEntry
+	version ContractVersion
+}
+
+// sorted decodes the history, newest version first.
+func (h versionHistory) sorted() ([]parsedEntry, error) {
+	entries := make([]parsedEntry, 0, len(h))
+	for i, raw := range h {
+		var p parsedEntry
+		if err := decodeMap(raw, &p.entry); err != nil {
+			return nil, fmt.Errorf("versionHistory[%d]: %w", i, err)
+		}
+		v, err := p.entry.ParsedVersion()
+		if err != nil {
+			return nil, fmt.Errorf("versionHistory[%d]: %w", i, err)
+		}
+		p.version = v
+		entries = append(entries, p)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].version.Compare(entries[j].version) > 0
+	})
+	return entries, nil
+}
+
+func (h versionHistory) typed() ([]VersionHistoryEntry, error) {
+	entries := make([]VersionHistoryEntry, len(h))
+	for i, raw := range h {
+		if err := decodeMap(raw, &entries[i]); err != nil {
+			return nil, fmt.Errorf("versionHistory[%d]: %w", i, err)
+		}
+	}
+	return entries, nil
+}
+
+func (h versionHistory) latest() (*VersionHistoryEntry, error) {
+	entries, err := h.sorted()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range entries {
+		if !p.entry.Yanked {
+			return &p.entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h versionHistory) matching(r ContractRange) ([]VersionHistoryEntry, error) {
+	entries, err := h.sorted()
+	if err != nil {
+		return nil, err
+	}
+	var out []VersionHistoryEntry
+	for _, p := range entries {
+		if p.entry.Yanked {
+			continue
+		}
+		ok, err := r.Includes(p.version)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, p.entry)
+		}
+	}
+	return out, nil
+}
+
+func (h versionHistory) isYanked(version string) bool {
+	want, err := ParseContractVersion(version)
+	for _, raw := range h {
+		var e VersionHistoryEntry
+		if decodeMap(raw, &e) != nil {
+			continue
+		}
+		same := e.Version == version
+		if v, perr := e.ParsedVersion(); err == nil && perr == nil {
+			same = v.Compare(want) == 0
+		}
+		if same {
+			return e.Yanked
+		}
+	}
+	return false
+}
+
+// VersionHistoryTyped decodes VersionHistory in its original order.
+func (m MarketplaceRunner) VersionHistoryTyped() ([]VersionHistoryEntry, error) {
+	return versionHistory(m.VersionHistory).typed()
+}
+
+// LatestVersion returns the newest version that has not been yanked, or nil
+// when there is none. Versions are ordered as semver, not by publish date.
+func (m MarketplaceRunner) LatestVersion() (*VersionHistoryEntry, error) {
+	return versionHistory(m.VersionHistory).latest()
+}
+
+// VersionsMatching returns the versions within r that have not been yanked,
+// newest first, so the first entry is the one to install.
+func (m MarketplaceRunner) VersionsMatching(r ContractRange) ([]VersionHistoryEntry, error) {
+	return versionHistory(m.VersionHistory).matching(r)
+}
+
+// IsYanked reports whether version appears in the history as yanked.
+func (m MarketplaceRunner) IsYanked(version string) bool {
+	return versionHistory(m.VersionHistory).isYanked(version)
+}
+
+// VersionHistoryTyped decodes VersionHistory in its original order.
+func (m MarketplaceConnector) VersionHistoryTyped() ([]VersionHistoryEntry, error) {
+	return versionHistory(m.VersionHistory).typed()
+}
+
+// LatestVersion returns the newest version that has not been yanked, or nil
+// when there is none.
+func (m MarketplaceConnector) LatestVersion() (*VersionHistoryEntry, error) {
+	return versionHistory(m.VersionHistory).latest()
+}
+
+// VersionsMatching returns the versions within r that have not been yanked,
+// newest first.
+func (m MarketplaceConnector) VersionsMatching(r ContractRange) ([]VersionHistoryEntry, error) {
+	return versionHistory(m.VersionHistory).matching(r)
+}
+
+// IsYanked reports whether version appears in the history as yanked.
+func (m MarketplaceConnector) IsYanked(version string) bool {
+	return versionHistory(m.VersionHistory).isYanked(version)
+}
@@ -0,0 +1,65 @@
+package controlplane
+
+import (
+	"testing"
+)
+
+func historyEntry(version string, yanked bool) map[string]interface{} {
+	return map[string]interface{}{"version": version, "publishedAt": "2024-01-01T00:00:00Z", "yanked": yanked}
+}
+
+func TestLatestVersionEmptyHistory(t *testing.T) {
+	latest, err := MarketplaceRunner{}.LatestVersion()
+	if err != nil || latest != nil {
+		t.Errorf("latest = %+v, %v", latest, err)
+	}
+}
+
+func TestLatestVersionSkipsYanked(t *testing.T) {
+	r := MarketplaceRunner{VersionHistory: []map[string]interface{}{
+		historyEntry("1.2.0", false),
+		historyEntry("1.10.0", true),
+		historyEntry("1.9.1", false),
+	}}
+	latest, err := r.LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest == nil || latest.Version != "1.9.1" {
+		t.Errorf("latest = %+v", latest)
+	}
+	if !r.IsYanked("1.10.0") || r.IsYanked("1.9.1") || r.IsYanked("3.0.0") {
+		t.Error("IsYanked reported wrong status")
+	}
+}
+
+func TestVersionsMatchingRange(t *testing.T) {
+	r := MarketplaceRunner{VersionHistory: []map[string]interface{}{
+		historyEntry("0.9.0", false),
+		historyEntry("1.0.0", false),
+		historyEntry("1.4.2", false),
+		historyEntry("1.5.0", true),
+		historyEntry("2.0.0", false),
+	}}
+	rng := ContractRange{
+		Min: map[string]interface{}{"major": 1.0, "minor": 0.0, "patch": 0.0},
+		Max: map[string]interface{}{"major": 1.0, "minor": 99.0, "patch": 0.0},
+	}
+	got, err := r.VersionsMatching(rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Version != "1.4.2" || got[1].Version != "1.0.0" {
+		t.Errorf("matching = %+v", got)
+	}
+
+	exact := ContractRange{Exact: map[string]interface{}{"major": 2.0, "minor": 0.0, "patch": 0.0}}
+	if got, _ := r.VersionsMatching(exact); len(got) != 1 || got[0].Version != "2.0.0" {
+		t.Errorf("exact = %+v", got)
+	}
+
+	r.VersionHistory = append(r.VersionHistory, historyEntry("latest", false))
+	if _, err := r.VersionsMatching(rng); err == nil {
+		t.Error("unparseable version accepted")
+	}
+}
@@ -0,0 +1,131 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultWaitPollInterval is how long WaitForJob waits between polls when
+// WaitOpts.PollInterval is zero.
+const DefaultWaitPollInterval = 2 * time.Second
+
+// WaitOpts configures SubmitAndWait and WaitForJob.
+type WaitOpts struct {
+	// PollInterval is how long each poll long-polls the job with
+	// GetJobWait, and how long to pause before the next one when a server
+	// without long-poll support answers at once. Zero selects
+	// DefaultWaitPollInterval.
+	PollInterval time.Duration
+	// OnStatusChange is called once for every transition the wait observes,
+	// starting with old empty for the first status seen, and again while
+	// the job is retrying whenever its retry attempt count changes (see
+	// JobResponse.Attempts). It runs on the waiting goroutine between
+	// polls, so it should return quickly; a panic in it is logged and
+	// does not end the wait.
+	OnStatusChange func(old, new string, resp JobResponse)
+}
+
+// IsTerminalJobStatus reports whether a job in the status will not change
+// again: completed, failed or cancelled.
+func IsTerminalJobStatus(status string) bool {
+	switch status {
+	case JobStatusCOMPLETED, JobStatusFAILED, JobStatusCANCELLED:
+		return true
+	}
+	return false
+}
+
+// Attempts returns how many times the job has been attempted, from the
+// attempts field of its result metadata, or zero when the server does not
+// report it.
+func (m JobResponse) Attempts() int {
+	meta, _ := m.Result["metadata"].(map[string]interface{})
+	n, _ := toFloat(meta["attempts"])
+	return int(n)
+}
+
+// SubmitAndWait submits a job and waits for it to reach a terminal status,
+// returning the final JobResponse. A job that failed is returned without an
+// error; see JobResponse.AsError. The options apply to every request.
+func (c *ControlPlaneClient) SubmitAndWait(ctx context.Context, job JobRequest, wait WaitOpts, opts ...RequestOption) (*JobResponse, error) {
+	resp, err := c.SubmitJob(ctx, job, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.waitFrom(ctx, resp, "", wait, opts)
+}
+
+// WaitForJob polls a submitted job until it reaches a terminal status and
+// returns the final JobResponse, like SubmitAndWait.
+func (c *ControlPlaneClient) WaitForJob(ctx context.Context, id string, wait WaitOpts, opts ...RequestOption) (*JobResponse, error) {
+	resp, etag, _, err := c.GetJobIfModified(ctx, id, "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.waitFrom(ctx, resp, etag, wait, opts)
+}
+
+// waitFrom polls until the job is terminal. Each poll is conditional on the
+// ETag of the last response, so a job sitting in one status for minutes
+// costs a 304 per poll rather than a full body.
+func (c *ControlPlaneClient) waitFrom(ctx context.Context, resp *JobResponse, etag string, wait WaitOpts, opts []RequestOption) (*JobResponse, error) {
+	interval := wait.PollInterval
+	if interval <= 0 {
+		interval = DefaultWaitPollInterval
+	}
+	var status string
+	var attempts int
+	observe := func(r *JobResponse) {
+		if r.Status == status && (r.Status != JobStatusRETRYING || r.Attempts() == attempts) {
+			return
+		}
+		old := status
+		status, attempts = r.Status, r.Attempts()
+		c.notifyStatusChange(wait.OnStatusChange, old, *r)
+	}
+
+	observe(resp)
+	for !IsTerminalJobStatus(resp.Status) {
+		start := c.config.Clock.Now()
+		next, tag, modified, err := c.getJobWait(ctx, resp.Id, etag, interval, opts)
+		if err != nil {
+			return nil, fmt.Errorf("wait for job %s: %w", resp.Id, err)
+		}
+		etag = tag
+		if !modified {
+			// A server that held the poll has already waited the interval;
+			// one that answered the ETag at once has not.
+			if rest := interval - c.config.Clock.Now().Sub(start); rest > 0 {
+				if err := c.config.Clock.Sleep(ctx, rest); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		changed := next.Status != resp.Status || next.Attempts() != resp.Attempts()
+		resp = next
+		observe(resp)
+		if !changed && !IsTerminalJobStatus(resp.Status) {
+			// The server answered without holding the poll.
+			if err := c.config.Clock.Sleep(ctx, interval); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+// notifyStatusChange calls fn, logging rather than propagating a panic.
+func (c *ControlPlaneClient) notifyStatusChange(fn func(old, new string, resp JobResponse), old string, resp JobResponse) {
+	if fn == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.config.Logger.Error("controlplane: OnStatusChange panicked",
+				"job", resp.Id, "status", resp.Status, "panic", r)
+		}
+	}()
+	fn(old, resp.Status, resp)
+}
@@ -0,0 +1,93 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jobScript answers the submit with queued and each poll of job j1 with the
+// next of states, given as "status" or "status/attempts"; it repeats the
+// last one once the script runs out.
+func jobScript(states ...string) http.Handler {
+	polls := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := "queued"
+		if r.Method == http.MethodGet {
+			state = states[min(polls, len(states)-1)]
+			polls++
+		}
+		status, attempts, _ := strings.Cut(state, "/")
+		result := ""
+		if attempts != "" {
+			result = fmt.Sprintf(`,"result":{"success":false,"metadata":{"attempts":%s}}`, attempts)
+		}
+		fmt.Fprintf(w, `{"id":"j1","status":%q,"request":{},"updatedAt":"2024-01-01T00:00:00Z"%s}`, status, result)
+	})
+}
+
+func TestSubmitAndWaitReportsEachTransitionOnce(t *testing.T) {
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:   testBaseURL,
+		Transport: HandlerTransport(jobScript("queued", "running", "running", "retrying/1", "retrying/2", "retrying/2", "running", "completed")),
+		Clock:     &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	})
+
+	type transition struct {
+		old, new string
+		attempts int
+	}
+	var got []transition
+	resp, err := client.SubmitAndWait(context.Background(), JobRequest{Id: "j1", Type: "csv"}, WaitOpts{
+		OnStatusChange: func(old, new string, r JobResponse) {
+			got = append(got, transition{old, new, r.Attempts()})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != JobStatusCOMPLETED {
+		t.Errorf("final status = %s", resp.Status)
+	}
+	want := []transition{
+		{"", "queued", 0},
+		{"queued", "running", 0},
+		{"running", "retrying", 1},
+		{"retrying", "retrying", 2},
+		{"retrying", "running", 0},
+		{"running", "completed", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transitions:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestWaitForJobSurvivesPanickingCallback(t *testing.T) {
+	logger := &recordingLogger{}
+	client := mustNewClient(t, ClientConfig{
+		BaseURL:   testBaseURL,
+		Transport: HandlerTransport(jobScript("running", "failed")),
+		Clock:     &manualClock{},
+		Logger:    logger,
+	})
+	calls := 0
+	resp, err := client.WaitForJob(context.Background(), "j1", WaitOpts{
+		OnStatusChange: func(old, new string, r JobResponse) {
+			calls++
+			panic("ui gone")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != JobStatusFAILED || calls != 2 {
+		t.Errorf("status = %s, calls = %d", resp.Status, calls)
+	}
+	if !logger.contains("OnStatusChange panicked") {
+		t.Errorf("panic not logged: %v", logger.lines)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/controlplane/sdk-go/wsrunner
+
+go 1.21
+
+require (
+	github.com/controlplane/sdk-go v1.0.0
+	github.com/gorilla/websocket v1.5.3
+)
+
+replace github.com/controlplane/sdk-go => ../
@@ -0,0 +1,303 @@
+// Package wsrunner lets a runner receive execution requests over a WebSocket
+// it dials itself, for runners that cannot accept inbound connections. It
+// lives in its own module so that the core SDK does not depend on a
+// WebSocket library.
+//
+// Each message is one JSON document: the control plane sends
+// controlplane.RunnerExecutionRequest frames and the runner answers each
+// with a controlplane.RunnerExecutionResponse frame.
+//
+//	err := wsrunner.Serve(ctx, wsrunner.Config{
+//		Client:   client,
+//		RunnerID: runnerID,
+//		Handler:  execute,
+//	})
+package wsrunner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"github.com/gorilla/websocket"
+)
+
+// Handler executes one request and returns its response. JobId, RunnerId
+// and ExecutionTimeMs are filled in when left zero. ctx is cancelled when the
+// request's TimeoutMs elapses or the connection is lost.
+type Handler func(ctx context.Context, req controlplane.RunnerExecutionRequest) controlplane.RunnerExecutionResponse
+
+// Config configures Serve.
+type Config struct {
+	// Client supplies the base URL and credentials for the connection.
+	Client   *controlplane.ControlPlaneClient
+	RunnerID string
+	Handler  Handler
+
+	// PingInterval is how often a ping is sent. The connection is
+	// considered lost when no pong arrives within two intervals. Defaults
+	// to 30 seconds.
+	PingInterval time.Duration
+
+	// Reconnect controls the backoff between connection attempts.
+	// MaxRetries bounds consecutive failed attempts. Nil retries forever,
+	// backing off from half a second up to thirty.
+	Reconnect *controlplane.RetryPolicy
+
+	// Dialer dials the connection. Defaults to websocket.DefaultDialer.
+	Dialer *websocket.Dialer
+
+	// Capabilities, when set, makes Serve check each request's payload
+	// against the InputSchema of the capability named by its CapabilityId
+	// before calling Handler, and the data of a successful response against
+	// its OutputSchema. A failure is answered with an unsuccessful response
+	// whose Error is a VALIDATION_ERROR envelope for the payload or a
+	// SCHEMA_MISMATCH one for the result. Schemas are compiled with the
+	// Client's SchemaCompiler. Requests for capabilities not listed are not
+	// checked.
+	Capabilities []controlplane.RunnerCapability
+}
+
+// Serve connects to /v1/runners/{id}/ws and handles execution requests
+// until ctx is cancelled, reconnecting with exponential backoff whenever the
+// connection drops. It returns ctx.Err() after cancellation, or an error
+// when the server rejects the connection with a 4xx status other than 429
+// or the reconnect attempts are exhausted.
+func Serve(ctx context.Context, cfg Config) error {
+	if cfg.Client == nil || cfg.Handler == nil || cfg.RunnerID == "" {
+		return errors.New("wsrunner: Client, RunnerID and Handler are required")
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+	if cfg.Dialer == nil {
+		cfg.Dialer = websocket.DefaultDialer
+	}
+
+	failures := 0
+	for {
+		connected, err := serveConn(ctx, cfg)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if isTerminal(err) {
+			return err
+		}
+		if connected {
+			failures = 0
+		}
+		failures++
+		if cfg.Reconnect != nil && failures > controlplane.IntValue(cfg.Reconnect.MaxRetries) {
+			return fmt.Errorf("wsrunner: giving up after %d reconnect attempts: %w", controlplane.IntValue(cfg.Reconnect.MaxRetries), err)
+		}
+		timer := time.NewTimer(backoff(cfg.Reconnect, failures))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// HandshakeError reports a connection the server refused during the
+// WebSocket upgrade.
+type HandshakeError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("wsrunner: handshake failed with status %d: %v", e.StatusCode, e.Err)
+}
+
+func (e *HandshakeError) Unwrap() error { return e.Err }
+
+func isTerminal(err error) bool {
+	var h *HandshakeError
+	return errors.As(err, &h) && h.StatusCode >= 400 && h.StatusCode < 500 && h.StatusCode != http.StatusTooManyRequests
+}
+
+// serveConn runs one connection until it fails. connected reports whether
+// the handshake succeeded.
+func serveConn(ctx context.Context, cfg Config) (connected bool, err error) {
+	req, err := cfg.Client.NewRequest(ctx, http.MethodGet, "/v1/runners/"+url.PathEscape(cfg.RunnerID)+"/ws")
+	if err != nil {
+		return false, err
+	}
+	u := *req.URL
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	header := req.Header.Clone()
+	header.Del("Content-Type")
+
+	conn, resp, err := cfg.Dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+			return false, &HandshakeError{StatusCode: resp.StatusCode, Err: err}
+		}
+		return false, err
+	}
+	defer conn.Close()
+	// Handlers and the pinger are cancelled, then awaited, before the
+	// connection is closed.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := &writer{conn: conn}
+
+	wait := 2 * cfg.PingInterval
+	conn.SetReadDeadline(time.Now().Add(wait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wait))
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ping(ctx, w, cfg.PingInterval)
+	}()
+	go func() {
+		// Unblock ReadJSON when ctx is cancelled.
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		var exec controlplane.RunnerExecutionRequest
+		if err := conn.ReadJSON(&exec); err != nil {
+			return true, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := execute(ctx, cfg, exec)
+			if err := w.writeJSON(resp); err != nil {
+				cancel()
+			}
+		}()
+	}
+}
+
+func execute(ctx context.Context, cfg Config, req controlplane.RunnerExecutionRequest) controlplane.RunnerExecutionResponse {
+	if ms := controlplane.FloatValue(req.TimeoutMs); ms > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ms*float64(time.Millisecond)))
+		defer cancel()
+	}
+	start := time.Now()
+	capability := findCapability(cfg.Capabilities, req.CapabilityId)
+	var resp controlplane.RunnerExecutionResponse
+	if capability == nil {
+		resp = cfg.Handler(ctx, req)
+	} else if err := cfg.Client.ValidateCapabilityInput(*capability, req.Payload); err != nil {
+		resp = invalid(cfg, err)
+	} else if resp = cfg.Handler(ctx, req); resp.Success {
+		if resp.RunnerId == "" {
+			resp.RunnerId = cfg.RunnerID
+		}
+		if err := cfg.Client.ValidateCapabilityResponse(*capability, resp); err != nil {
+			resp = invalid(cfg, err)
+		}
+	}
+	if resp.JobId == "" {
+		resp.JobId = req.JobId
+	}
+	if resp.RunnerId == "" {
+		resp.RunnerId = cfg.RunnerID
+	}
+	if resp.ExecutionTimeMs == 0 {
+		resp.ExecutionTimeMs = float64(time.Since(start).Milliseconds())
+	}
+	return resp
+}
+
+func findCapability(capabilities []controlplane.RunnerCapability, id string) *controlplane.RunnerCapability {
+	for i := range capabilities {
+		if capabilities[i].Id == id {
+			return &capabilities[i]
+		}
+	}
+	return nil
+}
+
+// invalid answers a request whose payload or result failed validation.
+func invalid(cfg Config, err error) controlplane.RunnerExecutionResponse {
+	var env controlplane.ErrorEnvelope
+	var mismatch *controlplane.OutputMismatchError
+	var verrs *controlplane.ValidationErrors
+	if errors.As(err, &mismatch) {
+		env = mismatch.Envelope
+	} else if errors.As(err, &verrs) {
+		env = verrs.ToEnvelope(cfg.RunnerID, "execute")
+	} else {
+		env = controlplane.NewErrorEnvelope(controlplane.ErrorCategorySCHEMA_MISMATCH, "INVALID_SCHEMA", err.Error(), cfg.RunnerID)
+	}
+	var raw map[string]interface{}
+	if data, err := json.Marshal(env); err == nil {
+		json.Unmarshal(data, &raw)
+	}
+	return controlplane.RunnerExecutionResponse{Success: false, Error: raw}
+}
+
+func ping(ctx context.Context, w *writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.ping(interval); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writer serializes writes; a websocket.Conn supports one concurrent writer.
+type writer struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (w *writer) writeJSON(v interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteJSON(v)
+}
+
+func (w *writer) ping(timeout time.Duration) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout))
+}
+
+// backoff returns the delay before reconnect attempt n.
+func backoff(p *controlplane.RetryPolicy, n int) time.Duration {
+	base, max, mult := 500.0, 30000.0, 2.0
+	if p != nil {
+		base = controlplane.FloatValue(p.BackoffMs)
+		max = controlplane.FloatValue(p.MaxBackoffMs)
+		mult = controlplane.FloatValue(p.BackoffMultiplier)
+	}
+	if mult < 1 {
+		mult = 1
+	}
+	ms := base * math.Pow(mult, float64(n-1))
+	if max > 0 && ms > max {
+		ms = max
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,163 @@
+package wsrunner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	controlplane "github.com/controlplane/sdk-go"
+	"github.com/gorilla/websocket"
+)
+
+func newClient(t *testing.T, url string) *controlplane.ControlPlaneClient {
+	t.Helper()
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: url, APIKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestServeExchangesFramesAndReconnects(t *testing.T) {
+	var conns int32
+	responses := make(chan controlplane.RunnerExecutionResponse, 2)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/runners/r1/ws" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("request = %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		n := atomic.AddInt32(&conns, 1)
+
+		jobID := "job-1"
+		if n == 2 {
+			jobID = "job-2"
+		}
+		if err := conn.WriteJSON(controlplane.RunnerExecutionRequest{JobId: jobID, CapabilityId: "echo"}); err != nil {
+			t.Error(err)
+			return
+		}
+		var resp controlplane.RunnerExecutionResponse
+		if err := conn.ReadJSON(&resp); err != nil {
+			t.Error(err)
+			return
+		}
+		responses <- resp
+		// The first connection drops right away; the second stays open.
+		if n == 2 {
+			conn.ReadMessage()
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, Config{
+			Client:    newClient(t, srv.URL),
+			RunnerID:  "r1",
+			Reconnect: &controlplane.RetryPolicy{MaxRetries: controlplane.Int(3), BackoffMs: controlplane.Float(1)},
+			Handler: func(ctx context.Context, req controlplane.RunnerExecutionRequest) controlplane.RunnerExecutionResponse {
+				return controlplane.RunnerExecutionResponse{Success: true, Data: req.CapabilityId}
+			},
+		})
+	}()
+
+	for _, want := range []string{"job-1", "job-2"} {
+		select {
+		case resp := <-responses:
+			if resp.JobId != want || resp.RunnerId != "r1" || !resp.Success || resp.Data != "echo" {
+				t.Errorf("response = %+v, want job %s", resp, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after cancel")
+	}
+}
+
+func TestServeStopsOnRejectedHandshake(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := Serve(context.Background(), Config{
+		Client:   newClient(t, srv.URL),
+		RunnerID: "r1",
+		Handler: func(context.Context, controlplane.RunnerExecutionRequest) controlplane.RunnerExecutionResponse {
+			return controlplane.RunnerExecutionResponse{}
+		},
+	})
+	var h *HandshakeError
+	if !errors.As(err, &h) || h.StatusCode != http.StatusForbidden {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := &controlplane.RetryPolicy{BackoffMs: controlplane.Float(100), MaxBackoffMs: controlplane.Float(300), BackoffMultiplier: controlplane.Float(2)}
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond} {
+		if got := backoff(p, n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+	if got := backoff(nil, 1); got != 500*time.Millisecond {
+		t.Errorf("default backoff = %v", got)
+	}
+}
+
+func TestExecuteChecksCapabilitySchemas(t *testing.T) {
+	client, err := controlplane.NewClient(controlplane.ClientConfig{BaseURL: "http://cp.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		Client:   client,
+		RunnerID: "r1",
+		Capabilities: []controlplane.RunnerCapability{{
+			Id:           "sum",
+			InputSchema:  map[string]interface{}{"type": "object", "required": []interface{}{"values"}},
+			OutputSchema: map[string]interface{}{"type": "number"},
+		}},
+		Handler: func(ctx context.Context, req controlplane.RunnerExecutionRequest) controlplane.RunnerExecutionResponse {
+			return controlplane.RunnerExecutionResponse{Success: true, Data: req.Payload["result"]}
+		},
+	}
+	tests := []struct {
+		name       string
+		capability string
+		payload    map[string]interface{}
+		code       string
+	}{
+		{"valid", "sum", map[string]interface{}{"values": []int{1, 2}, "result": 3}, ""},
+		{"bad input", "sum", map[string]interface{}{"result": 3}, "VALIDATION_FAILED"},
+		{"bad output", "sum", map[string]interface{}{"values": []int{1}, "result": "one"}, controlplane.ErrCodeOutputSchemaMismatch},
+		{"unchecked capability", "echo", map[string]interface{}{"result": "one"}, ""},
+	}
+	for _, tt := range tests {
+		resp := execute(context.Background(), cfg, controlplane.RunnerExecutionRequest{JobId: "j1", CapabilityId: tt.capability, Payload: tt.payload})
+		code, _ := resp.Error["code"].(string)
+		if resp.Success != (tt.code == "") || code != tt.code || resp.JobId != "j1" {
+			t.Errorf("%s: response = %+v", tt.name, resp)
+		}
+	}
+}
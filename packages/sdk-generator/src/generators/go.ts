@@ -13,6 +13,9 @@ export function generateGoSDK(
   const clientContent = generateGoClientFile(config);
   files.set('client.go', clientContent);
 
+  const sdkVersionContent = generateGoSDKVersionFile(config);
+  files.set('sdk_version.go', sdkVersionContent);
+
   const validationContent = generateGoValidationFile();
   files.set('validation.go', validationContent);
 
@@ -47,7 +50,6 @@ function generateGoTypesFile(schemas: SchemaDefinition[]): string {
   lines.push('package controlplane');
   lines.push('');
   lines.push('import (');
-  lines.push('\t"encoding/json"');
   lines.push('\t"time"');
   lines.push(')');
   lines.push('');
@@ -98,8 +100,14 @@ function generateGoStructCode(schema: SchemaDefinition): string[] {
       const isOptional =
         fieldDef?.typeName === 'ZodOptional' || fieldDef?.typeName === 'ZodDefault';
       const jsonTag = isOptional ? `json:"${key},omitempty"` : `json:"${key}"`;
-
-      lines.push(`\t${capitalizeFirst(key)} ${goType} \`${jsonTag}\``);
+      // Optional scalars are pointers so an explicit zero, such as
+      // activeJobs: 0, is sent rather than dropped by omitempty.
+      const fieldType =
+        isOptional && (goType === 'int' || goType === 'float64' || goType === 'bool')
+          ? `*${goType}`
+          : goType;
+
+      lines.push(`\t${capitalizeFirst(key)} ${fieldType} \`${jsonTag}\``);
     }
   } else if (zodDef?.typeName === 'ZodEnum') {
     const values = zodDef.values as string[];
@@ -199,111 +207,38 @@ function capitalizeFirst(str: string): string {
   return str.charAt(0).toUpperCase() + str.slice(1);
 }
 
+function lowerFirst(str: string): string {
+  return str.charAt(0).toLowerCase() + str.slice(1);
+}
+
 function toGoConstName(typeName: string, value: string): string {
   const cleanValue = value.toUpperCase().replace(/[^A-Z0-9]/g, '_');
   return `${typeName}${cleanValue}`;
 }
 
-function generateGoClientFile(config: SDKGeneratorConfig): string {
-  return `// Auto-generated ControlPlane SDK Client
+function generateGoSDKVersionFile(config: SDKGeneratorConfig): string {
+  return `// Auto-generated ControlPlane SDK version
 // DO NOT EDIT MANUALLY - regenerate from source
 
 package controlplane
 
-import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"time"
-)
-
-// ClientConfig holds configuration for the ControlPlane client
-type ClientConfig struct {
-	BaseURL    string
-	APIKey     string
-	Timeout    time.Duration
-	HTTPClient *http.Client
-}
-
-// ControlPlaneClient is the main SDK client
-type ControlPlaneClient struct {
-	config          ClientConfig
-	contractVersion ContractVersion
-	client          *http.Client
-}
-
-// NewClient creates a new ControlPlane SDK client
-func NewClient(config ClientConfig) *ControlPlaneClient {
-	if config.Timeout == 0 {
-		config.Timeout = 30 * time.Second
-	}
-	if config.HTTPClient == nil {
-		config.HTTPClient = &http.Client{Timeout: config.Timeout}
-	}
-
-	return &ControlPlaneClient{
-		config: config,
-		contractVersion: ContractVersion{
-			Major: ${config.contractVersion.split('.')[0]},
-			Minor: ${config.contractVersion.split('.')[1]},
-			Patch: ${config.contractVersion.split('.')[2]},
-		},
-		client: config.HTTPClient,
-	}
-}
-
-// GetContractVersion returns the contract version used by this client
-func (c *ControlPlaneClient) GetContractVersion() ContractVersion {
-	return c.contractVersion
-}
-
-func (c *ControlPlaneClient) serializeVersion(v ContractVersion) string {
-	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
-}
-
-func (c *ControlPlaneClient) defaultHeaders() map[string]string {
-	headers := map[string]string{
-		"Content-Type":       "application/json",
-		"X-Contract-Version": c.serializeVersion(c.contractVersion),
-	}
-	if c.config.APIKey != "" {
-		headers["Authorization"] = fmt.Sprintf("Bearer %s", c.config.APIKey)
-	}
-	return headers
+// SDKVersion is the version of this SDK. It is reported in the User-Agent
+// header of every request.
+const SDKVersion = "${config.sdkVersion}"
+`;
 }
 
-// Request makes an HTTP request to the ControlPlane API
-func (c *ControlPlaneClient) Request(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
-	var bodyReader *bytes.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, err
-		}
-		bodyReader = bytes.NewReader(jsonBody)
-	} else {
-		bodyReader = bytes.NewReader([]byte{})
-	}
-
-	url := fmt.Sprintf("%s%s", c.config.BaseURL, path)
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
-	if err != nil {
-		return nil, err
-	}
-
-	for key, value := range c.defaultHeaders() {
-		req.Header.Set(key, value)
-	}
+function generateGoClientFile(config: SDKGeneratorConfig): string {
+  const [major, minor, patch] = config.contractVersion.split('.');
+  // The client itself (ClientConfig, NewClient, Request) is hand-written in
+  // controlplane.go; only what depends on the contracts is generated here.
+  return `// Auto-generated ControlPlane SDK Client
+// DO NOT EDIT MANUALLY - regenerate from source
 
-	return c.client.Do(req)
-}
+package controlplane
 
-// Validate validates a model using the generated validators
-func (c *ControlPlaneClient) Validate(model Validatable) error {
-	return model.Validate()
-}
+// clientContractVersion is the contract version this SDK was generated from.
+var clientContractVersion = ContractVersion{Major: ${major}, Minor: ${minor}, Patch: ${patch}}
 
 // Validatable interface for models that can be validated
 type Validatable interface {
@@ -321,8 +256,12 @@ package controlplane
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
+// ErrValidation matches every *ValidationErrors with errors.Is.
+var ErrValidation = errors.New("validation failed")
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
@@ -333,27 +272,114 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
-// ValidationErrors collects multiple validation errors
+// ValidationErrors collects multiple validation errors. Validators return
+// it as a *ValidationErrors; its methods are safe to call on nil, which
+// holds no errors.
 type ValidationErrors struct {
 	Errors []ValidationError
 }
 
-func (e ValidationErrors) Error() string {
-	if len(e.Errors) == 0 {
-		return "validation failed"
+// Error reports every error, separated by semicolons.
+func (e *ValidationErrors) Error() string {
+	if e.IsValid() {
+		return ErrValidation.Error()
 	}
-	return e.Errors[0].Error()
+	msgs := make([]string, len(e.Errors))
+	for i, ve := range e.Errors {
+		msgs[i] = ve.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationErrors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Unwrap returns each ValidationError, so errors.As can find one.
+func (e *ValidationErrors) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	errs := make([]error, len(e.Errors))
+	for i, ve := range e.Errors {
+		errs[i] = ve
+	}
+	return errs
+}
+
+// Fields returns the fields that have errors, each once, in the order they
+// were first reported.
+func (e *ValidationErrors) Fields() []string {
+	if e == nil {
+		return nil
+	}
+	var fields []string
+	seen := map[string]bool{}
+	for _, ve := range e.Errors {
+		if !seen[ve.Field] {
+			seen[ve.Field] = true
+			fields = append(fields, ve.Field)
+		}
+	}
+	return fields
+}
+
+// ByField returns the errors reported for the field.
+func (e *ValidationErrors) ByField(name string) []ValidationError {
+	if e == nil {
+		return nil
+	}
+	var errs []ValidationError
+	for _, ve := range e.Errors {
+		if ve.Field == name {
+			errs = append(errs, ve)
+		}
+	}
+	return errs
 }
 
 // IsValid checks if there are no validation errors
-func (e ValidationErrors) IsValid() bool {
-	return len(e.Errors) == 0
+func (e *ValidationErrors) IsValid() bool {
+	return e == nil || len(e.Errors) == 0
 }
 
 // Add adds a validation error
 func (e *ValidationErrors) Add(field, message string) {
 	e.Errors = append(e.Errors, ValidationError{Field: field, Message: message})
 }
+
+// validationRules holds checks that go beyond what the contract schemas can
+// express, keyed by schema name. Hand-written files register them in init.
+var validationRules = map[string][]func(interface{}, *ValidationErrors){}
+
+// registerRule adds a rule that every validate call for the schema runs.
+func registerRule[T any](schema string, rule func(m T, errs *ValidationErrors)) {
+	validationRules[schema] = append(validationRules[schema], func(m interface{}, errs *ValidationErrors) {
+		rule(m.(T), errs)
+	})
+}
+
+// applyRules runs the registered rules for the schema.
+func applyRules(schema string, m interface{}, errs *ValidationErrors) {
+	for _, rule := range validationRules[schema] {
+		rule(m, errs)
+	}
+}
+
+// checkEnum reports a value outside values. An empty value is left to the
+// required check, so optional enum fields may be unset.
+func checkEnum(field, value string, values []string, errs *ValidationErrors) {
+	if value == "" {
+		return
+	}
+	for _, v := range values {
+		if v == value {
+			return
+		}
+	}
+	errs.Add(field, "must be one of ["+strings.Join(values, ", ")+"]")
+}
 `;
 }
 
@@ -389,13 +415,89 @@ function generateGoSchemasFile(schemas: SchemaDefinition[]): string {
   lines.push('}');
   lines.push('');
 
+  lines.push('// requiredNumericFields lists, by schema, the required numeric fields. Zero');
+  lines.push('// is a legal value for them, so Validate cannot tell a missing field from a');
+  lines.push('// zero one; UnmarshalValidate and DecodeValidate check them for presence.');
+  lines.push('var requiredNumericFields = map[string][]string{');
+  const numericFields: Array<[string, string[]]> = [];
+  for (const schema of schemas) {
+    const zodDef = schema.schema._def as {
+      typeName?: string;
+      shape?: () => Record<string, z.ZodTypeAny>;
+    };
+    if (zodDef?.typeName !== 'ZodObject') {
+      continue;
+    }
+    const numeric = Object.entries(zodDef.shape?.() ?? {})
+      .filter(([, val]) => {
+        const typeName = val._def?.typeName;
+        const goType = zodToGoType(val);
+        return typeName !== 'ZodOptional' && typeName !== 'ZodDefault' && (goType === 'int' || goType === 'float64');
+      })
+      .map(([key]) => `"${key}"`);
+    if (numeric.length > 0) {
+      numericFields.push([schema.name, numeric]);
+    }
+  }
+  // Align the values as gofmt does.
+  const keyWidth = Math.max(0, ...numericFields.map(([name]) => name.length + 3));
+  for (const [name, numeric] of numericFields) {
+    lines.push(`\t${`"${name}":`.padEnd(keyWidth)} {${numeric.join(', ')}},`);
+  }
+  lines.push('}');
+  lines.push('');
+
+  const enums = collectEnums(schemas);
+  lines.push('// Value sets of the contract enums, in declaration order. An enum declared');
+  lines.push('// inline on a field shares the set of the enum schema with the same values,');
+  lines.push('// or else has its own, named after its schema and field.');
+  lines.push('var (');
+  const varWidth = Math.max(0, ...enums.sets.map((set) => set.varName.length));
+  for (const set of enums.sets) {
+    lines.push(`\t${set.varName.padEnd(varWidth)} = []string{${set.values.join(', ')}}`);
+  }
+  lines.push(')');
+  lines.push('');
+
+  lines.push('// enumFields maps a schema name and JSON field name to the values the field');
+  lines.push('// may take. Validate checks each field against them.');
+  lines.push('var enumFields = map[string]map[string][]string{');
+  for (const [name, fields] of enums.fields) {
+    lines.push(`\t"${name}": {`);
+    const fieldWidth = Math.max(0, ...fields.map(([key]) => key.length + 3));
+    for (const [key, varName] of fields) {
+      lines.push(`\t\t${`"${key}":`.padEnd(fieldWidth)} ${varName},`);
+    }
+    lines.push('\t},');
+  }
+  lines.push('}');
+  lines.push('');
+
   for (const schema of schemas) {
     const zodDef = schema.schema._def as {
       typeName?: string;
       shape?: () => Record<string, z.ZodTypeAny>;
     };
     if (zodDef?.typeName === 'ZodObject') {
-      lines.push(...generateGoValidationFunction(schema, zodDef.shape?.() ?? {}));
+      lines.push(
+        ...generateGoValidationFunction(
+          schema,
+          zodDef.shape?.() ?? {},
+          enums.fields.get(schema.name) ?? []
+        )
+      );
+      lines.push('');
+    }
+  }
+
+  // Scalar and union schemas still get a Validate method in types.go, so they
+  // need a (trivial) validator to compile.
+  for (const schema of schemas) {
+    const zodDef = schema.schema._def as {
+      typeName?: string;
+    };
+    if (zodDef?.typeName !== 'ZodObject' && zodDef?.typeName !== 'ZodEnum') {
+      lines.push(...generateGoValidationFunction(schema, {}, []));
       lines.push('');
     }
   }
@@ -403,9 +505,80 @@ function generateGoSchemasFile(schemas: SchemaDefinition[]): string {
   return lines.join('\n');
 }
 
+interface GoEnumSet {
+  varName: string;
+  // values holds Go expressions: the constants of an enum schema, or string
+  // literals for an enum declared inline.
+  values: string[];
+}
+
+interface GoEnums {
+  sets: GoEnumSet[];
+  // fields maps a schema name to its enum fields, each with the name of the
+  // variable holding its value set.
+  fields: Map<string, Array<[string, string]>>;
+}
+
+// collectEnums gathers the value set of every enum schema and of every enum
+// field of an object schema. The contracts declare most enum fields inline,
+// repeating an enum schema's values, so a field is matched to an enum schema
+// by its values.
+function collectEnums(schemas: SchemaDefinition[]): GoEnums {
+  const sets: GoEnumSet[] = [];
+  const byValues = new Map<string, string>();
+  for (const schema of schemas) {
+    const zodDef = schema.schema._def as { typeName?: string; values?: string[] };
+    if (zodDef?.typeName === 'ZodEnum') {
+      const values = zodDef.values ?? [];
+      const varName = `${lowerFirst(schema.name)}Values`;
+      sets.push({ varName, values: values.map((v) => toGoConstName(schema.name, v)) });
+      byValues.set(JSON.stringify(values), varName);
+    }
+  }
+
+  const fields = new Map<string, Array<[string, string]>>();
+  for (const schema of schemas) {
+    const zodDef = schema.schema._def as {
+      typeName?: string;
+      shape?: () => Record<string, z.ZodTypeAny>;
+    };
+    if (zodDef?.typeName !== 'ZodObject') {
+      continue;
+    }
+    const enumFields: Array<[string, string]> = [];
+    for (const [key, val] of Object.entries(zodDef.shape?.() ?? {})) {
+      const values = enumValues(val);
+      if (!values) {
+        continue;
+      }
+      let varName = byValues.get(JSON.stringify(values));
+      if (!varName) {
+        varName = `${lowerFirst(schema.name)}${capitalizeFirst(key)}Values`;
+        sets.push({ varName, values: values.map((v) => JSON.stringify(v)) });
+      }
+      enumFields.push([key, varName]);
+    }
+    if (enumFields.length > 0) {
+      fields.set(schema.name, enumFields);
+    }
+  }
+  return { sets, fields };
+}
+
+// enumValues returns the values of an enum field, looking through optional
+// and default wrappers, or undefined when the field is not an enum.
+function enumValues(schema: z.ZodTypeAny): string[] | undefined {
+  const def = schema?._def as { typeName?: string; innerType?: z.ZodTypeAny; values?: string[] };
+  if (def?.typeName === 'ZodOptional' || def?.typeName === 'ZodDefault') {
+    return def.innerType ? enumValues(def.innerType) : undefined;
+  }
+  return def?.typeName === 'ZodEnum' ? def.values : undefined;
+}
+
 function generateGoValidationFunction(
   schema: SchemaDefinition,
-  shape: Record<string, z.ZodTypeAny>
+  shape: Record<string, z.ZodTypeAny>,
+  enumFields: Array<[string, string]>
 ): string[] {
   const lines: string[] = [];
   lines.push(`// validate${schema.name} validates a ${schema.name} instance`);
@@ -424,17 +597,19 @@ function generateGoValidationFunction(
         lines.push(`\tif m.${capitalizedKey} == "" {`);
         lines.push(`\t\terrs.Add("${key}", "is required")`);
         lines.push(`\t}`);
-      } else if (goType === 'int' || goType === 'float64') {
-        lines.push(`\tif m.${capitalizedKey} == 0 {`);
-        lines.push(`\t\terrs.Add("${key}", "is required")`);
-        lines.push(`\t}`);
       }
+      // Zero is a legal value for numeric fields, so their presence is
+      // checked on decode instead; see requiredNumericFields.
     }
   }
+  for (const [key, varName] of enumFields) {
+    lines.push(`\tcheckEnum("${key}", m.${capitalizeFirst(key)}, ${varName}, &errs)`);
+  }
 
+  lines.push(`\tapplyRules("${schema.name}", m, &errs)`);
   lines.push('');
   lines.push('\tif !errs.IsValid() {');
-  lines.push('\t\treturn errs');
+  lines.push('\t\treturn &errs');
   lines.push('\t}');
   lines.push('\treturn nil');
   lines.push('}');
@@ -446,10 +621,6 @@ function generateGoMod(config: SDKGeneratorConfig): string {
   return `module github.com/${config.organization}/sdk-go
 
 go 1.21
-
-require (
-	github.com/google/uuid v1.6.0
-)
 `;
 }
 
@@ -478,7 +649,7 @@ import (
 func main() {
     // Models are fully typed Go structs
     job := controlplane.JobRequest{
-        ID:   "550e8400-e29b-41d4-a716-446655440000",
+        Id:   "550e8400-e29b-41d4-a716-446655440000",
         Type: "process-data",
         // ...
     }
@@ -497,7 +668,7 @@ import (
 
 func main() {
     job := controlplane.JobRequest{
-        ID:   "550e8400-e29b-41d4-a716-446655440000",
+        Id:   "550e8400-e29b-41d4-a716-446655440000",
         Type: "process-data",
     }
 
@@ -516,14 +687,18 @@ package main
 import (
     "context"
     "os"
+
     "github.com/${config.organization}/sdk-go"
 )
 
 func main() {
-    client := controlplane.NewClient(controlplane.ClientConfig{
+    client, err := controlplane.NewClient(controlplane.ClientConfig{
         BaseURL: "https://api.controlplane.io",
-		APIKey:  os.Getenv("CONTROLPLANE_API_KEY"),
+        APIKey:  os.Getenv("CONTROLPLANE_API_KEY"),
     })
+    if err != nil {
+        panic(err)
+    }
 
     ctx := context.Background()
     resp, err := client.Request(ctx, "GET", "/health", nil)
@@ -534,6 +709,13 @@ func main() {
 }
 \`\`\`
 
+## Guide
+
+The client covers far more than the raw \`Request\` above: typed endpoint
+methods, errors, retries and failover, caching, streaming, runners and test
+helpers. [GUIDE.md](GUIDE.md) documents them; it is written by hand, unlike
+this README.
+
 ## Features
 
 - ✅ **Strongly typed structs** - Full compile-time type safety
@@ -548,7 +730,10 @@ func main() {
 
 ## Regeneration
 
-This SDK is auto-generated. Do not edit manually.
+The files marked \`DO NOT EDIT MANUALLY\` (\`types.go\`, \`client.go\`,
+\`validation.go\`, \`schemas.go\` and \`sdk_version.go\`), this README and
+\`go.mod\` are generated; do not edit them by hand. The client itself lives in
+hand-written files such as \`controlplane.go\`, documented in \`GUIDE.md\`.
 To regenerate, run: \`sdk-gen --language go\`
 
 ## License
@@ -108,6 +108,26 @@ describe('SDK Generator', () => {
       expect(sdk.files.has('schemas.go')).toBe(true);
       expect(sdk.files.has('go.mod')).toBe(true);
       expect(sdk.files.has('README.md')).toBe(true);
+      expect(sdk.files.has('sdk_version.go')).toBe(true);
+    });
+
+    it('should stamp the SDK version into the Go package', async () => {
+      const schemas = await extractSchemas();
+      const sdk = generateGoSDK(schemas, DEFAULT_CONFIG);
+
+      expect(sdk.files.get('sdk_version.go')).toContain(
+        `const SDKVersion = "${DEFAULT_CONFIG.sdkVersion}"`
+      );
+    });
+
+    it('should stamp the contract version into the Go client', async () => {
+      const schemas = await extractSchemas();
+      const sdk = generateGoSDK(schemas, DEFAULT_CONFIG);
+      const [major, minor, patch] = DEFAULT_CONFIG.contractVersion.split('.');
+
+      expect(sdk.files.get('client.go')).toContain(
+        `var clientContractVersion = ContractVersion{Major: ${major}, Minor: ${minor}, Patch: ${patch}}`
+      );
     });
 
     it('should generate valid Go structs', async () => {
@@ -128,5 +148,29 @@ describe('SDK Generator', () => {
 
       expect(typesContent).toContain('Validate() error');
     });
+
+    it('should show NewClient returning an error in the README', async () => {
+      const schemas = await extractSchemas();
+      const sdk = generateGoSDK(schemas, DEFAULT_CONFIG);
+      const readme = sdk.files.get('README.md');
+
+      expect(readme).toContain('client, err := controlplane.NewClient(');
+      expect(readme).toContain('[GUIDE.md](GUIDE.md)');
+    });
+
+    it('should check enum fields against the contract enums', async () => {
+      const schemas = await extractSchemas();
+      const sdk = generateGoSDK(schemas, DEFAULT_CONFIG);
+      const schemasContent = sdk.files.get('schemas.go');
+
+      // Inline enums repeating an enum schema share its value set.
+      expect(schemasContent).toContain(
+        'checkEnum("connectorType", m.ConnectorType, connectorTypeValues, &errs)'
+      );
+      expect(schemasContent).toMatch(
+        /registryQueryHealthStatusValues +=\s\[\]string\{"healthy", "degraded", "unhealthy", "offline", "any"\}/
+      );
+      expect(schemasContent).toContain('"HealthCheck": {\n\t\t"status": healthStatusValues,');
+    });
   });
 });